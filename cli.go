@@ -0,0 +1,194 @@
+// Package main 管理命令
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/importer"
+	"yggdrasil-api-go/src/storage/cached"
+	storage "yggdrasil-api-go/src/storage/interface"
+
+	"github.com/bytedance/sonic"
+)
+
+// commandUsage 管理命令列表（config命令在加载配置之前执行，见main）
+const commandUsage = `commands:
+  user create|list|passwd|ban|unban
+  profile add|rename|delete
+  texture set|gc
+  keys generate|rotate|show
+  tokens revoke
+  config check
+  import-texture
+run "<command> <subcommand> -h" for flags; add -json for machine-readable output`
+
+// runCommand 执行管理命令
+func runCommand(cfg *config.Config, store storage.Storage, args []string) error {
+	switch args[0] {
+	case "import-texture":
+		return runImportTexture(cfg, store, args[1:])
+	case "user":
+		return runUserCommand(cfg, store, args[1:])
+	case "profile":
+		return runProfileCommand(store, args[1:])
+	case "texture":
+		return runTextureCommand(store, args[1:])
+	case "keys":
+		return runKeysCommand(cfg, store, args[1:])
+	case "tokens":
+		return runTokensCommand(cfg, store, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Println(commandUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command: %s\n%s", args[0], commandUsage)
+	}
+}
+
+// commandStorage 管理命令使用的存储
+// Token缓存使用Redis时经过用户缓存装饰器，修改密码、封禁和角色变更通过发布/订阅使运行中服务器的用户缓存和角色响应缓存立即失效
+func commandStorage(cfg *config.Config, store storage.Storage) (storage.Storage, func()) {
+	broadcaster, err := cache.NewBroadcaster(cfg.Cache.Token.Type, cfg.Cache.Token.Options)
+	if err != nil {
+		slog.Warn("Failed to connect cache invalidation channel, running servers keep cached users until they expire", "error", err)
+		return store, func() {}
+	}
+	if broadcaster == nil {
+		return store, func() {}
+	}
+
+	// 存储通知的角色变更（材质、改名、删除）同样广播给运行中的服务器，清除其角色响应缓存
+	propagateProfileChanges(broadcaster)
+
+	userCache := cache.NewUserCache(context.Background(), cfg.Cache.User.Duration, cfg.Cache.User.MaxUsers, cfg.Cache.User.CleanupInterval)
+	return cached.NewStorage(store, userCache, broadcaster), func() {
+		userCache.Close()
+		broadcaster.Close()
+	}
+}
+
+// commandContext 管理命令写入存储使用的context（材质历史记录的操作者为cli）
+func commandContext() context.Context {
+	return storage.WithTextureChange(context.Background(), storage.TextureChange{Actor: "cli"})
+}
+
+// subcommand 取出子命令名称（不在subcommands中时返回用法错误）
+func subcommand(command string, args []string, subcommands ...string) (string, []string, error) {
+	usage := fmt.Errorf("usage: %s %s", command, strings.Join(subcommands, "|"))
+	if len(args) == 0 {
+		return "", nil, usage
+	}
+	for _, name := range subcommands {
+		if args[0] == name {
+			return name, args[1:], nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown %s subcommand: %s (%w)", command, args[0], usage)
+}
+
+// cliOutput 管理命令的输出格式（-json输出JSON便于部署脚本处理，否则输出便于阅读的文本）
+type cliOutput struct {
+	json bool
+}
+
+// newFlagSet 创建子命令的参数解析（包含-json）
+func newFlagSet(name string) (*flag.FlagSet, *cliOutput) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	out := &cliOutput{}
+	fs.BoolVar(&out.json, "json", false, "以JSON格式输出")
+	return fs, out
+}
+
+// print 输出命令结果：JSON模式输出v，否则调用human输出文本
+func (o *cliOutput) print(v any, human func()) error {
+	if !o.json {
+		human()
+		return nil
+	}
+	data, err := sonic.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// failCommand 报告管理命令失败并退出（带-json时同时在stdout输出 {"error": "..."}）
+func failCommand(args []string, err error) {
+	// -h只输出用法
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	// 检查未通过时结果已经输出
+	if !errors.Is(err, errCheckFailed) {
+		for _, arg := range args {
+			if arg == "-json" || arg == "--json" || arg == "-json=true" || arg == "--json=true" {
+				(&cliOutput{json: true}).print(map[string]string{"error": err.Error()}, nil)
+				break
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	os.Exit(1)
+}
+
+// runImportTexture 从上游服务器导入角色材质
+// 用法: import-texture -upstream mojang -source Notch -profile <uuid> [-types skin,cape] [-json]
+func runImportTexture(cfg *config.Config, store storage.Storage, args []string) error {
+	fs, out := newFlagSet("import-texture")
+	upstream := fs.String("upstream", "", "上游名称（texture.import.upstreams中的name）")
+	source := fs.String("source", "", "上游角色名或UUID")
+	profile := fs.String("profile", "", "目标角色UUID")
+	types := fs.String("types", "", "导入的材质类型（逗号分隔，留空导入所有）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *upstream == "" || *source == "" || *profile == "" {
+		fs.Usage()
+		return fmt.Errorf("-upstream, -source and -profile are required")
+	}
+
+	var textureTypes []storage.TextureType
+	if *types != "" {
+		for _, name := range strings.Split(*types, ",") {
+			def, ok := storage.LookupTextureType(strings.TrimSpace(name))
+			if !ok || !def.Upload {
+				return fmt.Errorf("invalid texture type: %s", name)
+			}
+			textureTypes = append(textureTypes, def.Type)
+		}
+	}
+
+	// 管理命令不要求 texture.import.enabled，只需配置上游
+	skinImporter, err := importer.NewImporter(cfg, store)
+	if err != nil {
+		return err
+	}
+
+	result, err := skinImporter.Import(context.Background(), &importer.Request{
+		Upstream:  *upstream,
+		Source:    *source,
+		ProfileID: *profile,
+		Types:     textureTypes,
+		Actor:     "cli",
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.print(result, func() {
+		fmt.Printf("✅ Imported textures of %s (%s) from %s\n", result.SourceName, result.SourceID, result.Upstream)
+		for textureType, info := range result.Textures {
+			fmt.Printf("   %s: %s\n", textureType, info.URL)
+		}
+	})
+}
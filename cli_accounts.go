@@ -0,0 +1,370 @@
+// Package main 用户、角色和令牌管理命令
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// profileUUIDPattern 无符号或带连字符的角色UUID
+var profileUUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+// runUserCommand 用户管理
+// 用法: user create -email <email> (-password <pwd> | -password-stdin) [-admin]
+//
+//	user list
+//	user passwd -email <email> (-password <pwd> | -password-stdin)
+//	user ban|unban -email <email>
+func runUserCommand(cfg *config.Config, store storage.Storage, args []string) error {
+	name, args, err := subcommand("user", args, "create", "list", "passwd", "ban", "unban")
+	if err != nil {
+		return err
+	}
+	manager, err := accountManager(store)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	fs, out := newFlagSet("user " + name)
+	switch name {
+	case "list":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		accounts, err := manager.ListAccounts(ctx)
+		if err != nil {
+			return err
+		}
+		return out.print(accounts, func() {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tEMAIL\tADMIN\tBANNED\tPROFILES")
+			for _, account := range accounts {
+				var names []string
+				for _, profile := range account.Profiles {
+					names = append(names, profile.Name)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%s\n", account.ID, account.Email, account.Admin, account.Banned, strings.Join(names, ","))
+			}
+			w.Flush()
+		})
+
+	case "create":
+		email := fs.String("email", "", "用户邮箱")
+		admin := fs.Bool("admin", false, "设为管理员")
+		password := passwordFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if !utils.IsValidEmail(*email) {
+			return fmt.Errorf("a valid -email is required")
+		}
+		hash, err := password.hash()
+		if err != nil {
+			return err
+		}
+		account, err := manager.CreateAccount(ctx, *email, hash, *admin)
+		if err != nil {
+			return err
+		}
+		return out.print(account, func() {
+			fmt.Printf("✅ Created user %s (id %s)\n", account.Email, account.ID)
+		})
+
+	case "passwd":
+		email := fs.String("email", "", "用户邮箱")
+		password := passwordFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *email == "" {
+			return fmt.Errorf("-email is required")
+		}
+		hash, err := password.hash()
+		if err != nil {
+			return err
+		}
+		user, err := store.GetUserByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if err := manager.SetPassword(ctx, *email, hash); err != nil {
+			return err
+		}
+		// 修改密码后已签发的令牌全部失效
+		revoked := revokeTokensBestEffort(cfg, store, user.ID)
+		return out.print(map[string]any{"email": *email, "tokens_revoked": revoked}, func() {
+			fmt.Printf("✅ Password of %s updated, %d tokens revoked\n", *email, revoked)
+		})
+
+	default: // ban, unban
+		email := fs.String("email", "", "用户邮箱")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *email == "" {
+			return fmt.Errorf("-email is required")
+		}
+		user, err := store.GetUserByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		banned := name == "ban"
+		if err := manager.SetBanned(ctx, *email, banned); err != nil {
+			return err
+		}
+		revoked := 0
+		if banned {
+			revoked = revokeTokensBestEffort(cfg, store, user.ID)
+		}
+		return out.print(map[string]any{"email": *email, "banned": banned, "tokens_revoked": revoked}, func() {
+			if banned {
+				fmt.Printf("✅ Banned %s, %d tokens revoked\n", *email, revoked)
+			} else {
+				fmt.Printf("✅ Unbanned %s\n", *email)
+			}
+		})
+	}
+}
+
+// runProfileCommand 角色管理
+// 用法: profile add -user <email> -name <name> [-uuid <uuid>]
+//
+//	profile rename -profile <uuid|name> -name <new name>
+//	profile delete -profile <uuid|name>
+func runProfileCommand(store storage.Storage, args []string) error {
+	name, args, err := subcommand("profile", args, "add", "rename", "delete")
+	if err != nil {
+		return err
+	}
+	manager, err := accountManager(store)
+	if err != nil {
+		return err
+	}
+	ctx := commandContext()
+
+	fs, out := newFlagSet("profile " + name)
+	switch name {
+	case "add":
+		email := fs.String("user", "", "所属用户邮箱")
+		profileName := fs.String("name", "", "角色名称")
+		profileUUID := fs.String("uuid", "", "角色UUID（留空时按离线模式规则由名称生成）")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *email == "" || !utils.IsValidPlayerName(*profileName) {
+			return fmt.Errorf("-user and a valid -name are required")
+		}
+		id := utils.GenerateProfileUUID(*profileName)
+		if *profileUUID != "" {
+			if !profileUUIDPattern.MatchString(*profileUUID) {
+				return fmt.Errorf("invalid -uuid: %s", *profileUUID)
+			}
+			id = strings.ToLower(utils.RemoveUUIDHyphens(*profileUUID))
+		}
+		profile := &yggdrasil.Profile{ID: id, Name: *profileName}
+		if err := manager.AddProfile(ctx, *email, profile); err != nil {
+			return err
+		}
+		return out.print(map[string]any{"id": profile.ID, "name": profile.Name, "user": *email}, func() {
+			fmt.Printf("✅ Added profile %s (%s) to %s\n", profile.Name, profile.ID, *email)
+		})
+
+	case "rename":
+		ref := fs.String("profile", "", "角色UUID或名称")
+		newName := fs.String("name", "", "新角色名称")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if !utils.IsValidPlayerName(*newName) {
+			return fmt.Errorf("a valid -name is required")
+		}
+		profile, err := resolveProfile(ctx, store, *ref)
+		if err != nil {
+			return err
+		}
+		if err := manager.RenameProfile(ctx, profile.ID, *newName); err != nil {
+			return err
+		}
+		return out.print(map[string]any{"id": profile.ID, "old_name": profile.Name, "name": *newName}, func() {
+			fmt.Printf("✅ Renamed profile %s to %s\n", profile.Name, *newName)
+		})
+
+	default: // delete
+		ref := fs.String("profile", "", "角色UUID或名称")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		profile, err := resolveProfile(ctx, store, *ref)
+		if err != nil {
+			return err
+		}
+
+		// 先删除角色的材质，未被引用的材质文件由 texture gc 清理
+		deleted := []storage.TextureType{}
+		for _, def := range storage.GetTextureTypes() {
+			if _, err := store.GetTexture(ctx, def.Type, profile.ID); err != nil {
+				continue
+			}
+			if err := store.DeleteTexture(ctx, def.Type, profile.ID); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", strings.ToLower(string(def.Type)), err)
+			}
+			deleted = append(deleted, def.Type)
+		}
+
+		if err := manager.RemoveProfile(ctx, profile.ID); err != nil {
+			return err
+		}
+		return out.print(map[string]any{"id": profile.ID, "name": profile.Name, "textures_deleted": deleted}, func() {
+			fmt.Printf("✅ Deleted profile %s (%s)\n", profile.Name, profile.ID)
+		})
+	}
+}
+
+// runTokensCommand 令牌管理
+// 用法: tokens revoke -user <email|用户ID>
+func runTokensCommand(cfg *config.Config, store storage.Storage, args []string) error {
+	name, args, err := subcommand("tokens", args, "revoke")
+	if err != nil {
+		return err
+	}
+
+	fs, out := newFlagSet("tokens " + name)
+	ref := fs.String("user", "", "用户邮箱或用户ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	user, err := resolveUser(context.Background(), store, *ref)
+	if err != nil {
+		return err
+	}
+	revoked, err := revokeUserTokens(cfg, store, user.ID)
+	if err != nil {
+		return err
+	}
+	return out.print(map[string]any{"user_id": user.ID, "email": user.Email, "tokens_revoked": revoked}, func() {
+		fmt.Printf("✅ Revoked %d tokens of %s\n", revoked, user.Email)
+	})
+}
+
+// accountManager 获取存储的用户管理能力
+func accountManager(store storage.Storage) (storage.AccountManager, error) {
+	manager, ok := storage.As[storage.AccountManager](store)
+	if !ok {
+		return nil, fmt.Errorf("user and profile management is not supported by %s storage", store.GetStorageType())
+	}
+	return manager, nil
+}
+
+// cliPassword 密码参数（-password-stdin从标准输入读取一行，避免密码出现在进程列表和shell历史中）
+type cliPassword struct {
+	value *string
+	stdin *bool
+}
+
+// passwordFlags 注册密码参数
+func passwordFlags(fs *flag.FlagSet) *cliPassword {
+	return &cliPassword{
+		value: fs.String("password", "", "密码"),
+		stdin: fs.Bool("password-stdin", false, "从标准输入读取密码"),
+	}
+}
+
+// hash 读取并哈希密码（bcrypt）
+func (p *cliPassword) hash() (string, error) {
+	password := *p.value
+	if *p.stdin {
+		if password != "" {
+			return "", fmt.Errorf("-password and -password-stdin are mutually exclusive")
+		}
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if !utils.IsValidPassword(password) {
+		return "", fmt.Errorf("password must be at least 6 characters")
+	}
+	return utils.HashPassword(password)
+}
+
+// resolveUser 按邮箱或用户ID查找用户
+func resolveUser(ctx context.Context, store storage.Storage, ref string) (*yggdrasil.User, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("-user is required")
+	}
+	if strings.Contains(ref, "@") {
+		return store.GetUserByEmail(ctx, ref)
+	}
+	return store.GetUserByID(ctx, ref)
+}
+
+// resolveProfile 按UUID或名称查找角色
+func resolveProfile(ctx context.Context, store storage.Storage, ref string) (*yggdrasil.Profile, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("-profile is required")
+	}
+	if profileUUIDPattern.MatchString(ref) {
+		if profile, err := store.GetProfileByUUID(ctx, strings.ToLower(utils.RemoveUUIDHyphens(ref))); err == nil {
+			return profile, nil
+		}
+	}
+	return store.GetProfileByName(ctx, ref)
+}
+
+// revokeUserTokens 删除用户的所有令牌，返回删除的数量
+// memory缓存只存在于服务器进程中，通过运行中服务器的管理接口撤销；共享缓存直接删除（分层缓存的本地层通过发布/订阅失效）
+func revokeUserTokens(cfg *config.Config, store storage.Storage, userID string) (int, error) {
+	if cache.SharedBackendType(cfg.Cache.Token.Type, cfg.Cache.Token.Options) == "" {
+		client, err := newAdminClient(cfg)
+		if err != nil {
+			return 0, err
+		}
+		return client.revokeUserTokens(context.Background(), userID)
+	}
+
+	tokenCache, err := cache.NewCacheFactory().CreateTokenCache(cfg.Cache.Token.Type, cfg.Cache.Token.Options)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create token cache: %w", err)
+	}
+	defer tokenCache.Close()
+
+	// Laravel格式的Redis缓存需要通过存储在用户ID和邮箱之间转换
+	if setter, ok := tokenCache.(cache.UserResolverSetter); ok {
+		setter.SetUserResolver(store)
+	}
+
+	ctx := context.Background()
+	count, err := tokenCache.GetUserTokenCount(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := tokenCache.DeleteUserTokens(ctx, userID); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// revokeTokensBestEffort 删除用户的所有令牌（失败时只记录警告，用于封禁和修改密码）
+func revokeTokensBestEffort(cfg *config.Config, store storage.Storage, userID string) int {
+	revoked, err := revokeUserTokens(cfg, store, userID)
+	if err != nil {
+		slog.Warn("Failed to revoke tokens", "user_id", userID, "error", err)
+	}
+	return revoked
+}
@@ -0,0 +1,150 @@
+// Package main 调用运行中服务器的管理接口（令牌只存在于服务器进程中时使用）
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"github.com/bytedance/sonic"
+)
+
+// 管理接口的连接配置（环境变量）
+const (
+	envAdminURL        = config.EnvPrefix + "ADMIN_URL"         // 服务器地址（默认按server配置连接本机）
+	envAdminClientCert = config.EnvPrefix + "ADMIN_CLIENT_CERT" // 客户端证书（配置了client_ca_file时需要）
+	envAdminClientKey  = config.EnvPrefix + "ADMIN_CLIENT_KEY"  // 客户端证书私钥
+)
+
+// adminTokenLifetime 管理命令签发的管理令牌有效期
+const adminTokenLifetime = time.Minute
+
+// adminClient 管理接口客户端
+type adminClient struct {
+	baseURL string
+	http    *http.Client
+}
+
+// newAdminClient 创建管理接口客户端
+// 未设置YGG_ADMIN_URL时连接本机的监听端口，启用HTTPS时只接受server.tls.cert_file中的证书（本机地址通常不在证书的域名中）
+func newAdminClient(cfg *config.Config) (*adminClient, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	baseURL := os.Getenv(envAdminURL)
+	if baseURL == "" {
+		scheme := "http"
+		if cfg.Server.TLS.Enabled {
+			scheme = "https"
+			pinned, err := pinnedCertificate(cfg.Server.TLS.CertFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+				if len(state.PeerCertificates) == 0 || !bytes.Equal(state.PeerCertificates[0].Raw, pinned) {
+					return fmt.Errorf("server certificate does not match %s", cfg.Server.TLS.CertFile)
+				}
+				return nil
+			}
+		}
+
+		host := cfg.Server.Host
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			host = "127.0.0.1"
+		}
+		baseURL = (&url.URL{
+			Scheme: scheme,
+			Host:   net.JoinHostPort(host, strconv.Itoa(cfg.Server.Port)),
+			Path:   strings.TrimSuffix(path.Join("/", cfg.Server.BaseURL), "/"),
+		}).String()
+	}
+
+	certFile, keyFile := os.Getenv(envAdminClientCert), os.Getenv(envAdminClientKey)
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load admin client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &adminClient{
+		baseURL: baseURL,
+		http: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// pinnedCertificate 读取证书文件中的第一个证书（服务器证书）
+func pinnedCertificate(certFile string) ([]byte, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read server certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no certificate found in %s", certFile)
+	}
+	return block.Bytes, nil
+}
+
+// call 调用管理接口（使用以auth.jwt_secret签名的短期管理令牌），响应写入out
+func (a *adminClient) call(ctx context.Context, method, apiPath string, out any) error {
+	token, err := utils.GenerateAdminJWT("", "cli", true, adminTokenLifetime)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+apiPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach running server at %s (set %s if it listens elsewhere): %w", a.baseURL, envAdminURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr yggdrasil.ErrorResponse
+		if sonic.Unmarshal(body, &apiErr) == nil && apiErr.ErrorMessage != "" {
+			return fmt.Errorf("server returned %d: %s", resp.StatusCode, apiErr.ErrorMessage)
+		}
+		return fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+	return sonic.Unmarshal(body, out)
+}
+
+// revokeUserTokens 通过运行中的服务器撤销用户的所有令牌，返回撤销的数量
+func (a *adminClient) revokeUserTokens(ctx context.Context, userID string) (int, error) {
+	var result struct {
+		TokensRevoked int `json:"tokens_revoked"`
+	}
+	if err := a.call(ctx, http.MethodDelete, "/api/admin/users/"+url.PathEscape(userID)+"/tokens", &result); err != nil {
+		return 0, err
+	}
+	return result.TokensRevoked, nil
+}
@@ -0,0 +1,176 @@
+// Package main 配置检查命令
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/health"
+	storage_factory "yggdrasil-api-go/src/storage"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/tlsutil"
+)
+
+// errCheckFailed 检查未通过（结果已输出，只需以非零状态退出）
+var errCheckFailed = errors.New("config check failed")
+
+// configCheckResult 配置检查结果
+type configCheckResult struct {
+	Path         string         `json:"path"`
+	Valid        bool           `json:"valid"`
+	Storage      string         `json:"storage"`
+	TokenCache   string         `json:"token_cache"`
+	SessionCache string         `json:"session_cache"`
+	TLS          bool           `json:"tls"`
+	Warnings     []string       `json:"warnings"`
+	Connections  *health.Report `json:"connections,omitempty"` // -connect时各后端的检查结果
+}
+
+// runConfigCommand 配置相关命令（在加载配置之前执行，配置无效时同样能输出原因）
+// 用法: config check [-connect]
+func runConfigCommand(configPath string, args []string) error {
+	name, args, err := subcommand("config", args, "check")
+	if err != nil {
+		return err
+	}
+
+	fs, out := newFlagSet("config " + name)
+	connect := fs.Bool("connect", false, "同时连接存储和缓存后端")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// 配置文件不存在时LoadConfig会创建默认配置，检查命令不应产生文件
+	if _, err := os.Stat(configPath); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	// 读取配置（包括环境变量覆盖）并验证
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	result := &configCheckResult{
+		Path:         configPath,
+		Valid:        true,
+		Storage:      cfg.Storage.Type,
+		TokenCache:   cfg.Cache.Token.Type,
+		SessionCache: cfg.Cache.Session.Type,
+		TLS:          cfg.Server.TLS.Enabled,
+		Warnings:     []string{},
+	}
+	if err := checkConfigFiles(cfg, result); err != nil {
+		return err
+	}
+	if *connect {
+		result.Connections = checkConnections(cfg)
+	}
+
+	if err := out.print(result, func() {
+		fmt.Printf("✅ %s is valid (storage: %s, token cache: %s, session cache: %s)\n",
+			result.Path, result.Storage, result.TokenCache, result.SessionCache)
+		for _, warning := range result.Warnings {
+			fmt.Printf("⚠️  %s\n", warning)
+		}
+		if result.Connections != nil {
+			names := make([]string, 0, len(result.Connections.Components))
+			for name := range result.Connections.Components {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				component := result.Connections.Components[name]
+				if component.Status == health.StatusUp {
+					fmt.Printf("✅ %s: %.1fms\n", name, component.LatencyMs)
+				} else {
+					fmt.Printf("❌ %s: %s\n", name, component.Error)
+				}
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	if result.Connections != nil && !result.Connections.Ready() {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// checkConfigFiles 检查配置引用的证书和密钥文件（服务器启动时才会读取这些文件）
+func checkConfigFiles(cfg *config.Config, result *configCheckResult) error {
+	if cfg.Server.TLS.Enabled {
+		if _, err := tlsutil.NewCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile, cfg.Server.TLS.ReloadInterval); err != nil {
+			return err
+		}
+		if cfg.Server.TLS.ClientCAFile != "" {
+			if _, err := tlsutil.LoadCertPool(cfg.Server.TLS.ClientCAFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.Storage.Type != "blessing_skin" {
+		privatePEM, publicPEM, err := readKeyPair(cfg.Yggdrasil.Keys.PrivateKeyPath, cfg.Yggdrasil.Keys.PublicKeyPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			result.Warnings = append(result.Warnings, "signature key pair not found, a new one will be generated at startup (keys generate)")
+		case err != nil:
+			return err
+		default:
+			if err := describeKey(&keyInfo{}, privatePEM, publicPEM); err != nil {
+				return fmt.Errorf("invalid signature key pair: %w", err)
+			}
+		}
+	}
+
+	if config.IsSampleJWTSecret(cfg.Auth.JWTSecret) {
+		result.Warnings = append(result.Warnings, "auth.jwt_secret is the sample secret (only allowed in debug mode)")
+	}
+	if cache.SharedBackendType(cfg.Cache.Token.Type, cfg.Cache.Token.Options) == "" {
+		result.Warnings = append(result.Warnings, "tokens are kept in server memory: tokens revoke cannot reach them from the command line")
+	}
+	return nil
+}
+
+// checkConnections 通过与服务器相同的工厂创建存储和缓存，按就绪检查的方式检查后端是否可用
+func checkConnections(cfg *config.Config) *health.Report {
+	checker := health.NewChecker(cfg.Monitoring.HealthTimeout)
+	failed := func(err error) health.CheckFunc {
+		return func(context.Context) error { return err }
+	}
+
+	if err := storage.RegisterTextureTypes(cfg.Texture.Types); err != nil {
+		checker.Register("storage", failed(err))
+	} else if store, err := storage_factory.NewStorageFactory().CreateStorage(&cfg.Storage, &cfg.Texture); err != nil {
+		checker.Register("storage", failed(err))
+	} else {
+		defer store.Close()
+		checker.Register("storage", func(context.Context) error { return store.Ping() })
+	}
+
+	// memory缓存只存在于服务器进程中（关闭时还会写入快照），只检查共享的缓存后端
+	cacheFactory := cache.NewCacheFactory()
+	if cache.SharedBackendType(cfg.Cache.Token.Type, cfg.Cache.Token.Options) != "" {
+		if tokenCache, err := cacheFactory.CreateTokenCache(cfg.Cache.Token.Type, cfg.Cache.Token.Options); err != nil {
+			checker.Register("token_cache", failed(err))
+		} else {
+			defer tokenCache.Close()
+			checker.Register("token_cache", tokenCache.Ping)
+		}
+	}
+	if cache.SharedBackendType(cfg.Cache.Session.Type, cfg.Cache.Session.Options) != "" {
+		if sessionCache, err := cacheFactory.CreateSessionCache(cfg.Cache.Session.Type, cfg.Cache.Session.Options); err != nil {
+			checker.Register("session_cache", failed(err))
+		} else {
+			defer sessionCache.Close()
+			checker.Register("session_cache", sessionCache.Ping)
+		}
+	}
+
+	return checker.Check(context.Background())
+}
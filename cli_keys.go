@@ -0,0 +1,177 @@
+// Package main 签名密钥管理命令
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+)
+
+// keyInfo 签名密钥信息（命令输出）
+type keyInfo struct {
+	Source         string   `json:"source"`                     // file 或 blessing_skin
+	PrivateKeyPath string   `json:"private_key_path,omitempty"` // 私钥文件
+	PublicKeyPath  string   `json:"public_key_path,omitempty"`  // 公钥文件
+	Bits           int      `json:"bits"`                       // 密钥长度
+	Fingerprint    string   `json:"fingerprint"`                // 公钥（DER）的SHA-256指纹
+	PublicKey      string   `json:"public_key"`                 // PEM格式公钥
+	Backups        []string `json:"backups,omitempty"`          // rotate时备份的旧密钥文件
+}
+
+// runKeysCommand 签名密钥管理（BlessingSkin存储的密钥保存在其数据库中，只支持show）
+// 用法: keys show
+//
+//	keys generate [-bits 4096] [-force]
+//	keys rotate [-bits 4096]
+func runKeysCommand(cfg *config.Config, store storage.Storage, args []string) error {
+	name, args, err := subcommand("keys", args, "generate", "rotate", "show")
+	if err != nil {
+		return err
+	}
+	privatePath := cfg.Yggdrasil.Keys.PrivateKeyPath
+	publicPath := cfg.Yggdrasil.Keys.PublicKeyPath
+
+	fs, out := newFlagSet("keys " + name)
+	bits := utils.DefaultKeyBits
+	force := false
+	if name != "show" {
+		fs.IntVar(&bits, "bits", utils.DefaultKeyBits, "RSA密钥长度")
+	}
+	if name == "generate" {
+		fs.BoolVar(&force, "force", false, "覆盖已存在的密钥文件")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if name == "show" {
+		var privatePEM, publicPEM string
+		info := &keyInfo{Source: "file", PrivateKeyPath: privatePath, PublicKeyPath: publicPath}
+		if store.GetStorageType() == "blessing_skin" {
+			info = &keyInfo{Source: "blessing_skin"}
+			privatePEM, publicPEM, err = store.GetSignatureKeyPair()
+		} else {
+			privatePEM, publicPEM, err = readKeyPair(privatePath, publicPath)
+		}
+		if err != nil {
+			return err
+		}
+		if err := describeKey(info, privatePEM, publicPEM); err != nil {
+			return err
+		}
+		return out.print(info, func() { printKeyInfo(info) })
+	}
+
+	if store.GetStorageType() == "blessing_skin" {
+		return fmt.Errorf("signature keys of blessing_skin storage are managed by BlessingSkin (yggdrasil plugin options)")
+	}
+	if bits < 2048 {
+		return fmt.Errorf("-bits must be at least 2048")
+	}
+
+	info := &keyInfo{Source: "file", PrivateKeyPath: privatePath, PublicKeyPath: publicPath}
+	if name == "generate" && !force {
+		for _, path := range []string{privatePath, publicPath} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists (use -force to overwrite, or keys rotate to keep a backup)", path)
+			}
+		}
+	}
+	if name == "rotate" {
+		if _, _, err := readKeyPair(privatePath, publicPath); err != nil {
+			return fmt.Errorf("no key pair to rotate: %w", err)
+		}
+	}
+
+	// 先生成新密钥，生成失败时不改动已有文件
+	privatePEM, publicPEM, err := utils.GenerateKeyPair(bits)
+	if err != nil {
+		return err
+	}
+	if name == "rotate" {
+		suffix := "." + time.Now().Format("20060102-150405") + ".bak"
+		for _, path := range []string{privatePath, publicPath} {
+			if err := os.Rename(path, path+suffix); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+			info.Backups = append(info.Backups, path+suffix)
+		}
+	}
+
+	if err := utils.WriteKeyFile(privatePath, privatePEM, 0600); err != nil {
+		return err
+	}
+	if err := utils.WriteKeyFile(publicPath, publicPEM, 0644); err != nil {
+		return err
+	}
+	if err := describeKey(info, privatePEM, publicPEM); err != nil {
+		return err
+	}
+
+	return out.print(info, func() {
+		fmt.Printf("✅ Generated %d-bit RSA key pair\n", info.Bits)
+		printKeyInfo(info)
+		for _, backup := range info.Backups {
+			fmt.Printf("   Backup:      %s\n", backup)
+		}
+		fmt.Println("ℹ️  Restart the server to sign with the new key; launchers pick up the new public key from the API metadata")
+	})
+}
+
+// readKeyPair 读取密钥文件
+func readKeyPair(privatePath, publicPath string) (string, string, error) {
+	privatePEM, err := os.ReadFile(privatePath)
+	if err != nil {
+		return "", "", err
+	}
+	publicPEM, err := os.ReadFile(publicPath)
+	if err != nil {
+		return "", "", err
+	}
+	return string(privatePEM), string(publicPEM), nil
+}
+
+// describeKey 校验密钥对并填充长度和指纹
+func describeKey(info *keyInfo, privatePEM, publicPEM string) error {
+	privateKey, err := utils.ParsePrivateKey(privatePEM)
+	if err != nil {
+		return err
+	}
+	publicKey, err := utils.ParsePublicKey(publicPEM)
+	if err != nil {
+		return err
+	}
+	if !privateKey.PublicKey.Equal(publicKey) {
+		return fmt.Errorf("public key does not match the private key")
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	fingerprint := sha256.Sum256(publicDER)
+	info.Bits = publicKey.N.BitLen()
+	info.Fingerprint = hex.EncodeToString(fingerprint[:])
+	info.PublicKey = publicPEM
+	return nil
+}
+
+// printKeyInfo 输出密钥信息（文本格式）
+func printKeyInfo(info *keyInfo) {
+	if info.Source == "file" {
+		fmt.Printf("   Private key: %s\n", info.PrivateKeyPath)
+		fmt.Printf("   Public key:  %s\n", info.PublicKeyPath)
+	} else {
+		fmt.Printf("   Source:      %s\n", info.Source)
+	}
+	fmt.Printf("   Bits:        %d\n", info.Bits)
+	fmt.Printf("   SHA-256:     %s\n", info.Fingerprint)
+	fmt.Print(info.PublicKey)
+}
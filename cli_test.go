@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/storage/blob"
+	"yggdrasil-api-go/src/storage/database"
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// newCommandTestEnv 使用SQLite数据库存储和数据库Token缓存（管理命令直接撤销共享缓存中的令牌）
+func newCommandTestEnv(t *testing.T) (*config.Config, *database.Storage) {
+	t.Helper()
+	dir := t.TempDir()
+	blobs, err := blob.NewLocalStore(map[string]any{"root": filepath.Join(dir, "textures")})
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	store, err := database.NewStorage(map[string]any{
+		"database_dsn": "sqlite://" + filepath.Join(dir, "ygg.db"),
+		"blob_store":   blobs,
+	}, &config.TextureConfig{BaseURL: "http://textures.test"})
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := config.DefaultConfig()
+	cfg.Cache.Token.Type = "database"
+	cfg.Cache.Token.Options = map[string]any{"dsn": "sqlite://" + filepath.Join(dir, "tokens.db")}
+	return cfg, store
+}
+
+func TestUserAndProfileCommands(t *testing.T) {
+	cfg, store := newCommandTestEnv(t)
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "create user", args: []string{"user", "create", "-email", "steve@example.com", "-password", "secret1", "-json"}},
+		{name: "duplicate user", args: []string{"user", "create", "-email", "steve@example.com", "-password", "secret1"}, wantErr: true},
+		{name: "invalid email", args: []string{"user", "create", "-email", "steve", "-password", "secret1"}, wantErr: true},
+		{name: "short password", args: []string{"user", "create", "-email", "alex@example.com", "-password", "123"}, wantErr: true},
+		{name: "add profile", args: []string{"profile", "add", "-user", "steve@example.com", "-name", "Steve"}},
+		{name: "rename profile", args: []string{"profile", "rename", "-profile", "Steve", "-name", "Alex"}},
+		{name: "invalid uuid", args: []string{"profile", "add", "-user", "steve@example.com", "-name", "Herobrine", "-uuid", "xyz"}, wantErr: true},
+		{name: "list users", args: []string{"user", "list", "-json"}},
+		{name: "unknown subcommand", args: []string{"user", "remove"}, wantErr: true},
+		{name: "unknown command", args: []string{"users"}, wantErr: true},
+	}
+	for _, step := range steps {
+		if err := runCommand(cfg, store, step.args); (err != nil) != step.wantErr {
+			t.Fatalf("%s: runCommand(%v) = %v; want error %v", step.name, step.args, err, step.wantErr)
+		}
+	}
+
+	// 未指定UUID时按离线模式规则由名称生成
+	profile, err := store.GetProfileByName(ctx, "Alex")
+	if err != nil || profile.ID != utils.GenerateProfileUUID("Steve") {
+		t.Fatalf("GetProfileByName(Alex) = %+v, %v; want the offline UUID of Steve", profile, err)
+	}
+
+	if err := runCommand(cfg, store, []string{"profile", "delete", "-profile", profile.ID}); err != nil {
+		t.Fatalf("profile delete: %v", err)
+	}
+	if _, err := store.GetProfileByUUID(ctx, profile.ID); err == nil {
+		t.Fatal("profile still present after delete")
+	}
+}
+
+func TestUserPasswordAndBanCommands(t *testing.T) {
+	cfg, store := newCommandTestEnv(t)
+	ctx := context.Background()
+
+	if err := runCommand(cfg, store, []string{"user", "create", "-email", "steve@example.com", "-password", "secret1"}); err != nil {
+		t.Fatalf("user create: %v", err)
+	}
+	user, err := store.GetUserByEmail(ctx, "steve@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+
+	tokenCache, err := cache.NewCacheFactory().CreateTokenCache(cfg.Cache.Token.Type, cfg.Cache.Token.Options)
+	if err != nil {
+		t.Fatalf("CreateTokenCache: %v", err)
+	}
+	defer tokenCache.Close()
+	tokenCount := func() int {
+		t.Helper()
+		count, err := tokenCache.GetUserTokenCount(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetUserTokenCount: %v", err)
+		}
+		return count
+	}
+	storeToken := func() {
+		t.Helper()
+		utils.SetJWTSecret(cfg.Auth.JWTSecret)
+		accessToken, err := utils.GenerateJWT(user.ID, "", time.Hour)
+		if err != nil {
+			t.Fatalf("GenerateJWT: %v", err)
+		}
+		token := &yggdrasil.Token{AccessToken: accessToken, ClientToken: "client", Owner: user.ID, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+		if err := tokenCache.Store(ctx, token); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+		if n := tokenCount(); n != 1 {
+			t.Fatalf("%d tokens stored; want 1", n)
+		}
+	}
+
+	// 修改密码后旧密码失效，已签发的令牌全部撤销
+	storeToken()
+	if err := runCommand(cfg, store, []string{"user", "passwd", "-email", "steve@example.com", "-password", "secret2"}); err != nil {
+		t.Fatalf("user passwd: %v", err)
+	}
+	if _, err := store.AuthenticateUser(ctx, "steve@example.com", "secret1"); err == nil {
+		t.Fatal("old password still accepted")
+	}
+	if _, err := store.AuthenticateUser(ctx, "steve@example.com", "secret2"); err != nil {
+		t.Fatalf("AuthenticateUser with new password: %v", err)
+	}
+	if n := tokenCount(); n != 0 {
+		t.Fatalf("%d tokens left after passwd; want 0", n)
+	}
+
+	storeToken()
+	if err := runCommand(cfg, store, []string{"user", "ban", "-email", "steve@example.com"}); err != nil {
+		t.Fatalf("user ban: %v", err)
+	}
+	if _, err := store.AuthenticateUser(ctx, "steve@example.com", "secret2"); err == nil {
+		t.Fatal("banned user authenticated")
+	}
+	if n := tokenCount(); n != 0 {
+		t.Fatalf("%d tokens left after ban; want 0", n)
+	}
+
+	if err := runCommand(cfg, store, []string{"user", "unban", "-email", "steve@example.com"}); err != nil {
+		t.Fatalf("user unban: %v", err)
+	}
+	if _, err := store.AuthenticateUser(ctx, "steve@example.com", "secret2"); err != nil {
+		t.Fatalf("AuthenticateUser after unban: %v", err)
+	}
+
+	storeToken()
+	if err := runCommand(cfg, store, []string{"tokens", "revoke", "-user", user.ID}); err != nil {
+		t.Fatalf("tokens revoke: %v", err)
+	}
+	if n := tokenCount(); n != 0 {
+		t.Fatalf("%d tokens left after revoke; want 0", n)
+	}
+}
@@ -0,0 +1,97 @@
+// Package main 材质管理命令
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+)
+
+// runTextureCommand 材质管理
+// 用法: texture set -profile <uuid|name> -file <png> [-type skin] [-slim]
+//
+//	texture gc [-dry-run]
+func runTextureCommand(store storage.Storage, args []string) error {
+	name, args, err := subcommand("texture", args, "set", "gc")
+	if err != nil {
+		return err
+	}
+	ctx := commandContext()
+
+	fs, out := newFlagSet("texture " + name)
+	if name == "gc" {
+		dryRun := fs.Bool("dry-run", false, "只列出将被清理的内容，不删除")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		collector, ok := storage.As[storage.TextureCollector](store)
+		if !ok {
+			return fmt.Errorf("texture garbage collection is not supported by %s storage", store.GetStorageType())
+		}
+		result, err := collector.CollectTextures(ctx, *dryRun)
+		if err != nil {
+			return err
+		}
+		return out.print(result, func() {
+			verb := "Removed"
+			if result.DryRun {
+				verb = "Would remove"
+			}
+			fmt.Printf("✅ %s %d texture files, %d stale texture records and %d stale history entries\n",
+				verb, len(result.OrphanBlobs), result.StaleMetadata, result.StaleHistory)
+			for _, key := range result.OrphanBlobs {
+				fmt.Printf("   %s\n", key)
+			}
+		})
+	}
+
+	ref := fs.String("profile", "", "角色UUID或名称")
+	file := fs.String("file", "", "材质图片文件（PNG）")
+	typeName := fs.String("type", "skin", "材质类型")
+	slim := fs.Bool("slim", false, "使用纤细（Alex）模型（仅皮肤）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+
+	def, ok := storage.LookupTextureType(*typeName)
+	if !ok || !def.Upload {
+		return fmt.Errorf("invalid texture type: %s (supported: %s)", *typeName, storage.GetUploadableTextureTypes())
+	}
+	profile, err := resolveProfile(ctx, store, *ref)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	if err := def.ValidateImage(data); err != nil {
+		return err
+	}
+
+	isSlim := *slim && def.Type == storage.TextureTypeSkin
+	metadata := &storage.TextureMetadata{
+		FileSize:   int64(len(data)),
+		UploadedAt: time.Now(),
+		Slim:       isSlim,
+	}
+	if isSlim {
+		metadata.Model = "slim"
+	}
+
+	textureInfo, err := store.UploadTexture(ctx, def.Type, profile.ID, data, metadata)
+	if err != nil {
+		return err
+	}
+
+	return out.print(map[string]any{"profile": profile.ID, "name": profile.Name, "texture": textureInfo}, func() {
+		fmt.Printf("✅ Set %s of %s: %s\n", strings.ToLower(string(def.Type)), profile.Name, textureInfo.URL)
+	})
+}
@@ -0,0 +1,158 @@
+// Package main 后台任务
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/middleware"
+	"yggdrasil-api-go/src/scheduler"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+)
+
+// startJobs 创建任务调度器并注册后台任务
+// 清理共享缓存（redis、database、file，包括分层缓存的L2）的任务通过分布式锁在集群内只执行一次，
+// 分层缓存L1、速率限制器和预热等本地状态的任务在每个实例上执行
+func startJobs(ctx context.Context, cfg *config.Config, store storage.Storage, tokenCache cache.TokenCache, sessionCache cache.SessionCache) (*scheduler.Scheduler, error) {
+	locker, err := newJobLocker(cfg)
+	if err != nil {
+		return nil, err
+	}
+	jobScheduler := scheduler.NewScheduler(ctx, locker)
+
+	jobs := []scheduler.Job{
+		{
+			Name:      "token_cleanup",
+			Schedule:  "@every 5m",
+			Singleton: cache.SharedBackendType(cfg.Cache.Token.Type, cfg.Cache.Token.Options) != "",
+			Run: func(context.Context) error {
+				return tokenCache.CleanupExpired()
+			},
+		},
+		{
+			Name:      "session_cleanup",
+			Schedule:  "@every 5m",
+			Singleton: cache.SharedBackendType(cfg.Cache.Session.Type, cfg.Cache.Session.Options) != "",
+			Run: func(context.Context) error {
+				return sessionCache.CleanupExpired()
+			},
+		},
+		{
+			Name:     "rate_limit_cleanup",
+			Schedule: "@every 1m",
+			Run: func(context.Context) error {
+				middleware.CleanupRateLimiters()
+				return nil
+			},
+		},
+		{
+			// 默认禁用，启动时已预热一次
+			Name:     "cache_warmup",
+			Schedule: "off",
+			Run: func(context.Context) error {
+				return utils.WarmupCaches(cfg, store)
+			},
+		},
+	}
+
+	// 分层缓存的L1在每个实例的进程内，单独清理（token_cleanup/session_cleanup为单例时只会在一个实例上执行）
+	var localCleaners []cache.LocalCleaner
+	if cfg.Cache.Token.Type == "layered" {
+		if cleaner, ok := tokenCache.(cache.LocalCleaner); ok {
+			localCleaners = append(localCleaners, cleaner)
+		}
+	}
+	if cfg.Cache.Session.Type == "layered" {
+		if cleaner, ok := sessionCache.(cache.LocalCleaner); ok {
+			localCleaners = append(localCleaners, cleaner)
+		}
+	}
+	if len(localCleaners) > 0 {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "cache_local_cleanup",
+			Schedule: "@every 1m",
+			Run: func(context.Context) error {
+				for _, cleaner := range localCleaners {
+					cleaner.CleanupLocal()
+				}
+				return nil
+			},
+		})
+	}
+
+	// 内存缓存快照（每个实例保存自己的快照文件）
+	var snapshotters []cache.Snapshotter
+	for _, c := range []any{tokenCache, sessionCache} {
+		if snapshotter, ok := c.(cache.Snapshotter); ok && snapshotter.SnapshotEnabled() {
+			snapshotters = append(snapshotters, snapshotter)
+		}
+	}
+	if len(snapshotters) > 0 {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "cache_snapshot",
+			Schedule: "@every 1m",
+			Run: func(context.Context) error {
+				var errs []error
+				for _, snapshotter := range snapshotters {
+					if err := snapshotter.SaveSnapshot(); err != nil {
+						errs = append(errs, err)
+					}
+				}
+				return errors.Join(errs...)
+			},
+		})
+	}
+
+	for _, job := range jobs {
+		schedule, enabled := scheduler.ResolveSchedule(cfg.Jobs.Schedules, job.Name, job.Schedule)
+		if !enabled {
+			slog.Info("Job disabled", "job", job.Name)
+			continue
+		}
+		job.Schedule = schedule
+		if job.Timeout == 0 {
+			job.Timeout = 5 * time.Minute
+		}
+		if err := jobScheduler.Register(job); err != nil {
+			locker.Close()
+			return nil, err
+		}
+	}
+
+	jobScheduler.Start()
+	slog.Info("Job scheduler started", "lock", locker.Type())
+	return jobScheduler, nil
+}
+
+// newJobLocker 创建任务锁（auto时跟随Token缓存：redis/database缓存使用同一后端加锁）
+func newJobLocker(cfg *config.Config) (scheduler.Locker, error) {
+	lockType := cfg.Jobs.Lock.Type
+	options := cfg.Jobs.Lock.Options
+	if len(options) == 0 {
+		options = cfg.Cache.Token.Options
+	}
+
+	if lockType == "" || lockType == "auto" {
+		switch backend := cache.SharedBackendType(cfg.Cache.Token.Type, cfg.Cache.Token.Options); backend {
+		case "redis", "database":
+			lockType = backend
+		case "file":
+			slog.Warn("File cache cannot be used as job lock, cleanup jobs will run on every instance (set jobs.lock.type for multi-instance deployments)")
+			lockType = "local"
+		default:
+			lockType = "local"
+		}
+	}
+
+	locker, err := scheduler.NewLocker(lockType, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job lock: %w", err)
+	}
+	return locker, nil
+}
@@ -2,21 +2,38 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"path"
 	"strings"
+	"syscall"
 	"time"
 
 	"yggdrasil-api-go/src/cache"
+	cacheredis "yggdrasil-api-go/src/cache/redis"
 	"yggdrasil-api-go/src/config"
 	"yggdrasil-api-go/src/database"
 	"yggdrasil-api-go/src/handlers"
+	"yggdrasil-api-go/src/health"
+	"yggdrasil-api-go/src/importer"
+	"yggdrasil-api-go/src/logging"
+	"yggdrasil-api-go/src/metrics"
 	"yggdrasil-api-go/src/middleware"
-	"yggdrasil-api-go/src/models"
-	"yggdrasil-api-go/src/routes"
+	"yggdrasil-api-go/src/proxyproto"
+	"yggdrasil-api-go/src/scheduler"
 	storage_factory "yggdrasil-api-go/src/storage"
+	"yggdrasil-api-go/src/storage/cached"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/storage/traced"
+	"yggdrasil-api-go/src/tlsutil"
+	"yggdrasil-api-go/src/tracing"
 	"yggdrasil-api-go/src/utils"
 
 	"github.com/gin-gonic/gin"
@@ -28,63 +45,184 @@ func main() {
 	configPath := flag.String("config", path.Join("conf", "config.yml"), "配置文件路径")
 	flag.Parse()
 
+	// 配置检查在加载配置之前执行（配置无效时输出原因）
+	if flag.Arg(0) == "config" {
+		if err := runConfigCommand(*configPath, flag.Args()[1:]); err != nil {
+			failCommand(flag.Args(), err)
+		}
+		return
+	}
+
 	// 加载配置
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
 	}
 
-	log.Printf("✅ Loaded config from: %s", *configPath)
+	// 管理命令的结果输出到stdout，日志改为输出到stderr
+	if flag.NArg() > 0 && cfg.Logging.Output != "file" {
+		cfg.Logging.Output = "stderr"
+	}
 
-	// 确保密钥对存在（对于非BlessingSkin存储）
-	if cfg.Storage.Type != "blessing_skin" {
-		_, _, err = utils.LoadOrGenerateKeyPair(cfg.Yggdrasil.Keys.PrivateKeyPath, cfg.Yggdrasil.Keys.PublicKeyPath)
-		if err != nil {
-			log.Fatalf("Failed to load or generate key pair: %v", err)
-		}
-		log.Printf("✅ Loaded RSA key pair from %s and %s", cfg.Yggdrasil.Keys.PrivateKeyPath, cfg.Yggdrasil.Keys.PublicKeyPath)
-	} else {
-		log.Printf("✅ RSA key pair will be loaded from BlessingSkin database options table")
+	// 初始化结构化日志（标准库log的输出同样经过该记录器）
+	closeLogs, err := logging.Init(cfg.Logging)
+	if err != nil {
+		fatal("Failed to initialize logging", err)
 	}
+	defer closeLogs()
+
+	slog.Info("Loaded config", "path", *configPath)
+
+	// 收到SIGINT/SIGTERM时取消ctx，后台协程随之退出，随后按顺序优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 配置热重载（可热重载的配置项由处理器和中间件通过config.Current()读取）
+	configReloader := config.NewReloader(*configPath, cfg)
+	configReloader.OnReload(func(old, updated *config.Config) {
+		if updated.Logging.Level != old.Logging.Level {
+			if err := logging.SetLevel(updated.Logging.Level); err != nil {
+				slog.Warn("Failed to apply logging level", "level", updated.Logging.Level, "error", err)
+			}
+		}
+		// 元数据、皮肤域名和功能开关可能变化，重新生成API元数据
+		utils.InvalidateAPIMetadata()
+	})
 
 	// 设置JWT密钥
 	utils.SetJWTSecret(cfg.Auth.JWTSecret)
 
+	// 设置访问令牌哈希（Token缓存只保存令牌的HMAC，不保存原始令牌）
+	utils.SetTokenHashSecret(cfg.TokenHashSecret(), !cfg.Cache.TokenHash.RejectPlaintext)
+
+	// 初始化链路追踪（未启用时只安装W3C traceparent传播器）
+	shutdownTracing, err := tracing.Init(cfg.Monitoring.Tracing)
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// 注册材质类型
+	if err := storage.RegisterTextureTypes(cfg.Texture.Types); err != nil {
+		fatal("Failed to register texture types", err)
+	}
+
 	// 创建存储实例
 	storageFactory := storage_factory.NewStorageFactory()
 	store, err := storageFactory.CreateStorage(&cfg.Storage, &cfg.Texture)
 	if err != nil {
-		log.Fatalf("Failed to create storage: %v", err)
+		fatal("Failed to create storage", err)
+	}
+
+	slog.Info("Storage initialized", "type", store.GetStorageType())
+
+	// 执行管理命令（如 user create、keys rotate，在生成密钥和包装存储之前执行）
+	if flag.NArg() > 0 {
+		commandStore, closeCommandStore := commandStorage(cfg, store)
+		err := runCommand(cfg, commandStore, flag.Args())
+		closeCommandStore()
+		store.Close()
+		if err != nil {
+			failCommand(flag.Args(), err)
+		}
+		return
+	}
+
+	// 确保密钥对存在（对于非BlessingSkin存储）
+	if cfg.Storage.Type != "blessing_skin" {
+		_, _, err = utils.LoadOrGenerateKeyPair(cfg.Yggdrasil.Keys.PrivateKeyPath, cfg.Yggdrasil.Keys.PublicKeyPath)
+		if err != nil {
+			fatal("Failed to load or generate key pair", err)
+		}
+		slog.Info("Loaded RSA key pair", "private_key", cfg.Yggdrasil.Keys.PrivateKeyPath, "public_key", cfg.Yggdrasil.Keys.PublicKeyPath)
+	} else {
+		slog.Info("RSA key pair will be loaded from BlessingSkin options table")
 	}
-	defer store.Close()
 
-	log.Printf("✅ Using %s storage", store.GetStorageType())
+	// 存储调用链路追踪（位于用户缓存之下，只记录实际访问后端的调用）
+	if cfg.Monitoring.Tracing.Enabled {
+		store = traced.NewStorage(store)
+	}
 
 	// 创建缓存实例
 	cacheFactory := cache.NewCacheFactory()
 	tokenCache, err := cacheFactory.CreateTokenCache(cfg.Cache.Token.Type, cfg.Cache.Token.Options)
 	if err != nil {
-		log.Fatalf("Failed to create token cache: %v", err)
+		fatal("Failed to create token cache", err)
 	}
 	sessionCache, err := cacheFactory.CreateSessionCache(cfg.Cache.Session.Type, cfg.Cache.Session.Options)
 	if err != nil {
-		log.Fatalf("Failed to create session cache: %v", err)
+		fatal("Failed to create session cache", err)
+	}
+
+	// Laravel格式的Redis缓存需要通过存储在用户ID和邮箱之间转换
+	if setter, ok := tokenCache.(cache.UserResolverSetter); ok {
+		setter.SetUserResolver(store)
+		if utils.TokenHashEnabled() {
+			slog.Warn("Token hashing is enabled: tokens issued by this server cannot be read by the BlessingSkin plugin (set cache.token_hash.mode to plaintext to share tokens)")
+		}
+	}
+
+	// 用户缓存和响应缓存的跨实例失效（Token缓存使用Redis时启用）
+	broadcaster, err := cache.NewBroadcaster(cfg.Cache.Token.Type, cfg.Cache.Token.Options)
+	if err != nil {
+		fatal("Failed to create cache invalidation broadcaster", err)
 	}
 
-	log.Printf("✅ Token cache initialized: %s", cfg.Cache.Token.Type)
-	log.Printf("✅ Session cache initialized: %s", cfg.Cache.Session.Type)
+	slog.Info("Token cache initialized", "type", cfg.Cache.Token.Type)
+	slog.Info("Session cache initialized", "type", cfg.Cache.Session.Type)
+
+	// 注册缓存条目数指标，并包装缓存以按后端记录命中率
+	cache.RegisterEntryGauges(tokenCache, sessionCache)
+	tokenCache = cache.InstrumentTokenCache(tokenCache)
+	sessionCache = cache.InstrumentSessionCache(sessionCache)
 
 	// 初始化用户缓存配置
 	if cfg.Cache.User.Enabled {
-		cache.InitUserCache(cfg.Cache.User.Duration)
-		log.Printf("✅ User cache initialized: %v duration", cfg.Cache.User.Duration)
+		cache.InitUserCache(ctx, cfg.Cache.User.Duration, cfg.Cache.User.MaxUsers, cfg.Cache.User.CleanupInterval)
+		// 用户和角色读取经过缓存，材质写入后自动失效
+		store = cached.NewStorage(store, cache.GlobalUserCache, broadcaster)
+		slog.Info("User cache initialized", "duration", cfg.Cache.User.Duration, "max_users", cfg.Cache.User.MaxUsers)
 	} else {
-		log.Printf("ℹ️  User cache disabled")
+		slog.Info("User cache disabled")
 	}
 
+	// 初始化响应缓存，角色材质或名称变化时立即清除本实例和其他实例的相关响应
+	utils.InitResponseCache(cfg.Cache.Response)
+	propagateProfileChanges(broadcaster)
+
 	// 缓存预热
 	if err := utils.WarmupCaches(cfg, store); err != nil {
-		log.Printf("⚠️  Cache warmup failed: %v", err)
+		slog.Warn("Cache warmup failed", "error", err)
+	}
+
+	// 启动后台任务（过期Token/Session清理等）
+	jobScheduler, err := startJobs(ctx, cfg, store, tokenCache, sessionCache)
+	if err != nil {
+		fatal("Failed to start job scheduler", err)
+	}
+
+	// 就绪检查（依赖检查在创建处理器时注册）
+	readiness := health.NewChecker(cfg.Monitoring.HealthTimeout)
+
+	// 管理子系统的MySQL连接（配置文件中有database.mysql时启用）
+	adminDB, err := openAdminDatabase(*configPath)
+	if err != nil {
+		fatal("Failed to connect to admin database", err)
+	}
+	if adminDB != nil {
+		defer adminDB.Close()
+		readiness.Register("mysql", adminDB.HealthCheck)
+	}
+
+	// 材质导入（从上游Yggdrasil/Mojang服务器）
+	var skinImporter *importer.Importer
+	if cfg.Texture.Import.Enabled {
+		skinImporter, err = importer.NewImporter(cfg, store)
+		if err != nil {
+			fatal("Failed to create texture importer", err)
+		}
+		slog.Info("Texture import enabled", "upstreams", skinImporter.GetUpstreams())
 	}
 
 	// 创建处理器（直接传入存储和缓存）
@@ -92,20 +230,72 @@ func main() {
 	authHandler := handlers.NewAuthHandler(store, tokenCache, sessionCache)
 	sessionHandler := handlers.NewSessionHandler(store, tokenCache, sessionCache, cfg)
 	profileHandler := handlers.NewProfileHandler(store, cfg)
-	textureHandler := handlers.NewTextureHandler(store)
+	healthHandler := handlers.NewHealthHandler(readiness, metaHandler, tokenCache, sessionCache)
+	textureHandler := handlers.NewTextureHandler(store, tokenCache, skinImporter, cfg)
+	configHandler := handlers.NewConfigHandler(store, tokenCache, configReloader)
+	tokenAdminHandler := handlers.NewTokenAdminHandler(store, tokenCache)
+
+	// SIGHUP或配置文件变化时重新加载配置
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				configReloader.Reload(config.ReloadTriggerSignal)
+			}
+		}
+	}()
+	if cfg.Server.ConfigReload.Watch {
+		go configReloader.Watch(ctx, cfg.Server.ConfigReload.Interval)
+	}
 
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = logging.StdWriter(slog.LevelDebug)
+	gin.DefaultErrorWriter = logging.StdWriter(slog.LevelError)
+
+	// 请求体大小限制（材质上传的multipart请求体需要容纳材质文件）
+	maxRequestSize, err := cfg.Security.MaxRequestBytes()
+	if err != nil {
+		fatal("Invalid max request size", err)
+	}
+	uploadLimits := make(map[string]int64)
+	if maxRequestSize > 0 {
+		uploadRoute := path.Join("/", cfg.Server.BaseURL, "/api/user/profile/:uuid/:textureType")
+		uploadLimits[uploadRoute] = max(maxRequestSize, cfg.Texture.MaxFileSize+multipartOverhead)
+	}
 
 	// 创建路由器
 	router := gin.New()
 	router.RemoveExtraSlash = true
 	router.RedirectTrailingSlash = true
 
+	// 客户端IP只采用可信代理发送的请求头（会话IP验证、速率限制和日志使用同一个IP）
+	router.RemoteIPHeaders = []string{cfg.Server.Proxy.ClientIPHeader}
+	if err := router.SetTrustedProxies(cfg.Server.Proxy.TrustedProxies); err != nil {
+		fatal("Invalid trusted proxies", err)
+	}
+	if len(cfg.Server.Proxy.TrustedProxies) > 0 {
+		slog.Info("Trusting client IP header from proxies", "header", cfg.Server.Proxy.ClientIPHeader, "proxies", cfg.Server.Proxy.TrustedProxies)
+	}
+
 	// 添加中间件
-	router.Use(gin.Logger())
+	router.Use(middleware.RequestLogger()) // 请求日志中间件（请求ID、路由、用户ID等字段）
 	router.Use(gin.Recovery())
+	router.Use(middleware.MaxRequestSize(maxRequestSize, uploadLimits)) // 请求体大小限制（材质上传按max_file_size放宽）
 	router.Use(middleware.CORS())
+	if cfg.Server.TLS.ClientCAFile != "" {
+		// 管理接口需要客户端证书（mTLS），挂载在根路径和基础路径下的管理接口都受保护
+		router.Use(middleware.RequireClientCert("/api/admin", path.Join("/", cfg.Server.BaseURL, "/api/admin")))
+	}
+	if cfg.Monitoring.Tracing.Enabled {
+		router.Use(middleware.Tracing()) // 链路追踪中间件（解析上游traceparent）
+	}
 	router.Use(middleware.PerformanceMonitor()) // 性能监控中间件
 
 	// 根据配置决定是否使用基础路径
@@ -119,9 +309,24 @@ func main() {
 	// API元数据端点
 	baseGroup.GET("/", metaHandler.GetAPIMetadata)
 
-	// 性能监控端点
+	// 健康检查端点（存活检查不检查依赖，就绪检查逐项检查存储、缓存和签名密钥）
+	baseGroup.GET("/healthz", healthHandler.Liveness)
+	baseGroup.GET("/readyz", healthHandler.Readiness)
+
+	// 性能监控端点（Prometheus抓取时按Accept头返回文本格式，否则返回JSON）
+	prometheusHandler := gin.WrapH(metrics.Handler())
+	baseGroup.GET("/metrics/prometheus", prometheusHandler)
 	baseGroup.GET("/metrics", func(c *gin.Context) {
+		if metrics.WantsPrometheus(c.Request) {
+			prometheusHandler(c)
+			return
+		}
+
 		stats := utils.GlobalMetrics.GetStats()
+		if cfg.Cache.User.Enabled {
+			stats["user_cache"] = cache.GlobalUserCache.GetStats()
+		}
+		stats["jobs"] = jobScheduler.GetStats()
 		utils.RespondJSONFast(c, stats)
 	})
 
@@ -129,17 +334,16 @@ func main() {
 	authGroup := baseGroup.Group("/authserver")
 	authGroup.Use(middleware.CheckContentType())
 	{
-		// 需要速率限制的端点（如果启用）
-		if cfg.Rate.Enabled {
-			rateLimitedGroup := authGroup.Group("")
-			rateLimitedGroup.Use(middleware.RateLimit(cfg.Rate.AuthInterval))
-			{
-				rateLimitedGroup.POST("/authenticate", authHandler.Authenticate)
-				rateLimitedGroup.POST("/signout", authHandler.Signout)
-			}
-		} else {
-			authGroup.POST("/authenticate", authHandler.Authenticate)
-			authGroup.POST("/signout", authHandler.Signout)
+		// 需要速率限制的端点（禁用时间隔为0，热重载rate配置后立即生效）
+		authLimiter := middleware.NewRateLimiter(authRateInterval(cfg.Rate))
+		configReloader.OnReload(func(_, updated *config.Config) {
+			authLimiter.SetInterval(authRateInterval(updated.Rate))
+		})
+		rateLimitedGroup := authGroup.Group("")
+		rateLimitedGroup.Use(middleware.RateLimitWith(authLimiter))
+		{
+			rateLimitedGroup.POST("/authenticate", authHandler.Authenticate)
+			rateLimitedGroup.POST("/signout", authHandler.Signout)
 		}
 
 		// 其他认证端点
@@ -162,53 +366,224 @@ func main() {
 		apiGroup.POST("/profiles/minecraft", middleware.CheckContentType(), profileHandler.SearchMultipleProfiles)
 		apiGroup.GET("/users/profiles/minecraft/:username", profileHandler.SearchSingleProfile)
 
-		// 材质管理端点 (符合Yggdrasil规范)
-		apiGroup.PUT("/user/profile/:uuid/:textureType", middleware.CheckContentType(), textureHandler.UploadTexture)
+		// 材质管理端点 (符合Yggdrasil规范，上传使用multipart/form-data)
+		apiGroup.PUT("/user/profile/:uuid/:textureType", textureHandler.UploadTexture)
 		apiGroup.DELETE("/user/profile/:uuid/:textureType", textureHandler.DeleteTexture)
+
+		// 材质变更历史（角色所有者或管理员）
+		apiGroup.GET("/user/profile/:uuid/:textureType/history", textureHandler.GetTextureHistory)
+		apiGroup.POST("/user/profile/:uuid/:textureType/history/:id/revert", textureHandler.RevertTexture)
+
+		// 从上游服务器导入材质
+		apiGroup.POST("/user/profile/:uuid/import", middleware.CheckContentType(), textureHandler.ImportTexture)
 	}
 
-	// 启动清理协程
-	go startCleanupRoutines(tokenCache, sessionCache)
+	// 管理接口（需要管理员的访问令牌，配置了client_ca_file时还需要客户端证书）
+	adminGroup := baseGroup.Group("/api/admin")
+	{
+		adminGroup.GET("/config/reload", configHandler.GetReloadStatus)
+		adminGroup.POST("/config/reload", configHandler.Reload)
+		adminGroup.DELETE("/users/:id/tokens", tokenAdminHandler.RevokeUserTokens)
+	}
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
-	apiRoot := fmt.Sprintf("http://localhost:%d%s", cfg.Server.Port, cfg.Server.BaseURL)
+	scheme := "http"
+	if cfg.Server.TLS.Enabled {
+		scheme = "https"
+	}
+	apiRoot := fmt.Sprintf("%s://localhost:%d%s", scheme, cfg.Server.Port, cfg.Server.BaseURL)
 	if cfg.Server.BaseURL == "" {
-		apiRoot = fmt.Sprintf("http://localhost:%d/", cfg.Server.Port)
+		apiRoot = fmt.Sprintf("%s://localhost:%d/", scheme, cfg.Server.Port)
 	} else if !strings.HasSuffix(apiRoot, "/") {
 		apiRoot += "/"
 	}
 
-	log.Printf("🚀 Yggdrasil API Server starting on %s", addr)
-	log.Printf("📖 API Documentation: http://localhost:%d", cfg.Server.Port)
-	log.Printf("🔗 API Root: %s", apiRoot)
-	if cfg.Server.BaseURL != "" {
-		log.Printf("📍 Base URL: %s", cfg.Server.BaseURL)
+	slog.Info("Yggdrasil API Server starting", "addr", addr, "api_root", apiRoot, "base_url", cfg.Server.BaseURL)
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      router,
+		ReadTimeout:  cfg.Security.ReadTimeout,
+		WriteTimeout: cfg.Security.WriteTimeout,
+		IdleTimeout:  cfg.Security.IdleTimeout,
 	}
 
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	listener, err := listen(addr, cfg.Server.Proxy)
+	if err != nil {
+		fatal("Failed to start server", err)
 	}
-}
+	if cfg.Server.Proxy.ProxyProtocol {
+		slog.Info("PROXY protocol enabled", "addr", addr)
+	}
+
+	servers := []*http.Server{server}
+	serverErr := make(chan error, 2)
 
-// startCleanupRoutines 启动清理协程
-func startCleanupRoutines(tokenCache cache.TokenCache, sessionCache cache.SessionCache) {
-	ticker := time.NewTicker(5 * time.Minute) // 每5分钟清理一次
-	defer ticker.Stop()
+	if cfg.Server.TLS.Enabled {
+		// 证书文件更新后自动重新加载，无需重启
+		reloader, err := tlsutil.NewCertReloader(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile, cfg.Server.TLS.ReloadInterval)
+		if err != nil {
+			fatal("Failed to load TLS certificate", err)
+		}
+		go reloader.Run(ctx)
 
-	for range ticker.C {
-		log.Println("🧹 Running cleanup routine...")
+		server.TLSConfig, err = tlsutil.NewServerConfig(reloader, cfg.Server.TLS.ClientCAFile)
+		if err != nil {
+			fatal("Failed to configure TLS", err)
+		}
+		if cfg.Server.TLS.ClientCAFile != "" {
+			slog.Info("Client certificates required for admin routes", "client_ca_file", cfg.Server.TLS.ClientCAFile)
+		}
 
-		// 清理过期Token
-		if err := tokenCache.CleanupExpired(); err != nil {
-			log.Printf("❌ Failed to cleanup expired tokens: %v", err)
+		go func() {
+			serverErr <- server.ServeTLS(listener, "", "")
+		}()
+
+		// HTTP→HTTPS跳转
+		if cfg.Server.TLS.RedirectAddr != "" {
+			redirectServer := &http.Server{
+				Addr:         cfg.Server.TLS.RedirectAddr,
+				Handler:      tlsutil.RedirectHandler(cfg.Server.Port),
+				ReadTimeout:  cfg.Security.ReadTimeout,
+				WriteTimeout: cfg.Security.WriteTimeout,
+				IdleTimeout:  cfg.Security.IdleTimeout,
+			}
+			redirectListener, err := listen(cfg.Server.TLS.RedirectAddr, cfg.Server.Proxy)
+			if err != nil {
+				fatal("Failed to start redirect server", err)
+			}
+			servers = append(servers, redirectServer)
+			slog.Info("Redirecting HTTP to HTTPS", "addr", cfg.Server.TLS.RedirectAddr)
+
+			go func() {
+				serverErr <- redirectServer.Serve(redirectListener)
+			}()
 		}
+	} else {
+		go func() {
+			serverErr <- server.Serve(listener)
+		}()
+	}
 
-		// 清理过期Session
-		if err := sessionCache.CleanupExpired(); err != nil {
-			log.Printf("❌ Failed to cleanup expired sessions: %v", err)
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal("Failed to start server", err)
 		}
+	case <-ctx.Done():
+		// 恢复默认信号处理，关闭过程中再次收到信号时直接退出
+		stop()
+	}
+
+	shutdown(servers, cfg.Server, readiness, jobScheduler, tokenCache, sessionCache, broadcaster, store)
+}
 
-		log.Println("✅ Cleanup routine completed")
+// authRateInterval 认证请求的速率限制间隔（未启用时为0，不限制）
+func authRateInterval(rate config.RateConfig) time.Duration {
+	if !rate.Enabled {
+		return 0
+	}
+	return rate.AuthInterval
+}
+
+// listen 创建TCP监听器（启用PROXY协议时从可信代理的PROXY头中获取客户端地址）
+func listen(addr string, proxy config.ProxyConfig) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
 	}
+	if !proxy.ProxyProtocol {
+		return listener, nil
+	}
+
+	trusted, err := proxy.TrustedNets()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return proxyproto.NewListener(listener, trusted, proxy.ProxyProtocolTimeout), nil
+}
+
+// openAdminDatabase 连接管理子系统的MySQL数据库（未配置database.mysql时返回nil）
+func openAdminDatabase(configPath string) (*database.MySQLManager, error) {
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if !v.IsSet("database.mysql") {
+		return nil, nil
+	}
+
+	mysqlConfig, err := database.LoadMySQLConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	return database.NewMySQLManager(mysqlConfig)
+}
+
+// fatal 记录错误并退出（与log.Fatalf相同，不执行defer）
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// multipartOverhead 材质上传请求体中multipart边界和表单字段的额外空间
+const multipartOverhead = 64 * 1024
+
+// shutdown 优雅关闭：先让就绪检查返回失败，再停止接受新连接并等待进行中的请求完成，
+// 最后依次停止后台任务、关闭缓存（内存缓存在关闭时写入快照）和存储
+func shutdown(servers []*http.Server, serverCfg config.ServerConfig, readiness *health.Checker, jobScheduler *scheduler.Scheduler, tokenCache cache.TokenCache, sessionCache cache.SessionCache, broadcaster *cacheredis.Broadcaster, store storage.Storage) {
+	slog.Info("Shutting down, draining requests", "timeout", serverCfg.ShutdownTimeout)
+	readiness.SetShuttingDown()
+
+	// 等待负载均衡器观察到未就绪状态并摘除实例
+	if serverCfg.ShutdownDelay > 0 {
+		time.Sleep(serverCfg.ShutdownDelay)
+	}
+
+	ctx := context.Background()
+	if serverCfg.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, serverCfg.ShutdownTimeout)
+		defer cancel()
+	}
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Warn("Failed to drain requests", "addr", server.Addr, "error", err)
+			server.Close()
+		}
+	}
+
+	if err := jobScheduler.Stop(); err != nil {
+		slog.Warn("Failed to stop job scheduler", "error", err)
+	}
+	if err := tokenCache.Close(); err != nil {
+		slog.Warn("Failed to close token cache", "error", err)
+	}
+	if err := sessionCache.Close(); err != nil {
+		slog.Warn("Failed to close session cache", "error", err)
+	}
+	if err := broadcaster.Close(); err != nil {
+		slog.Warn("Failed to close cache invalidation broadcaster", "error", err)
+	}
+	cache.GlobalUserCache.Close()
+	if err := store.Close(); err != nil {
+		slog.Warn("Failed to close storage", "error", err)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// profileResponseKind 角色响应缓存的跨实例失效消息类型（角色UUID）
+const profileResponseKind = "profile_response"
+
+// propagateProfileChanges 角色变更时清除本进程的角色响应缓存，并通知其他实例（broadcaster为nil时只清除本进程）
+func propagateProfileChanges(broadcaster *cacheredis.Broadcaster) {
+	storage.OnProfileChanged(func(profileUUID string) {
+		utils.InvalidateProfileResponses(profileUUID)
+		broadcaster.Publish(profileResponseKind, profileUUID)
+	})
+	broadcaster.Subscribe(profileResponseKind, utils.InvalidateProfileResponses)
 }
@@ -0,0 +1,99 @@
+// Package database 数据库缓存的表结构迁移（按方言）
+package database
+
+import (
+	sqldb "yggdrasil-api-go/src/database"
+)
+
+// tokenMigrations Token缓存表的迁移
+var tokenMigrations = []sqldb.Migration{
+	{
+		Version: 1,
+		Name:    "create token table",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	user_id varchar(50) NOT NULL,
+	token_id varchar(50) NOT NULL,
+	client_token varchar(255),
+	profile_id varchar(50),
+	created_at datetime(3) NOT NULL,
+	expires_at datetime(3) NOT NULL,
+	updated_at datetime(3) NOT NULL,
+	PRIMARY KEY (user_id, token_id),
+	KEY idx_{{table}}_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			sqldb.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	user_id varchar(50) NOT NULL,
+	token_id varchar(50) NOT NULL,
+	client_token varchar(255),
+	profile_id varchar(50),
+	created_at timestamptz NOT NULL,
+	expires_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL,
+	PRIMARY KEY (user_id, token_id)
+)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_expires_at ON {{table}} (expires_at)`},
+			sqldb.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	user_id text NOT NULL,
+	token_id text NOT NULL,
+	client_token text,
+	profile_id text,
+	created_at datetime NOT NULL,
+	expires_at datetime NOT NULL,
+	updated_at datetime NOT NULL,
+	PRIMARY KEY (user_id, token_id)
+)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_expires_at ON {{table}} (expires_at)`},
+		},
+	},
+}
+
+// sessionMigrations Session缓存表的迁移
+var sessionMigrations = []sqldb.Migration{
+	{
+		Version: 1,
+		Name:    "create session table",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	server_id varchar(255) NOT NULL,
+	client_ip varchar(45) NOT NULL,
+	access_token varchar(512) NOT NULL DEFAULT '',
+	profile_id varchar(50) NOT NULL DEFAULT '',
+	created_at datetime(3) NOT NULL,
+	expires_at datetime(3) NOT NULL,
+	PRIMARY KEY (server_id),
+	KEY idx_{{table}}_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			sqldb.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	server_id varchar(255) NOT NULL,
+	client_ip varchar(45) NOT NULL,
+	access_token varchar(512) NOT NULL DEFAULT '',
+	profile_id varchar(50) NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL,
+	expires_at timestamptz NOT NULL,
+	PRIMARY KEY (server_id)
+)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_expires_at ON {{table}} (expires_at)`},
+			sqldb.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	server_id text NOT NULL,
+	client_ip text NOT NULL,
+	access_token text NOT NULL DEFAULT '',
+	profile_id text NOT NULL DEFAULT '',
+	created_at datetime NOT NULL,
+	expires_at datetime NOT NULL,
+	PRIMARY KEY (server_id)
+)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_expires_at ON {{table}} (expires_at)`},
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add hasJoined check counter",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL:    {`ALTER TABLE {{table}} ADD COLUMN checks bigint NOT NULL DEFAULT 0`},
+			sqldb.DialectPostgres: {`ALTER TABLE {{table}} ADD COLUMN checks bigint NOT NULL DEFAULT 0`},
+			sqldb.DialectSQLite:   {`ALTER TABLE {{table}} ADD COLUMN checks integer NOT NULL DEFAULT 0`},
+		},
+		Skip: sqldb.ColumnExists("checks"),
+	},
+}
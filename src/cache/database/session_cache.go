@@ -2,17 +2,16 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
+	sqldb "yggdrasil-api-go/src/database"
 	"yggdrasil-api-go/src/yggdrasil"
 
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	"gorm.io/gorm/clause"
 )
 
 // CacheSession 数据库缓存Session表结构（优化设计）
@@ -21,9 +20,10 @@ type CacheSession struct {
 	ServerID string `gorm:"primaryKey;column:server_id;size:255" json:"server_id"` // 服务器ID
 
 	// Session信息（只存储必要信息）
-	ClientIP    string `gorm:"size:45;column:client_ip;not null" json:"client_ip"`                          // 客户端IP
+	ClientIP    string `gorm:"size:45;column:client_ip;not null" json:"client_ip"`                   // 客户端IP
 	AccessToken string `gorm:"size:512;column:access_token;not null;default:''" json:"access_token"` // AccessToken（验证用）
-	ProfileID   string `gorm:"size:50;column:profile_id;not null;default:''" json:"profile_id"`                         // 角色ID（冗余字段，暂不使用）
+	ProfileID   string `gorm:"size:50;column:profile_id;not null;default:''" json:"profile_id"`      // 角色ID（冗余字段，暂不使用）
+	Checks      int    `gorm:"column:checks;not null;default:0" json:"checks"`                       // 已通过的hasJoined验证次数
 
 	// 时间信息
 	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
@@ -44,6 +44,7 @@ func (cs CacheSession) TableName() string {
 // SessionCache 数据库Session缓存
 type SessionCache struct {
 	db          *gorm.DB
+	dialect     string // 数据库方言：mysql, postgres, sqlite
 	tablePrefix string
 	mu          sync.RWMutex
 }
@@ -61,45 +62,26 @@ func NewSessionCache(options map[string]any) (*SessionCache, error) {
 	// 获取debug配置，默认为false
 	debug, _ := options["debug"].(bool)
 
-	// 确定日志级别
-	logLevel := logger.Silent
-	if debug {
-		logLevel = logger.Info
-	}
-
-	// 根据DSN自动选择数据库驱动
-	var db *gorm.DB
-	var err error
-
-	if strings.HasPrefix(dsn, "file:") || strings.HasSuffix(dsn, ".db") {
-		// SQLite数据库
-		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logLevel),
-		})
-	} else {
-		// MySQL数据库
-		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logLevel),
-		})
-	}
-
+	// 根据DSN的scheme选择数据库驱动（postgres://、mysql://、sqlite://）
+	db, dialect, err := sqldb.Open(dsn, debug)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
+	sqldb.Instrument(db, "session_cache")
 
 	// 创建SessionCache实例
 	cache := &SessionCache{
 		db:          db,
+		dialect:     dialect,
 		tablePrefix: tablePrefix,
 	}
 
 	// 创建带前缀的表结构实例用于迁移
 	sessionModel := cache.newCacheSession()
 
-	// 使用Table()方法指定表名进行迁移
-	tableName := sessionModel.TableName()
-	if err := db.Table(tableName).AutoMigrate(&CacheSession{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate %s table: %w", tableName, err)
+	// 按方言执行表结构迁移
+	if err := sqldb.Migrate(db, dialect, sessionModel.TableName(), sessionMigrations); err != nil {
+		return nil, err
 	}
 
 	// 注释：不启动内部清理，使用全局清理例程
@@ -114,12 +96,16 @@ func (c *SessionCache) newCacheSession() *CacheSession {
 }
 
 // Store 存储Session
-func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error {
+func (c *SessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Session默认30秒过期（与Yggdrasil标准一致）
-	expiresAt := time.Now().Add(30 * time.Second)
+	// 按剩余有效期存储（与Yggdrasil标准一致，最长30秒），已过期的Session不再存储
+	ttl := session.TTL()
+	if ttl <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(ttl)
 
 	// 存储到数据库（只存储必要信息，不存储AccessToken和ProfileID）
 	cacheSession := c.newCacheSession()
@@ -128,10 +114,11 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 	cacheSession.ProfileID = session.ProfileID
 	cacheSession.ClientIP = session.ClientIP
 	cacheSession.CreatedAt = session.CreatedAt
+	cacheSession.Checks = session.Checks
 	cacheSession.ExpiresAt = expiresAt
 
-	// 使用Table()方法明确指定表名进行Save操作
-	result := c.db.Table(cacheSession.TableName()).Save(cacheSession)
+	// 按ServerID插入或更新（方言相关的upsert语句）
+	result := c.db.WithContext(ctx).Table(cacheSession.TableName()).Clauses(sqldb.Upsert("server_id")).Create(cacheSession)
 	if result.Error != nil {
 		return fmt.Errorf("failed to store session: %w", result.Error)
 	}
@@ -140,12 +127,12 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 }
 
 // Get 获取Session（优化版：直接从数据库字段构建Session对象）
-func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
+func (c *SessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	cacheSession := c.newCacheSession()
-	result := c.db.Table(cacheSession.TableName()).Where("server_id = ? AND expires_at > ?", serverID, time.Now()).First(cacheSession)
+	result := c.db.WithContext(ctx).Table(cacheSession.TableName()).Where("server_id = ? AND expires_at > ?", serverID, time.Now()).First(cacheSession)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("session not found")
@@ -154,24 +141,28 @@ func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
 	}
 
 	// 直接从数据库字段构建Session对象（不需要反序列化）
-	session := &yggdrasil.Session{
-		ServerID:    cacheSession.ServerID,
-		AccessToken: cacheSession.AccessToken,
-		ProfileID:   cacheSession.ProfileID,
-		ClientIP:    cacheSession.ClientIP,
-		CreatedAt:   cacheSession.CreatedAt,
-	}
+	return cacheSession.toSession(), nil
+}
 
-	return session, nil
+// toSession 转换为Session
+func (cs *CacheSession) toSession() *yggdrasil.Session {
+	return &yggdrasil.Session{
+		ServerID:    cs.ServerID,
+		AccessToken: cs.AccessToken,
+		ProfileID:   cs.ProfileID,
+		ClientIP:    cs.ClientIP,
+		CreatedAt:   cs.CreatedAt,
+		Checks:      cs.Checks,
+	}
 }
 
 // Delete 删除Session（优化版：直接按ServerID删除）
-func (c *SessionCache) Delete(serverID string) error {
+func (c *SessionCache) Delete(ctx context.Context, serverID string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	cacheSession := c.newCacheSession()
-	result := c.db.Table(cacheSession.TableName()).Where("server_id = ?", serverID).Delete(&CacheSession{})
+	result := c.db.WithContext(ctx).Table(cacheSession.TableName()).Where("server_id = ?", serverID).Delete(&CacheSession{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete session: %w", result.Error)
 	}
@@ -179,20 +170,78 @@ func (c *SessionCache) Delete(serverID string) error {
 	return nil
 }
 
+// Consume 获取并删除Session
+// PostgreSQL和SQLite使用DELETE ... RETURNING；MySQL不支持RETURNING，在事务内SELECT ... FOR UPDATE后删除
+func (c *SessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tableName := c.newCacheSession().TableName()
+
+	var cacheSession CacheSession
+	var consumed bool
+	if c.dialect == sqldb.DialectMySQL {
+		err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			result := tx.Table(tableName).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("server_id = ?", serverID).Limit(1).Find(&cacheSession)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			result = tx.Table(tableName).Where("server_id = ?", serverID).Delete(&CacheSession{})
+			consumed = result.RowsAffected > 0
+			return result.Error
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to consume session: %w", err)
+		}
+	} else {
+		var deleted []CacheSession
+		result := c.db.WithContext(ctx).Table(tableName).Clauses(clause.Returning{}).
+			Where("server_id = ?", serverID).Delete(&deleted)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to consume session: %w", result.Error)
+		}
+		if len(deleted) > 0 {
+			cacheSession = deleted[0]
+			consumed = true
+		}
+	}
+
+	if !consumed {
+		return nil, fmt.Errorf("session not found")
+	}
+	if !time.Now().Before(cacheSession.ExpiresAt) {
+		return nil, fmt.Errorf("session expired")
+	}
+
+	return cacheSession.toSession(), nil
+}
+
 // CleanupExpired 清理过期Session
 func (c *SessionCache) CleanupExpired() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// 分批删除过期Session
 	cacheSession := c.newCacheSession()
-	result := c.db.Table(cacheSession.TableName()).Where("expires_at <= ?", time.Now()).Delete(cacheSession)
-	if result.Error != nil {
-		return fmt.Errorf("failed to cleanup expired sessions: %w", result.Error)
+	if _, err := sqldb.DeleteExpired(c.db, c.dialect, cacheSession.TableName(), "expires_at", time.Now()); err != nil {
+		return fmt.Errorf("failed to cleanup expired sessions: %w", err)
 	}
 
 	return nil
 }
 
+// Count 获取未过期的Session数量
+func (c *SessionCache) Count() (int, error) {
+	cacheSession := c.newCacheSession()
+	var count int64
+	if err := c.db.Table(cacheSession.TableName()).Where("expires_at > ?", time.Now()).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return int(count), nil
+}
+
 // Close 关闭缓存连接
 func (c *SessionCache) Close() error {
 	c.mu.Lock()
@@ -208,6 +257,15 @@ func (c *SessionCache) Close() error {
 	return nil
 }
 
+// Ping 检查缓存后端是否可用
+func (c *SessionCache) Ping(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetCacheType 获取缓存类型
 func (c *SessionCache) GetCacheType() string {
 	return "database"
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// newTestSessionCaches 创建共享同一SQLite数据库的两个Session缓存（模拟两个实例）
+func newTestSessionCaches(t *testing.T) []*SessionCache {
+	t.Helper()
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "cache.db") + "?_busy_timeout=5000"
+
+	var caches []*SessionCache
+	for i := 0; i < 2; i++ {
+		c, err := NewSessionCache(map[string]any{"dsn": dsn})
+		if err != nil {
+			t.Fatalf("NewSessionCache: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		caches = append(caches, c)
+	}
+	return caches
+}
+
+func TestSessionCacheConsumeConcurrent(t *testing.T) {
+	caches := newTestSessionCaches(t)
+
+	ctx := context.Background()
+	for round := 0; round < 10; round++ {
+		if err := caches[0].Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var consumed atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(c *SessionCache) {
+				defer wg.Done()
+				if session, err := c.Consume(ctx, "server"); err == nil && session != nil {
+					consumed.Add(1)
+				}
+			}(caches[i%len(caches)])
+		}
+		wg.Wait()
+
+		if n := consumed.Load(); n != 1 {
+			t.Fatalf("round %d: %d callers consumed the session; want 1", round, n)
+		}
+	}
+}
+
+func TestSessionCacheStoreKeepsCreatedAt(t *testing.T) {
+	c := newTestSessionCaches(t)[0]
+
+	ctx := context.Background()
+	createdAt := time.Now().Add(-25 * time.Second)
+	if err := c.Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: createdAt, Checks: 1}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	session, err := c.Consume(ctx, "server")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if !session.CreatedAt.Equal(createdAt) || session.Checks != 1 {
+		t.Fatalf("session = %+v; want CreatedAt %v and Checks 1", session, createdAt)
+	}
+	if session.TTL() > 5*time.Second {
+		t.Fatalf("re-stored session TTL = %v; want at most the remaining 5s", session.TTL())
+	}
+}
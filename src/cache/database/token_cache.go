@@ -2,29 +2,27 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
 
+	sqldb "yggdrasil-api-go/src/database"
 	"yggdrasil-api-go/src/utils"
 	"yggdrasil-api-go/src/yggdrasil"
 
-	"gorm.io/driver/mysql"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // CacheToken 数据库缓存Token表结构（优化设计）
 type CacheToken struct {
 	// 复合主键：用户ID + TokenID（从JWT中提取）
-	UserID  string `gorm:"primaryKey;column:user_id;size:50" json:"user_id"`  // 用户ID（JWT.sub）
+	UserID  string `gorm:"primaryKey;column:user_id;size:50" json:"user_id"`   // 用户ID（JWT.sub）
 	TokenID string `gorm:"primaryKey;column:token_id;size:50" json:"token_id"` // TokenID（JWT.yggt）
 
 	// Token信息
 	ClientToken string `gorm:"column:client_token;size:255" json:"client_token"` // ClientToken（验证用）
-    ProfileID   string `gorm:"column:profile_id;size:50" json:"profile_id"`   // ProfileID（从JWT中提取）
+	ProfileID   string `gorm:"column:profile_id;size:50" json:"profile_id"`      // ProfileID（从JWT中提取）
 
 	// 时间信息
 	CreatedAt time.Time `gorm:"column:created_at;not null" json:"created_at"`
@@ -46,6 +44,7 @@ func (ct CacheToken) TableName() string {
 // TokenCache 数据库Token缓存
 type TokenCache struct {
 	db          *gorm.DB
+	dialect     string // 数据库方言：mysql, postgres, sqlite
 	tablePrefix string
 	mu          sync.RWMutex
 }
@@ -63,45 +62,26 @@ func NewTokenCache(options map[string]any) (*TokenCache, error) {
 	// 获取debug配置，默认为false
 	debug, _ := options["debug"].(bool)
 
-	// 确定日志级别
-	logLevel := logger.Silent
-	if debug {
-		logLevel = logger.Info
-	}
-
-	// 根据DSN自动选择数据库驱动
-	var db *gorm.DB
-	var err error
-
-	if strings.HasPrefix(dsn, "file:") || strings.HasSuffix(dsn, ".db") {
-		// SQLite数据库
-		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logLevel),
-		})
-	} else {
-		// MySQL数据库
-		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logLevel),
-		})
-	}
-
+	// 根据DSN的scheme选择数据库驱动（postgres://、mysql://、sqlite://）
+	db, dialect, err := sqldb.Open(dsn, debug)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
+	sqldb.Instrument(db, "token_cache")
 
 	// 创建TokenCache实例
 	cache := &TokenCache{
 		db:          db,
+		dialect:     dialect,
 		tablePrefix: tablePrefix,
 	}
 
 	// 创建带前缀的表结构实例用于迁移
 	tokenModel := cache.newCacheToken()
 
-	// 使用Table()方法指定表名进行迁移
-	tableName := tokenModel.TableName()
-	if err := db.Table(tableName).AutoMigrate(&CacheToken{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate %s table: %w", tableName, err)
+	// 按方言执行表结构迁移
+	if err := sqldb.Migrate(db, dialect, tokenModel.TableName(), tokenMigrations); err != nil {
+		return nil, err
 	}
 
 	// 注释：不启动内部清理，使用全局清理例程
@@ -116,7 +96,7 @@ func (c *TokenCache) newCacheToken() *CacheToken {
 }
 
 // Store 存储Token（优化版：先验证JWT，提取信息）
-func (c *TokenCache) Store(token *yggdrasil.Token) error {
+func (c *TokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -136,9 +116,9 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 	cacheToken.ExpiresAt = token.ExpiresAt
 	cacheToken.UpdatedAt = time.Now()
 
-	// 使用Table()方法明确指定表名进行Save操作
+	// 按主键插入或更新（方言相关的upsert语句，避免Save的先更新后插入在并发时主键冲突）
 	tableName := cacheToken.TableName()
-	result := c.db.Table(tableName).Save(cacheToken)
+	result := c.db.WithContext(ctx).Table(tableName).Clauses(sqldb.Upsert("user_id", "token_id")).Create(cacheToken)
 	if result.Error != nil {
 		return fmt.Errorf("failed to store token: %w", result.Error)
 	}
@@ -147,7 +127,7 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 }
 
 // Get 获取Token（优化版：先验证JWT，按需查询数据库）
-func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
+func (c *TokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
 	// 第一步：验证JWT（本地计算，极快）
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -159,7 +139,7 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 	defer c.mu.RUnlock()
 
 	cacheToken := c.newCacheToken()
-	result := c.db.Table(cacheToken.TableName()).Where("user_id = ? AND token_id = ? AND expires_at > ?",
+	result := c.db.WithContext(ctx).Table(cacheToken.TableName()).Where("user_id = ? AND token_id = ? AND expires_at > ?",
 		claims.UserID, claims.TokenID, time.Now()).First(cacheToken)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
@@ -182,7 +162,7 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 }
 
 // Delete 删除Token（优化版：先验证JWT，提取用户ID和TokenID）
-func (c *TokenCache) Delete(accessToken string) error {
+func (c *TokenCache) Delete(ctx context.Context, accessToken string) error {
 	// 先验证JWT并提取信息
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -194,7 +174,7 @@ func (c *TokenCache) Delete(accessToken string) error {
 	defer c.mu.Unlock()
 
 	cacheToken := c.newCacheToken()
-	result := c.db.Table(cacheToken.TableName()).Where("user_id = ? AND token_id = ?",
+	result := c.db.WithContext(ctx).Table(cacheToken.TableName()).Where("user_id = ? AND token_id = ?",
 		claims.UserID, claims.TokenID).Delete(cacheToken)
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete token: %w", result.Error)
@@ -204,13 +184,13 @@ func (c *TokenCache) Delete(accessToken string) error {
 }
 
 // GetUserTokens 获取用户的所有Token（按用户ID查询）
-func (c *TokenCache) GetUserTokens(userID string) ([]*yggdrasil.Token, error) {
+func (c *TokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	cacheToken := c.newCacheToken()
 	var cacheTokens []CacheToken
-	result := c.db.Table(cacheToken.TableName()).Where("user_id = ? AND expires_at > ?", userID, time.Now()).Find(&cacheTokens)
+	result := c.db.WithContext(ctx).Table(cacheToken.TableName()).Where("user_id = ? AND expires_at > ?", userID, time.Now()).Find(&cacheTokens)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get user tokens: %w", result.Error)
 	}
@@ -234,12 +214,12 @@ func (c *TokenCache) GetUserTokens(userID string) ([]*yggdrasil.Token, error) {
 }
 
 // DeleteUserTokens 删除用户的所有Token（按用户ID）
-func (c *TokenCache) DeleteUserTokens(userID string) error {
+func (c *TokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	cacheToken := c.newCacheToken()
-	result := c.db.Table(cacheToken.TableName()).Where("user_id = ?", userID).Delete(&CacheToken{})
+	result := c.db.WithContext(ctx).Table(cacheToken.TableName()).Where("user_id = ?", userID).Delete(&CacheToken{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete user tokens: %w", result.Error)
 	}
@@ -248,8 +228,8 @@ func (c *TokenCache) DeleteUserTokens(userID string) error {
 }
 
 // GetUserTokenCount 获取用户Token数量
-func (c *TokenCache) GetUserTokenCount(userID string) (int, error) {
-	tokens, err := c.GetUserTokens(userID)
+func (c *TokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	tokens, err := c.GetUserTokens(ctx, userID)
 	if err != nil {
 		return 0, err
 	}
@@ -261,15 +241,25 @@ func (c *TokenCache) CleanupExpired() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// 分批删除，避免一次删除大量过期Token时长时间锁表
 	cacheToken := c.newCacheToken()
-	result := c.db.Table(cacheToken.TableName()).Where("expires_at <= ?", time.Now()).Delete(cacheToken)
-	if result.Error != nil {
-		return fmt.Errorf("failed to cleanup expired tokens: %w", result.Error)
+	if _, err := sqldb.DeleteExpired(c.db, c.dialect, cacheToken.TableName(), "expires_at", time.Now()); err != nil {
+		return fmt.Errorf("failed to cleanup expired tokens: %w", err)
 	}
 
 	return nil
 }
 
+// Count 获取未过期的Token数量
+func (c *TokenCache) Count() (int, error) {
+	cacheToken := c.newCacheToken()
+	var count int64
+	if err := c.db.Table(cacheToken.TableName()).Where("expires_at > ?", time.Now()).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return int(count), nil
+}
+
 // Close 关闭缓存连接
 func (c *TokenCache) Close() error {
 	c.mu.Lock()
@@ -285,6 +275,15 @@ func (c *TokenCache) Close() error {
 	return nil
 }
 
+// Ping 检查缓存后端是否可用
+func (c *TokenCache) Ping(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetCacheType 获取缓存类型
 func (c *TokenCache) GetCacheType() string {
 	return "database"
@@ -6,6 +6,7 @@ import (
 
 	"yggdrasil-api-go/src/cache/database"
 	"yggdrasil-api-go/src/cache/file"
+	"yggdrasil-api-go/src/cache/layered"
 	"yggdrasil-api-go/src/cache/memory"
 	"yggdrasil-api-go/src/cache/redis"
 )
@@ -24,11 +25,29 @@ func (f *DefaultCacheFactory) CreateTokenCache(cacheType string, options map[str
 	case "memory":
 		return memory.NewTokenCache(options)
 	case "redis":
+		if redis.IsLaravelFormat(options) {
+			return redis.NewLaravelTokenCache(options)
+		}
 		return redis.NewTokenCache(options)
 	case "file":
 		return file.NewTokenCache(options)
 	case "database":
 		return database.NewTokenCache(options)
+	case "layered":
+		l2Type, err := layeredBackendType(options)
+		if err != nil {
+			return nil, err
+		}
+		l2, err := f.CreateTokenCache(l2Type, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create L2 token cache: %w", err)
+		}
+		tokenCache, err := layered.NewTokenCache(l2, options)
+		if err != nil {
+			l2.Close()
+			return nil, err
+		}
+		return tokenCache, nil
 	default:
 		return nil, fmt.Errorf("unsupported token cache type: %s", cacheType)
 	}
@@ -40,17 +59,78 @@ func (f *DefaultCacheFactory) CreateSessionCache(cacheType string, options map[s
 	case "memory":
 		return memory.NewSessionCache(options)
 	case "redis":
+		if redis.IsLaravelFormat(options) {
+			return redis.NewLaravelSessionCache(options)
+		}
 		return redis.NewSessionCache(options)
 	case "file":
 		return file.NewSessionCache(options)
 	case "database":
 		return database.NewSessionCache(options)
+	case "layered":
+		l2Type, err := layeredBackendType(options)
+		if err != nil {
+			return nil, err
+		}
+		l2, err := f.CreateSessionCache(l2Type, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create L2 session cache: %w", err)
+		}
+		sessionCache, err := layered.NewSessionCache(l2, options)
+		if err != nil {
+			l2.Close()
+			return nil, err
+		}
+		return sessionCache, nil
 	default:
 		return nil, fmt.Errorf("unsupported session cache type: %s", cacheType)
 	}
 }
 
+// layeredBackendType 获取分层缓存的L2类型（默认redis）
+func layeredBackendType(options map[string]any) (string, error) {
+	l2Type := "redis"
+	if t, ok := options["l2_type"].(string); ok && t != "" {
+		l2Type = t
+	}
+
+	switch l2Type {
+	case "redis", "database", "file":
+		return l2Type, nil
+	default:
+		return "", fmt.Errorf("unsupported layered cache L2 type: %s", l2Type)
+	}
+}
+
+// SharedBackendType 获取多实例共享的缓存后端类型（memory缓存只属于本实例，返回空字符串）
+func SharedBackendType(cacheType string, options map[string]any) string {
+	switch cacheType {
+	case "redis", "database", "file":
+		return cacheType
+	case "layered":
+		l2Type, err := layeredBackendType(options)
+		if err != nil {
+			return ""
+		}
+		return l2Type
+	default:
+		return ""
+	}
+}
+
+// NewBroadcaster 创建进程内缓存（用户缓存、响应缓存）的跨实例失效广播
+// Token缓存使用Redis（或分层缓存配置了Redis）时复用其连接选项，否则返回nil，各实例只依赖缓存过期时间
+func NewBroadcaster(cacheType string, options map[string]any) (*redis.Broadcaster, error) {
+	if enabled, ok := options["pubsub"].(bool); ok && !enabled {
+		return nil, nil
+	}
+	if SharedBackendType(cacheType, options) != "redis" && !(cacheType == "layered" && redis.HasClientOptions(options)) {
+		return nil, nil
+	}
+	return redis.NewBroadcaster(options)
+}
+
 // GetSupportedTypes 获取支持的缓存类型
 func (f *DefaultCacheFactory) GetSupportedTypes() []string {
-	return []string{"memory", "redis", "file", "database"}
+	return []string{"memory", "redis", "file", "database", "layered"}
 }
@@ -30,6 +30,19 @@ func NewLaravelFileCache(cacheDir string) *LaravelFileCache {
 	}
 }
 
+// Ping 检查缓存目录是否可写
+func (c *LaravelFileCache) Ping() error {
+	if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+		return fmt.Errorf("cache directory is not accessible: %w", err)
+	}
+	probe, err := os.CreateTemp(c.cacheDir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("cache directory is not writable: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
 // GetCacheFilePath 获取缓存文件路径（Laravel兼容）
 func (c *LaravelFileCache) GetCacheFilePath(key string) string {
 	// Laravel使用MD5哈希作为文件名
@@ -114,6 +127,61 @@ func (c *LaravelFileCache) Delete(key string) error {
 	return nil
 }
 
+// TTL 获取缓存的剩余有效期（不存在或已过期时返回0）
+func (c *LaravelFileCache) TTL(key string) time.Duration {
+	data, err := os.ReadFile(c.GetCacheFilePath(key))
+	if err != nil {
+		return 0
+	}
+
+	_, expiresAt, err := c.ParseLaravelCache(string(data))
+	if err != nil {
+		return 0
+	}
+
+	ttl := time.Until(time.Unix(expiresAt, 0))
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// Pull 原子地读取并删除缓存（对应Cache::pull）
+// 先将缓存文件重命名为唯一的认领文件，重命名成功的进程才能读取，多个进程或实例共享缓存目录时同样有效
+// 返回缓存的过期时间
+func (c *LaravelFileCache) Pull(key string, target interface{}) (time.Time, error) {
+	filePath := c.GetCacheFilePath(key)
+	claimPath := fmt.Sprintf("%s.claim-%d-%d", filePath, os.Getpid(), time.Now().UnixNano())
+
+	if err := os.Rename(filePath, claimPath); err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, fmt.Errorf("cache not found")
+		}
+		return time.Time{}, fmt.Errorf("failed to claim cache file: %w", err)
+	}
+	defer os.Remove(claimPath)
+
+	data, err := os.ReadFile(claimPath)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	serializedData, expiresAt, err := c.ParseLaravelCache(string(data))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse Laravel cache: %w", err)
+	}
+
+	if time.Now().Unix() > expiresAt {
+		return time.Time{}, fmt.Errorf("cache expired")
+	}
+
+	if err := phpserialize.Unmarshal([]byte(serializedData), target); err != nil {
+		return time.Time{}, fmt.Errorf("failed to unserialize cached data: %w", err)
+	}
+
+	return time.Unix(expiresAt, 0), nil
+}
+
 // CleanupExpired 清理过期的缓存文件
 func (c *LaravelFileCache) CleanupExpired() error {
 	return filepath.Walk(c.cacheDir, func(path string, info os.FileInfo, err error) error {
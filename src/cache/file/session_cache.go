@@ -2,9 +2,9 @@
 package file
 
 import (
+	"context"
 	"fmt"
 	"sync"
-	"time"
 
 	"yggdrasil-api-go/src/yggdrasil"
 )
@@ -28,20 +28,24 @@ func NewSessionCache(options map[string]any) (*SessionCache, error) {
 }
 
 // Store 存储Session（优化版：验证JWT但只存储必要信息）
-func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error {
+func (c *SessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Session固定过期时间为30秒（与Yggdrasil标准一致）
-	ttl := 30 * time.Second
+	// 按剩余有效期存储（与Yggdrasil标准一致，最长30秒），已过期的Session不再存储
+	ttl := session.TTL()
+	if ttl <= 0 {
+		return nil
+	}
 
 	// 创建简化的Session对象（不存储AccessToken和ProfileID）
 	cacheSession := &yggdrasil.Session{
 		ServerID:    serverID,
 		AccessToken: session.AccessToken, // 仍然存储AccessToken以供验证
-		ProfileID:   session.ProfileID,  // 仍然存储ProfileID以供验证
+		ProfileID:   session.ProfileID,   // 仍然存储ProfileID以供验证
 		ClientIP:    session.ClientIP,
 		CreatedAt:   session.CreatedAt,
+		Checks:      session.Checks,
 	}
 
 	sessionKey := generateYggdrasilSessionKey(serverID)
@@ -53,7 +57,7 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 }
 
 // Get 获取Session（优化版：直接从缓存字段构建Session对象）
-func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
+func (c *SessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -69,7 +73,7 @@ func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
 }
 
 // Delete 删除Session
-func (c *SessionCache) Delete(serverID string) error {
+func (c *SessionCache) Delete(ctx context.Context, serverID string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -77,6 +81,27 @@ func (c *SessionCache) Delete(serverID string) error {
 	return c.cache.Delete(sessionKey)
 }
 
+// Consume 获取并删除Session（基于重命名认领，跨进程原子）
+func (c *SessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sessionKey := generateYggdrasilSessionKey(serverID)
+
+	var session yggdrasil.Session
+	expiresAt, err := c.cache.Pull(sessionKey, &session)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+
+	// PHP序列化不保留time.Time，根据过期时间推算创建时间
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = expiresAt.Add(-yggdrasil.SessionMaxAge)
+	}
+
+	return &session, nil
+}
+
 // CleanupExpired 清理过期Session
 func (c *SessionCache) CleanupExpired() error {
 	c.mu.Lock()
@@ -91,6 +116,11 @@ func (c *SessionCache) Close() error {
 	return nil
 }
 
+// Ping 检查缓存后端是否可用
+func (c *SessionCache) Ping(ctx context.Context) error {
+	return c.cache.Ping()
+}
+
 // GetCacheType 获取缓存类型
 func (c *SessionCache) GetCacheType() string {
 	return "file"
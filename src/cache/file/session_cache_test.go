@@ -0,0 +1,73 @@
+package file
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// newTestSessionCaches 创建共享同一缓存目录的两个Session缓存（模拟两个进程）
+func newTestSessionCaches(t *testing.T) []*SessionCache {
+	t.Helper()
+	dir := t.TempDir()
+
+	var caches []*SessionCache
+	for i := 0; i < 2; i++ {
+		c, err := NewSessionCache(map[string]any{"cache_dir": dir})
+		if err != nil {
+			t.Fatalf("NewSessionCache: %v", err)
+		}
+		caches = append(caches, c)
+	}
+	return caches
+}
+
+func TestSessionCacheConsumeConcurrent(t *testing.T) {
+	caches := newTestSessionCaches(t)
+
+	ctx := context.Background()
+	for round := 0; round < 20; round++ {
+		if err := caches[0].Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var consumed atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(c *SessionCache) {
+				defer wg.Done()
+				if session, err := c.Consume(ctx, "server"); err == nil && session != nil {
+					consumed.Add(1)
+				}
+			}(caches[i%len(caches)])
+		}
+		wg.Wait()
+
+		if n := consumed.Load(); n != 1 {
+			t.Fatalf("round %d: %d callers consumed the session; want 1", round, n)
+		}
+	}
+}
+
+func TestSessionCacheStoreKeepsExpiry(t *testing.T) {
+	c := newTestSessionCaches(t)[0]
+
+	ctx := context.Background()
+	if err := c.Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: time.Now().Add(-25 * time.Second)}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	// 文件缓存不保留创建时间，根据过期时间推算，重新存储不能延长有效期
+	session, err := c.Consume(ctx, "server")
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	if session.TTL() > 5*time.Second {
+		t.Fatalf("re-stored session TTL = %v; want at most the remaining 5s", session.TTL())
+	}
+}
@@ -2,7 +2,9 @@
 package file
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,7 +31,7 @@ func NewTokenCache(options map[string]any) (*TokenCache, error) {
 }
 
 // Store 存储Token（优化版：先验证JWT，提取信息）
-func (c *TokenCache) Store(token *yggdrasil.Token) error {
+func (c *TokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -47,7 +49,7 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 
 	// 创建简化的Token对象（只存储JWT中没有的信息）
 	cacheToken := &yggdrasil.Token{
-		AccessToken: token.AccessToken, // 保留完整的AccessToken用于兼容性
+		AccessToken: utils.HashAccessToken(token.AccessToken), // 只保存令牌哈希
 		ClientToken: token.ClientToken,
 		ProfileID:   token.ProfileID,
 		Owner:       claims.UserID, // 从JWT中获取用户ID
@@ -61,7 +63,7 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 		return fmt.Errorf("failed to store token: %w", err)
 	}
 
-	// 更新用户Token列表（使用用户ID）
+	// 更新用户Token列表（使用用户ID，列表中保存TokenID而不是访问令牌）
 	userTokensKey := generateYggdrasilUserTokensKey(claims.UserID)
 
 	// 获取现有Token列表
@@ -73,8 +75,8 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 
 	// 检查Token是否已存在
 	found := false
-	for _, accessToken := range existingTokens {
-		if accessToken == token.AccessToken {
+	for _, entry := range existingTokens {
+		if entry == claims.TokenID {
 			found = true
 			break
 		}
@@ -82,7 +84,7 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 
 	// 如果不存在，添加到列表
 	if !found {
-		existingTokens = append(existingTokens, token.AccessToken)
+		existingTokens = append(existingTokens, claims.TokenID)
 	}
 
 	// 存储更新后的Token列表（使用较长的TTL）
@@ -95,7 +97,7 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 }
 
 // Get 获取Token（优化版：先验证JWT，按需查询缓存）
-func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
+func (c *TokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
 	// 第一步：验证JWT（本地计算，极快）
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -113,6 +115,15 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 		return nil, fmt.Errorf("token not found in cache: %w", err)
 	}
 
+	// 升级前写入的明文条目：迁移期内改写为哈希，之后视为不存在
+	if utils.IsPlaintextTokenEntry(token.AccessToken) {
+		if !utils.AcceptPlaintextTokens() {
+			c.cache.Delete(tokenKey)
+			return nil, fmt.Errorf("token not found in cache")
+		}
+		c.rehash(tokenKey, &token)
+	}
+
 	// 构建Token对象（结合JWT信息和缓存信息）
 	result := &yggdrasil.Token{
 		AccessToken: accessToken,
@@ -125,8 +136,20 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 	return result, nil
 }
 
+// rehash 将明文条目改写为哈希（保持缓存文件原有的过期时间）
+func (c *TokenCache) rehash(tokenKey string, token *yggdrasil.Token) {
+	ttl := c.cache.TTL(tokenKey)
+	if ttl <= 0 {
+		return
+	}
+
+	cacheToken := *token
+	cacheToken.AccessToken = utils.HashAccessToken(token.AccessToken)
+	c.cache.Store(tokenKey, &cacheToken, ttl)
+}
+
 // Delete 删除Token（优化版：先验证JWT，提取用户ID和TokenID）
-func (c *TokenCache) Delete(accessToken string) error {
+func (c *TokenCache) Delete(ctx context.Context, accessToken string) error {
 	// 先验证JWT并提取信息
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -138,7 +161,7 @@ func (c *TokenCache) Delete(accessToken string) error {
 	defer c.mu.Unlock()
 
 	// 从用户Token列表中移除（使用用户ID）
-	c.removeTokenFromUserList(claims.UserID, accessToken)
+	c.removeTokenFromUserList(claims.UserID, claims.TokenID, accessToken)
 
 	// 删除Token
 	tokenKey := generateOptimizedTokenKey(claims.UserID, claims.TokenID)
@@ -146,45 +169,61 @@ func (c *TokenCache) Delete(accessToken string) error {
 }
 
 // GetUserTokens 获取用户的所有Token（按用户ID查询）
-func (c *TokenCache) GetUserTokens(userID string) ([]*yggdrasil.Token, error) {
+func (c *TokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	userTokensKey := generateYggdrasilUserTokensKey(userID)
 
-	var accessTokens []string
-	if err := c.cache.Get(userTokensKey, &accessTokens); err != nil {
+	var entries []string
+	if err := c.cache.Get(userTokensKey, &entries); err != nil {
 		return []*yggdrasil.Token{}, nil
 	}
 
 	var tokens []*yggdrasil.Token
-	for _, accessToken := range accessTokens {
+	for _, entry := range entries {
+		tokenID, ok := userListTokenID(entry)
+		if !ok {
+			continue
+		}
+
 		// Laravel缓存已经处理了过期检查，如果能获取到Token就说明没有过期
-		if token, err := c.Get(accessToken); err == nil {
-			tokens = append(tokens, token)
+		var token yggdrasil.Token
+		if err := c.cache.Get(generateOptimizedTokenKey(userID, tokenID), &token); err != nil {
+			continue
+		}
+
+		// 不返回明文令牌
+		if utils.IsPlaintextTokenEntry(token.AccessToken) {
+			if !utils.AcceptPlaintextTokens() {
+				continue
+			}
+			token.AccessToken = utils.HashAccessToken(token.AccessToken)
 		}
+		tokens = append(tokens, &token)
 	}
 
 	return tokens, nil
 }
 
 // DeleteUserTokens 删除用户的所有Token（按用户ID）
-func (c *TokenCache) DeleteUserTokens(userID string) error {
+func (c *TokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	// 获取用户的所有Token
 	userTokensKey := generateYggdrasilUserTokensKey(userID)
 
-	var accessTokens []string
-	if err := c.cache.Get(userTokensKey, &accessTokens); err != nil {
+	var entries []string
+	if err := c.cache.Get(userTokensKey, &entries); err != nil {
 		return nil // 用户没有Token
 	}
 
 	// 删除所有Token
-	for _, accessToken := range accessTokens {
-		tokenKey := generateYggdrasilTokenKey(accessToken)
-		c.cache.Delete(tokenKey)
+	for _, entry := range entries {
+		if tokenID, ok := userListTokenID(entry); ok {
+			c.cache.Delete(generateOptimizedTokenKey(userID, tokenID))
+		}
 	}
 
 	// 删除用户Token列表
@@ -192,8 +231,8 @@ func (c *TokenCache) DeleteUserTokens(userID string) error {
 }
 
 // GetUserTokenCount 获取用户Token数量
-func (c *TokenCache) GetUserTokenCount(userID string) (int, error) {
-	tokens, err := c.GetUserTokens(userID)
+func (c *TokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	tokens, err := c.GetUserTokens(ctx, userID)
 	if err != nil {
 		return 0, err
 	}
@@ -214,36 +253,57 @@ func (c *TokenCache) Close() error {
 	return nil
 }
 
+// Ping 检查缓存后端是否可用
+func (c *TokenCache) Ping(ctx context.Context) error {
+	return c.cache.Ping()
+}
+
 // GetCacheType 获取缓存类型
 func (c *TokenCache) GetCacheType() string {
 	return "file"
 }
 
-// removeTokenFromUserList 从用户Token列表中移除指定Token
-func (c *TokenCache) removeTokenFromUserList(userID, accessToken string) error {
+// removeTokenFromUserList 从用户Token列表中移除指定Token（同时移除升级前保存的明文访问令牌）
+func (c *TokenCache) removeTokenFromUserList(userID, tokenID, accessToken string) error {
 	userTokensKey := generateYggdrasilUserTokensKey(userID)
 
-	var accessTokens []string
-	if err := c.cache.Get(userTokensKey, &accessTokens); err != nil {
+	var entries []string
+	if err := c.cache.Get(userTokensKey, &entries); err != nil {
 		return nil // 用户没有Token列表
 	}
 
 	// 移除指定Token
-	for i, token := range accessTokens {
-		if token == accessToken {
-			accessTokens = append(accessTokens[:i], accessTokens[i+1:]...)
+	for i, entry := range entries {
+		if entry == tokenID || entry == accessToken {
+			entries = append(entries[:i], entries[i+1:]...)
 			break
 		}
 	}
 
 	// 如果列表为空，删除用户Token列表
-	if len(accessTokens) == 0 {
+	if len(entries) == 0 {
 		return c.cache.Delete(userTokensKey)
 	}
 
 	// 更新Token列表
 	userTokensTTL := 7 * 24 * time.Hour // 7天
-	return c.cache.Store(userTokensKey, accessTokens, userTokensTTL)
+	return c.cache.Store(userTokensKey, entries, userTokensTTL)
+}
+
+// userListTokenID 用户Token列表条目对应的TokenID（升级前的条目是完整的访问令牌，从JWT中提取）
+func userListTokenID(entry string) (string, bool) {
+	if !strings.Contains(entry, ".") {
+		return entry, entry != ""
+	}
+	if !utils.AcceptPlaintextTokens() {
+		return "", false
+	}
+
+	claims, err := utils.ValidateJWT(entry)
+	if err != nil {
+		return "", false
+	}
+	return claims.TokenID, true
 }
 
 // generateOptimizedTokenKey 生成优化的Token键（用户ID+TokenID）
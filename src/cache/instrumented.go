@@ -0,0 +1,205 @@
+// Package cache 带监控指标和链路追踪的缓存包装
+package cache
+
+import (
+	"context"
+
+	"yggdrasil-api-go/src/metrics"
+	"yggdrasil-api-go/src/tracing"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedTokenCache 记录Token缓存命中率和Span的包装
+type instrumentedTokenCache struct {
+	TokenCache
+	backend string
+}
+
+// instrumentedSessionCache 记录Session缓存命中率和Span的包装
+type instrumentedSessionCache struct {
+	SessionCache
+	backend string
+}
+
+// InstrumentTokenCache 包装Token缓存，按后端记录Get的命中和未命中及各操作的Span（需在SetUserResolver之后调用）
+func InstrumentTokenCache(c TokenCache) TokenCache {
+	return &instrumentedTokenCache{TokenCache: c, backend: c.GetCacheType()}
+}
+
+// InstrumentSessionCache 包装Session缓存，按后端记录Get和Consume的命中和未命中及各操作的Span
+func InstrumentSessionCache(c SessionCache) SessionCache {
+	return &instrumentedSessionCache{SessionCache: c, backend: c.GetCacheType()}
+}
+
+// startSpan 创建缓存操作的Span
+func startSpan(ctx context.Context, cache, backend, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "cache."+cache+"."+operation,
+		attribute.String("cache.name", cache),
+		attribute.String("cache.backend", backend),
+		attribute.String("cache.operation", operation),
+	)
+}
+
+// endLookup 结束查询类Span（未命中不视为错误）
+func endLookup(span trace.Span, cache, backend string, err error) {
+	metrics.RecordCache(cache, backend, err == nil)
+	result := "hit"
+	if err != nil {
+		result = "miss"
+	}
+	span.SetAttributes(attribute.String("cache.result", result), attribute.String("outcome", result))
+	span.End()
+}
+
+// Store 存储Token
+func (c *instrumentedTokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
+	ctx, span := startSpan(ctx, "token", c.backend, "Store")
+	err := c.TokenCache.Store(ctx, token)
+	tracing.End(span, err)
+	return err
+}
+
+// Get 获取Token
+func (c *instrumentedTokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
+	ctx, span := startSpan(ctx, "token", c.backend, "Get")
+	token, err := c.TokenCache.Get(ctx, accessToken)
+	endLookup(span, "token", c.backend, err)
+	return token, err
+}
+
+// Delete 删除Token
+func (c *instrumentedTokenCache) Delete(ctx context.Context, accessToken string) error {
+	ctx, span := startSpan(ctx, "token", c.backend, "Delete")
+	err := c.TokenCache.Delete(ctx, accessToken)
+	tracing.End(span, err)
+	return err
+}
+
+// GetUserTokens 获取用户的所有Token
+func (c *instrumentedTokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
+	ctx, span := startSpan(ctx, "token", c.backend, "GetUserTokens")
+	tokens, err := c.TokenCache.GetUserTokens(ctx, userID)
+	tracing.End(span, err)
+	return tokens, err
+}
+
+// DeleteUserTokens 删除用户的所有Token
+func (c *instrumentedTokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
+	ctx, span := startSpan(ctx, "token", c.backend, "DeleteUserTokens")
+	err := c.TokenCache.DeleteUserTokens(ctx, userID)
+	tracing.End(span, err)
+	return err
+}
+
+// GetUserTokenCount 获取用户Token数量
+func (c *instrumentedTokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	ctx, span := startSpan(ctx, "token", c.backend, "GetUserTokenCount")
+	count, err := c.TokenCache.GetUserTokenCount(ctx, userID)
+	tracing.End(span, err)
+	return count, err
+}
+
+// SaveSnapshot 传递给被包装的缓存
+func (c *instrumentedTokenCache) SaveSnapshot() error {
+	if snapshotter, ok := c.TokenCache.(Snapshotter); ok {
+		return snapshotter.SaveSnapshot()
+	}
+	return nil
+}
+
+// SnapshotEnabled 被包装的缓存是否启用快照
+func (c *instrumentedTokenCache) SnapshotEnabled() bool {
+	snapshotter, ok := c.TokenCache.(Snapshotter)
+	return ok && snapshotter.SnapshotEnabled()
+}
+
+// CleanupLocal 传递给被包装的缓存
+func (c *instrumentedTokenCache) CleanupLocal() {
+	if cleaner, ok := c.TokenCache.(LocalCleaner); ok {
+		cleaner.CleanupLocal()
+	}
+}
+
+// Store 存储Session
+func (c *instrumentedSessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
+	ctx, span := startSpan(ctx, "session", c.backend, "Store")
+	err := c.SessionCache.Store(ctx, serverID, session)
+	tracing.End(span, err)
+	return err
+}
+
+// Get 获取Session
+func (c *instrumentedSessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	ctx, span := startSpan(ctx, "session", c.backend, "Get")
+	session, err := c.SessionCache.Get(ctx, serverID)
+	endLookup(span, "session", c.backend, err)
+	return session, err
+}
+
+// Delete 删除Session
+func (c *instrumentedSessionCache) Delete(ctx context.Context, serverID string) error {
+	ctx, span := startSpan(ctx, "session", c.backend, "Delete")
+	err := c.SessionCache.Delete(ctx, serverID)
+	tracing.End(span, err)
+	return err
+}
+
+// Consume 获取并删除Session
+func (c *instrumentedSessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	ctx, span := startSpan(ctx, "session", c.backend, "Consume")
+	session, err := c.SessionCache.Consume(ctx, serverID)
+	endLookup(span, "session", c.backend, err)
+	return session, err
+}
+
+// SaveSnapshot 传递给被包装的缓存
+func (c *instrumentedSessionCache) SaveSnapshot() error {
+	if snapshotter, ok := c.SessionCache.(Snapshotter); ok {
+		return snapshotter.SaveSnapshot()
+	}
+	return nil
+}
+
+// SnapshotEnabled 被包装的缓存是否启用快照
+func (c *instrumentedSessionCache) SnapshotEnabled() bool {
+	snapshotter, ok := c.SessionCache.(Snapshotter)
+	return ok && snapshotter.SnapshotEnabled()
+}
+
+// CleanupLocal 传递给被包装的缓存
+func (c *instrumentedSessionCache) CleanupLocal() {
+	if cleaner, ok := c.SessionCache.(LocalCleaner); ok {
+		cleaner.CleanupLocal()
+	}
+}
+
+// RegisterEntryGauges 为支持统计的缓存注册条目数指标（yggdrasil_cache_entries，传入未包装的缓存）
+func RegisterEntryGauges(tokenCache TokenCache, sessionCache SessionCache) {
+	registerEntryGauge("token", tokenCache)
+	registerEntryGauge("session", sessionCache)
+}
+
+// registerEntryGauge 注册单个缓存的条目数指标（不支持统计的后端跳过）
+func registerEntryGauge(name string, c interface{ GetCacheType() string }) {
+	counter, ok := c.(Counter)
+	if !ok {
+		return
+	}
+	// 分层缓存的L2不支持统计时同样跳过
+	if _, err := counter.Count(); err != nil {
+		return
+	}
+
+	metrics.RegisterGauge("cache_entries", "Number of unexpired entries in the token and session caches.",
+		map[string]string{"cache": name, "backend": c.GetCacheType()},
+		func() float64 {
+			count, err := counter.Count()
+			if err != nil {
+				return 0
+			}
+			return float64(count)
+		})
+}
@@ -2,34 +2,39 @@
 package cache
 
 import (
+	"context"
 	"time"
 
+	"yggdrasil-api-go/src/cache/redis"
 	"yggdrasil-api-go/src/yggdrasil"
 )
 
 // TokenCache Token缓存接口
 type TokenCache interface {
 	// Store 存储Token
-	Store(token *yggdrasil.Token) error
+	Store(ctx context.Context, token *yggdrasil.Token) error
 
 	// Get 获取Token
-	Get(accessToken string) (*yggdrasil.Token, error)
+	Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error)
 
 	// Delete 删除Token
-	Delete(accessToken string) error
+	Delete(ctx context.Context, accessToken string) error
 
 	// GetUserTokens 获取用户的所有Token
-	GetUserTokens(userID string) ([]*yggdrasil.Token, error)
+	GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error)
 
 	// DeleteUserTokens 删除用户的所有Token
-	DeleteUserTokens(userID string) error
+	DeleteUserTokens(ctx context.Context, userID string) error
 
 	// GetUserTokenCount 获取用户Token数量
-	GetUserTokenCount(userID string) (int, error)
+	GetUserTokenCount(ctx context.Context, userID string) (int, error)
 
 	// CleanupExpired 清理过期Token
 	CleanupExpired() error
 
+	// Ping 检查缓存后端是否可用（就绪检查使用）
+	Ping(ctx context.Context) error
+
 	// Close 关闭缓存连接
 	Close() error
 
@@ -40,17 +45,23 @@ type TokenCache interface {
 // SessionCache Session缓存接口
 type SessionCache interface {
 	// Store 存储Session
-	Store(serverID string, session *yggdrasil.Session) error
+	Store(ctx context.Context, serverID string, session *yggdrasil.Session) error
 
 	// Get 获取Session
-	Get(serverID string) (*yggdrasil.Session, error)
+	Get(ctx context.Context, serverID string) (*yggdrasil.Session, error)
 
 	// Delete 删除Session
-	Delete(serverID string) error
+	Delete(ctx context.Context, serverID string) error
+
+	// Consume 原子地获取并删除Session（同一serverID只有一个调用者能取到）
+	Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error)
 
 	// CleanupExpired 清理过期Session
 	CleanupExpired() error
 
+	// Ping 检查缓存后端是否可用（就绪检查使用）
+	Ping(ctx context.Context) error
+
 	// Close 关闭缓存连接
 	Close() error
 
@@ -58,6 +69,35 @@ type SessionCache interface {
 	GetCacheType() string
 }
 
+// UserResolver 用户查询（存储实现即满足该接口）
+type UserResolver = redis.UserResolver
+
+// UserResolverSetter 需要在用户ID和邮箱之间转换的缓存（Laravel格式的Redis Token缓存）
+type UserResolverSetter interface {
+	SetUserResolver(resolver UserResolver)
+}
+
+// Snapshotter 支持快照持久化的缓存（内存缓存配置snapshot_file后启用）
+type Snapshotter interface {
+	// SaveSnapshot 保存快照
+	SaveSnapshot() error
+
+	// SnapshotEnabled 是否启用快照
+	SnapshotEnabled() bool
+}
+
+// LocalCleaner 带进程内缓存层的缓存（分层缓存的L1），每个实例需要各自清理过期条目
+type LocalCleaner interface {
+	// CleanupLocal 清理本实例进程内的过期条目（CleanupExpired只清理共享的L2）
+	CleanupLocal()
+}
+
+// Counter 支持统计有效条目数的缓存（用于监控指标；文件缓存的文件名为键的MD5，Token和Session无法区分，不支持）
+type Counter interface {
+	// Count 获取未过期的条目数
+	Count() (int, error)
+}
+
 // CacheFactory 缓存工厂接口
 type CacheFactory interface {
 	// CreateTokenCache 创建Token缓存实例
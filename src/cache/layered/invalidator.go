@@ -0,0 +1,34 @@
+// Package layered 基于Redis发布/订阅的L1失效广播
+package layered
+
+import (
+	cacheredis "yggdrasil-api-go/src/cache/redis"
+)
+
+// 失效消息类型
+const (
+	kindToken   = "token"   // 单个Token（userID:tokenID）
+	kindUser    = "user"    // 用户的所有Token（userID）
+	kindSession = "session" // 单个Session（serverID）
+)
+
+// newInvalidator 创建失效广播器并订阅L1相关的消息（未配置Redis时返回nil，此时只依赖L1过期时间）
+func newInvalidator(options map[string]any, l2Type string, handle func(kind, key string)) (*cacheredis.Broadcaster, error) {
+	if enabled, ok := options["pubsub"].(bool); ok && !enabled {
+		return nil, nil
+	}
+
+	// L2为redis时复用其连接选项（包括Sentinel/Cluster），否则需要显式配置Redis
+	if l2Type != "redis" && !cacheredis.HasClientOptions(options) {
+		return nil, nil
+	}
+
+	broadcaster, err := cacheredis.NewBroadcaster(options)
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range []string{kindToken, kindUser, kindSession} {
+		broadcaster.Subscribe(kind, func(key string) { handle(kind, key) })
+	}
+	return broadcaster, nil
+}
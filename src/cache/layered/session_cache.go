@@ -0,0 +1,155 @@
+// Package layered 分层Session缓存实现
+package layered
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yggdrasil-api-go/src/cache/lru"
+	cacheredis "yggdrasil-api-go/src/cache/redis"
+	"yggdrasil-api-go/src/metrics"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// SessionBackend L2 Session缓存（与cache.SessionCache方法一致，避免循环引用）
+type SessionBackend interface {
+	Store(ctx context.Context, serverID string, session *yggdrasil.Session) error
+	Get(ctx context.Context, serverID string) (*yggdrasil.Session, error)
+	Delete(ctx context.Context, serverID string) error
+	Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error)
+	CleanupExpired() error
+	Ping(ctx context.Context) error
+	Close() error
+	GetCacheType() string
+}
+
+// SessionCache 分层Session缓存：Get优先读L1，写入和删除时广播失效
+// Session的过期由L2负责，L1条目最长保留l1_ttl
+type SessionCache struct {
+	l1          *lru.Cache[*yggdrasil.Session] // serverID -> Session
+	l2          SessionBackend
+	invalidator *cacheredis.Broadcaster
+}
+
+// NewSessionCache 创建分层Session缓存
+func NewSessionCache(l2 SessionBackend, options map[string]any) (*SessionCache, error) {
+	maxEntries, ttl, err := parseL1Options(options)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &SessionCache{
+		l1: lru.New[*yggdrasil.Session](maxEntries, ttl),
+		l2: l2,
+	}
+
+	c.invalidator, err = newInvalidator(options, l2.GetCacheType(), c.handleInvalidation)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// handleInvalidation 处理其他实例发来的失效消息
+func (c *SessionCache) handleInvalidation(kind, key string) {
+	if kind == kindSession {
+		c.l1.Delete(key)
+	}
+}
+
+// Store 存储Session（同一serverID重复加入时，其他实例的L1需要失效）
+func (c *SessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
+	if err := c.l2.Store(ctx, serverID, session); err != nil {
+		return err
+	}
+
+	c.l1.Delete(serverID)
+	c.invalidator.Publish(kindSession, serverID)
+	return nil
+}
+
+// Get 获取Session（L1未命中时读取L2并回填）
+func (c *SessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	if session, ok := c.l1.Get(serverID); ok {
+		metrics.RecordCache("session_l1", "memory", true)
+		sessionCopy := *session
+		return &sessionCopy, nil
+	}
+	metrics.RecordCache("session_l1", "memory", false)
+
+	// 读取L2期间收到的失效会使回填放弃，不会把已删除的Session写回L1
+	gen := c.l1.Generation()
+	session, err := c.l2.Get(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt time.Time
+	if !session.CreatedAt.IsZero() {
+		expiresAt = session.CreatedAt.Add(yggdrasil.SessionMaxAge)
+	}
+
+	if ttl, ok := l1TTL(expiresAt); ok {
+		sessionCopy := *session
+		c.l1.SetIfGeneration(serverID, &sessionCopy, ttl, gen)
+	}
+	return session, nil
+}
+
+// Delete 删除Session并广播失效
+func (c *SessionCache) Delete(ctx context.Context, serverID string) error {
+	err := c.l2.Delete(ctx, serverID)
+
+	c.l1.Delete(serverID)
+	c.invalidator.Publish(kindSession, serverID)
+	return err
+}
+
+// Consume 从L2获取并删除Session（原子性由L2保证，L1仅用于失效）
+func (c *SessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	session, err := c.l2.Consume(ctx, serverID)
+
+	c.l1.Delete(serverID)
+	c.invalidator.Publish(kindSession, serverID)
+	return session, err
+}
+
+// CleanupExpired 清理L2中过期的Session（L2为共享存储，集群内只需一个实例执行）
+func (c *SessionCache) CleanupExpired() error {
+	return c.l2.CleanupExpired()
+}
+
+// CleanupLocal 清理本实例L1中过期的Session
+func (c *SessionCache) CleanupLocal() {
+	c.l1.PurgeExpired()
+}
+
+// Close 关闭缓存连接
+func (c *SessionCache) Close() error {
+	if err := c.invalidator.Close(); err != nil {
+		c.l2.Close()
+		return err
+	}
+	return c.l2.Close()
+}
+
+// Ping 检查缓存后端是否可用
+func (c *SessionCache) Ping(ctx context.Context) error {
+	// L1为进程内缓存，只检查L2
+	return c.l2.Ping(ctx)
+}
+
+// GetCacheType 获取缓存类型
+func (c *SessionCache) GetCacheType() string {
+	return "layered(" + c.l2.GetCacheType() + ")"
+}
+
+// Count 获取L2中的Session数量（L2不支持统计时返回错误）
+func (c *SessionCache) Count() (int, error) {
+	if counter, ok := c.l2.(interface{ Count() (int, error) }); ok {
+		return counter.Count()
+	}
+	return 0, fmt.Errorf("count not supported by %s", c.l2.GetCacheType())
+}
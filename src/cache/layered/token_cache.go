@@ -0,0 +1,243 @@
+// Package layered 分层缓存实现（进程内L1 + Redis/数据库等L2）
+package layered
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"yggdrasil-api-go/src/cache/lru"
+	cacheredis "yggdrasil-api-go/src/cache/redis"
+	"yggdrasil-api-go/src/metrics"
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+const (
+	defaultL1MaxEntries = 10000
+	defaultL1TTL        = 30 * time.Second
+)
+
+// TokenBackend L2 Token缓存（与cache.TokenCache方法一致，避免循环引用）
+type TokenBackend interface {
+	Store(ctx context.Context, token *yggdrasil.Token) error
+	Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error)
+	Delete(ctx context.Context, accessToken string) error
+	GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error)
+	DeleteUserTokens(ctx context.Context, userID string) error
+	GetUserTokenCount(ctx context.Context, userID string) (int, error)
+	CleanupExpired() error
+	Ping(ctx context.Context) error
+	Close() error
+	GetCacheType() string
+}
+
+// TokenCache 分层Token缓存：Get优先读L1，删除时通过Redis发布/订阅让所有实例的L1失效
+// 用户Token列表和数量始终读取L2，保证数量限制准确
+type TokenCache struct {
+	l1          *lru.Cache[*yggdrasil.Token] // "userID:tokenID" -> Token
+	l2          TokenBackend
+	invalidator *cacheredis.Broadcaster
+}
+
+// NewTokenCache 创建分层Token缓存
+func NewTokenCache(l2 TokenBackend, options map[string]any) (*TokenCache, error) {
+	maxEntries, ttl, err := parseL1Options(options)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &TokenCache{
+		l1: lru.New[*yggdrasil.Token](maxEntries, ttl),
+		l2: l2,
+	}
+
+	c.invalidator, err = newInvalidator(options, l2.GetCacheType(), c.handleInvalidation)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// handleInvalidation 处理其他实例发来的失效消息
+func (c *TokenCache) handleInvalidation(kind, key string) {
+	switch kind {
+	case kindToken:
+		c.l1.Delete(key)
+	case kindUser:
+		c.dropUser(key)
+	}
+}
+
+// dropUser 删除L1中用户的所有Token
+func (c *TokenCache) dropUser(userID string) {
+	prefix := userID + ":"
+	c.l1.DeleteFunc(func(key string, _ *yggdrasil.Token) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// tokenKey 从JWT中提取L1键（userID:tokenID）
+func tokenKey(accessToken string) (string, error) {
+	claims, err := utils.ValidateJWT(accessToken)
+	if err != nil {
+		return "", fmt.Errorf("invalid JWT token: %w", err)
+	}
+	return claims.UserID + ":" + claims.TokenID, nil
+}
+
+// Store 存储Token（写入L2后更新本地L1）
+func (c *TokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
+	if err := c.l2.Store(ctx, token); err != nil {
+		return err
+	}
+
+	if key, err := tokenKey(token.AccessToken); err == nil {
+		// 同一Token被重新存储时，其他实例的L1需要失效
+		c.l1.Delete(key)
+		c.invalidator.Publish(kindToken, key)
+	}
+	return nil
+}
+
+// Get 获取Token（L1未命中时读取L2并回填）
+func (c *TokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
+	key, err := tokenKey(accessToken)
+	if err != nil {
+		// 非本服务签发的Token（如Laravel格式L2中插件签发的Token）不进入L1
+		return c.l2.Get(ctx, accessToken)
+	}
+
+	if token, ok := c.l1.Get(key); ok {
+		metrics.RecordCache("token_l1", "memory", true)
+		tokenCopy := *token
+		return &tokenCopy, nil
+	}
+	metrics.RecordCache("token_l1", "memory", false)
+
+	// 读取L2期间收到的失效（本实例删除或其他实例广播）会使回填放弃，不会把旧值写回L1
+	gen := c.l1.Generation()
+	token, err := c.l2.Get(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl, ok := l1TTL(token.ExpiresAt); ok {
+		tokenCopy := *token
+		c.l1.SetIfGeneration(key, &tokenCopy, ttl, gen)
+	}
+	return token, nil
+}
+
+// Delete 删除Token并广播失效
+func (c *TokenCache) Delete(ctx context.Context, accessToken string) error {
+	err := c.l2.Delete(ctx, accessToken)
+
+	if key, keyErr := tokenKey(accessToken); keyErr == nil {
+		c.l1.Delete(key)
+		c.invalidator.Publish(kindToken, key)
+	}
+	return err
+}
+
+// GetUserTokens 获取用户的所有Token（读取L2）
+func (c *TokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
+	return c.l2.GetUserTokens(ctx, userID)
+}
+
+// DeleteUserTokens 删除用户的所有Token并广播失效
+func (c *TokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
+	err := c.l2.DeleteUserTokens(ctx, userID)
+
+	c.dropUser(userID)
+	c.invalidator.Publish(kindUser, userID)
+	return err
+}
+
+// GetUserTokenCount 获取用户Token数量（读取L2）
+func (c *TokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	return c.l2.GetUserTokenCount(ctx, userID)
+}
+
+// CleanupExpired 清理L2中过期的Token（L2为共享存储，集群内只需一个实例执行）
+func (c *TokenCache) CleanupExpired() error {
+	return c.l2.CleanupExpired()
+}
+
+// CleanupLocal 清理本实例L1中过期的Token
+func (c *TokenCache) CleanupLocal() {
+	c.l1.PurgeExpired()
+}
+
+// Close 关闭缓存连接
+func (c *TokenCache) Close() error {
+	if err := c.invalidator.Close(); err != nil {
+		c.l2.Close()
+		return err
+	}
+	return c.l2.Close()
+}
+
+// Ping 检查缓存后端是否可用
+func (c *TokenCache) Ping(ctx context.Context) error {
+	// L1为进程内缓存，只检查L2
+	return c.l2.Ping(ctx)
+}
+
+// GetCacheType 获取缓存类型
+func (c *TokenCache) GetCacheType() string {
+	return "layered(" + c.l2.GetCacheType() + ")"
+}
+
+// Count 获取L2中的Token数量（L2不支持统计时返回错误）
+func (c *TokenCache) Count() (int, error) {
+	if counter, ok := c.l2.(interface{ Count() (int, error) }); ok {
+		return counter.Count()
+	}
+	return 0, fmt.Errorf("count not supported by %s", c.l2.GetCacheType())
+}
+
+// SetUserResolver 将用户查询传递给L2（L2为Laravel格式Redis缓存时需要）
+func (c *TokenCache) SetUserResolver(resolver cacheredis.UserResolver) {
+	if setter, ok := c.l2.(interface {
+		SetUserResolver(resolver cacheredis.UserResolver)
+	}); ok {
+		setter.SetUserResolver(resolver)
+	}
+}
+
+// l1TTL L1条目的过期时间（不超过条目本身的过期时间，expiresAt为零时使用l1_ttl），已过期的条目不回填
+func l1TTL(expiresAt time.Time) (time.Duration, bool) {
+	if expiresAt.IsZero() {
+		return 0, true
+	}
+	ttl := time.Until(expiresAt)
+	return ttl, ttl > 0
+}
+
+// parseL1Options 解析L1缓存选项
+func parseL1Options(options map[string]any) (int, time.Duration, error) {
+	maxEntries := defaultL1MaxEntries
+	switch v := options["l1_max_entries"].(type) {
+	case int:
+		maxEntries = v
+	case float64:
+		maxEntries = int(v)
+	}
+	if maxEntries <= 0 {
+		return 0, 0, fmt.Errorf("l1_max_entries must be positive")
+	}
+
+	ttl := defaultL1TTL
+	if v, ok := options["l1_ttl"].(string); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return 0, 0, fmt.Errorf("invalid l1_ttl: %s", v)
+		}
+		ttl = d
+	}
+
+	return maxEntries, ttl, nil
+}
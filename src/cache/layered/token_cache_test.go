@@ -0,0 +1,141 @@
+package layered
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// stubTokenBackend 内存L2，getHook在Get返回前调用（模拟读取L2期间发生的失效）
+type stubTokenBackend struct {
+	mu      sync.Mutex
+	tokens  map[string]*yggdrasil.Token
+	gets    int
+	getHook func()
+}
+
+func newStubTokenBackend() *stubTokenBackend {
+	return &stubTokenBackend{tokens: make(map[string]*yggdrasil.Token)}
+}
+
+func (b *stubTokenBackend) Store(ctx context.Context, token *yggdrasil.Token) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tokenCopy := *token
+	b.tokens[token.AccessToken] = &tokenCopy
+	return nil
+}
+
+func (b *stubTokenBackend) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
+	b.mu.Lock()
+	b.gets++
+	token, ok := b.tokens[accessToken]
+	hook := b.getHook
+	b.mu.Unlock()
+
+	if hook != nil {
+		hook()
+	}
+	if !ok {
+		return nil, fmt.Errorf("token not found")
+	}
+	tokenCopy := *token
+	return &tokenCopy, nil
+}
+
+func (b *stubTokenBackend) Delete(ctx context.Context, accessToken string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.tokens, accessToken)
+	return nil
+}
+
+func (b *stubTokenBackend) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
+	return nil, nil
+}
+func (b *stubTokenBackend) DeleteUserTokens(ctx context.Context, userID string) error { return nil }
+func (b *stubTokenBackend) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	return 0, nil
+}
+func (b *stubTokenBackend) CleanupExpired() error          { return nil }
+func (b *stubTokenBackend) Ping(ctx context.Context) error { return nil }
+func (b *stubTokenBackend) Close() error                   { return nil }
+func (b *stubTokenBackend) GetCacheType() string           { return "stub" }
+
+func (b *stubTokenBackend) getCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.gets
+}
+
+func newTestToken(t *testing.T, userID string) *yggdrasil.Token {
+	t.Helper()
+	utils.SetJWTSecret("layered-test-secret")
+	accessToken, err := utils.GenerateJWT(userID, "", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+	return &yggdrasil.Token{AccessToken: accessToken, Owner: userID, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+}
+
+func TestTokenCacheL1(t *testing.T) {
+	ctx := context.Background()
+	l2 := newStubTokenBackend()
+	c, err := NewTokenCache(l2, map[string]any{})
+	if err != nil {
+		t.Fatalf("NewTokenCache: %v", err)
+	}
+	token := newTestToken(t, "1")
+	if err := c.Store(ctx, token); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, token.AccessToken); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	if n := l2.getCount(); n != 1 {
+		t.Fatalf("L2 Get called %d times; want 1 (later reads served by L1)", n)
+	}
+
+	// 其他实例删除用户的所有Token（广播）
+	c.handleInvalidation(kindUser, "1")
+	l2.Delete(ctx, token.AccessToken)
+	if _, err := c.Get(ctx, token.AccessToken); err == nil {
+		t.Fatal("Get after invalidation succeeded; want error")
+	}
+}
+
+func TestTokenCacheL1FillRace(t *testing.T) {
+	ctx := context.Background()
+	l2 := newStubTokenBackend()
+	c, err := NewTokenCache(l2, map[string]any{})
+	if err != nil {
+		t.Fatalf("NewTokenCache: %v", err)
+	}
+	token := newTestToken(t, "1")
+	if err := l2.Store(ctx, token); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	// 读取L2之后、回填L1之前，其他实例删除了该Token
+	key, _ := tokenKey(token.AccessToken)
+	l2.getHook = func() {
+		l2.getHook = nil
+		delete(l2.tokens, token.AccessToken)
+		c.handleInvalidation(kindToken, key)
+	}
+	if _, err := c.Get(ctx, token.AccessToken); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+
+	if _, err := c.Get(ctx, token.AccessToken); err == nil {
+		t.Fatal("deleted token was served from L1 after a racing fill")
+	}
+}
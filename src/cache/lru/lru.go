@@ -0,0 +1,244 @@
+// Package lru 有容量上限的进程内缓存（LRU + TTL + 按标签失效），供L1缓存、响应缓存和用户缓存共用
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache 进程内缓存
+// 条目数或字节数任一超出上限时淘汰最久未使用的条目；条目可带标签，按标签批量失效
+type Cache[V any] struct {
+	maxEntries int
+	maxBytes   int64                           // 为0时不限制字节数
+	ttl        time.Duration                   // 默认（也是最长）过期时间
+	sizeOf     func(key string, value V) int64 // 条目大小，为nil时不统计字节数
+
+	items map[string]*list.Element
+	order *list.List                     // 最近使用的在前
+	tags  map[string]map[string]struct{} // 标签 -> 缓存键
+	bytes int64
+	gen   uint64 // 删除代数，每次删除或清空时增加
+	mu    sync.Mutex
+}
+
+// entry 缓存条目
+type entry[V any] struct {
+	key       string
+	value     V
+	size      int64
+	expiresAt time.Time
+	tags      []string
+}
+
+// New 创建缓存（maxEntries必须为正数）
+func New[V any](maxEntries int, ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// NewSized 创建同时限制字节数的缓存（sizeOf计算条目大小）
+func NewSized[V any](maxEntries int, maxBytes int64, ttl time.Duration, sizeOf func(key string, value V) int64) *Cache[V] {
+	c := New[V](maxEntries, ttl)
+	c.maxBytes = maxBytes
+	c.sizeOf = sizeOf
+	return c
+}
+
+// Get 获取未过期的条目
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, exists := c.items[key]
+	if !exists {
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[V])
+	if time.Now().After(e.expiresAt) {
+		c.removeElement(elem)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set 写入条目，返回被淘汰的条目数
+// ttl不大于0或超过默认过期时间时使用默认过期时间；单个条目超过字节上限时不缓存
+func (c *Cache[V]) Set(key string, value V, ttl time.Duration, tags ...string) int {
+	evicted, _ := c.set(key, value, ttl, nil, tags)
+	return evicted
+}
+
+// Generation 当前删除代数，与SetIfGeneration配合使用
+func (c *Cache[V]) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// SetIfGeneration 只在gen之后没有发生过删除时写入条目，返回是否写入
+// 用于从下层存储回填：读取前记录代数，读取期间条目被删除（失效）时放弃回填，避免写回旧值
+// 任意键的删除都会使回填放弃，只影响命中率，不影响正确性
+func (c *Cache[V]) SetIfGeneration(key string, value V, ttl time.Duration, gen uint64, tags ...string) bool {
+	_, stored := c.set(key, value, ttl, &gen, tags)
+	return stored
+}
+
+// set 写入条目（gen不为nil时检查删除代数）
+func (c *Cache[V]) set(key string, value V, ttl time.Duration, gen *uint64, tags []string) (int, bool) {
+	if ttl <= 0 || ttl > c.ttl {
+		ttl = c.ttl
+	}
+
+	e := &entry[V]{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+		tags:      tags,
+	}
+	if c.sizeOf != nil {
+		e.size = c.sizeOf(key, value)
+		if c.maxBytes > 0 && e.size > c.maxBytes {
+			return 0, false
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != nil && *gen != c.gen {
+		return 0, false
+	}
+
+	if elem, exists := c.items[key]; exists {
+		c.removeElement(elem)
+	}
+
+	c.items[key] = c.order.PushFront(e)
+	c.bytes += e.size
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	evicted := 0
+	for c.order.Len() > c.maxEntries || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+		evicted++
+	}
+	return evicted, true
+}
+
+// Delete 删除条目
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	if elem, exists := c.items[key]; exists {
+		c.removeElement(elem)
+	}
+}
+
+// DeleteTag 删除带有指定标签的所有条目
+func (c *Cache[V]) DeleteTag(tag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	for key := range c.tags[tag] {
+		if elem, exists := c.items[key]; exists {
+			c.removeElement(elem)
+		}
+	}
+	delete(c.tags, tag)
+}
+
+// DeleteFunc 删除满足条件的条目
+func (c *Cache[V]) DeleteFunc(match func(key string, value V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	for key, elem := range c.items {
+		if match(key, elem.Value.(*entry[V]).value) {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// PurgeExpired 清理过期条目
+func (c *Cache[V]) PurgeExpired() {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, elem := range c.items {
+		if now.After(elem.Value.(*entry[V]).expiresAt) {
+			c.removeElement(elem)
+		}
+	}
+}
+
+// Purge 清空缓存
+func (c *Cache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.tags = make(map[string]map[string]struct{})
+	c.bytes = 0
+}
+
+// Stats 获取条目数、其中已过期（尚未清理）的条目数和字节数
+func (c *Cache[V]) Stats() (entries, expired int, bytes int64) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, elem := range c.items {
+		if now.After(elem.Value.(*entry[V]).expiresAt) {
+			expired++
+		}
+	}
+	return c.order.Len(), expired, c.bytes
+}
+
+// removeElement 移除条目及其标签索引（调用方需持有锁）
+func (c *Cache[V]) removeElement(elem *list.Element) {
+	e := elem.Value.(*entry[V])
+	c.order.Remove(elem)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+
+	for _, tag := range e.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, e.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
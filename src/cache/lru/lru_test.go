@@ -0,0 +1,109 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheEviction(t *testing.T) {
+	c := New[int](2, time.Minute)
+	c.Set("a", 1, 0)
+	c.Set("b", 2, 0)
+	c.Get("a") // b成为最久未使用的条目
+	if evicted := c.Set("c", 3, 0); evicted != 1 {
+		t.Fatalf("Set evicted %d entries; want 1", evicted)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Fatalf("entry %q was evicted", key)
+		}
+	}
+}
+
+func TestCacheSizeLimit(t *testing.T) {
+	c := NewSized[[]byte](10, 10, time.Minute, func(key string, value []byte) int64 { return int64(len(value)) })
+	c.Set("a", make([]byte, 4), 0)
+	c.Set("b", make([]byte, 4), 0)
+	c.Set("c", make([]byte, 4), 0)
+	c.Set("huge", make([]byte, 11), 0)
+
+	entries, _, bytes := c.Stats()
+	if entries != 2 || bytes != 8 {
+		t.Fatalf("Stats = %d entries, %d bytes; want 2 entries, 8 bytes", entries, bytes)
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("entry over the byte limit was not evicted")
+	}
+	if _, ok := c.Get("huge"); ok {
+		t.Fatal("entry larger than the byte limit was cached")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := New[int](10, time.Minute)
+	c.Set("short", 1, 10*time.Millisecond)
+	c.Set("capped", 2, time.Hour) // 不超过默认过期时间
+	time.Sleep(20 * time.Millisecond)
+
+	if _, expired, _ := c.Stats(); expired != 1 {
+		t.Fatalf("Stats expired = %d; want 1", expired)
+	}
+	c.PurgeExpired()
+	if _, ok := c.Get("short"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if entries, _, _ := c.Stats(); entries != 1 {
+		t.Fatalf("Stats entries = %d; want 1", entries)
+	}
+}
+
+func TestCacheTags(t *testing.T) {
+	c := New[int](10, time.Minute)
+	c.Set("profile_a_true", 1, 0, "profile:a")
+	c.Set("profile_a_false", 2, 0, "profile:a")
+	c.Set("profile_b_true", 3, 0, "profile:b")
+	c.Set("profile_a_true", 4, 0) // 覆盖时去掉旧标签
+
+	c.DeleteTag("profile:a")
+	if _, ok := c.Get("profile_a_false"); ok {
+		t.Fatal("tagged entry was not deleted")
+	}
+	if _, ok := c.Get("profile_a_true"); !ok {
+		t.Fatal("entry overwritten without the tag was deleted")
+	}
+
+	c.DeleteFunc(func(key string, value int) bool { return value == 3 })
+	if _, ok := c.Get("profile_b_true"); ok {
+		t.Fatal("DeleteFunc did not delete the matching entry")
+	}
+
+	c.Purge()
+	if entries, _, _ := c.Stats(); entries != 0 {
+		t.Fatalf("Stats entries after Purge = %d; want 0", entries)
+	}
+}
+
+func TestCacheSetIfGeneration(t *testing.T) {
+	c := New[int](10, time.Minute)
+
+	gen := c.Generation()
+	c.Delete("a") // 读取下层存储期间条目失效
+	if c.SetIfGeneration("a", 1, 0, gen) {
+		t.Fatal("SetIfGeneration stored a value read before the deletion")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("stale value was cached")
+	}
+
+	gen = c.Generation()
+	if !c.SetIfGeneration("a", 2, 0, gen) {
+		t.Fatal("SetIfGeneration did not store without deletions")
+	}
+	if v, ok := c.Get("a"); !ok || v != 2 {
+		t.Fatalf("Get = %v, %v; want 2", v, ok)
+	}
+}
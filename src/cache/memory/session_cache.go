@@ -2,7 +2,11 @@
 package memory
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"sync"
 	"time"
 
@@ -13,6 +17,8 @@ import (
 type SessionCache struct {
 	sessions map[string]*sessionEntry // serverID -> sessionEntry
 	mu       sync.RWMutex
+
+	snapshotFile string // 快照文件（为空表示不启用）
 }
 
 // sessionEntry Session缓存条目
@@ -21,20 +27,88 @@ type sessionEntry struct {
 	ExpiresAt time.Time
 }
 
+// sessionSnapshot Session缓存快照
+type sessionSnapshot struct {
+	SavedAt  time.Time
+	Sessions map[string]*sessionEntry
+}
+
 // NewSessionCache 创建内存Session缓存
 func NewSessionCache(options map[string]any) (*SessionCache, error) {
-	return &SessionCache{
-		sessions: make(map[string]*sessionEntry),
-	}, nil
+	c := &SessionCache{
+		sessions:     make(map[string]*sessionEntry),
+		snapshotFile: snapshotPath(options),
+	}
+
+	if c.snapshotFile != "" {
+		c.loadSnapshot()
+	}
+
+	return c, nil
 }
 
-// Store 存储Session
-func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error {
+// loadSnapshot 从快照恢复未过期的Session（快照损坏时跳过，不影响启动）
+func (c *SessionCache) loadSnapshot() {
+	var snapshot sessionSnapshot
+	if err := readSnapshot(c.snapshotFile, snapshotKindSession, &snapshot); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Skipping session cache snapshot", "file", c.snapshotFile, "error", err)
+		}
+		return
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Session固定过期时间为120秒
-	expiresAt := time.Now().Add(120 * time.Second)
+	now := time.Now()
+	for serverID, entry := range snapshot.Sessions {
+		if entry == nil || entry.Session == nil || now.After(entry.ExpiresAt) {
+			continue
+		}
+		c.sessions[serverID] = entry
+	}
+
+	slog.Info("Restored sessions from snapshot", "count", len(c.sessions), "file", c.snapshotFile)
+}
+
+// SaveSnapshot 保存未过期的Session到快照文件
+func (c *SessionCache) SaveSnapshot() error {
+	if c.snapshotFile == "" {
+		return nil
+	}
+
+	snapshot := sessionSnapshot{
+		SavedAt:  time.Now(),
+		Sessions: make(map[string]*sessionEntry),
+	}
+
+	c.mu.RLock()
+	for serverID, entry := range c.sessions {
+		if snapshot.SavedAt.Before(entry.ExpiresAt) {
+			snapshot.Sessions[serverID] = entry
+		}
+	}
+	c.mu.RUnlock()
+
+	return writeSnapshot(c.snapshotFile, snapshotKindSession, &snapshot)
+}
+
+// SnapshotEnabled 是否配置了快照文件
+func (c *SessionCache) SnapshotEnabled() bool {
+	return c.snapshotFile != ""
+}
+
+// Store 存储Session
+func (c *SessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
+	// 按剩余有效期存储，已过期的Session不再存储
+	ttl := session.TTL()
+	if ttl <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	c.sessions[serverID] = &sessionEntry{
 		Session:   session,
@@ -45,7 +119,7 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 }
 
 // Get 获取Session
-func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
+func (c *SessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -65,7 +139,7 @@ func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
 }
 
 // Delete 删除Session
-func (c *SessionCache) Delete(serverID string) error {
+func (c *SessionCache) Delete(ctx context.Context, serverID string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -73,6 +147,25 @@ func (c *SessionCache) Delete(serverID string) error {
 	return nil
 }
 
+// Consume 获取并删除Session（在同一把写锁内完成）
+func (c *SessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.sessions[serverID]
+	if !exists {
+		return nil, fmt.Errorf("session not found")
+	}
+	delete(c.sessions, serverID)
+
+	if time.Now().After(entry.ExpiresAt) {
+		return nil, fmt.Errorf("session expired")
+	}
+
+	sessionCopy := *entry.Session
+	return &sessionCopy, nil
+}
+
 // CleanupExpired 清理过期Session
 func (c *SessionCache) CleanupExpired() error {
 	c.mu.Lock()
@@ -88,9 +181,29 @@ func (c *SessionCache) CleanupExpired() error {
 	return nil
 }
 
-// Close 关闭缓存连接
+// Count 获取未过期的Session数量
+func (c *SessionCache) Count() (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	count := 0
+	for _, entry := range c.sessions {
+		if now.Before(entry.ExpiresAt) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Close 关闭缓存连接（启用快照时写入最终快照）
 func (c *SessionCache) Close() error {
-	// 内存缓存无需关闭操作
+	return c.SaveSnapshot()
+}
+
+// Ping 检查缓存后端是否可用
+func (c *SessionCache) Ping(ctx context.Context) error {
+	// 内存缓存始终可用
 	return nil
 }
 
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+func TestSessionCacheConsumeConcurrent(t *testing.T) {
+	c, err := NewSessionCache(map[string]any{})
+	if err != nil {
+		t.Fatalf("NewSessionCache: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	for round := 0; round < 20; round++ {
+		if err := c.Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		var consumed atomic.Int32
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if session, err := c.Consume(ctx, "server"); err == nil && session != nil {
+					consumed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if n := consumed.Load(); n != 1 {
+			t.Fatalf("round %d: %d callers consumed the session; want 1", round, n)
+		}
+	}
+}
+
+func TestSessionCacheStoreKeepsExpiry(t *testing.T) {
+	c, err := NewSessionCache(map[string]any{})
+	if err != nil {
+		t.Fatalf("NewSessionCache: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	createdAt := time.Now().Add(-25 * time.Second)
+	if err := c.Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: createdAt}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if remaining := time.Until(c.sessions["server"].ExpiresAt); remaining > 5*time.Second {
+		t.Fatalf("re-stored session expires in %v; want at most the remaining 5s", remaining)
+	}
+
+	// 已过期的Session不再存储
+	if err := c.Store(ctx, "expired", &yggdrasil.Session{CreatedAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if _, err := c.Consume(ctx, "expired"); err == nil {
+		t.Fatal("Consume of an expired session succeeded")
+	}
+}
@@ -0,0 +1,134 @@
+// Package memory 内存缓存快照（重启后恢复未过期的Token/Session）
+package memory
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 快照文件格式：
+//
+//	magic(4) | version(1) | kind(1) | reserved(2) | payload长度(4, 大端) | payload的SHA-256(32) | payload
+//
+// payload为gzip压缩的gob编码数据；版本、类型、长度或校验和不匹配时拒绝加载
+const (
+	snapshotMagic      = "YGCS"
+	snapshotVersion    = 1
+	snapshotHeaderSize = 4 + 1 + 1 + 2 + 4 + sha256.Size
+)
+
+// 快照类型
+const (
+	snapshotKindToken   byte = 1
+	snapshotKindSession byte = 2
+)
+
+// snapshotPath 读取快照文件选项（为空表示不启用快照）
+func snapshotPath(options map[string]any) string {
+	if path, ok := options["snapshot_file"].(string); ok {
+		return path
+	}
+	return ""
+}
+
+// writeSnapshot 编码并原子写入快照（先写临时文件再重命名，避免写入中断留下不完整的文件）
+func writeSnapshot(path string, kind byte, data any) error {
+	var payload bytes.Buffer
+	gz := gzip.NewWriter(&payload)
+	if err := gob.NewEncoder(gz).Encode(data); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	header := make([]byte, snapshotHeaderSize)
+	copy(header, snapshotMagic)
+	header[4] = snapshotVersion
+	header[5] = kind
+	binary.BigEndian.PutUint32(header[8:12], uint32(payload.Len()))
+	checksum := sha256.Sum256(payload.Bytes())
+	copy(header[12:], checksum[:])
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// 快照包含访问令牌，仅所有者可读
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set snapshot permissions: %w", err)
+	}
+	if _, err := tmp.Write(header); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if _, err := tmp.Write(payload.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// readSnapshot 读取并校验快照（文件不存在时返回os.ErrNotExist）
+func readSnapshot(path string, kind byte, data any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if len(raw) < snapshotHeaderSize || string(raw[:4]) != snapshotMagic {
+		return fmt.Errorf("not a cache snapshot")
+	}
+	if raw[4] != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", raw[4])
+	}
+	if raw[5] != kind {
+		return fmt.Errorf("snapshot kind mismatch: expected %d, got %d", kind, raw[5])
+	}
+
+	payload := raw[snapshotHeaderSize:]
+	if int(binary.BigEndian.Uint32(raw[8:12])) != len(payload) {
+		return fmt.Errorf("snapshot is truncated")
+	}
+	checksum := sha256.Sum256(payload)
+	if !bytes.Equal(checksum[:], raw[12:snapshotHeaderSize]) {
+		return fmt.Errorf("snapshot checksum mismatch")
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	if err := gob.NewDecoder(gz).Decode(data); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return nil
+}
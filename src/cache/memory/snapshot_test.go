@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+func TestTokenCacheSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.snapshot")
+	c, err := NewTokenCache(map[string]any{"snapshot_file": path})
+	if err != nil {
+		t.Fatalf("NewTokenCache: %v", err)
+	}
+	c.tokens["1:valid"] = &yggdrasil.Token{AccessToken: "valid", Owner: "1", ExpiresAt: time.Now().Add(time.Hour)}
+	c.tokens["1:expired"] = &yggdrasil.Token{AccessToken: "expired", Owner: "1", ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("snapshot permissions = %v; want 0600", info.Mode().Perm())
+	}
+
+	restored, err := NewTokenCache(map[string]any{"snapshot_file": path})
+	if err != nil {
+		t.Fatalf("NewTokenCache: %v", err)
+	}
+	if len(restored.tokens) != 1 || restored.tokens["1:valid"] == nil || len(restored.userTokens["1"]) != 1 {
+		t.Fatalf("restored tokens = %v; want only the unexpired token", restored.tokens)
+	}
+}
+
+func TestReadSnapshotCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    byte
+		corrupt func(raw []byte) []byte
+	}{
+		{name: "wrong magic", kind: snapshotKindToken, corrupt: func(raw []byte) []byte { raw[0] = 'X'; return raw }},
+		{name: "unsupported version", kind: snapshotKindToken, corrupt: func(raw []byte) []byte { raw[4] = snapshotVersion + 1; return raw }},
+		{name: "kind mismatch", kind: snapshotKindSession},
+		{name: "truncated", kind: snapshotKindToken, corrupt: func(raw []byte) []byte { return raw[:len(raw)-1] }},
+		{name: "header only", kind: snapshotKindToken, corrupt: func(raw []byte) []byte { return raw[:snapshotHeaderSize-1] }},
+		{name: "checksum mismatch", kind: snapshotKindToken, corrupt: func(raw []byte) []byte { raw[len(raw)-1] ^= 0xFF; return raw }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tokens.snapshot")
+			snapshot := tokenSnapshot{
+				SavedAt: time.Now(),
+				Tokens:  map[string]*yggdrasil.Token{"1:a": {AccessToken: "a", ExpiresAt: time.Now().Add(time.Hour)}},
+			}
+			if err := writeSnapshot(path, snapshotKindToken, &snapshot); err != nil {
+				t.Fatalf("writeSnapshot: %v", err)
+			}
+			if tt.corrupt != nil {
+				raw, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("ReadFile: %v", err)
+				}
+				if err := os.WriteFile(path, tt.corrupt(raw), 0600); err != nil {
+					t.Fatalf("WriteFile: %v", err)
+				}
+			}
+
+			var decoded tokenSnapshot
+			if err := readSnapshot(path, tt.kind, &decoded); err == nil {
+				t.Fatal("readSnapshot succeeded; want error")
+			}
+
+			// 损坏的快照被跳过，缓存仍可创建
+			if tt.kind == snapshotKindToken {
+				c, err := NewTokenCache(map[string]any{"snapshot_file": path})
+				if err != nil || len(c.tokens) != 0 {
+					t.Fatalf("NewTokenCache = %d tokens, %v; want empty cache", len(c.tokens), err)
+				}
+			}
+		})
+	}
+}
@@ -2,8 +2,14 @@
 package memory
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"yggdrasil-api-go/src/utils"
 	"yggdrasil-api-go/src/yggdrasil"
@@ -14,18 +20,93 @@ type TokenCache struct {
 	tokens     map[string]*yggdrasil.Token // "userID:tokenID" -> Token（简化版）
 	userTokens map[string][]string         // userID -> []tokenID
 	mu         sync.RWMutex
+
+	snapshotFile string // 快照文件（为空表示不启用）
+}
+
+// tokenSnapshot Token缓存快照
+type tokenSnapshot struct {
+	SavedAt time.Time
+	Tokens  map[string]*yggdrasil.Token // "userID:tokenID" -> Token
 }
 
 // NewTokenCache 创建内存Token缓存
 func NewTokenCache(options map[string]any) (*TokenCache, error) {
-	return &TokenCache{
-		tokens:     make(map[string]*yggdrasil.Token),
-		userTokens: make(map[string][]string),
-	}, nil
+	c := &TokenCache{
+		tokens:       make(map[string]*yggdrasil.Token),
+		userTokens:   make(map[string][]string),
+		snapshotFile: snapshotPath(options),
+	}
+
+	if c.snapshotFile != "" {
+		c.loadSnapshot()
+	}
+
+	return c, nil
+}
+
+// loadSnapshot 从快照恢复未过期的Token（快照损坏时跳过，不影响启动）
+func (c *TokenCache) loadSnapshot() {
+	var snapshot tokenSnapshot
+	if err := readSnapshot(c.snapshotFile, snapshotKindToken, &snapshot); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Skipping token cache snapshot", "file", c.snapshotFile, "error", err)
+		}
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tokenKey, token := range snapshot.Tokens {
+		userID, tokenID, ok := strings.Cut(tokenKey, ":")
+		if !ok || token == nil || !token.IsValid() {
+			continue
+		}
+		// 旧快照中的明文令牌改写为哈希（迁移期结束后直接丢弃）
+		if utils.IsPlaintextTokenEntry(token.AccessToken) {
+			if !utils.AcceptPlaintextTokens() {
+				continue
+			}
+			token.AccessToken = utils.HashAccessToken(token.AccessToken)
+		}
+		c.tokens[tokenKey] = token
+		c.userTokens[userID] = append(c.userTokens[userID], tokenID)
+	}
+
+	slog.Info("Restored tokens from snapshot", "count", len(c.tokens), "file", c.snapshotFile, "saved_at", snapshot.SavedAt)
+}
+
+// SaveSnapshot 保存未过期的Token到快照文件
+func (c *TokenCache) SaveSnapshot() error {
+	if c.snapshotFile == "" {
+		return nil
+	}
+
+	snapshot := tokenSnapshot{
+		SavedAt: time.Now(),
+		Tokens:  make(map[string]*yggdrasil.Token),
+	}
+
+	c.mu.RLock()
+	for tokenKey, token := range c.tokens {
+		if token.IsValid() {
+			tokenCopy := *token
+			snapshot.Tokens[tokenKey] = &tokenCopy
+		}
+	}
+	c.mu.RUnlock()
+
+	return writeSnapshot(c.snapshotFile, snapshotKindToken, &snapshot)
+}
+
+// SnapshotEnabled 是否配置了快照文件
+func (c *TokenCache) SnapshotEnabled() bool {
+	return c.snapshotFile != ""
 }
 
 // Store 存储Token（优化版：先验证JWT，提取信息）
-func (c *TokenCache) Store(token *yggdrasil.Token) error {
+func (c *TokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -37,10 +118,10 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 
 	// 创建简化的Token对象（只存储JWT中没有的信息）
 	cacheToken := &yggdrasil.Token{
-		AccessToken: token.AccessToken, // 保留完整的AccessToken用于兼容性
+		AccessToken: utils.HashAccessToken(token.AccessToken), // 只保存令牌哈希
 		ClientToken: token.ClientToken,
 		ProfileID:   claims.ProfileID, // 从JWT中获取ProfileID
-		Owner:       claims.UserID, // 从JWT中获取用户ID
+		Owner:       claims.UserID,    // 从JWT中获取用户ID
 		CreatedAt:   token.CreatedAt,
 		ExpiresAt:   token.ExpiresAt,
 	}
@@ -69,7 +150,7 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 }
 
 // Get 获取Token（优化版：先验证JWT，按需查询缓存）
-func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
+func (c *TokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
 	// 第一步：验证JWT（本地计算，极快）
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -100,87 +181,77 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 }
 
 // Delete 删除Token
-func (c *TokenCache) Delete(accessToken string) error {
+func (c *TokenCache) Delete(ctx context.Context, accessToken string) error {
+	// 过期或无效的JWT无法定位缓存条目，由CleanupExpired清理
+	claims, err := utils.ValidateJWT(accessToken)
+	if err != nil {
+		return nil
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// 获取Token以找到所有者
-	token, exists := c.tokens[accessToken]
-	if exists {
-		// 从用户Token列表中移除
-		userTokens := c.userTokens[token.Owner]
-		for i, userToken := range userTokens {
-			if userToken == accessToken {
-				c.userTokens[token.Owner] = append(userTokens[:i], userTokens[i+1:]...)
-				break
-			}
-		}
+	c.removeToken(claims.UserID, claims.TokenID)
+	return nil
+}
+
+// removeToken 删除Token及其在用户Token列表中的条目（调用方需持有写锁）
+func (c *TokenCache) removeToken(userID, tokenID string) {
+	delete(c.tokens, userID+":"+tokenID)
 
-		// 如果用户没有Token了，删除用户条目
-		if len(c.userTokens[token.Owner]) == 0 {
-			delete(c.userTokens, token.Owner)
+	userTokens := c.userTokens[userID]
+	for i, id := range userTokens {
+		if id == tokenID {
+			c.userTokens[userID] = append(userTokens[:i], userTokens[i+1:]...)
+			break
 		}
 	}
 
-	// 删除Token
-	delete(c.tokens, accessToken)
-	return nil
+	// 如果用户没有Token了，删除用户条目
+	if len(c.userTokens[userID]) == 0 {
+		delete(c.userTokens, userID)
+	}
 }
 
 // GetUserTokens 获取用户的所有Token
-func (c *TokenCache) GetUserTokens(userEmail string) ([]*yggdrasil.Token, error) {
+func (c *TokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	accessTokens, exists := c.userTokens[userEmail]
-	if !exists {
-		return []*yggdrasil.Token{}, nil
-	}
-
 	var tokens []*yggdrasil.Token
-	for _, accessToken := range accessTokens {
-		if token, exists := c.tokens[accessToken]; exists && token.IsValid() {
+	for _, tokenID := range c.userTokens[userID] {
+		if token, exists := c.tokens[userID+":"+tokenID]; exists && token.IsValid() {
 			tokenCopy := *token
 			tokens = append(tokens, &tokenCopy)
 		}
 	}
+	if tokens == nil {
+		tokens = []*yggdrasil.Token{}
+	}
 
 	return tokens, nil
 }
 
 // DeleteUserTokens 删除用户的所有Token
-func (c *TokenCache) DeleteUserTokens(userEmail string) error {
+func (c *TokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	accessTokens, exists := c.userTokens[userEmail]
-	if !exists {
-		return nil
-	}
-
-	// 删除所有Token
-	for _, accessToken := range accessTokens {
-		delete(c.tokens, accessToken)
+	for _, tokenID := range c.userTokens[userID] {
+		delete(c.tokens, userID+":"+tokenID)
 	}
-
-	// 删除用户Token列表
-	delete(c.userTokens, userEmail)
+	delete(c.userTokens, userID)
 	return nil
 }
 
 // GetUserTokenCount 获取用户Token数量
-func (c *TokenCache) GetUserTokenCount(userEmail string) (int, error) {
+func (c *TokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	accessTokens, exists := c.userTokens[userEmail]
-	if !exists {
-		return 0, nil
-	}
-
 	count := 0
-	for _, accessToken := range accessTokens {
-		if token, exists := c.tokens[accessToken]; exists && token.IsValid() {
+	for _, tokenID := range c.userTokens[userID] {
+		if token, exists := c.tokens[userID+":"+tokenID]; exists && token.IsValid() {
 			count++
 		}
 	}
@@ -193,42 +264,43 @@ func (c *TokenCache) CleanupExpired() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// 收集过期的Token
-	var expiredTokens []string
-	for accessToken, token := range c.tokens {
-		if !token.IsValid() {
-			expiredTokens = append(expiredTokens, accessToken)
+	for tokenKey, token := range c.tokens {
+		if token.IsValid() {
+			continue
+		}
+		if userID, tokenID, ok := strings.Cut(tokenKey, ":"); ok {
+			c.removeToken(userID, tokenID)
+		} else {
+			delete(c.tokens, tokenKey)
 		}
 	}
 
-	// 删除过期Token
-	for _, accessToken := range expiredTokens {
-		token := c.tokens[accessToken]
+	return nil
+}
 
-		// 从用户Token列表中移除
-		userTokens := c.userTokens[token.Owner]
-		for i, userToken := range userTokens {
-			if userToken == accessToken {
-				c.userTokens[token.Owner] = append(userTokens[:i], userTokens[i+1:]...)
-				break
-			}
-		}
+// Count 获取未过期的Token数量
+func (c *TokenCache) Count() (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-		// 如果用户没有Token了，删除用户条目
-		if len(c.userTokens[token.Owner]) == 0 {
-			delete(c.userTokens, token.Owner)
+	now := time.Now()
+	count := 0
+	for _, token := range c.tokens {
+		if now.Before(token.ExpiresAt) {
+			count++
 		}
-
-		// 删除Token
-		delete(c.tokens, accessToken)
 	}
-
-	return nil
+	return count, nil
 }
 
-// Close 关闭缓存连接
+// Close 关闭缓存连接（启用快照时写入最终快照）
 func (c *TokenCache) Close() error {
-	// 内存缓存无需关闭操作
+	return c.SaveSnapshot()
+}
+
+// Ping 检查缓存后端是否可用
+func (c *TokenCache) Ping(ctx context.Context) error {
+	// 内存缓存始终可用
 	return nil
 }
 
@@ -0,0 +1,139 @@
+// Package redis 基于发布/订阅的跨实例失效广播
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/bytedance/sonic"
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultBroadcastChannel 未配置invalidation_channel时使用的频道
+const DefaultBroadcastChannel = "yggdrasil-cache-invalidate"
+
+// broadcastMessage 失效消息
+type broadcastMessage struct {
+	Origin string `json:"origin"` // 发送方实例ID（忽略自己发出的消息）
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+}
+
+// Broadcaster 进程内缓存的失效广播：消息按类型分发给订阅者，不投递给发送方自己
+// 方法在nil接收者上为空操作，未配置Redis时调用方无需判断
+type Broadcaster struct {
+	client  redis.UniversalClient
+	pubsub  *redis.PubSub
+	channel string
+	origin  string
+	done    chan struct{}
+
+	mu       sync.RWMutex
+	handlers map[string][]func(key string)
+}
+
+// NewBroadcaster 创建失效广播并开始订阅（连接选项同NewClient，invalidation_channel指定频道）
+func NewBroadcaster(options map[string]any) (*Broadcaster, error) {
+	channel := DefaultBroadcastChannel
+	if ch, ok := options["invalidation_channel"].(string); ok && ch != "" {
+		channel = ch
+	}
+
+	client, err := NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+
+	// 确认订阅成功后再返回，避免启动初期丢失失效消息
+	pubsub := client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		client.Close()
+		return nil, fmt.Errorf("failed to subscribe invalidation channel: %w", err)
+	}
+
+	b := &Broadcaster{
+		client:   client,
+		pubsub:   pubsub,
+		channel:  channel,
+		origin:   utils.GenerateRandomUUID(),
+		done:     make(chan struct{}),
+		handlers: make(map[string][]func(key string)),
+	}
+
+	go func() {
+		defer close(b.done)
+
+		// 断线后go-redis会自动重新订阅，期间丢失的消息由各缓存的过期时间兜底
+		for msg := range pubsub.Channel() {
+			var m broadcastMessage
+			if err := sonic.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Origin == b.origin {
+				continue
+			}
+			b.mu.RLock()
+			handlers := b.handlers[m.Kind]
+			b.mu.RUnlock()
+			for _, handle := range handlers {
+				handle(m.Key)
+			}
+		}
+	}()
+
+	return b, nil
+}
+
+// HasClientOptions 是否配置了Redis连接
+func HasClientOptions(options map[string]any) bool {
+	for _, name := range []string{"redis_url", "sentinel_master", "cluster_addrs"} {
+		if v, ok := options[name]; ok && v != "" && v != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe 订阅其他实例发出的指定类型的失效消息
+func (b *Broadcaster) Subscribe(kind string, handle func(key string)) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[kind] = append(b.handlers[kind], handle)
+}
+
+// Publish 广播失效消息（失败时只记录日志，其他实例的缓存会在过期后自动失效）
+func (b *Broadcaster) Publish(kind, key string) {
+	if b == nil {
+		return
+	}
+
+	data, err := sonic.Marshal(&broadcastMessage{Origin: b.origin, Kind: kind, Key: key})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.client.Publish(ctx, b.channel, data).Err(); err != nil {
+		slog.Warn("Failed to publish cache invalidation", "channel", b.channel, "kind", kind, "error", err)
+	}
+}
+
+// Close 停止订阅并关闭连接
+func (b *Broadcaster) Close() error {
+	if b == nil {
+		return nil
+	}
+
+	b.pubsub.Close()
+	<-b.done
+	return b.client.Close()
+}
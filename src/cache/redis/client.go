@@ -0,0 +1,364 @@
+// Package redis Redis连接创建（单机、Sentinel、Cluster）
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 连接模式
+const (
+	modeSingle   = "single"
+	modeSentinel = "sentinel"
+	modeCluster  = "cluster"
+)
+
+// NewClient 根据选项创建Redis客户端
+//
+// 支持的选项：
+//   - mode: single（默认）、sentinel、cluster；未配置时根据sentinel_master/cluster_addrs推断
+//   - redis_url: 单机模式连接URL（支持rediss://）
+//   - sentinel_master, sentinel_addrs, sentinel_username, sentinel_password: Sentinel模式
+//   - cluster_addrs: Cluster模式种子节点
+//   - username, password, db: Sentinel/Cluster模式的认证信息（Cluster不支持db）
+//   - tls, tls_ca_file, tls_cert_file, tls_key_file, tls_server_name, tls_insecure_skip_verify: TLS设置
+func NewClient(options map[string]any) (redis.UniversalClient, error) {
+	mode, err := clientMode(options)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := parseTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+
+	username, _ := options["username"].(string)
+	password, _ := options["password"].(string)
+	db := intOption(options, "db", 0)
+
+	var client redis.UniversalClient
+	switch mode {
+	case modeSentinel:
+		addrs := stringListOption(options, "sentinel_addrs")
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("sentinel_addrs is required in sentinel mode")
+		}
+		masterName, _ := options["sentinel_master"].(string)
+		if masterName == "" {
+			return nil, fmt.Errorf("sentinel_master is required in sentinel mode")
+		}
+		sentinelUsername, _ := options["sentinel_username"].(string)
+		sentinelPassword, _ := options["sentinel_password"].(string)
+
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       masterName,
+			SentinelAddrs:    addrs,
+			SentinelUsername: sentinelUsername,
+			SentinelPassword: sentinelPassword,
+			Username:         username,
+			Password:         password,
+			DB:               db,
+			TLSConfig:        tlsConfig,
+		})
+	case modeCluster:
+		addrs := stringListOption(options, "cluster_addrs")
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("cluster_addrs is required in cluster mode")
+		}
+		if db != 0 {
+			return nil, fmt.Errorf("db is not supported in cluster mode")
+		}
+
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     addrs,
+			Username:  username,
+			Password:  password,
+			TLSConfig: tlsConfig,
+		})
+	default:
+		redisURL := "redis://localhost:6379"
+		if url, ok := options["redis_url"].(string); ok && url != "" {
+			redisURL = url
+		}
+
+		opt, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		// 显式的TLS选项覆盖rediss://的默认设置
+		if tlsConfig != nil {
+			if tlsConfig.ServerName == "" && opt.TLSConfig != nil {
+				tlsConfig.ServerName = opt.TLSConfig.ServerName
+			}
+			opt.TLSConfig = tlsConfig
+		}
+
+		client = redis.NewClient(opt)
+	}
+
+	// 测试连接
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// clientMode 获取连接模式
+func clientMode(options map[string]any) (string, error) {
+	if mode, ok := options["mode"].(string); ok && mode != "" {
+		switch mode {
+		case modeSingle, modeSentinel, modeCluster:
+			return mode, nil
+		default:
+			return "", fmt.Errorf("unsupported Redis mode: %s", mode)
+		}
+	}
+
+	if master, ok := options["sentinel_master"].(string); ok && master != "" {
+		return modeSentinel, nil
+	}
+	if len(stringListOption(options, "cluster_addrs")) > 0 {
+		return modeCluster, nil
+	}
+	return modeSingle, nil
+}
+
+// parseTLSConfig 解析TLS选项（未启用时返回nil）
+func parseTLSConfig(options map[string]any) (*tls.Config, error) {
+	enabled, _ := options["tls"].(bool)
+	caFile, _ := options["tls_ca_file"].(string)
+	certFile, _ := options["tls_cert_file"].(string)
+	keyFile, _ := options["tls_key_file"].(string)
+	serverName, _ := options["tls_server_name"].(string)
+	insecure, _ := options["tls_insecure_skip_verify"].(bool)
+
+	if !enabled && caFile == "" && certFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         serverName,
+		InsecureSkipVerify: insecure,
+	}
+
+	if caFile != "" {
+		caData, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no valid certificates in Redis CA file: %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// stringListOption 读取字符串列表选项（支持YAML列表或逗号分隔的字符串）
+func stringListOption(options map[string]any, name string) []string {
+	var values []string
+	switch v := options[name].(type) {
+	case []string:
+		values = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case string:
+		values = strings.Split(v, ",")
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// intOption 读取整数选项（YAML解析为int，JSON解析为float64）
+func intOption(options map[string]any, name string, defaultValue int) int {
+	switch v := options[name].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		// 环境变量覆盖的选项为字符串
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// keySchema Redis键命名（可选前缀，Cluster模式下用户相关键使用{userID}哈希标签保证落在同一槽位）
+type keySchema struct {
+	prefix   string
+	hashTags bool
+}
+
+// newKeySchema 根据选项创建键命名规则
+func newKeySchema(options map[string]any) (keySchema, error) {
+	mode, err := clientMode(options)
+	if err != nil {
+		return keySchema{}, err
+	}
+
+	keys := keySchema{hashTags: mode == modeCluster}
+	if prefix, ok := options["key_prefix"].(string); ok {
+		keys.prefix = prefix
+	}
+	if hashTags, ok := options["hash_tags"].(bool); ok {
+		if !hashTags && mode == modeCluster {
+			return keySchema{}, fmt.Errorf("hash_tags cannot be disabled in cluster mode")
+		}
+		keys.hashTags = hashTags
+	}
+	return keys, nil
+}
+
+// tag 包装用户ID（启用哈希标签时为{userID}）
+func (k keySchema) tag(userID string) string {
+	if k.hashTags {
+		return "{" + userID + "}"
+	}
+	return userID
+}
+
+// token Token键：yggdrasil-token-<userID>:<tokenID>
+func (k keySchema) token(userID, tokenID string) string {
+	return k.prefix + "yggdrasil-token-" + k.tag(userID) + ":" + tokenID
+}
+
+// tokensPattern 所有Token键的匹配模式
+func (k keySchema) tokensPattern() string {
+	return k.prefix + "yggdrasil-token-*"
+}
+
+// userTokens 用户Token列表键：yggdrasil-id-<userID>
+func (k keySchema) userTokens(userID string) string {
+	return k.prefix + "yggdrasil-id-" + k.tag(userID)
+}
+
+// userTokensPattern 所有用户Token列表键的匹配模式
+func (k keySchema) userTokensPattern() string {
+	return k.prefix + "yggdrasil-id-*"
+}
+
+// userIDFromTokensKey 从用户Token列表键中提取用户ID
+func (k keySchema) userIDFromTokensKey(key string) string {
+	userID := strings.TrimPrefix(key, k.prefix+"yggdrasil-id-")
+	if k.hashTags {
+		userID = strings.TrimSuffix(strings.TrimPrefix(userID, "{"), "}")
+	}
+	return userID
+}
+
+// session Session键：yggdrasil-server-<serverID>
+func (k keySchema) session(serverID string) string {
+	return k.prefix + "yggdrasil-server-" + serverID
+}
+
+// sessionsPattern 所有Session键的匹配模式
+func (k keySchema) sessionsPattern() string {
+	return k.prefix + "yggdrasil-server-*"
+}
+
+// scanKeys 扫描匹配的键（Cluster模式下遍历所有主节点，避免KEYS阻塞）
+func scanKeys(ctx context.Context, client redis.UniversalClient, pattern string) ([]string, error) {
+	var (
+		keys []string
+		mu   sync.Mutex
+	)
+	err := scanEach(ctx, client, pattern, func(key string) {
+		mu.Lock()
+		keys = append(keys, key)
+		mu.Unlock()
+	})
+	return keys, err
+}
+
+// scanEach 对每个匹配的键调用fn（Cluster模式下并发遍历所有主节点，fn需要并发安全）
+func scanEach(ctx context.Context, client redis.UniversalClient, pattern string, fn func(key string)) error {
+	scan := func(ctx context.Context, node redis.UniversalClient) error {
+		iter := node.Scan(ctx, 0, pattern, 1000).Iterator()
+		for iter.Next(ctx) {
+			fn(iter.Val())
+		}
+		return iter.Err()
+	}
+
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return scan(ctx, client)
+	}
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return scan(ctx, node)
+	})
+}
+
+// keyCountTTL 键数量统计结果的缓存时间（监控每次抓取不重复全量扫描）
+const keyCountTTL = 30 * time.Second
+
+// keyCounter 按模式统计键数量（SCAN遍历，结果缓存keyCountTTL）
+// Redis自动删除过期键，统计结果即为未过期的条目数
+type keyCounter struct {
+	client  redis.UniversalClient
+	pattern string
+
+	mu        sync.Mutex
+	count     int
+	countedAt time.Time
+}
+
+// newKeyCounter 创建键数量统计
+func newKeyCounter(client redis.UniversalClient, pattern string) *keyCounter {
+	return &keyCounter{client: client, pattern: pattern}
+}
+
+// Count 获取匹配的键数量（缓存期内返回上次的结果）
+func (k *keyCounter) Count() (int, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.countedAt.IsZero() && time.Since(k.countedAt) < keyCountTTL {
+		return k.count, nil
+	}
+
+	var count atomic.Int64
+	if err := scanEach(context.Background(), k.client, k.pattern, func(string) { count.Add(1) }); err != nil {
+		return 0, fmt.Errorf("failed to count keys: %w", err)
+	}
+
+	k.count = int(count.Load())
+	k.countedAt = time.Now()
+	return k.count, nil
+}
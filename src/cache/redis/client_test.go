@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClientMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]any
+		want    string
+		wantErr bool
+	}{
+		{name: "default", options: map[string]any{"redis_url": "redis://localhost:6379"}, want: modeSingle},
+		{name: "explicit", options: map[string]any{"mode": "cluster", "sentinel_master": "mymaster"}, want: modeCluster},
+		{name: "sentinel inferred", options: map[string]any{"sentinel_master": "mymaster"}, want: modeSentinel},
+		{name: "cluster inferred", options: map[string]any{"cluster_addrs": "a:6379, b:6379"}, want: modeCluster},
+		{name: "unsupported", options: map[string]any{"mode": "ring"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := clientMode(tt.options)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("clientMode = %q, %v; want %q (error %v)", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClientOptionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]any
+	}{
+		{name: "sentinel without addrs", options: map[string]any{"sentinel_master": "mymaster"}},
+		{name: "sentinel without master", options: map[string]any{"mode": "sentinel", "sentinel_addrs": []any{"a:26379"}}},
+		{name: "cluster with db", options: map[string]any{"cluster_addrs": []string{"a:6379"}, "db": 1}},
+		{name: "invalid url", options: map[string]any{"redis_url": "http://localhost"}},
+		{name: "missing ca file", options: map[string]any{"tls_ca_file": filepath.Join(t.TempDir(), "ca.pem")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if client, err := NewClient(tt.options); err == nil {
+				client.Close()
+				t.Fatal("NewClient succeeded; want error")
+			}
+		})
+	}
+}
+
+func TestParseTLSConfig(t *testing.T) {
+	tlsConfig, err := parseTLSConfig(map[string]any{})
+	if err != nil || tlsConfig != nil {
+		t.Fatalf("parseTLSConfig without options = %v, %v; want nil", tlsConfig, err)
+	}
+
+	tlsConfig, err = parseTLSConfig(map[string]any{"tls": true, "tls_server_name": "redis.internal", "tls_insecure_skip_verify": true})
+	if err != nil || tlsConfig == nil || tlsConfig.ServerName != "redis.internal" || !tlsConfig.InsecureSkipVerify {
+		t.Fatalf("parseTLSConfig = %+v, %v", tlsConfig, err)
+	}
+}
+
+func TestStringListOption(t *testing.T) {
+	want := []string{"a:6379", "b:6379"}
+	for _, value := range []any{"a:6379, b:6379,", []any{"a:6379", "", "b:6379"}, []string{" a:6379", "b:6379 "}} {
+		if got := stringListOption(map[string]any{"addrs": value}, "addrs"); !reflect.DeepEqual(got, want) {
+			t.Fatalf("stringListOption(%#v) = %v; want %v", value, got, want)
+		}
+	}
+}
+
+func TestKeySchema(t *testing.T) {
+	keys, err := newKeySchema(map[string]any{"key_prefix": "ygg:"})
+	if err != nil {
+		t.Fatalf("newKeySchema: %v", err)
+	}
+	if got := keys.token("1", "abc"); got != "ygg:yggdrasil-token-1:abc" {
+		t.Fatalf("token key = %q", got)
+	}
+	if got := keys.userIDFromTokensKey(keys.userTokens("1")); got != "1" {
+		t.Fatalf("userIDFromTokensKey = %q; want 1", got)
+	}
+
+	// Cluster模式下用户相关键使用哈希标签
+	keys, err = newKeySchema(map[string]any{"cluster_addrs": "a:6379"})
+	if err != nil {
+		t.Fatalf("newKeySchema: %v", err)
+	}
+	if got := keys.userTokens("1"); got != "yggdrasil-id-{1}" {
+		t.Fatalf("cluster user tokens key = %q", got)
+	}
+	if got := keys.userIDFromTokensKey(keys.userTokens("1")); got != "1" {
+		t.Fatalf("userIDFromTokensKey = %q; want 1", got)
+	}
+	if got := keys.session("server"); got != "yggdrasil-server-server" {
+		t.Fatalf("session key = %q", got)
+	}
+
+	if _, err := newKeySchema(map[string]any{"mode": "cluster", "cluster_addrs": "a:6379", "hash_tags": false}); err == nil {
+		t.Fatal("newKeySchema allowed disabling hash_tags in cluster mode")
+	}
+}
@@ -0,0 +1,580 @@
+// Package redis Laravel格式Redis缓存（与BlessingSkin的yggdrasil-api插件共享Token和Session）
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/trim21/go-phpserialize"
+)
+
+const (
+	defaultLaravelCachePrefix = "laravel_cache"
+	defaultLaravelTokenClass  = `Yggdrasil\Models\Token`
+	laravelUserTokensTTL      = 7 * 24 * time.Hour // 用户Token列表保留时间（与文件缓存一致）
+	laravelSessionTTL         = 30 * time.Second
+)
+
+// IsLaravelFormat 是否使用Laravel格式（options.format为laravel）
+func IsLaravelFormat(options map[string]any) bool {
+	format, _ := options["format"].(string)
+	return format == "laravel"
+}
+
+// UserResolver 用户查询（插件以邮箱标识Token所有者，Go端使用用户ID）
+type UserResolver interface {
+	GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error)
+}
+
+// laravelStore Laravel RedisStore兼容的读写
+// 键：{key_prefix}{laravel_cache_prefix}:{key}，值：PHP serialize()结果（Laravel对数字不做序列化）
+type laravelStore struct {
+	client redis.UniversalClient
+	prefix string
+	ctx    context.Context
+}
+
+// newLaravelStore 创建Laravel格式存储
+func newLaravelStore(options map[string]any) (*laravelStore, error) {
+	client, err := NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与Laravel的RedisStore一致：Redis连接前缀 + 缓存前缀 + ":"
+	prefix, _ := options["key_prefix"].(string)
+	cachePrefix := defaultLaravelCachePrefix
+	if p, ok := options["laravel_cache_prefix"].(string); ok {
+		cachePrefix = p
+	}
+	if cachePrefix != "" {
+		prefix += cachePrefix + ":"
+	}
+
+	return &laravelStore{
+		client: client,
+		prefix: prefix,
+		ctx:    context.Background(),
+	}, nil
+}
+
+// put 写入缓存（对应Cache::put）
+func (s *laravelStore) put(key string, value any, ttl time.Duration) error {
+	data, err := phpserialize.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to serialize data: %w", err)
+	}
+
+	// Laravel以秒为单位设置过期时间，最少1秒
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return s.client.Set(s.ctx, s.prefix+key, data, ttl.Truncate(time.Second)).Err()
+}
+
+// get 读取缓存（兼容插件先serialize()再放入缓存的双重序列化）
+func (s *laravelStore) get(key string, target any) error {
+	data, err := s.client.Get(s.ctx, s.prefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return fmt.Errorf("cache not found")
+		}
+		return fmt.Errorf("failed to get cache: %w", err)
+	}
+
+	return unserializeLaravel(data, target)
+}
+
+// pull 原子地读取并删除缓存（对应Cache::pull），返回删除前的剩余有效期
+func (s *laravelStore) pull(key string, target any) (time.Duration, error) {
+	data, ttl, err := consumeKey(s.ctx, s.client, s.prefix+key)
+	if err != nil {
+		if err == redis.Nil {
+			return 0, fmt.Errorf("cache not found")
+		}
+		return 0, fmt.Errorf("failed to pull cache: %w", err)
+	}
+
+	return ttl, unserializeLaravel([]byte(data), target)
+}
+
+// unserializeLaravel 反序列化缓存值
+func unserializeLaravel(data []byte, target any) error {
+	if err := phpserialize.Unmarshal(data, target); err != nil {
+		var inner string
+		if phpserialize.Unmarshal(data, &inner) != nil || inner == "" {
+			return fmt.Errorf("failed to unserialize cached data: %w", err)
+		}
+		if err := phpserialize.Unmarshal([]byte(inner), target); err != nil {
+			return fmt.Errorf("failed to unserialize cached data: %w", err)
+		}
+	}
+	return nil
+}
+
+// ttl 获取剩余有效期
+func (s *laravelStore) ttl(key string) time.Duration {
+	ttl, err := s.client.TTL(s.ctx, s.prefix+key).Result()
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// forget 删除缓存（对应Cache::forget）
+func (s *laravelStore) forget(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Cluster模式下这些键不在同一槽位，使用Pipeline逐个删除
+	_, err := s.client.Pipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(s.ctx, s.prefix+key)
+		}
+		return nil
+	})
+	return err
+}
+
+// laravelToken 插件的Token对象（Yggdrasil\Models\Token）
+type laravelToken struct {
+	Owner       string `php:"owner"` // 所有者邮箱（未设置UserResolver时为用户ID）
+	ClientToken string `php:"clientToken"`
+	AccessToken string `php:"accessToken"`
+	ProfileID   string `php:"profileId"`
+	CreatedAt   int64  `php:"createdAt"` // Unix时间戳（秒）
+
+	class string
+}
+
+// MarshalPHP 序列化为PHP对象（phpserialize默认只输出数组）
+func (t laravelToken) MarshalPHP() ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "O:%d:\"%s\":5:{", len(t.class), t.class)
+	for _, field := range [][2]string{
+		{"owner", t.Owner},
+		{"clientToken", t.ClientToken},
+		{"accessToken", t.AccessToken},
+		{"profileId", t.ProfileID},
+	} {
+		writePHPString(&b, field[0])
+		writePHPString(&b, field[1])
+	}
+	writePHPString(&b, "createdAt")
+	fmt.Fprintf(&b, "i:%d;", t.CreatedAt)
+	b.WriteString("}")
+	return []byte(b.String()), nil
+}
+
+// writePHPString 写入PHP字符串（长度为字节数）
+func writePHPString(b *strings.Builder, s string) {
+	fmt.Fprintf(b, "s:%d:\"%s\";", len(s), s)
+}
+
+// laravelSession 插件的Session格式
+type laravelSession struct {
+	Profile string `php:"profile"`
+	IP      string `php:"ip"`
+	Checks  int    `php:"checks,omitempty"` // 插件不读取该字段，仅用于允许重复验证
+}
+
+// LaravelTokenCache Laravel格式Redis Token缓存
+// 键与插件一致：yggdrasil-token-{accessToken}、yggdrasil-id-{email}
+// 启用令牌哈希时键和用户Token列表使用令牌哈希代替访问令牌（插件无法读取，共享缓存时需使用plaintext模式）
+type LaravelTokenCache struct {
+	store      *laravelStore
+	counter    *keyCounter
+	tokenClass string
+	resolver   UserResolver
+	mu         sync.RWMutex
+}
+
+// NewLaravelTokenCache 创建Laravel格式Redis Token缓存
+func NewLaravelTokenCache(options map[string]any) (*LaravelTokenCache, error) {
+	store, err := newLaravelStore(options)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenClass := defaultLaravelTokenClass
+	if class, ok := options["laravel_token_class"].(string); ok && class != "" {
+		tokenClass = class
+	}
+
+	return &LaravelTokenCache{
+		store:      store,
+		counter:    newKeyCounter(store.client, store.prefix+generateYggdrasilTokenKey("*")),
+		tokenClass: tokenClass,
+	}, nil
+}
+
+// SetUserResolver 设置用户查询，用于在用户ID和插件使用的邮箱之间转换
+func (c *LaravelTokenCache) SetUserResolver(resolver UserResolver) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resolver = resolver
+}
+
+// ownerIdentifier 用户ID转换为插件的所有者标识（邮箱）
+func (c *LaravelTokenCache) ownerIdentifier(ctx context.Context, userID string) string {
+	c.mu.RLock()
+	resolver := c.resolver
+	c.mu.RUnlock()
+
+	if resolver == nil {
+		return userID
+	}
+	if user, err := resolver.GetUserByID(ctx, userID); err == nil && user.Email != "" {
+		return user.Email
+	}
+	return userID
+}
+
+// ownerUserID 插件的所有者标识转换为用户ID
+func (c *LaravelTokenCache) ownerUserID(ctx context.Context, owner string) string {
+	c.mu.RLock()
+	resolver := c.resolver
+	c.mu.RUnlock()
+
+	if resolver == nil || !strings.Contains(owner, "@") {
+		return owner
+	}
+	if user, err := resolver.GetUserByEmail(ctx, owner); err == nil {
+		return user.ID
+	}
+	return owner
+}
+
+// Store 存储Token并加入用户Token列表
+func (c *LaravelTokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("token already expired")
+	}
+
+	owner := c.ownerIdentifier(ctx, token.Owner)
+	tokenHash := utils.HashAccessToken(token.AccessToken)
+	cacheToken := laravelToken{
+		Owner:       owner,
+		ClientToken: token.ClientToken,
+		AccessToken: tokenHash,
+		ProfileID:   token.ProfileID,
+		CreatedAt:   token.CreatedAt.Unix(),
+		class:       c.tokenClass,
+	}
+	if err := c.store.put(generateYggdrasilTokenKey(tokenHash), cacheToken, ttl); err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+
+	userTokensKey := generateYggdrasilUserTokensKey(owner)
+	accessTokens := c.userAccessTokens(userTokensKey)
+	for _, accessToken := range accessTokens {
+		if accessToken == tokenHash {
+			return nil
+		}
+	}
+	accessTokens = append(accessTokens, tokenHash)
+
+	if err := c.store.put(userTokensKey, accessTokens, laravelUserTokensTTL); err != nil {
+		return fmt.Errorf("failed to store user tokens list: %w", err)
+	}
+	return nil
+}
+
+// Get 获取Token（不要求Go端签发的JWT，插件签发的Token同样有效）
+// 先按令牌哈希查找，迁移期内再按明文访问令牌查找（升级前或插件写入的条目）
+func (c *LaravelTokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
+	tokenHash := utils.HashAccessToken(accessToken)
+	token, err := c.getEntry(ctx, tokenHash)
+	if err != nil && tokenHash != accessToken && !utils.IsHashedAccessToken(accessToken) && utils.AcceptPlaintextTokens() {
+		token, err = c.getEntry(ctx, accessToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	token.AccessToken = accessToken
+	return token, nil
+}
+
+// getEntry 按缓存键中的标识（令牌哈希或明文访问令牌）读取Token
+func (c *LaravelTokenCache) getEntry(ctx context.Context, id string) (*yggdrasil.Token, error) {
+	tokenKey := generateYggdrasilTokenKey(id)
+
+	var cacheToken laravelToken
+	if err := c.store.get(tokenKey, &cacheToken); err != nil {
+		return nil, fmt.Errorf("token not found in cache: %w", err)
+	}
+
+	ttl := c.store.ttl(tokenKey)
+	if ttl <= 0 {
+		return nil, fmt.Errorf("token not found in cache")
+	}
+
+	tokenHash := id
+	if !utils.IsHashedAccessToken(id) {
+		tokenHash = utils.HashAccessToken(id)
+	}
+	return &yggdrasil.Token{
+		AccessToken: tokenHash,
+		ClientToken: cacheToken.ClientToken,
+		ProfileID:   cacheToken.ProfileID,
+		Owner:       c.ownerUserID(ctx, cacheToken.Owner),
+		CreatedAt:   time.Unix(cacheToken.CreatedAt, 0),
+		ExpiresAt:   time.Now().Add(ttl),
+	}, nil
+}
+
+// Delete 删除Token并从用户Token列表中移除（哈希和明文两种条目都会删除）
+func (c *LaravelTokenCache) Delete(ctx context.Context, accessToken string) error {
+	ids := []string{utils.HashAccessToken(accessToken)}
+	if ids[0] != accessToken && !utils.IsHashedAccessToken(accessToken) {
+		ids = append(ids, accessToken)
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		tokenKey := generateYggdrasilTokenKey(id)
+		keys = append(keys, tokenKey)
+
+		var cacheToken laravelToken
+		if err := c.store.get(tokenKey, &cacheToken); err == nil && cacheToken.Owner != "" {
+			c.removeTokenFromUserList(cacheToken.Owner, id)
+		}
+	}
+
+	return c.store.forget(keys...)
+}
+
+// GetUserTokens 获取用户的所有Token（返回的AccessToken为令牌哈希）
+func (c *LaravelTokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
+	userTokensKey := generateYggdrasilUserTokensKey(c.ownerIdentifier(ctx, userID))
+
+	var tokens []*yggdrasil.Token
+	for _, id := range c.userAccessTokens(userTokensKey) {
+		if utils.IsPlaintextTokenEntry(id) && !utils.AcceptPlaintextTokens() {
+			continue
+		}
+		if token, err := c.getEntry(ctx, id); err == nil {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// DeleteUserTokens 删除用户的所有Token
+func (c *LaravelTokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
+	userTokensKey := generateYggdrasilUserTokensKey(c.ownerIdentifier(ctx, userID))
+
+	accessTokens := c.userAccessTokens(userTokensKey)
+	keys := make([]string, 0, len(accessTokens)+1)
+	for _, accessToken := range accessTokens {
+		keys = append(keys, generateYggdrasilTokenKey(accessToken))
+	}
+	keys = append(keys, userTokensKey)
+
+	return c.store.forget(keys...)
+}
+
+// GetUserTokenCount 获取用户Token数量
+func (c *LaravelTokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	tokens, err := c.GetUserTokens(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// CleanupExpired 清理过期Token
+func (c *LaravelTokenCache) CleanupExpired() error {
+	// Redis会自动清理过期的键，用户Token列表中的无效引用在读取时跳过
+	return nil
+}
+
+// Close 关闭缓存连接
+func (c *LaravelTokenCache) Close() error {
+	return c.store.client.Close()
+}
+
+// Ping 检查Redis连接
+func (c *LaravelTokenCache) Ping(ctx context.Context) error {
+	return c.store.client.Ping(ctx).Err()
+}
+
+// GetCacheType 获取缓存类型
+func (c *LaravelTokenCache) GetCacheType() string {
+	return "redis"
+}
+
+// Count 获取Token数量（SCAN统计，结果缓存30秒）
+func (c *LaravelTokenCache) Count() (int, error) {
+	return c.counter.Count()
+}
+
+// userAccessTokens 读取用户Token列表（插件可能存储访问令牌字符串或Token对象）
+func (c *LaravelTokenCache) userAccessTokens(userTokensKey string) []string {
+	var items []any
+	if err := c.store.get(userTokensKey, &items); err != nil {
+		return []string{}
+	}
+
+	accessTokens := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			accessTokens = append(accessTokens, v)
+		case map[string]any:
+			if accessToken, ok := v["accessToken"].(string); ok {
+				accessTokens = append(accessTokens, accessToken)
+			}
+		case map[any]any:
+			if accessToken, ok := v["accessToken"].(string); ok {
+				accessTokens = append(accessTokens, accessToken)
+			}
+		}
+	}
+	return accessTokens
+}
+
+// removeTokenFromUserList 从用户Token列表中移除指定Token
+func (c *LaravelTokenCache) removeTokenFromUserList(owner, accessToken string) error {
+	userTokensKey := generateYggdrasilUserTokensKey(owner)
+
+	accessTokens := c.userAccessTokens(userTokensKey)
+	for i, token := range accessTokens {
+		if token == accessToken {
+			accessTokens = append(accessTokens[:i], accessTokens[i+1:]...)
+			break
+		}
+	}
+
+	if len(accessTokens) == 0 {
+		return c.store.forget(userTokensKey)
+	}
+	return c.store.put(userTokensKey, accessTokens, laravelUserTokensTTL)
+}
+
+// LaravelSessionCache Laravel格式Redis Session缓存（yggdrasil-server-{serverId}）
+type LaravelSessionCache struct {
+	store   *laravelStore
+	counter *keyCounter
+}
+
+// NewLaravelSessionCache 创建Laravel格式Redis Session缓存
+func NewLaravelSessionCache(options map[string]any) (*LaravelSessionCache, error) {
+	store, err := newLaravelStore(options)
+	if err != nil {
+		return nil, err
+	}
+	return &LaravelSessionCache{
+		store:   store,
+		counter: newKeyCounter(store.client, store.prefix+generateYggdrasilSessionKey("*")),
+	}, nil
+}
+
+// Store 存储Session
+func (c *LaravelSessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
+	cacheSession := laravelSession{
+		Profile: session.ProfileID,
+		IP:      session.ClientIP,
+		Checks:  session.Checks,
+	}
+	// 插件格式不含创建时间，按剩余有效期存储，读取时据此推算创建时间
+	ttl := session.TTL()
+	if ttl <= 0 {
+		return nil
+	}
+	if err := c.store.put(generateYggdrasilSessionKey(serverID), cacheSession, ttl); err != nil {
+		return fmt.Errorf("failed to store session: %w", err)
+	}
+	return nil
+}
+
+// Get 获取Session
+func (c *LaravelSessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	key := generateYggdrasilSessionKey(serverID)
+
+	var cacheSession laravelSession
+	if err := c.store.get(key, &cacheSession); err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+
+	return cacheSession.toSession(serverID, c.store.ttl(key)), nil
+}
+
+// Consume 获取并删除Session（Lua脚本保证原子性）
+func (c *LaravelSessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	var cacheSession laravelSession
+	ttl, err := c.store.pull(generateYggdrasilSessionKey(serverID), &cacheSession)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+
+	return cacheSession.toSession(serverID, ttl), nil
+}
+
+// toSession 转换为Session（插件格式不含创建时间，根据剩余有效期推算）
+func (s laravelSession) toSession(serverID string, ttl time.Duration) *yggdrasil.Session {
+	return &yggdrasil.Session{
+		ServerID:  serverID,
+		ProfileID: s.Profile,
+		ClientIP:  s.IP,
+		CreatedAt: time.Now().Add(ttl - laravelSessionTTL),
+		Checks:    s.Checks,
+	}
+}
+
+// Delete 删除Session
+func (c *LaravelSessionCache) Delete(ctx context.Context, serverID string) error {
+	return c.store.forget(generateYggdrasilSessionKey(serverID))
+}
+
+// CleanupExpired 清理过期Session
+func (c *LaravelSessionCache) CleanupExpired() error {
+	// Redis会自动清理过期的键
+	return nil
+}
+
+// Close 关闭缓存连接
+func (c *LaravelSessionCache) Close() error {
+	return c.store.client.Close()
+}
+
+// Ping 检查Redis连接
+func (c *LaravelSessionCache) Ping(ctx context.Context) error {
+	return c.store.client.Ping(ctx).Err()
+}
+
+// GetCacheType 获取缓存类型
+func (c *LaravelSessionCache) GetCacheType() string {
+	return "redis"
+}
+
+// Count 获取Session数量（SCAN统计，结果缓存30秒）
+func (c *LaravelSessionCache) Count() (int, error) {
+	return c.counter.Count()
+}
+
+// generateYggdrasilTokenKey 生成Token缓存键（与BlessingSkin兼容）
+func generateYggdrasilTokenKey(accessToken string) string {
+	return "yggdrasil-token-" + accessToken
+}
+
+// generateYggdrasilUserTokensKey 生成用户Token列表缓存键（与BlessingSkin兼容）
+func generateYggdrasilUserTokensKey(owner string) string {
+	return "yggdrasil-id-" + owner
+}
+
+// generateYggdrasilSessionKey 生成Session缓存键（与BlessingSkin兼容）
+func generateYggdrasilSessionKey(serverID string) string {
+	return "yggdrasil-server-" + serverID
+}
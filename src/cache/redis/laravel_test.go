@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"github.com/trim21/go-phpserialize"
+)
+
+func TestLaravelTokenCacheHashedTokens(t *testing.T) {
+	options := testRedisOptions(t)
+	utils.SetTokenHashSecret("secret", true)
+	defer utils.SetTokenHashSecret("", true)
+
+	c, err := NewLaravelTokenCache(options)
+	if err != nil {
+		t.Fatalf("NewLaravelTokenCache: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	token := &yggdrasil.Token{AccessToken: "access", ClientToken: "client", Owner: "1", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := c.Store(ctx, token); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	if got, err := c.Get(ctx, "access"); err != nil || got.ClientToken != "client" || got.Owner != "1" {
+		t.Fatalf("Get = %+v, %v; want the stored token", got, err)
+	}
+
+	// 缓存中的令牌哈希不能当作访问令牌使用
+	tokenHash := utils.HashAccessToken("access")
+	if _, err := c.Get(ctx, tokenHash); err == nil {
+		t.Fatal("Get with the token hash succeeded; want error")
+	}
+	if err := c.Delete(ctx, tokenHash); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	tokens, err := c.GetUserTokens(ctx, "1")
+	if err != nil || len(tokens) != 1 || tokens[0].AccessToken != tokenHash {
+		t.Fatalf("GetUserTokens = %v, %v; want one token with the stored hash", tokens, err)
+	}
+}
+
+func TestLaravelTokenSerialization(t *testing.T) {
+	token := laravelToken{
+		Owner:       "steve@example.com",
+		ClientToken: "client",
+		AccessToken: "access",
+		ProfileID:   "5627dd98e6be3c21b8a8e92344183641",
+		CreatedAt:   1700000000,
+		class:       defaultLaravelTokenClass,
+	}
+	data, err := phpserialize.Marshal(token)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `O:22:"Yggdrasil\Models\Token":5:{s:5:"owner";s:17:"steve@example.com";s:11:"clientToken";s:6:"client";` +
+		`s:11:"accessToken";s:6:"access";s:9:"profileId";s:32:"5627dd98e6be3c21b8a8e92344183641";s:9:"createdAt";i:1700000000;}`
+	if string(data) != want {
+		t.Fatalf("Marshal = %s; want %s", data, want)
+	}
+
+	var got laravelToken
+	if err := unserializeLaravel(data, &got); err != nil {
+		t.Fatalf("unserializeLaravel: %v", err)
+	}
+	got.class = token.class
+	if got != token {
+		t.Fatalf("unserializeLaravel = %+v; want %+v", got, token)
+	}
+
+	// 插件先serialize()再放入缓存时为双重序列化
+	double, err := phpserialize.Marshal(string(data))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	got = laravelToken{}
+	if err := unserializeLaravel(double, &got); err != nil || got.AccessToken != "access" {
+		t.Fatalf("unserializeLaravel(double) = %+v, %v", got, err)
+	}
+
+	if err := unserializeLaravel([]byte("not serialized"), &got); err == nil {
+		t.Fatal("unserializeLaravel accepted invalid data")
+	}
+}
+
+func TestLaravelSessionSerialization(t *testing.T) {
+	data, err := phpserialize.Marshal(laravelSession{Profile: "p", IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if want := `a:2:{s:7:"profile";s:1:"p";s:2:"ip";s:9:"127.0.0.1";}`; string(data) != want {
+		t.Fatalf("Marshal = %s; want %s", data, want)
+	}
+
+	var got laravelSession
+	if err := unserializeLaravel(data, &got); err != nil {
+		t.Fatalf("unserializeLaravel: %v", err)
+	}
+	session := got.toSession("server", laravelSessionTTL-10*time.Second)
+	if session.ProfileID != "p" || session.ClientIP != "127.0.0.1" || time.Since(session.CreatedAt) < 9*time.Second {
+		t.Fatalf("toSession = %+v; want profile p created about 10s ago", session)
+	}
+}
@@ -12,40 +12,63 @@ import (
 	"github.com/go-redis/redis/v8"
 )
 
+// consumeScript 原子地读取并删除键，返回值和删除前的剩余有效期（毫秒）
+// 不使用GETDEL以兼容Redis 6.2之前的版本
+var consumeScript = redis.NewScript(`
+local value = redis.call('GET', KEYS[1])
+if not value then
+	return false
+end
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('DEL', KEYS[1])
+return {value, ttl}
+`)
+
+// consumeKey 执行consumeScript（键不存在时返回redis.Nil）
+func consumeKey(ctx context.Context, client redis.UniversalClient, key string) (string, time.Duration, error) {
+	result, err := consumeScript.Run(ctx, client, []string{key}).Slice()
+	if err != nil {
+		return "", 0, err
+	}
+	if len(result) != 2 {
+		return "", 0, fmt.Errorf("unexpected consume script result")
+	}
+
+	value, _ := result[0].(string)
+	ttl, _ := result[1].(int64)
+	return value, time.Duration(ttl) * time.Millisecond, nil
+}
+
 // SessionCache Redis Session缓存
 type SessionCache struct {
-	client *redis.Client
-	ctx    context.Context
+	client  redis.UniversalClient
+	keys    keySchema
+	counter *keyCounter
+	ctx     context.Context
 }
 
-// NewSessionCache 创建Redis Session缓存
+// NewSessionCache 创建Redis Session缓存（连接选项见NewClient）
 func NewSessionCache(options map[string]any) (*SessionCache, error) {
-	redisURL := "redis://localhost:6379"
-	if url, ok := options["redis_url"].(string); ok && url != "" {
-		redisURL = url
-	}
-
-	opt, err := redis.ParseURL(redisURL)
+	keys, err := newKeySchema(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		return nil, err
 	}
 
-	client := redis.NewClient(opt)
-	ctx := context.Background()
-
-	// 测试连接
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	client, err := NewClient(options)
+	if err != nil {
+		return nil, err
 	}
 
 	return &SessionCache{
-		client: client,
-		ctx:    ctx,
+		client:  client,
+		keys:    keys,
+		counter: newKeyCounter(client, keys.sessionsPattern()),
+		ctx:     context.Background(),
 	}, nil
 }
 
 // Store 存储Session（优化版：验证JWT但只存储必要信息）
-func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error {
+func (c *SessionCache) Store(ctx context.Context, serverID string, session *yggdrasil.Session) error {
 	// 创建简化的Session对象（不存储AccessToken和ProfileID）
 	cacheSession := &yggdrasil.Session{
 		ServerID:    serverID,
@@ -53,6 +76,7 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 		ProfileID:   session.ProfileID,
 		ClientIP:    session.ClientIP,
 		CreatedAt:   session.CreatedAt,
+		Checks:      session.Checks,
 	}
 
 	// 序列化Session
@@ -61,12 +85,15 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 		return fmt.Errorf("failed to marshal session: %w", err)
 	}
 
-	// Session固定过期时间为30秒（与Yggdrasil标准一致）
-	ttl := 30 * time.Second
+	// 按剩余有效期存储（与Yggdrasil标准一致，最长30秒），已过期的Session不再存储
+	ttl := session.TTL()
+	if ttl <= 0 {
+		return nil
+	}
 
 	// 存储Session
-	sessionKey := fmt.Sprintf("yggdrasil-server-%s", serverID)
-	if err := c.client.Set(c.ctx, sessionKey, sessionData, ttl).Err(); err != nil {
+	sessionKey := c.keys.session(serverID)
+	if err := c.client.Set(ctx, sessionKey, sessionData, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to store session: %w", err)
 	}
 
@@ -74,10 +101,10 @@ func (c *SessionCache) Store(serverID string, session *yggdrasil.Session) error
 }
 
 // Get 获取Session
-func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
-	sessionKey := fmt.Sprintf("yggdrasil-server-%s", serverID)
+func (c *SessionCache) Get(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	sessionKey := c.keys.session(serverID)
 
-	data, err := c.client.Get(c.ctx, sessionKey).Result()
+	data, err := c.client.Get(ctx, sessionKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, fmt.Errorf("session not found")
@@ -94,9 +121,27 @@ func (c *SessionCache) Get(serverID string) (*yggdrasil.Session, error) {
 }
 
 // Delete 删除Session
-func (c *SessionCache) Delete(serverID string) error {
-	sessionKey := fmt.Sprintf("yggdrasil-server-%s", serverID)
-	return c.client.Del(c.ctx, sessionKey).Err()
+func (c *SessionCache) Delete(ctx context.Context, serverID string) error {
+	sessionKey := c.keys.session(serverID)
+	return c.client.Del(ctx, sessionKey).Err()
+}
+
+// Consume 获取并删除Session（Lua脚本保证原子性）
+func (c *SessionCache) Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error) {
+	data, _, err := consumeKey(ctx, c.client, c.keys.session(serverID))
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("session not found")
+		}
+		return nil, fmt.Errorf("failed to consume session: %w", err)
+	}
+
+	var session yggdrasil.Session
+	if err := sonic.Unmarshal([]byte(data), &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+
+	return &session, nil
 }
 
 // CleanupExpired 清理过期Session
@@ -110,7 +155,17 @@ func (c *SessionCache) Close() error {
 	return c.client.Close()
 }
 
+// Ping 检查缓存后端是否可用
+func (c *SessionCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
 // GetCacheType 获取缓存类型
 func (c *SessionCache) GetCacheType() string {
 	return "redis"
 }
+
+// Count 获取Session数量（SCAN统计，结果缓存30秒）
+func (c *SessionCache) Count() (int, error) {
+	return c.counter.Count()
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// sessionStore 两种Redis Session缓存的公共方法
+type sessionStore interface {
+	Store(ctx context.Context, serverID string, session *yggdrasil.Session) error
+	Consume(ctx context.Context, serverID string) (*yggdrasil.Session, error)
+	Close() error
+}
+
+// testRedisOptions 测试用的Redis连接（需要设置YGG_TEST_REDIS_URL，否则跳过）
+func testRedisOptions(t *testing.T) map[string]any {
+	t.Helper()
+	url := os.Getenv("YGG_TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("YGG_TEST_REDIS_URL not set")
+	}
+	return map[string]any{
+		"redis_url":  url,
+		"key_prefix": fmt.Sprintf("ygg-test-%d:", time.Now().UnixNano()),
+	}
+}
+
+func TestSessionCacheConsumeConcurrent(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(options map[string]any) (sessionStore, error)
+	}{
+		{name: "native", open: func(options map[string]any) (sessionStore, error) { return NewSessionCache(options) }},
+		{name: "laravel", open: func(options map[string]any) (sessionStore, error) { return NewLaravelSessionCache(options) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := testRedisOptions(t)
+
+			// 两个连接模拟两个实例
+			var caches []sessionStore
+			for i := 0; i < 2; i++ {
+				c, err := tt.open(options)
+				if err != nil {
+					t.Fatalf("open: %v", err)
+				}
+				defer c.Close()
+				caches = append(caches, c)
+			}
+
+			ctx := context.Background()
+			for round := 0; round < 20; round++ {
+				if err := caches[0].Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: time.Now()}); err != nil {
+					t.Fatalf("Store: %v", err)
+				}
+
+				var wg sync.WaitGroup
+				var consumed atomic.Int32
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func(c sessionStore) {
+						defer wg.Done()
+						if session, err := c.Consume(ctx, "server"); err == nil && session != nil {
+							consumed.Add(1)
+						}
+					}(caches[i%len(caches)])
+				}
+				wg.Wait()
+
+				if n := consumed.Load(); n != 1 {
+					t.Fatalf("round %d: %d callers consumed the session; want 1", round, n)
+				}
+			}
+
+			// 重新存储的Session保留剩余有效期
+			if err := caches[0].Store(ctx, "server", &yggdrasil.Session{ProfileID: "p", CreatedAt: time.Now().Add(-25 * time.Second)}); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			session, err := caches[1].Consume(ctx, "server")
+			if err != nil {
+				t.Fatalf("Consume: %v", err)
+			}
+			if session.TTL() > 5*time.Second {
+				t.Fatalf("re-stored session TTL = %v; want at most the remaining 5s", session.TTL())
+			}
+		})
+	}
+}
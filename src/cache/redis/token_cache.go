@@ -15,38 +15,34 @@ import (
 
 // TokenCache Redis Token缓存
 type TokenCache struct {
-	client *redis.Client
-	ctx    context.Context
+	client  redis.UniversalClient
+	keys    keySchema
+	counter *keyCounter
+	ctx     context.Context
 }
 
-// NewTokenCache 创建Redis Token缓存
+// NewTokenCache 创建Redis Token缓存（连接选项见NewClient，key_prefix为所有键添加前缀）
 func NewTokenCache(options map[string]any) (*TokenCache, error) {
-	redisURL := "redis://localhost:6379"
-	if url, ok := options["redis_url"].(string); ok && url != "" {
-		redisURL = url
-	}
-
-	opt, err := redis.ParseURL(redisURL)
+	keys, err := newKeySchema(options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		return nil, err
 	}
 
-	client := redis.NewClient(opt)
-	ctx := context.Background()
-
-	// 测试连接
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	client, err := NewClient(options)
+	if err != nil {
+		return nil, err
 	}
 
 	return &TokenCache{
-		client: client,
-		ctx:    ctx,
+		client:  client,
+		keys:    keys,
+		counter: newKeyCounter(client, keys.tokensPattern()),
+		ctx:     context.Background(),
 	}, nil
 }
 
 // Store 存储Token（优化版：先验证JWT，提取信息）
-func (c *TokenCache) Store(token *yggdrasil.Token) error {
+func (c *TokenCache) Store(ctx context.Context, token *yggdrasil.Token) error {
 	// 第一步：验证JWT并提取信息
 	claims, err := utils.ValidateJWT(token.AccessToken)
 	if err != nil {
@@ -55,10 +51,10 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 
 	// 创建简化的Token对象（只存储JWT中没有的信息）
 	cacheToken := &yggdrasil.Token{
-		AccessToken: token.AccessToken, // 保留完整的AccessToken用于兼容性
+		AccessToken: utils.HashAccessToken(token.AccessToken), // 只保存令牌哈希
 		ClientToken: token.ClientToken,
 		ProfileID:   claims.ProfileID, // 从JWT中获取ProfileID
-		Owner:       claims.UserID, // 从JWT中获取用户ID
+		Owner:       claims.UserID,    // 从JWT中获取用户ID
 		CreatedAt:   token.CreatedAt,
 		ExpiresAt:   token.ExpiresAt,
 	}
@@ -76,25 +72,25 @@ func (c *TokenCache) Store(token *yggdrasil.Token) error {
 	}
 
 	// 存储Token（使用用户ID:TokenID作为键）
-	tokenKey := fmt.Sprintf("yggdrasil-token-%s:%s", claims.UserID, claims.TokenID)
-	if err := c.client.Set(c.ctx, tokenKey, tokenData, ttl).Err(); err != nil {
+	tokenKey := c.keys.token(claims.UserID, claims.TokenID)
+	if err := c.client.Set(ctx, tokenKey, tokenData, ttl).Err(); err != nil {
 		return fmt.Errorf("failed to store token: %w", err)
 	}
 
 	// 更新用户Token列表（使用用户ID）
-	userTokensKey := fmt.Sprintf("yggdrasil-id-%s", claims.UserID)
-	if err := c.client.SAdd(c.ctx, userTokensKey, claims.TokenID).Err(); err != nil {
+	userTokensKey := c.keys.userTokens(claims.UserID)
+	if err := c.client.SAdd(ctx, userTokensKey, claims.TokenID).Err(); err != nil {
 		return fmt.Errorf("failed to add token to user list: %w", err)
 	}
 
 	// 设置用户Token列表的过期时间（7天）
-	c.client.Expire(c.ctx, userTokensKey, 7*24*time.Hour)
+	c.client.Expire(ctx, userTokensKey, 7*24*time.Hour)
 
 	return nil
 }
 
 // Get 获取Token（优化版：先验证JWT，按需查询缓存）
-func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
+func (c *TokenCache) Get(ctx context.Context, accessToken string) (*yggdrasil.Token, error) {
 	// 第一步：验证JWT（本地计算，极快）
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -102,9 +98,9 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 	}
 
 	// 第二步：从缓存获取ClientToken等额外信息
-	tokenKey := fmt.Sprintf("yggdrasil-token-%s:%s", claims.UserID, claims.TokenID)
+	tokenKey := c.keys.token(claims.UserID, claims.TokenID)
 
-	data, err := c.client.Get(c.ctx, tokenKey).Result()
+	data, err := c.client.Get(ctx, tokenKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, fmt.Errorf("token not found in cache")
@@ -117,6 +113,15 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
 	}
 
+	// 升级前写入的明文条目：迁移期内改写为哈希，之后视为不存在
+	if utils.IsPlaintextTokenEntry(token.AccessToken) {
+		if !utils.AcceptPlaintextTokens() {
+			c.client.Del(ctx, tokenKey)
+			return nil, fmt.Errorf("token not found in cache")
+		}
+		c.rehash(tokenKey, &token)
+	}
+
 	// 构建Token对象（结合JWT信息和缓存信息）
 	result := &yggdrasil.Token{
 		AccessToken: accessToken,
@@ -130,8 +135,22 @@ func (c *TokenCache) Get(accessToken string) (*yggdrasil.Token, error) {
 	return result, nil
 }
 
+// rehash 将明文条目改写为哈希（保持原有过期时间）
+func (c *TokenCache) rehash(tokenKey string, token *yggdrasil.Token) {
+	ttl := time.Until(token.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	cacheToken := *token
+	cacheToken.AccessToken = utils.HashAccessToken(token.AccessToken)
+	if tokenData, err := sonic.Marshal(&cacheToken); err == nil {
+		c.client.Set(c.ctx, tokenKey, tokenData, ttl)
+	}
+}
+
 // Delete 删除Token（优化版：先验证JWT，提取用户ID和TokenID）
-func (c *TokenCache) Delete(accessToken string) error {
+func (c *TokenCache) Delete(ctx context.Context, accessToken string) error {
 	// 先验证JWT并提取信息
 	claims, err := utils.ValidateJWT(accessToken)
 	if err != nil {
@@ -140,19 +159,19 @@ func (c *TokenCache) Delete(accessToken string) error {
 	}
 
 	// 从用户Token列表中移除（使用用户ID）
-	userTokensKey := fmt.Sprintf("yggdrasil-id-%s", claims.UserID)
-	c.client.SRem(c.ctx, userTokensKey, claims.TokenID)
+	userTokensKey := c.keys.userTokens(claims.UserID)
+	c.client.SRem(ctx, userTokensKey, claims.TokenID)
 
 	// 删除Token
-	tokenKey := fmt.Sprintf("yggdrasil-token-%s:%s", claims.UserID, claims.TokenID)
-	return c.client.Del(c.ctx, tokenKey).Err()
+	tokenKey := c.keys.token(claims.UserID, claims.TokenID)
+	return c.client.Del(ctx, tokenKey).Err()
 }
 
 // GetUserTokens 获取用户的所有Token（按用户ID查询）
-func (c *TokenCache) GetUserTokens(userID string) ([]*yggdrasil.Token, error) {
-	userTokensKey := fmt.Sprintf("yggdrasil-id-%s", userID)
+func (c *TokenCache) GetUserTokens(ctx context.Context, userID string) ([]*yggdrasil.Token, error) {
+	userTokensKey := c.keys.userTokens(userID)
 
-	tokenIDs, err := c.client.SMembers(c.ctx, userTokensKey).Result()
+	tokenIDs, err := c.client.SMembers(ctx, userTokensKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return []*yggdrasil.Token{}, nil
@@ -163,21 +182,30 @@ func (c *TokenCache) GetUserTokens(userID string) ([]*yggdrasil.Token, error) {
 	var tokens []*yggdrasil.Token
 	for _, tokenID := range tokenIDs {
 		// 直接从Redis获取Token数据
-		tokenKey := fmt.Sprintf("yggdrasil-token-%s:%s", userID, tokenID)
-		data, err := c.client.Get(c.ctx, tokenKey).Result()
+		tokenKey := c.keys.token(userID, tokenID)
+		data, err := c.client.Get(ctx, tokenKey).Result()
 		if err != nil {
 			// 清理无效的Token引用
-			c.client.SRem(c.ctx, userTokensKey, tokenID)
+			c.client.SRem(ctx, userTokensKey, tokenID)
 			continue
 		}
 
 		var token yggdrasil.Token
 		if err := sonic.Unmarshal([]byte(data), &token); err != nil {
 			// 清理无效的Token引用
-			c.client.SRem(c.ctx, userTokensKey, tokenID)
+			c.client.SRem(ctx, userTokensKey, tokenID)
 			continue
 		}
 
+		// 不返回明文令牌
+		if utils.IsPlaintextTokenEntry(token.AccessToken) {
+			if !utils.AcceptPlaintextTokens() {
+				continue
+			}
+			c.rehash(tokenKey, &token)
+			token.AccessToken = utils.HashAccessToken(token.AccessToken)
+		}
+
 		tokens = append(tokens, &token)
 	}
 
@@ -185,11 +213,11 @@ func (c *TokenCache) GetUserTokens(userID string) ([]*yggdrasil.Token, error) {
 }
 
 // DeleteUserTokens 删除用户的所有Token（按用户ID）
-func (c *TokenCache) DeleteUserTokens(userID string) error {
-	userTokensKey := fmt.Sprintf("yggdrasil-id-%s", userID)
+func (c *TokenCache) DeleteUserTokens(ctx context.Context, userID string) error {
+	userTokensKey := c.keys.userTokens(userID)
 
 	// 获取用户的所有TokenID
-	tokenIDs, err := c.client.SMembers(c.ctx, userTokensKey).Result()
+	tokenIDs, err := c.client.SMembers(ctx, userTokensKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil // 用户没有Token
@@ -197,21 +225,21 @@ func (c *TokenCache) DeleteUserTokens(userID string) error {
 		return fmt.Errorf("failed to get user tokens: %w", err)
 	}
 
-	// 删除所有Token
+	// 一次删除所有Token和用户Token列表（Cluster模式下这些键通过哈希标签位于同一槽位）
+	keys := make([]string, 0, len(tokenIDs)+1)
 	for _, tokenID := range tokenIDs {
-		tokenKey := fmt.Sprintf("yggdrasil-token-%s:%s", userID, tokenID)
-		c.client.Del(c.ctx, tokenKey)
+		keys = append(keys, c.keys.token(userID, tokenID))
 	}
+	keys = append(keys, userTokensKey)
 
-	// 删除用户Token列表
-	return c.client.Del(c.ctx, userTokensKey).Err()
+	return c.client.Del(ctx, keys...).Err()
 }
 
 // GetUserTokenCount 获取用户Token数量
-func (c *TokenCache) GetUserTokenCount(userID string) (int, error) {
-	userTokensKey := fmt.Sprintf("yggdrasil-id-%s", userID)
+func (c *TokenCache) GetUserTokenCount(ctx context.Context, userID string) (int, error) {
+	userTokensKey := c.keys.userTokens(userID)
 
-	count, err := c.client.SCard(c.ctx, userTokensKey).Result()
+	count, err := c.client.SCard(ctx, userTokensKey).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return 0, nil
@@ -227,14 +255,14 @@ func (c *TokenCache) CleanupExpired() error {
 	// Redis会自动清理过期的键，这里主要清理用户Token列表中的无效引用
 
 	// 获取所有用户Token列表键
-	keys, err := c.client.Keys(c.ctx, "yggdrasil-id-*").Result()
+	keys, err := scanKeys(c.ctx, c.client, c.keys.userTokensPattern())
 	if err != nil {
 		return fmt.Errorf("failed to get user token keys: %w", err)
 	}
 
 	for _, userTokensKey := range keys {
 		// 提取用户ID
-		userID := userTokensKey[len("yggdrasil-id-"):]
+		userID := c.keys.userIDFromTokensKey(userTokensKey)
 
 		// 获取用户TokenID列表
 		tokenIDs, err := c.client.SMembers(c.ctx, userTokensKey).Result()
@@ -244,7 +272,7 @@ func (c *TokenCache) CleanupExpired() error {
 
 		// 检查每个Token是否仍然存在
 		for _, tokenID := range tokenIDs {
-			tokenKey := fmt.Sprintf("yggdrasil-token-%s:%s", userID, tokenID)
+			tokenKey := c.keys.token(userID, tokenID)
 			exists, err := c.client.Exists(c.ctx, tokenKey).Result()
 			if err != nil || exists == 0 {
 				// Token不存在，从用户列表中移除
@@ -267,7 +295,17 @@ func (c *TokenCache) Close() error {
 	return c.client.Close()
 }
 
+// Ping 检查缓存后端是否可用
+func (c *TokenCache) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
 // GetCacheType 获取缓存类型
 func (c *TokenCache) GetCacheType() string {
 	return "redis"
 }
+
+// Count 获取Token数量（SCAN统计，结果缓存30秒）
+func (c *TokenCache) Count() (int, error) {
+	return c.counter.Count()
+}
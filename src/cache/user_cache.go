@@ -2,117 +2,183 @@
 package cache
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"yggdrasil-api-go/src/cache/lru"
 	"yggdrasil-api-go/src/middleware"
 	"yggdrasil-api-go/src/yggdrasil"
 )
 
-// UserCacheItem 用户缓存项
-type UserCacheItem struct {
-	User      *yggdrasil.User
-	ExpiresAt time.Time
-}
+// 默认用户缓存限制
+const (
+	defaultUserCacheMaxItems        = 500
+	defaultUserCacheCleanupInterval = time.Minute
+)
 
-// IsExpired 检查是否过期
-func (item *UserCacheItem) IsExpired() bool {
-	return time.Now().After(item.ExpiresAt)
+// UserCacheItem 用户缓存项（用户或角色）
+type UserCacheItem struct {
+	User    *yggdrasil.User
+	Profile *yggdrasil.Profile
 }
 
-// UserCache 用户信息缓存
+// UserCache 用户信息缓存（LRU，超出max_users时淘汰最久未使用的条目）
+// 条目可带标签（如用户ID、角色UUID），写操作后按标签批量失效
 type UserCache struct {
-	cache    sync.Map
 	duration time.Duration
+	maxItems int
+	items    *lru.Cache[*UserCacheItem]
+
+	hits      int64
+	misses    int64
+	evictions int64
+
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
-// NewUserCache 创建用户缓存
-func NewUserCache(duration time.Duration) *UserCache {
+// NewUserCache 创建用户缓存（ctx取消或调用Close时停止清理协程）
+func NewUserCache(ctx context.Context, duration time.Duration, maxItems int, cleanupInterval time.Duration) *UserCache {
+	if maxItems <= 0 {
+		maxItems = defaultUserCacheMaxItems
+	}
+	if cleanupInterval <= 0 {
+		cleanupInterval = defaultUserCacheCleanupInterval
+	}
+
 	cache := &UserCache{
 		duration: duration,
+		maxItems: maxItems,
+		items:    lru.New[*UserCacheItem](maxItems, duration),
+		stop:     make(chan struct{}),
 	}
 
 	// 启动清理协程
-	go cache.cleanup()
+	go cache.cleanup(ctx, cleanupInterval)
 
 	return cache
 }
 
 // Get 获取用户信息
 func (uc *UserCache) Get(key string) (*yggdrasil.User, bool) {
-	if value, ok := uc.cache.Load(key); ok {
-		item := value.(*UserCacheItem)
-		if !item.IsExpired() {
-			middleware.GlobalCacheMonitor.RecordHit()
-			return item.User, true
-		}
-		// 过期则删除
-		uc.cache.Delete(key)
+	item, ok := uc.get(key)
+	if !ok || item.User == nil {
+		return nil, false
+	}
+	return item.User, true
+}
+
+// GetProfile 获取角色信息
+func (uc *UserCache) GetProfile(key string) (*yggdrasil.Profile, bool) {
+	item, ok := uc.get(key)
+	if !ok || item.Profile == nil {
+		return nil, false
+	}
+	return item.Profile, true
+}
+
+// get 获取未过期的条目并记录命中情况
+func (uc *UserCache) get(key string) (*UserCacheItem, bool) {
+	if item, ok := uc.items.Get(key); ok {
+		atomic.AddInt64(&uc.hits, 1)
+		middleware.GlobalCacheMonitor.RecordHit()
+		return item, true
 	}
 
+	atomic.AddInt64(&uc.misses, 1)
 	middleware.GlobalCacheMonitor.RecordMiss()
 	return nil, false
 }
 
-// Set 设置用户信息
-func (uc *UserCache) Set(key string, user *yggdrasil.User) {
-	item := &UserCacheItem{
-		User:      user,
-		ExpiresAt: time.Now().Add(uc.duration),
+// Set 设置用户信息（tags用于之后按标签失效）
+func (uc *UserCache) Set(key string, user *yggdrasil.User, tags ...string) {
+	uc.set(key, &UserCacheItem{User: user}, tags)
+}
+
+// SetProfile 设置角色信息
+func (uc *UserCache) SetProfile(key string, profile *yggdrasil.Profile, tags ...string) {
+	uc.set(key, &UserCacheItem{Profile: profile}, tags)
+}
+
+// set 写入条目并记录淘汰数
+func (uc *UserCache) set(key string, item *UserCacheItem, tags []string) {
+	if evicted := uc.items.Set(key, item, uc.duration, tags...); evicted > 0 {
+		atomic.AddInt64(&uc.evictions, int64(evicted))
 	}
-	uc.cache.Store(key, item)
 }
 
 // Delete 删除用户信息
 func (uc *UserCache) Delete(key string) {
-	uc.cache.Delete(key)
+	uc.items.Delete(key)
+}
+
+// DeleteTag 删除带有指定标签的所有条目
+func (uc *UserCache) DeleteTag(tag string) {
+	uc.items.DeleteTag(tag)
+}
+
+// Purge 清空缓存
+func (uc *UserCache) Purge() {
+	uc.items.Purge()
+}
+
+// Close 停止清理协程
+func (uc *UserCache) Close() {
+	uc.stopOnce.Do(func() { close(uc.stop) })
 }
 
 // cleanup 定期清理过期缓存
-func (uc *UserCache) cleanup() {
-	ticker := time.NewTicker(time.Minute)
+func (uc *UserCache) cleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		uc.cache.Range(func(key, value interface{}) bool {
-			item := value.(*UserCacheItem)
-			if item.IsExpired() {
-				uc.cache.Delete(key)
-			}
-			return true
-		})
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-uc.stop:
+			return
+		case <-ticker.C:
+			uc.items.PurgeExpired()
+		}
 	}
 }
 
 // GetStats 获取缓存统计
 func (uc *UserCache) GetStats() map[string]interface{} {
-	count := 0
-	expired := 0
-
-	uc.cache.Range(func(key, value interface{}) bool {
-		count++
-		item := value.(*UserCacheItem)
-		if item.IsExpired() {
-			expired++
-		}
-		return true
-	})
+	count, expired, _ := uc.items.Stats()
+
+	hits := atomic.LoadInt64(&uc.hits)
+	misses := atomic.LoadInt64(&uc.misses)
+	var hitRate float64
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
 
 	return map[string]interface{}{
 		"total_items":            count,
 		"expired_items":          expired,
 		"valid_items":            count - expired,
+		"max_items":              uc.maxItems,
+		"hits":                   hits,
+		"misses":                 misses,
+		"hit_rate":               hitRate,
+		"evictions":              atomic.LoadInt64(&uc.evictions),
 		"cache_duration_minutes": uc.duration.Minutes(),
 	}
 }
 
 // 全局用户缓存实例（默认5分钟缓存，可通过配置修改）
-var GlobalUserCache = NewUserCache(5 * time.Minute)
+var GlobalUserCache = NewUserCache(context.Background(), 5*time.Minute, defaultUserCacheMaxItems, defaultUserCacheCleanupInterval)
 
 // InitUserCache 根据配置初始化用户缓存
-func InitUserCache(duration time.Duration) {
+func InitUserCache(ctx context.Context, duration time.Duration, maxUsers int, cleanupInterval time.Duration) {
 	if duration > 0 {
-		GlobalUserCache = NewUserCache(duration)
+		previous := GlobalUserCache
+		GlobalUserCache = NewUserCache(ctx, duration, maxUsers, cleanupInterval)
+		previous.Close()
 	}
 }
 
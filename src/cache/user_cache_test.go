@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+func TestUserCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	uc := NewUserCache(ctx, time.Minute, 2, time.Hour)
+
+	uc.Set("user:1", &yggdrasil.User{ID: "1"}, "user:1")
+	uc.SetProfile("profile:a", &yggdrasil.Profile{ID: "a"}, "user:1", "profile:a")
+	if _, ok := uc.Get("profile:a"); ok {
+		t.Fatal("Get returned a profile entry as a user")
+	}
+	if profile, ok := uc.GetProfile("profile:a"); !ok || profile.ID != "a" {
+		t.Fatalf("GetProfile = %v, %v; want profile a", profile, ok)
+	}
+
+	// 超出max_users时淘汰最久未使用的条目
+	uc.Set("user:2", &yggdrasil.User{ID: "2"})
+	if _, ok := uc.Get("user:1"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+
+	uc.DeleteTag("user:1")
+	if _, ok := uc.GetProfile("profile:a"); ok {
+		t.Fatal("tagged entry was not deleted")
+	}
+
+	stats := uc.GetStats()
+	if stats["total_items"] != 1 || stats["evictions"] != int64(1) || stats["hits"] != int64(2) {
+		t.Fatalf("GetStats = %v; want 1 item, 1 eviction and 2 hits", stats)
+	}
+}
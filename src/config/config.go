@@ -2,8 +2,14 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +30,7 @@ type Config struct {
 	Monitoring MonitoringConfig `yaml:"monitoring"`
 	Security   SecurityConfig   `yaml:"security"`
 	Warmup     WarmupConfig     `yaml:"warmup"`
+	Jobs       JobsConfig       `yaml:"jobs"`
 }
 
 // StorageConfig 存储配置
@@ -47,8 +54,9 @@ type FileStorageOptions struct {
 
 // DatabaseStorageOptions 数据库存储选项
 type DatabaseStorageOptions struct {
-	DatabaseDSN string `yaml:"database_dsn"` // 数据库连接字符串
+	DatabaseDSN string `yaml:"database_dsn"` // 数据库连接字符串（mysql://、postgres://或sqlite://，按scheme选择驱动）
 	Debug       bool   `yaml:"debug"`        // 调试模式
+	TablePrefix string `yaml:"table_prefix"` // 表名前缀
 }
 
 // BlessingSkinStorageOptions BlessingSkin存储选项
@@ -56,6 +64,10 @@ type BlessingSkinStorageOptions struct {
 	DatabaseDSN            string               `yaml:"database_dsn"`              // MySQL连接字符串
 	Debug                  bool                 `yaml:"debug"`                     // 调试模式
 	TextureBaseURLOverride bool                 `yaml:"texture_base_url_override"` // 为true时使用配置文件的texture.base_url而不是options中的site_url
+	TexturesDir            string               `yaml:"textures_dir"`              // BlessingSkin材质目录（storage/textures），本地对象存储的默认根目录
+	UploadPublic           bool                 `yaml:"upload_public"`             // 上传的材质是否公开到皮肤库
+	AddToCloset            bool                 `yaml:"add_to_closet"`             // 上传后是否加入用户衣柜
+	ExtensionTextures      bool                 `yaml:"extension_textures"`        // 是否支持SKIN/CAPE之外的材质类型（写入textures表，皮肤站不识别这些类型）
 	Security               BlessingSkinSecurity `yaml:"security"`                  // 安全配置
 }
 
@@ -68,15 +80,16 @@ type BlessingSkinSecurity struct {
 
 // CacheConfig 缓存配置
 type CacheConfig struct {
-	Token    CacheBackendConfig  `yaml:"token"`    // Token缓存配置
-	Session  CacheBackendConfig  `yaml:"session"`  // Session缓存配置
-	Response ResponseCacheConfig `yaml:"response"` // 响应缓存配置
-	User     UserCacheConfig     `yaml:"user"`     // 用户缓存配置
+	Token     CacheBackendConfig  `yaml:"token"`      // Token缓存配置
+	Session   CacheBackendConfig  `yaml:"session"`    // Session缓存配置
+	Response  ResponseCacheConfig `yaml:"response"`   // 响应缓存配置
+	User      UserCacheConfig     `yaml:"user"`       // 用户缓存配置
+	TokenHash TokenHashConfig     `yaml:"token_hash"` // 访问令牌哈希配置
 }
 
 // CacheBackendConfig 缓存后端配置
 type CacheBackendConfig struct {
-	Type    string         `yaml:"type"`    // 缓存类型：memory, redis, file, database
+	Type    string         `yaml:"type"`    // 缓存类型：memory, redis, file, database, layered
 	Options map[string]any `yaml:"options"` // 缓存选项
 }
 
@@ -86,8 +99,10 @@ type ResponseCacheConfig struct {
 	APIMetadata      bool          `yaml:"api_metadata"`      // 是否缓存API元数据
 	ErrorResponses   bool          `yaml:"error_responses"`   // 是否缓存错误响应
 	ProfileResponses bool          `yaml:"profile_responses"` // 是否缓存角色响应
+	ProfileDuration  time.Duration `yaml:"profile_duration"`  // 角色响应的缓存时间（不超过cache_duration；BlessingSkin网页端修改材质和角色名不会通知本服务，按此时间过期）
 	CacheDuration    time.Duration `yaml:"cache_duration"`    // 缓存持续时间
 	MaxCacheSize     int           `yaml:"max_cache_size"`    // 最大缓存条目数
+	MaxCacheBytes    int64         `yaml:"max_cache_bytes"`   // 最大缓存字节数
 }
 
 // UserCacheConfig 用户缓存配置
@@ -98,12 +113,92 @@ type UserCacheConfig struct {
 	CleanupInterval time.Duration `yaml:"cleanup_interval"` // 清理间隔
 }
 
+// TokenHashConfig 访问令牌哈希配置（Token缓存只保存令牌的HMAC-SHA256）
+type TokenHashConfig struct {
+	Mode            string `yaml:"mode"`             // hmac或plaintext（默认hmac；Token缓存使用Laravel格式时默认plaintext）
+	Secret          string `yaml:"secret"`           // HMAC密钥（为空时使用auth.jwt_secret，多实例部署时需保持一致）
+	RejectPlaintext bool   `yaml:"reject_plaintext"` // 拒绝升级前写入的明文缓存条目（迁移期结束后启用）
+}
+
+// TokenHashMode 实际使用的令牌哈希模式
+// 未配置时，Token缓存使用Laravel格式则为plaintext（BlessingSkin插件按原始令牌读取缓存），否则为hmac
+func (c *Config) TokenHashMode() string {
+	if c.Cache.TokenHash.Mode != "" {
+		return c.Cache.TokenHash.Mode
+	}
+	if c.laravelTokenCache() {
+		return "plaintext"
+	}
+	return "hmac"
+}
+
+// laravelTokenCache Token缓存是否使用Laravel格式（redis缓存或以redis为L2的分层缓存）
+func (c *Config) laravelTokenCache() bool {
+	format, _ := c.Cache.Token.Options["format"].(string)
+	return format == "laravel"
+}
+
+// TokenHashSecret 令牌哈希使用的密钥（plaintext模式返回空字符串）
+func (c *Config) TokenHashSecret() string {
+	if c.TokenHashMode() == "plaintext" {
+		return ""
+	}
+	if c.Cache.TokenHash.Secret != "" {
+		return c.Cache.TokenHash.Secret
+	}
+	return c.Auth.JWTSecret
+}
+
 // TextureConfig 材质配置
 type TextureConfig struct {
-	BaseURL       string   `yaml:"base_url"`       // 材质基础URL
-	UploadEnabled bool     `yaml:"upload_enabled"` // 是否启用上传
-	MaxFileSize   int64    `yaml:"max_file_size"`  // 最大文件大小（字节）
-	AllowedTypes  []string `yaml:"allowed_types"`  // 允许的文件类型
+	BaseURL       string               `yaml:"base_url"`       // 材质基础URL
+	UploadEnabled bool                 `yaml:"upload_enabled"` // 是否启用上传
+	MaxFileSize   int64                `yaml:"max_file_size"`  // 最大文件大小（字节）
+	AllowedTypes  []string             `yaml:"allowed_types"`  // 允许的文件类型
+	Blob          BlobStoreConfig      `yaml:"blob"`           // 材质文件存储配置
+	Types         []TextureTypeConfig  `yaml:"types"`          // 材质类型（SKIN和CAPE始终注册，同名配置覆盖默认定义）
+	History       TextureHistoryConfig `yaml:"history"`        // 材质变更历史
+	Import        TextureImportConfig  `yaml:"import"`         // 从上游服务器导入材质
+}
+
+// TextureHistoryConfig 材质变更历史配置
+type TextureHistoryConfig struct {
+	Enabled    bool          `yaml:"enabled"`     // 是否记录材质变更历史
+	MaxEntries int           `yaml:"max_entries"` // 每个角色每种材质保留的最大记录数（0为不限制）
+	MaxAge     time.Duration `yaml:"max_age"`     // 记录保留时间（0为不限制）
+}
+
+// TextureImportConfig 材质导入配置
+type TextureImportConfig struct {
+	Enabled   bool                    `yaml:"enabled"`   // 是否启用材质导入
+	Timeout   time.Duration           `yaml:"timeout"`   // 请求上游的超时时间
+	Upstreams []TextureUpstreamConfig `yaml:"upstreams"` // 上游服务器列表
+}
+
+// TextureUpstreamConfig 材质导入上游服务器配置
+// 配置api_root时按authlib-injector规范推导其余地址，也可单独指定各地址（如Mojang官方）
+type TextureUpstreamConfig struct {
+	Name          string `yaml:"name"`            // 上游名称（请求时引用）
+	APIRoot       string `yaml:"api_root"`        // authlib-injector API地址
+	ProfileURL    string `yaml:"profile_url"`     // 角色名查询地址（{profile_url}/{name}）
+	SessionURL    string `yaml:"session_url"`     // 角色档案地址（{session_url}/{uuid}）
+	PublicKeyURL  string `yaml:"public_key_url"`  // 签名公钥地址（API元数据或Mojang publickeys）
+	PublicKeyPath string `yaml:"public_key_path"` // 本地签名公钥文件（PEM，优先于public_key_url）
+}
+
+// TextureTypeConfig 材质类型配置
+type TextureTypeConfig struct {
+	Name       string   `yaml:"name"`       // 类型名称（如SKIN、CAPE、ELYTRA）
+	Upload     bool     `yaml:"upload"`     // 是否允许通过API上传
+	Hidden     bool     `yaml:"hidden"`     // 为true时不写入textures属性
+	Dimensions []string `yaml:"dimensions"` // 允许的图片尺寸（如64x32），为空时不限制
+	AllowHD    bool     `yaml:"allow_hd"`   // 是否允许尺寸的整数倍（高清材质）
+}
+
+// BlobStoreConfig 材质文件（对象）存储配置
+type BlobStoreConfig struct {
+	Type    string         `yaml:"type"`    // 存储类型：local, s3（留空为local）
+	Options map[string]any `yaml:"options"` // 存储选项
 }
 
 // MiddlewareConfig 中间件配置
@@ -157,16 +252,73 @@ type MonitoringConfig struct {
 	CacheStats      bool   `yaml:"cache_stats"`      // 是否启用缓存统计
 	DBStats         bool   `yaml:"db_stats"`         // 是否启用数据库统计
 	SystemStats     bool   `yaml:"system_stats"`     // 是否启用系统统计
+
+	HealthTimeout time.Duration `yaml:"health_timeout"` // 就绪检查中每个依赖的超时时间
+
+	Tracing TracingConfig `yaml:"tracing"` // OpenTelemetry链路追踪
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Enabled     bool              `yaml:"enabled"`      // 是否启用链路追踪
+	Exporter    string            `yaml:"exporter"`     // 导出方式：otlp（默认，OTLP/HTTP）、stdout（测试用）
+	Endpoint    string            `yaml:"endpoint"`     // OTLP地址，如 "localhost:4318"（为空时使用OTEL_EXPORTER_OTLP_*环境变量）
+	Insecure    bool              `yaml:"insecure"`     // OTLP不使用TLS
+	Headers     map[string]string `yaml:"headers"`      // OTLP请求头（如认证信息）
+	ServiceName string            `yaml:"service_name"` // 服务名（默认 yggdrasil-api-go）
+	SampleRatio float64           `yaml:"sample_ratio"` // 采样率（0-1，默认1；上游已采样的请求始终采样）
 }
 
 // SecurityConfig 安全配置
 type SecurityConfig struct {
-	MaxRequestSize string        `yaml:"max_request_size"` // 最大请求大小
+	MaxRequestSize string        `yaml:"max_request_size"` // 最大请求体大小（如 "1MB"、"512KB"，0表示不限制）
 	ReadTimeout    time.Duration `yaml:"read_timeout"`     // 读取超时
 	WriteTimeout   time.Duration `yaml:"write_timeout"`    // 写入超时
 	IdleTimeout    time.Duration `yaml:"idle_timeout"`     // 空闲超时
 }
 
+// MaxRequestBytes 解析最大请求体大小（字节），0表示不限制
+func (c *SecurityConfig) MaxRequestBytes() (int64, error) {
+	return ParseSize(c.MaxRequestSize)
+}
+
+// ParseSize 解析大小字符串，支持 B、KB、MB、GB 单位（按1024换算），无单位时按字节计算
+func ParseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %q", value)
+	}
+	if n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("size too large: %q", value)
+	}
+	return n * multiplier, nil
+}
+
 // WarmupConfig 预热配置
 type WarmupConfig struct {
 	Enabled       bool `yaml:"enabled"`        // 是否启用预热
@@ -177,12 +329,87 @@ type WarmupConfig struct {
 	ConcurrentNum int  `yaml:"concurrent_num"` // 并发预热数量
 }
 
+// JobsConfig 后台任务配置
+type JobsConfig struct {
+	Lock      JobLockConfig     `yaml:"lock"`      // 分布式锁配置（多实例部署时保证全局任务只执行一次）
+	Schedules map[string]string `yaml:"schedules"` // 任务调度覆盖：任务名 -> "@every 5m"或cron表达式，"off"表示禁用
+}
+
+// JobLockConfig 任务锁配置
+type JobLockConfig struct {
+	Type    string         `yaml:"type"`    // 锁类型：auto（跟随Token缓存）、local、redis、database
+	Options map[string]any `yaml:"options"` // 锁后端选项（为空时使用Token缓存的选项）
+}
+
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Host    string `yaml:"host"`     // 监听地址
 	Port    int    `yaml:"port"`     // 监听端口
 	Debug   bool   `yaml:"debug"`    // 调试模式
 	BaseURL string `yaml:"base_url"` // API基础路径，如 "/api/yggdrasil"
+
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // 优雅关闭时等待进行中请求完成的最长时间
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay"`   // 就绪检查返回失败后、停止接受新连接前的等待时间（留给负载均衡器摘除实例）
+
+	TLS          TLSConfig          `yaml:"tls"`           // HTTPS配置
+	Proxy        ProxyConfig        `yaml:"proxy"`         // 反向代理和负载均衡器（客户端IP解析）
+	ConfigReload ConfigReloadConfig `yaml:"config_reload"` // 配置热重载
+}
+
+// ProxyConfig 反向代理配置：客户端IP用于会话IP验证（hasJoined的ip参数）、速率限制和日志
+// 只有来自可信代理的转发头和PROXY头会被采用，其他请求使用连接地址
+type ProxyConfig struct {
+	TrustedProxies       []string      `yaml:"trusted_proxies"`        // 可信代理的IP或CIDR（为空时不信任任何转发头）
+	ClientIPHeader       string        `yaml:"client_ip_header"`       // 读取客户端IP的请求头：X-Forwarded-For（默认）、X-Real-IP、CF-Connecting-IP
+	ProxyProtocol        bool          `yaml:"proxy_protocol"`         // 监听器接受HAProxy PROXY协议（v1/v2），可信代理的连接必须发送PROXY头（未配置可信代理时所有连接都必须发送）
+	ProxyProtocolTimeout time.Duration `yaml:"proxy_protocol_timeout"` // 读取PROXY头的超时时间
+}
+
+// clientIPHeaders 支持的客户端IP请求头（小写 -> 规范名称）
+var clientIPHeaders = map[string]string{
+	"x-forwarded-for":  "X-Forwarded-For",
+	"x-real-ip":        "X-Real-IP",
+	"cf-connecting-ip": "CF-Connecting-IP",
+}
+
+// TrustedNets 解析可信代理列表（单个IP按/32或/128处理）
+func (p *ProxyConfig) TrustedNets() ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(p.TrustedProxies))
+	for _, proxy := range p.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address: %s", proxy)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR: %s", proxy)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ConfigReloadConfig 配置热重载配置（SIGHUP和管理接口始终可以触发重载）
+type ConfigReloadConfig struct {
+	Watch    bool          `yaml:"watch"`    // 是否监视配置文件变化并自动重载
+	Interval time.Duration `yaml:"interval"` // 检查配置文件变化的间隔
+}
+
+// TLSConfig HTTPS配置（启用后同时支持HTTP/2）
+type TLSConfig struct {
+	Enabled        bool          `yaml:"enabled"`         // 是否启用HTTPS
+	CertFile       string        `yaml:"cert_file"`       // 证书文件（PEM，可包含中间证书链）
+	KeyFile        string        `yaml:"key_file"`        // 私钥文件（PEM）
+	ReloadInterval time.Duration `yaml:"reload_interval"` // 检查证书文件变化的间隔，文件更新后自动重新加载
+	RedirectAddr   string        `yaml:"redirect_addr"`   // HTTP→HTTPS跳转的监听地址（如 ":80"），为空不启用
+	ClientCAFile   string        `yaml:"client_ca_file"`  // 客户端证书CA（设置后 /api/admin 需要该CA签发的客户端证书）
 }
 
 // AuthConfig 认证配置
@@ -223,7 +450,8 @@ type KeysConfig struct {
 
 // FeaturesConfig 功能配置
 type FeaturesConfig struct {
-	NonEmailLogin bool `yaml:"non_email_login"` // 支持非邮箱登录
+	NonEmailLogin     bool `yaml:"non_email_login"`     // 支持非邮箱登录
+	HasJoinedRechecks int  `yaml:"has_joined_rechecks"` // 同一次join允许的额外hasJoined验证次数（BungeeCord等代理与后端服务器都会验证时使用，默认0即一次性）
 }
 
 // LoadConfig 从文件加载配置
@@ -244,11 +472,19 @@ func LoadConfig(filename string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
+	// 在默认配置上解析：配置文件中省略的配置项保持默认值（如shutdown_timeout）
+	// JWT密钥除外：每次启动随机生成的密钥会使重启前和其他实例签发的令牌失效，未配置时由Validate报错
+	config := *DefaultConfig()
+	config.Auth.JWTSecret = ""
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
+	// 环境变量覆盖（YGG_AUTH_JWT_SECRET、YGG_AUTH_JWT_SECRET_FILE等）
+	if err := ApplyEnvOverrides(&config); err != nil {
+		return nil, fmt.Errorf("invalid environment override: %w", err)
+	}
+
 	// 验证配置
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
@@ -289,10 +525,62 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// 验证优雅关闭配置
+	if c.Server.ShutdownTimeout < 0 || c.Server.ShutdownDelay < 0 {
+		return fmt.Errorf("shutdown_timeout and shutdown_delay cannot be negative")
+	}
+
+	// 验证TLS配置
+	if c.Server.TLS.Enabled {
+		if c.Server.TLS.CertFile == "" || c.Server.TLS.KeyFile == "" {
+			return fmt.Errorf("tls cert_file and key_file are required when tls is enabled")
+		}
+		if c.Server.TLS.ReloadInterval < 0 {
+			return fmt.Errorf("tls reload_interval cannot be negative")
+		}
+	} else if c.Server.TLS.ClientCAFile != "" || c.Server.TLS.RedirectAddr != "" {
+		return fmt.Errorf("tls client_ca_file and redirect_addr require tls to be enabled")
+	}
+
+	// 验证代理配置（请求头名称统一为规范写法）
+	if _, err := c.Server.Proxy.TrustedNets(); err != nil {
+		return fmt.Errorf("invalid server.proxy.trusted_proxies: %w", err)
+	}
+	if c.Server.Proxy.ClientIPHeader == "" {
+		c.Server.Proxy.ClientIPHeader = "X-Forwarded-For"
+	}
+	header, ok := clientIPHeaders[strings.ToLower(c.Server.Proxy.ClientIPHeader)]
+	if !ok {
+		return fmt.Errorf("unsupported server.proxy.client_ip_header: %s (supported: X-Forwarded-For, X-Real-IP, CF-Connecting-IP)", c.Server.Proxy.ClientIPHeader)
+	}
+	c.Server.Proxy.ClientIPHeader = header
+	if c.Server.Proxy.ProxyProtocolTimeout < 0 {
+		return fmt.Errorf("server.proxy.proxy_protocol_timeout cannot be negative")
+	}
+
+	// 验证安全配置
+	if _, err := c.Security.MaxRequestBytes(); err != nil {
+		return fmt.Errorf("invalid security max_request_size: %w", err)
+	}
+	if c.Security.ReadTimeout < 0 || c.Security.WriteTimeout < 0 || c.Security.IdleTimeout < 0 {
+		return fmt.Errorf("security timeouts cannot be negative")
+	}
+
 	// 验证JWT密钥
+	if c.Auth.JWTSecret == "" {
+		return fmt.Errorf("auth.jwt_secret is required (or %sAUTH_JWT_SECRET / %sAUTH_JWT_SECRET_FILE)", EnvPrefix, EnvPrefix)
+	}
 	if len(c.Auth.JWTSecret) < 32 {
 		return fmt.Errorf("JWT secret must be at least 32 characters long")
 	}
+	if IsSampleJWTSecret(c.Auth.JWTSecret) && !c.Server.Debug {
+		return fmt.Errorf("auth.jwt_secret is the sample value from the example config; set a random secret (or %sAUTH_JWT_SECRET / %sAUTH_JWT_SECRET_FILE), or enable server.debug for local testing", EnvPrefix, EnvPrefix)
+	}
+
+	// 验证数据库存储配置
+	if c.Storage.Type == "database" && c.Storage.DatabaseOptions.DatabaseDSN == "" {
+		return fmt.Errorf("storage.database_options.database_dsn is required for database storage")
+	}
 
 	// 验证密钥文件路径（对于BlessingSkin存储，允许为空）
 	if c.Storage.Type != "blessing_skin" {
@@ -301,6 +589,104 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// 验证材质文件存储配置
+	switch c.Texture.Blob.Type {
+	case "", "local":
+	case "s3":
+		if bucket, _ := c.Texture.Blob.Options["bucket"].(string); bucket == "" {
+			return fmt.Errorf("texture.blob.options.bucket is required for s3 blob store")
+		}
+	default:
+		return fmt.Errorf("unsupported texture blob store type: %s", c.Texture.Blob.Type)
+	}
+
+	// 验证材质类型配置
+	textureTypeNames := make(map[string]bool)
+	for _, textureType := range c.Texture.Types {
+		name := strings.ToUpper(textureType.Name)
+		if name == "" {
+			return fmt.Errorf("texture type name cannot be empty")
+		}
+		if textureTypeNames[name] {
+			return fmt.Errorf("duplicate texture type: %s", name)
+		}
+		textureTypeNames[name] = true
+	}
+
+	// 验证材质导入上游配置
+	upstreamNames := make(map[string]bool)
+	for _, upstream := range c.Texture.Import.Upstreams {
+		if upstream.Name == "" {
+			return fmt.Errorf("texture import upstream name cannot be empty")
+		}
+		if upstreamNames[upstream.Name] {
+			return fmt.Errorf("duplicate texture import upstream: %s", upstream.Name)
+		}
+		upstreamNames[upstream.Name] = true
+
+		if upstream.APIRoot == "" {
+			if upstream.SessionURL == "" {
+				return fmt.Errorf("texture import upstream %s: api_root or session_url is required", upstream.Name)
+			}
+			if upstream.PublicKeyURL == "" && upstream.PublicKeyPath == "" {
+				return fmt.Errorf("texture import upstream %s: public_key_url or public_key_path is required", upstream.Name)
+			}
+		}
+	}
+
+	// 验证hasJoined重复验证次数
+	if c.Yggdrasil.Features.HasJoinedRechecks < 0 {
+		return fmt.Errorf("has_joined_rechecks cannot be negative")
+	}
+
+	// 验证令牌哈希配置
+	switch c.Cache.TokenHash.Mode {
+	case "", "hmac", "plaintext":
+	default:
+		return fmt.Errorf("unsupported token hash mode: %s", c.Cache.TokenHash.Mode)
+	}
+	if c.Cache.TokenHash.Mode == "hmac" && c.laravelTokenCache() {
+		return fmt.Errorf("token_hash.mode must be plaintext when the token cache uses the laravel format")
+	}
+
+	// 验证日志配置
+	switch strings.ToLower(c.Logging.Level) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("unsupported logging level: %s", c.Logging.Level)
+	}
+	switch c.Logging.Format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("unsupported logging format: %s", c.Logging.Format)
+	}
+	switch c.Logging.Output {
+	case "", "stdout", "stderr":
+	case "file":
+		if c.Logging.File == "" {
+			return fmt.Errorf("logging file cannot be empty when output is file")
+		}
+	default:
+		return fmt.Errorf("unsupported logging output: %s", c.Logging.Output)
+	}
+
+	// 验证链路追踪配置
+	switch c.Monitoring.Tracing.Exporter {
+	case "", "otlp", "stdout":
+	default:
+		return fmt.Errorf("unsupported tracing exporter: %s", c.Monitoring.Tracing.Exporter)
+	}
+	if c.Monitoring.Tracing.SampleRatio < 0 || c.Monitoring.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample_ratio must be between 0 and 1, got: %v", c.Monitoring.Tracing.SampleRatio)
+	}
+
+	// 验证任务锁配置
+	switch c.Jobs.Lock.Type {
+	case "", "auto", "local", "redis", "database":
+	default:
+		return fmt.Errorf("unsupported job lock type: %s", c.Jobs.Lock.Type)
+	}
+
 	// 验证皮肤域名配置
 	for _, domain := range c.Yggdrasil.SkinDomains {
 		if err := validateDomainOrCIDR(domain); err != nil {
@@ -330,9 +716,13 @@ func validateDomainOrCIDR(input string) error {
 
 // IsAllowedSkinDomain 检查域名是否在皮肤白名单中
 func (c *Config) IsAllowedSkinDomain(domain string) bool {
-	// 如果白名单为空，允许所有域名
+	// 如果白名单为空，只允许材质基础URL的域名
 	if len(c.Yggdrasil.SkinDomains) == 0 {
-		return true
+		if c.Texture.BaseURL == "" {
+			return false
+		}
+		base, err := url.Parse(c.Texture.BaseURL)
+		return err == nil && base.Hostname() != "" && strings.EqualFold(base.Hostname(), domain)
 	}
 
 	for _, allowed := range c.Yggdrasil.SkinDomains {
@@ -357,6 +747,9 @@ func (c *Config) GetBaseURL(host string) string {
 	if host == "" {
 		host = fmt.Sprintf("localhost:%d", c.Server.Port)
 	}
+	if c.Server.TLS.Enabled {
+		return fmt.Sprintf("https://%s", host)
+	}
 	return fmt.Sprintf("http://%s", host)
 }
 
@@ -377,6 +770,13 @@ func (c *Config) GetLinkURL(linkType, host string) string {
 	}
 }
 
+// randomJWTSecret 生成随机JWT密钥（写入默认配置文件）
+func randomJWTSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -385,10 +785,27 @@ func DefaultConfig() *Config {
 			Port:    8080,
 			Debug:   false,
 			BaseURL: "", // 默认为空，表示不使用基础路径
+
+			ShutdownTimeout: 30 * time.Second,
+			ShutdownDelay:   0,
+			TLS: TLSConfig{
+				Enabled:        false,
+				ReloadInterval: 30 * time.Second,
+			},
+			Proxy: ProxyConfig{
+				TrustedProxies:       []string{},
+				ClientIPHeader:       "X-Forwarded-For",
+				ProxyProtocol:        false,
+				ProxyProtocolTimeout: 5 * time.Second,
+			},
+			ConfigReload: ConfigReloadConfig{
+				Watch:    true,
+				Interval: 10 * time.Second,
+			},
 		},
 		Auth: AuthConfig{
 			TokenExpiration:     3 * 24 * time.Hour, // 3天
-			JWTSecret:           "yggdrasil-api-secret-key-change-in-production",
+			JWTSecret:           randomJWTSecret(),
 			TokensLimit:         10,
 			RequireVerification: false,
 		},
@@ -397,7 +814,7 @@ func DefaultConfig() *Config {
 			Enabled:      true,
 		},
 		Storage: StorageConfig{
-			Type:          "memory",
+			Type:          "file",
 			MemoryOptions: MemoryStorageOptions{},
 			FileOptions: FileStorageOptions{
 				DataDir: "data",
@@ -431,8 +848,10 @@ func DefaultConfig() *Config {
 				APIMetadata:      true,
 				ErrorResponses:   true,
 				ProfileResponses: true,
+				ProfileDuration:  time.Minute,
 				CacheDuration:    10 * time.Minute,
 				MaxCacheSize:     1000,
+				MaxCacheBytes:    32 * 1024 * 1024, // 32MB
 			},
 			User: UserCacheConfig{
 				Enabled:         true,
@@ -446,6 +865,23 @@ func DefaultConfig() *Config {
 			UploadEnabled: false,
 			MaxFileSize:   1024 * 1024, // 1MB
 			AllowedTypes:  []string{"image/png", "image/jpeg"},
+			Blob: BlobStoreConfig{
+				Type:    "local",
+				Options: map[string]any{},
+			},
+			Types: []TextureTypeConfig{
+				{Name: "SKIN", Upload: true, Dimensions: []string{"64x64", "64x32"}, AllowHD: true},
+				{Name: "CAPE", Upload: true, Dimensions: []string{"64x32", "22x17"}, AllowHD: true},
+			},
+			History: TextureHistoryConfig{
+				Enabled:    true,
+				MaxEntries: 20,
+				MaxAge:     90 * 24 * time.Hour,
+			},
+			Import: TextureImportConfig{
+				Enabled: false,
+				Timeout: 10 * time.Second,
+			},
 		},
 		Yggdrasil: YggdrasilConfig{
 			Meta: MetaConfig{
@@ -506,6 +942,12 @@ func DefaultConfig() *Config {
 			CacheStats:      true,
 			DBStats:         true,
 			SystemStats:     true,
+			HealthTimeout:   2 * time.Second,
+			Tracing: TracingConfig{
+				Exporter:    "otlp",
+				ServiceName: "yggdrasil-api-go",
+				SampleRatio: 1,
+			},
 		},
 		Security: SecurityConfig{
 			MaxRequestSize: "1MB",
@@ -521,5 +963,10 @@ func DefaultConfig() *Config {
 			ProfileCache:  false,
 			ConcurrentNum: 5,
 		},
+		Jobs: JobsConfig{
+			Lock: JobLockConfig{
+				Type: "auto",
+			},
+		},
 	}
 }
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func TestTokenHashMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		format     string
+		wantMode   string
+		wantSecret string
+		wantErr    bool
+	}{
+		{name: "default", wantMode: "hmac", wantSecret: testJWTSecret},
+		{name: "plaintext", mode: "plaintext", wantMode: "plaintext"},
+		{name: "laravel default", format: "laravel", wantMode: "plaintext"},
+		{name: "laravel plaintext", mode: "plaintext", format: "laravel", wantMode: "plaintext"},
+		{name: "laravel hmac", mode: "hmac", format: "laravel", wantErr: true},
+		{name: "unsupported", mode: "md5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Auth.JWTSecret = testJWTSecret
+			cfg.Cache.TokenHash.Mode = tt.mode
+			if tt.format != "" {
+				cfg.Cache.Token = CacheBackendConfig{Type: "redis", Options: map[string]any{"format": tt.format}}
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Validate succeeded; want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if got := cfg.TokenHashMode(); got != tt.wantMode {
+				t.Fatalf("TokenHashMode() = %q; want %q", got, tt.wantMode)
+			}
+			if got := cfg.TokenHashSecret(); got != tt.wantSecret {
+				t.Fatalf("TokenHashSecret() = %q; want %q", got, tt.wantSecret)
+			}
+		})
+	}
+}
+
+func TestIsAllowedSkinDomain(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains []string
+		baseURL string
+		domain  string
+		want    bool
+	}{
+		{name: "exact", domains: []string{"skin.example.com"}, domain: "skin.example.com", want: true},
+		{name: "wildcard", domains: []string{".example.com"}, domain: "skin.example.com", want: true},
+		{name: "not listed", domains: []string{".example.com"}, domain: "example.org"},
+		{name: "empty denies", domain: "skin.example.com"},
+		{name: "empty allows base url host", baseURL: "https://skin.example.com/textures/", domain: "skin.example.com", want: true},
+		{name: "empty denies other hosts", baseURL: "https://skin.example.com/textures/", domain: "evil.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Yggdrasil.SkinDomains = tt.domains
+			cfg.Texture.BaseURL = tt.baseURL
+			if got := cfg.IsAllowedSkinDomain(tt.domain); got != tt.want {
+				t.Fatalf("IsAllowedSkinDomain(%q) = %v; want %v", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultJWTSecret(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+	if a.Auth.JWTSecret == b.Auth.JWTSecret || IsSampleJWTSecret(a.Auth.JWTSecret) {
+		t.Fatalf("default JWT secrets %q and %q are not random", a.Auth.JWTSecret, b.Auth.JWTSecret)
+	}
+	if err := a.Validate(); err != nil {
+		t.Fatalf("Validate default config: %v", err)
+	}
+
+	// 配置文件省略jwt_secret时不使用随机密钥
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("server:\n  port: 8080\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Fatal("LoadConfig without jwt_secret succeeded; want error")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "", want: 0},
+		{value: "0", want: 0},
+		{value: "512", want: 512},
+		{value: "512KB", want: 512 << 10},
+		{value: " 1 mb ", want: 1 << 20},
+		{value: "2G", want: 2 << 30},
+		{value: "10B", want: 10},
+		{value: "-1MB", wantErr: true},
+		{value: "1.5MB", wantErr: true},
+		{value: "lots", wantErr: true},
+		{value: "9223372036854775807GB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseSize(tt.value)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("ParseSize(%q) = %d, %v; want %d (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShutdownConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := "server:\n  port: 8080\nauth:\n  jwt_secret: " + DefaultConfig().Auth.JWTSecret + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	// 配置文件省略的配置项保持默认值
+	if cfg.Server.ShutdownTimeout != 30*time.Second {
+		t.Fatalf("ShutdownTimeout = %v; want the 30s default", cfg.Server.ShutdownTimeout)
+	}
+
+	cfg.Server.ShutdownDelay = -time.Second
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate accepted a negative shutdown_delay")
+	}
+	cfg.Server.ShutdownDelay = 0
+	cfg.Security.MaxRequestSize = "1.5MB"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate accepted an invalid max_request_size")
+	}
+}
@@ -0,0 +1,254 @@
+// Package config 环境变量和密钥文件覆盖配置
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 覆盖配置的环境变量前缀
+// 环境变量名由配置项的yaml路径转换而来：auth.jwt_secret -> YGG_AUTH_JWT_SECRET
+const EnvPrefix = "YGG_"
+
+// envFileSuffix 从文件读取值的环境变量后缀（如Docker/Kubernetes挂载的密钥文件）：YGG_AUTH_JWT_SECRET_FILE=/run/secrets/jwt
+const envFileSuffix = "_FILE"
+
+// sampleJWTSecrets 示例配置和文档中的JWT密钥（非调试模式下拒绝使用）
+var sampleJWTSecrets = []string{
+	"yggdrasil-api-secret-key-change-in-production-32chars-minimum",
+	"yggdrasil-api-secret-key-change-in-production",
+	"your-super-secret-jwt-key-change-in-production",
+}
+
+// IsSampleJWTSecret 是否为示例配置中的JWT密钥
+func IsSampleJWTSecret(secret string) bool {
+	for _, sample := range sampleJWTSecrets {
+		if secret == sample {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyEnvOverrides 使用环境变量覆盖配置（YGG_前缀，或带_FILE后缀从文件读取）
+// 支持字符串、布尔、数字、时长（如30s）和字符串列表（逗号分隔）；
+// map类型的配置项（如cache.token.options）按键覆盖：YGG_CACHE_TOKEN_OPTIONS_REDIS_URL -> options.redis_url
+func ApplyEnvOverrides(cfg *Config) error {
+	return applyEnv(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(EnvPrefix, "_"))
+}
+
+// envLookup 读取环境变量，NAME和NAME_FILE同时设置时返回错误
+func envLookup(name string) (string, bool, error) {
+	value, ok := os.LookupEnv(name)
+	file, fileOK := os.LookupEnv(name + envFileSuffix)
+	if !fileOK {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("both %s and %s%s are set", name, name, envFileSuffix)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", false, fmt.Errorf("%s%s: %w", name, envFileSuffix, err)
+	}
+	// 密钥文件末尾通常带有换行
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// applyEnv 按yaml路径递归覆盖结构体字段
+func applyEnv(v reflect.Value, prefix string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+		name := prefix + "_" + strings.ToUpper(tag)
+		fv := v.Field(i)
+
+		switch {
+		case fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}):
+			if err := applyEnv(fv, name); err != nil {
+				return err
+			}
+			continue
+		case fv.Kind() == reflect.Map && fv.Type().Key().Kind() == reflect.String:
+			if err := applyEnvMap(fv, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok, err := envLookup(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := setValue(fv, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// pathOptionKeys 以_file结尾的后端选项（值本身是文件路径，对应的环境变量不按密钥文件处理）
+var pathOptionKeys = map[string]bool{
+	"tls_ca_file":   true,
+	"tls_cert_file": true,
+	"tls_key_file":  true,
+	"snapshot_file": true,
+}
+
+// applyEnvMap 覆盖map类型配置项的键（环境变量名中键的部分转为小写）
+func applyEnvMap(m reflect.Value, prefix string) error {
+	elemKind := m.Type().Elem().Kind()
+	if elemKind != reflect.String && elemKind != reflect.Interface {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		if base := strings.TrimSuffix(name, envFileSuffix); base != name && !pathOptionKeys[optionKey(name, prefix)] {
+			name = base
+		}
+		names[name] = true
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	for _, name := range sorted {
+		key := optionKey(name, prefix)
+
+		var value string
+		var ok bool
+		if pathOptionKeys[key] {
+			value, ok = os.LookupEnv(name)
+		} else {
+			var err error
+			if value, ok, err = envLookup(name); err != nil {
+				return err
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if elemKind == reflect.String {
+			m.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+			continue
+		}
+		m.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(optionValue(m.MapIndex(reflect.ValueOf(key)), value)))
+	}
+	return nil
+}
+
+// optionKey 环境变量名对应的map键
+func optionKey(name, prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(name, prefix))
+}
+
+// optionValue map[string]any选项的值：按配置文件中已有值的类型解析，没有已有值时 true/false 解析为布尔，其他保持字符串
+// （纯数字的密码等保持字符串，数字选项如redis的db同样接受字符串）
+func optionValue(existing reflect.Value, value string) any {
+	if existing.IsValid() {
+		switch existing.Interface().(type) {
+		case string:
+			return value
+		case int:
+			if n, err := strconv.Atoi(value); err == nil {
+				return n
+			}
+			return value
+		case float64:
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				return f
+			}
+			return value
+		}
+	}
+
+	switch strings.ToLower(value) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return value
+}
+
+// setValue 将环境变量的值写入字段
+func setValue(fv reflect.Value, value string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", value)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		fv.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("overriding this setting with an environment variable is not supported")
+		}
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("overriding this setting with an environment variable is not supported")
+	}
+	return nil
+}
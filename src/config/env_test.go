@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "jwt")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		options map[string]any // 配置文件中的cache.token.options
+		check   func(cfg *Config) any
+		want    any
+		wantErr bool
+	}{
+		{name: "string", env: map[string]string{"YGG_AUTH_JWT_SECRET": "s"}, check: func(cfg *Config) any { return cfg.Auth.JWTSecret }, want: "s"},
+		{name: "secret file", env: map[string]string{"YGG_AUTH_JWT_SECRET_FILE": secretFile}, check: func(cfg *Config) any { return cfg.Auth.JWTSecret }, want: "from-file"},
+		{name: "value and file", env: map[string]string{"YGG_AUTH_JWT_SECRET": "s", "YGG_AUTH_JWT_SECRET_FILE": secretFile}, wantErr: true},
+		{name: "missing file", env: map[string]string{"YGG_AUTH_JWT_SECRET_FILE": secretFile + ".missing"}, wantErr: true},
+		{name: "int", env: map[string]string{"YGG_SERVER_PORT": "9000"}, check: func(cfg *Config) any { return cfg.Server.Port }, want: 9000},
+		{name: "invalid int", env: map[string]string{"YGG_SERVER_PORT": "port"}, wantErr: true},
+		{name: "bool", env: map[string]string{"YGG_SERVER_DEBUG": "true"}, check: func(cfg *Config) any { return cfg.Server.Debug }, want: true},
+		{name: "duration", env: map[string]string{"YGG_SERVER_SHUTDOWN_TIMEOUT": "45s"}, check: func(cfg *Config) any { return cfg.Server.ShutdownTimeout }, want: 45 * time.Second},
+		{name: "invalid duration", env: map[string]string{"YGG_SERVER_SHUTDOWN_TIMEOUT": "45"}, wantErr: true},
+		{name: "string list", env: map[string]string{"YGG_YGGDRASIL_SKIN_DOMAINS": "a.com, .b.com,"}, check: func(cfg *Config) any { return cfg.Yggdrasil.SkinDomains }, want: []string{"a.com", ".b.com"}},
+		{
+			name:  "new option",
+			env:   map[string]string{"YGG_CACHE_TOKEN_OPTIONS_REDIS_URL": "redis://r:6379/1", "YGG_CACHE_TOKEN_OPTIONS_CLUSTER": "true"},
+			check: func(cfg *Config) any { return cfg.Cache.Token.Options },
+			want:  map[string]any{"redis_url": "redis://r:6379/1", "cluster": true},
+		},
+		{
+			name:    "option keeps existing type",
+			env:     map[string]string{"YGG_CACHE_TOKEN_OPTIONS_DB": "2", "YGG_CACHE_TOKEN_OPTIONS_PASSWORD": "1234"},
+			options: map[string]any{"db": 0, "password": "x"},
+			check:   func(cfg *Config) any { return cfg.Cache.Token.Options },
+			want:    map[string]any{"db": 2, "password": "1234"},
+		},
+		{
+			name:  "option secret file",
+			env:   map[string]string{"YGG_CACHE_TOKEN_OPTIONS_PASSWORD_FILE": secretFile},
+			check: func(cfg *Config) any { return cfg.Cache.Token.Options },
+			want:  map[string]any{"password": "from-file"},
+		},
+		{
+			name:  "path option is not a secret file",
+			env:   map[string]string{"YGG_CACHE_TOKEN_OPTIONS_SNAPSHOT_FILE": "/data/tokens.snapshot"},
+			check: func(cfg *Config) any { return cfg.Cache.Token.Options },
+			want:  map[string]any{"snapshot_file": "/data/tokens.snapshot"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			cfg := DefaultConfig()
+			cfg.Cache.Token.Options = tt.options
+
+			err := ApplyEnvOverrides(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ApplyEnvOverrides succeeded; want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyEnvOverrides: %v", err)
+			}
+			if got := tt.check(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v; want %#v", got, tt.want)
+			}
+		})
+	}
+}
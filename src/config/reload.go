@@ -0,0 +1,279 @@
+// Package config 配置热重载（只替换可在运行时生效的配置项）
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 重载触发方式
+const (
+	ReloadTriggerFile   = "file"   // 配置文件变化
+	ReloadTriggerSignal = "signal" // SIGHUP
+	ReloadTriggerAPI    = "api"    // 管理接口
+)
+
+// defaultWatchInterval 未配置时检查配置文件变化的间隔
+const defaultWatchInterval = 10 * time.Second
+
+// maxReloadHistory 保留的重载记录数量
+const maxReloadHistory = 20
+
+// current 当前生效的配置（热重载时原子替换）
+var current atomic.Pointer[Config]
+
+// Current 返回当前生效的配置（未调用SetCurrent时返回nil）
+// 处理器读取可热重载的配置项时应通过该函数获取，不要长期持有返回值
+func Current() *Config {
+	return current.Load()
+}
+
+// SetCurrent 设置当前生效的配置
+func SetCurrent(cfg *Config) {
+	current.Store(cfg)
+}
+
+// reloadableField 可在运行时生效的配置项
+type reloadableField struct {
+	name  string
+	get   func(c *Config) any
+	apply func(dst, src *Config)
+}
+
+// reloadableFields 可热重载的配置项，其余配置项（存储、缓存、端口等）的变化需要重启才能生效
+var reloadableFields = []reloadableField{
+	{
+		name:  "yggdrasil.meta",
+		get:   func(c *Config) any { return c.Yggdrasil.Meta },
+		apply: func(dst, src *Config) { dst.Yggdrasil.Meta = src.Yggdrasil.Meta },
+	},
+	{
+		name:  "yggdrasil.skin_domains",
+		get:   func(c *Config) any { return c.Yggdrasil.SkinDomains },
+		apply: func(dst, src *Config) { dst.Yggdrasil.SkinDomains = src.Yggdrasil.SkinDomains },
+	},
+	{
+		name:  "yggdrasil.features",
+		get:   func(c *Config) any { return c.Yggdrasil.Features },
+		apply: func(dst, src *Config) { dst.Yggdrasil.Features = src.Yggdrasil.Features },
+	},
+	{
+		name:  "rate",
+		get:   func(c *Config) any { return c.Rate },
+		apply: func(dst, src *Config) { dst.Rate = src.Rate },
+	},
+	{
+		name:  "cache.response.profile_responses",
+		get:   func(c *Config) any { return c.Cache.Response.ProfileResponses },
+		apply: func(dst, src *Config) { dst.Cache.Response.ProfileResponses = src.Cache.Response.ProfileResponses },
+	},
+	{
+		name:  "cache.response.profile_duration",
+		get:   func(c *Config) any { return c.Cache.Response.ProfileDuration },
+		apply: func(dst, src *Config) { dst.Cache.Response.ProfileDuration = src.Cache.Response.ProfileDuration },
+	},
+	{
+		name:  "middleware.cors",
+		get:   func(c *Config) any { return c.Middleware.CORS },
+		apply: func(dst, src *Config) { dst.Middleware.CORS = src.Middleware.CORS },
+	},
+	{
+		name:  "logging.level",
+		get:   func(c *Config) any { return c.Logging.Level },
+		apply: func(dst, src *Config) { dst.Logging.Level = src.Logging.Level },
+	},
+}
+
+// ReloadResult 一次配置重载的结果
+type ReloadResult struct {
+	Time    time.Time `json:"time"`
+	Trigger string    `json:"trigger"`           // file, signal, api
+	Success bool      `json:"success"`           // 配置是否有效（无变化时同样为true）
+	Error   string    `json:"error,omitempty"`   // 读取或验证失败的原因（失败时继续使用原配置）
+	Applied []string  `json:"applied,omitempty"` // 已生效的配置项
+	Ignored []string  `json:"ignored,omitempty"` // 需要重启才能生效、本次被忽略的配置项
+}
+
+// Reloader 配置热重载：重新读取并验证配置文件，将可热重载的配置项原子地替换到当前配置
+type Reloader struct {
+	path  string
+	mu    sync.Mutex
+	stamp string // 配置文件的修改时间和大小
+
+	hooks   []func(old, updated *Config)
+	history []ReloadResult
+}
+
+// NewReloader 创建配置热重载器（cfg为启动时加载的配置，同时设置为当前配置）
+func NewReloader(path string, cfg *Config) *Reloader {
+	SetCurrent(cfg)
+
+	r := &Reloader{path: path}
+	r.stamp, _ = fileStamp(path)
+	return r
+}
+
+// OnReload 注册配置变化后的回调（如清除API元数据缓存、调整速率限制），只在有配置项生效时调用
+func (r *Reloader) OnReload(fn func(old, updated *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Reload 重新加载配置文件
+func (r *Reloader) Reload(trigger string) ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := ReloadResult{Time: time.Now(), Trigger: trigger}
+	r.stamp, _ = fileStamp(r.path)
+
+	updated, err := r.load()
+	if err != nil {
+		result.Error = err.Error()
+		slog.Warn("Config reload failed, keeping the current config", "trigger", trigger, "error", err)
+		r.record(result)
+		return result
+	}
+	result.Success = true
+
+	old := Current()
+	merged := *old
+	for _, field := range reloadableFields {
+		if !reflect.DeepEqual(field.get(old), field.get(updated)) {
+			field.apply(&merged, updated)
+			result.Applied = append(result.Applied, field.name)
+		}
+	}
+
+	// 不可热重载的配置项：与当前配置比较（可热重载的配置项已合并，不会出现在差异中）
+	staged := *updated
+	for _, field := range reloadableFields {
+		field.apply(&staged, old)
+	}
+	result.Ignored = diffFields("", reflect.ValueOf(*old), reflect.ValueOf(staged))
+	if len(result.Ignored) > 0 {
+		slog.Warn("Config changes require a restart and were ignored", "trigger", trigger, "fields", result.Ignored)
+	}
+
+	if len(result.Applied) > 0 {
+		SetCurrent(&merged)
+		for _, hook := range r.hooks {
+			hook(old, &merged)
+		}
+		slog.Info("Config reloaded", "trigger", trigger, "fields", result.Applied)
+	} else if len(result.Ignored) == 0 {
+		slog.Info("Config reloaded without changes", "trigger", trigger)
+	}
+
+	r.record(result)
+	return result
+}
+
+// History 最近的重载记录（按时间倒序）
+func (r *Reloader) History() []ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := make([]ReloadResult, len(r.history))
+	for i, result := range r.history {
+		history[len(r.history)-1-i] = result
+	}
+	return history
+}
+
+// Watch 定期检查配置文件，文件变化时重新加载（ctx取消时返回）
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stamp, err := fileStamp(r.path)
+			if err != nil {
+				// 编辑器保存时文件可能短暂不存在，下次检查时再加载
+				continue
+			}
+			r.mu.Lock()
+			changed := stamp != r.stamp
+			r.mu.Unlock()
+			if changed {
+				r.Reload(ReloadTriggerFile)
+			}
+		}
+	}
+}
+
+// load 读取并验证配置文件（文件不存在时返回错误，不创建默认配置）
+func (r *Reloader) load() (*Config, error) {
+	if _, err := os.Stat(r.path); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return LoadConfig(r.path)
+}
+
+// record 保存重载记录（调用方持有锁）
+func (r *Reloader) record(result ReloadResult) {
+	r.history = append(r.history, result)
+	if len(r.history) > maxReloadHistory {
+		r.history = r.history[len(r.history)-maxReloadHistory:]
+	}
+}
+
+// fileStamp 配置文件的修改时间和大小
+func fileStamp(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// diffFields 比较两个配置结构，返回发生变化的配置项（按yaml名称，如 server.port）
+func diffFields(prefix string, a, b reflect.Value) []string {
+	var changed []string
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			name = strings.ToLower(field.Name)
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct && fa.NumField() > 0 {
+			changed = append(changed, diffFields(name, fa, fb)...)
+			continue
+		}
+		if !sameValue(fa, fb) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// sameValue 比较配置项的值（空列表/空map与未设置视为相同，如配置文件中的 headers: {}）
+func sameValue(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
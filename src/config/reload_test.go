@@ -0,0 +1,81 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{name: "unchanged", change: func(c *Config) {}},
+		{name: "empty map", change: func(c *Config) { c.Cache.Token.Options = map[string]any{} }},
+		{name: "nested scalar", change: func(c *Config) { c.Server.Port++ }, want: []string{"server.port"}},
+		{name: "duration", change: func(c *Config) { c.Server.ShutdownTimeout += time.Second }, want: []string{"server.shutdown_timeout"}},
+		{name: "slice", change: func(c *Config) { c.Yggdrasil.SkinDomains = append(c.Yggdrasil.SkinDomains, "x.com") }, want: []string{"yggdrasil.skin_domains"}},
+		{name: "empty slice", change: func(c *Config) { c.Texture.Import.Upstreams = []TextureUpstreamConfig{} }},
+		{name: "map", change: func(c *Config) { c.Cache.Token.Options = map[string]any{"redis_url": "redis://r"} }, want: []string{"cache.token.options"}},
+		{name: "multiple", change: func(c *Config) {
+			c.Server.Host = "127.0.0.1"
+			c.Auth.JWTSecret = "other"
+		}, want: []string{"server.host", "auth.jwt_secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := DefaultConfig(), DefaultConfig()
+			b.Auth.JWTSecret = a.Auth.JWTSecret
+			tt.change(b)
+			got := diffFields("", reflect.ValueOf(*a), reflect.ValueOf(*b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("diffFields = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	cfg := DefaultConfig()
+	cfg.Auth.JWTSecret = testJWTSecret
+	if err := SaveConfig(cfg, path); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	r := NewReloader(path, cfg)
+	defer SetCurrent(nil)
+
+	var hookCalls int
+	r.OnReload(func(old, updated *Config) { hookCalls++ })
+
+	updated := *cfg
+	updated.Logging.Level = "debug"
+	updated.Cache.Response.ProfileDuration = cfg.Cache.Response.ProfileDuration + time.Minute
+	updated.Server.Port = cfg.Server.Port + 1
+	if err := SaveConfig(&updated, path); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	result := r.Reload(ReloadTriggerAPI)
+	if !result.Success || !reflect.DeepEqual(result.Applied, []string{"cache.response.profile_duration", "logging.level"}) || !reflect.DeepEqual(result.Ignored, []string{"server.port"}) {
+		t.Fatalf("Reload = %+v; want cache.response.profile_duration and logging.level applied and server.port ignored", result)
+	}
+	if Current().Logging.Level != "debug" || Current().Cache.Response.ProfileDuration != updated.Cache.Response.ProfileDuration || Current().Server.Port != cfg.Server.Port || hookCalls != 1 {
+		t.Fatalf("current config = level %s, port %d, %d hook calls", Current().Logging.Level, Current().Server.Port, hookCalls)
+	}
+
+	// 无效配置：保持当前配置
+	updated.Server.Port = 0
+	if err := SaveConfig(&updated, path); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	if result := r.Reload(ReloadTriggerAPI); result.Success || result.Error == "" {
+		t.Fatalf("Reload of invalid config = %+v; want failure", result)
+	}
+	if Current().Logging.Level != "debug" || hookCalls != 1 || len(r.History()) != 2 {
+		t.Fatalf("invalid reload changed the current config or history")
+	}
+}
@@ -0,0 +1,92 @@
+// Package database 数据库查询指标和链路追踪（通过GORM回调记录查询耗时直方图、连接池指标和查询Span）
+package database
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"yggdrasil-api-go/src/metrics"
+	"yggdrasil-api-go/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	// metricsStartKey 查询开始时间在Statement中的键
+	metricsStartKey = "metrics:start"
+	// tracingSpanKey 查询Span在Statement中的键
+	tracingSpanKey = "tracing:span"
+)
+
+// Instrument 为连接注册查询耗时回调、查询Span和连接池指标（name为指标中的database标签）
+// 只有通过WithContext传入了请求Span的查询才会创建Span，后台任务的查询不会产生孤立的链路
+func Instrument(db *gorm.DB, name string) {
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDB(name, sqlDB)
+	}
+
+	startFn := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			tx.InstanceSet(metricsStartKey, time.Now())
+
+			ctx := tx.Statement.Context
+			if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+				return
+			}
+			_, span := tracing.Start(ctx, "db."+operation,
+				attribute.String("db.name", name),
+				attribute.String("db.system", tx.Dialector.Name()),
+				attribute.String("db.operation", operation),
+			)
+			tx.InstanceSet(tracingSpanKey, span)
+		}
+	}
+	observeFn := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if start, ok := tx.InstanceGet(metricsStartKey); ok {
+				if t, ok := start.(time.Time); ok {
+					metrics.ObserveDBQuery(name, operation, time.Since(t))
+				}
+			}
+
+			if value, ok := tx.InstanceGet(tracingSpanKey); ok {
+				if span, ok := value.(trace.Span); ok {
+					span.SetAttributes(attribute.String("db.table", tx.Statement.Table))
+					// 记录不存在是正常的查询结果，不标记为错误
+					err := tx.Error
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						err = nil
+					}
+					tracing.End(span, err)
+				}
+			}
+		}
+	}
+
+	// 每种操作在GORM内置回调前后各注册一个回调
+	callbacks := db.Callback()
+	errs := []error{
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", startFn("create")),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", observeFn("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", startFn("query")),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", observeFn("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", startFn("update")),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", observeFn("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", startFn("delete")),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", observeFn("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", startFn("row")),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", observeFn("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", startFn("raw")),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", observeFn("raw")),
+	}
+	for _, err := range errs {
+		if err != nil {
+			// 回调注册失败只影响指标，不影响数据库使用
+			slog.Warn("Failed to register metrics callbacks", "database", name, "error", err)
+			return
+		}
+	}
+}
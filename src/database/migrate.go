@@ -0,0 +1,124 @@
+// Package database 按方言的版本化表结构迁移（每个方言一份SQL，已执行的版本记录在schema_migrations表）
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrationsTable 记录已执行迁移的表
+const migrationsTable = "schema_migrations"
+
+// tableNamePattern 允许的表名（表名会拼接进DDL，只接受字母、数字和下划线）
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Migration 一个版本的表结构变更
+// SQL按方言提供（mysql、postgres、sqlite），语句中的{{table}}替换为目标表名；
+// 第一个版本应使用IF NOT EXISTS，兼容此前由AutoMigrate创建的表
+type Migration struct {
+	Version int                                      // 版本号（同一张表内递增）
+	Name    string                                   // 说明
+	SQL     map[string][]string                      // 方言 -> 按顺序执行的语句
+	Skip    func(tx *gorm.DB, tableName string) bool // 返回true时只记录版本不执行（如AutoMigrate已添加过该列）
+}
+
+// SchemaMigration 已执行的迁移（按目标表和版本记录）
+type SchemaMigration struct {
+	Target    string    `gorm:"primaryKey;column:target_table;size:100"`
+	Version   int       `gorm:"primaryKey;column:version;autoIncrement:false"`
+	Name      string    `gorm:"column:name;size:255;not null"`
+	AppliedAt time.Time `gorm:"column:applied_at;not null"`
+}
+
+// Migrate 对表执行尚未执行的迁移
+// PostgreSQL和SQLite的每个版本在一个事务中执行，并发启动的实例只有一个会执行；
+// MySQL的DDL会隐式提交，语句需要可重复执行（IF NOT EXISTS等）
+func Migrate(db *gorm.DB, dialect, tableName string, migrations []Migration) error {
+	if !tableNamePattern.MatchString(tableName) {
+		return fmt.Errorf("invalid table name: %s", tableName)
+	}
+	if err := MigrateTable(db, dialect, migrationsTable, &SchemaMigration{}); err != nil {
+		return err
+	}
+
+	var applied []int
+	if err := db.Table(migrationsTable).Where("target_table = ?", tableName).Pluck("version", &applied).Error; err != nil {
+		return fmt.Errorf("failed to read applied migrations of %s: %w", tableName, err)
+	}
+	done := make(map[int]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	pending := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Version < pending[j].Version })
+
+	for _, migration := range pending {
+		statements, ok := migration.SQL[dialect]
+		if !ok {
+			return fmt.Errorf("migration %d (%s) of %s has no %s statements", migration.Version, migration.Name, tableName, dialect)
+		}
+		if err := applyMigration(db, dialect, tableName, migration, statements); err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s) to %s: %w", migration.Version, migration.Name, tableName, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration 执行一个版本的语句并记录
+func applyMigration(db *gorm.DB, dialect, tableName string, migration Migration, statements []string) error {
+	record := &SchemaMigration{
+		Target:    tableName,
+		Version:   migration.Version,
+		Name:      migration.Name,
+		AppliedAt: time.Now(),
+	}
+	replacer := strings.NewReplacer("{{table}}", tableName)
+
+	if dialect == DialectMySQL {
+		if migration.Skip != nil && migration.Skip(db, tableName) {
+			statements = nil
+		}
+		for _, statement := range statements {
+			if err := db.Exec(replacer.Replace(statement)).Error; err != nil {
+				return err
+			}
+		}
+		return db.Table(migrationsTable).Clauses(clause.OnConflict{DoNothing: true}).Create(record).Error
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// 先写入版本记录：其他实例已执行（或正在执行）该版本时在这里等待并跳过
+		result := tx.Table(migrationsTable).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if migration.Skip != nil && migration.Skip(tx, tableName) {
+			return nil
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(replacer.Replace(statement)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ColumnExists 列已存在时跳过迁移（用于Migration.Skip，兼容由早期版本的AutoMigrate创建的表）
+func ColumnExists(column string) func(tx *gorm.DB, tableName string) bool {
+	return func(tx *gorm.DB, tableName string) bool {
+		return tx.Migrator().HasColumn(tableName, column)
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, dialect, err := Open("sqlite://"+filepath.Join(t.TempDir(), "test.db"), false)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if dialect != DialectSQLite {
+		t.Fatalf("dialect = %s; want sqlite", dialect)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+var testMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create",
+		SQL: map[string][]string{
+			DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (id integer PRIMARY KEY, name text NOT NULL)`},
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add note",
+		SQL: map[string][]string{
+			DialectSQLite: {`ALTER TABLE {{table}} ADD COLUMN note text NOT NULL DEFAULT ''`},
+		},
+		Skip: ColumnExists("note"),
+	},
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // 迁移前已存在的表（模拟AutoMigrate创建的旧表）
+		table    string
+		wantErr  string
+	}{
+		{name: "fresh database", table: "items"},
+		{name: "legacy table without column", existing: `CREATE TABLE legacy (id integer PRIMARY KEY, name text NOT NULL)`, table: "legacy"},
+		{name: "legacy table with column", existing: `CREATE TABLE legacy (id integer PRIMARY KEY, name text NOT NULL, note text)`, table: "legacy"},
+		{name: "invalid table name", table: "items; DROP TABLE x", wantErr: "invalid table name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if tt.existing != "" {
+				if err := db.Exec(tt.existing).Error; err != nil {
+					t.Fatalf("create existing table: %v", err)
+				}
+			}
+
+			err := Migrate(db, DialectSQLite, tt.table, testMigrations)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Migrate error = %v; want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Migrate: %v", err)
+			}
+			if !db.Migrator().HasColumn(tt.table, "note") {
+				t.Fatal("column note was not added")
+			}
+
+			// 再次执行不应重复应用
+			if err := Migrate(db, DialectSQLite, tt.table, testMigrations); err != nil {
+				t.Fatalf("second Migrate: %v", err)
+			}
+			var versions []int
+			db.Table(migrationsTable).Where("target_table = ?", tt.table).Order("version").Pluck("version", &versions)
+			if len(versions) != 2 || versions[0] != 1 || versions[1] != 2 {
+				t.Fatalf("recorded versions = %v; want [1 2]", versions)
+			}
+		})
+	}
+}
+
+func TestMigrateMissingDialect(t *testing.T) {
+	db := openTestDB(t)
+	migrations := []Migration{{Version: 1, Name: "postgres only", SQL: map[string][]string{DialectPostgres: {"SELECT 1"}}}}
+
+	err := Migrate(db, DialectSQLite, "items", migrations)
+	if err == nil || !strings.Contains(err.Error(), "has no sqlite statements") {
+		t.Fatalf("Migrate error = %v; want missing sqlite statements", err)
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn         string
+		wantDialect string
+		wantDSN     string
+	}{
+		{"postgres://u:p@db:5432/ygg?sslmode=disable", DialectPostgres, "postgres://u:p@db:5432/ygg?sslmode=disable"},
+		{"postgresql://db/ygg", DialectPostgres, "postgresql://db/ygg"},
+		{"mysql://u:p@db/ygg?parseTime=True", DialectMySQL, "u:p@tcp(db:3306)/ygg?parseTime=True"},
+		{"mysql://u:p@db:3307/ygg?charset=utf8mb4", DialectMySQL, "u:p@tcp(db:3307)/ygg?charset=utf8mb4&parseTime=true"},
+		{"mysql://u:p(1)@db/ygg", DialectMySQL, "u:p(1)@tcp(db:3306)/ygg?parseTime=true"},
+		{"mysql://u:p@tcp(db:3307)/ygg", DialectMySQL, "u:p@tcp(db:3307)/ygg?parseTime=true"},
+		{"mysql://u:p@unix(/run/mysqld/mysqld.sock)/ygg?loc=Local", DialectMySQL, "u:p@unix(/run/mysqld/mysqld.sock)/ygg?loc=Local&parseTime=true"},
+		{"mysql://tcp(db:3306)/ygg?parsetime=false", DialectMySQL, "tcp(db:3306)/ygg?parsetime=false"},
+		{"sqlite://data/cache.db", DialectSQLite, "data/cache.db"},
+		{"file:cache?mode=memory", DialectSQLite, "file:cache?mode=memory"},
+		{"u:p@tcp(db:3306)/ygg", DialectMySQL, "u:p@tcp(db:3306)/ygg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			dialect, dsn, err := ParseDSN(tt.dsn)
+			if err != nil {
+				t.Fatalf("ParseDSN: %v", err)
+			}
+			if dialect != tt.wantDialect || dsn != tt.wantDSN {
+				t.Fatalf("ParseDSN = %s, %s; want %s, %s", dialect, dsn, tt.wantDialect, tt.wantDSN)
+			}
+		})
+	}
+}
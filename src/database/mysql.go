@@ -1,15 +1,17 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
+	"yggdrasil-api-go/src/logging"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
-	"gorm.io/plugin/dbresolver"
 
 	"github.com/spf13/viper"
 )
@@ -66,7 +68,7 @@ func NewMySQLManager(config *MySQLConfig) (*MySQLManager, error) {
 		NamingStrategy: schema.NamingStrategy{
 			SingularTable: true, // 使用单数表名
 		},
-		Logger: logger.Default.LogMode(logLevel),
+		Logger: logging.NewGormLogger(logLevel),
 		NowFunc: func() time.Time {
 			return time.Now().Local()
 		},
@@ -98,8 +100,11 @@ func NewMySQLManager(config *MySQLConfig) (*MySQLManager, error) {
 		return nil, fmt.Errorf("failed to ping MySQL: %w", err)
 	}
 
-	log.Printf("Successfully connected to MySQL database: %s@%s:%d/%s",
-		config.Username, config.Host, config.Port, config.Database)
+	// 注册查询耗时和连接池指标
+	Instrument(db, "mysql")
+
+	slog.Info("Connected to MySQL database",
+		"user", config.Username, "host", config.Host, "port", config.Port, "database", config.Database)
 
 	return &MySQLManager{DB: db}, nil
 }
@@ -129,12 +134,12 @@ func (m *MySQLManager) GetDB() *gorm.DB {
 }
 
 // HealthCheck 数据库健康检查
-func (m *MySQLManager) HealthCheck() error {
+func (m *MySQLManager) HealthCheck(ctx context.Context) error {
 	sqlDB, err := m.DB.DB()
 	if err != nil {
 		return err
 	}
-	return sqlDB.Ping()
+	return sqlDB.PingContext(ctx)
 }
 
 // GetStats 获取数据库统计信息
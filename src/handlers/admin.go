@@ -7,9 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
-	"github.com/NewNanCity/YggdrasilGo/src/models"
-	"github.com/NewNanCity/YggdrasilGo/src/services"
-	"github.com/NewNanCity/YggdrasilGo/src/utils"
+	"yggdrasil-api-go/src/models"
+	"yggdrasil-api-go/src/services"
+	"yggdrasil-api-go/src/utils"
 )
 
 // AdminHandler 后台管理处理器
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/logging"
 	storage "yggdrasil-api-go/src/storage/interface"
 	"yggdrasil-api-go/src/utils"
 	"yggdrasil-api-go/src/yggdrasil"
@@ -43,11 +44,12 @@ func (h *AuthHandler) Authenticate(c *gin.Context) {
 	}
 
 	// 直接使用 AuthenticateUser 方法（已包含密码验证和单查询优化）
-	user, err := h.storage.AuthenticateUser(req.Username, req.Password)
+	user, err := h.storage.AuthenticateUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
 		utils.RespondInvalidCredentials(c)
 		return
 	}
+	logging.SetUser(c.Request.Context(), user.ID)
 
 	// 生成客户端令牌
 	clientToken := req.ClientToken
@@ -81,6 +83,7 @@ func (h *AuthHandler) Authenticate(c *gin.Context) {
 	}
 
 	// 生成访问令牌（使用配置中的过期时间）
+	logging.SetProfile(c.Request.Context(), profileID)
 	accessToken, err := utils.GenerateJWT(user.ID, profileID, 3*24*time.Hour) // 使用默认3天有效期
 	if err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to generate token")
@@ -97,7 +100,7 @@ func (h *AuthHandler) Authenticate(c *gin.Context) {
 		ExpiresAt:   time.Now().Add(3 * 24 * time.Hour),
 	}
 
-	if err := h.tokenCache.Store(token); err != nil {
+	if err := h.tokenCache.Store(c.Request.Context(), token); err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to store token")
 		return
 	}
@@ -130,7 +133,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	}
 
 	// 获取并验证令牌
-	token, err := h.tokenCache.Get(req.AccessToken)
+	token, err := h.tokenCache.Get(c.Request.Context(), req.AccessToken)
 	if err != nil || !token.IsValid() {
 		utils.RespondInvalidToken(c)
 		return
@@ -143,14 +146,15 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	}
 
 	// 获取用户信息
-	user, err := h.storage.GetUserByID(token.Owner)
+	user, err := h.storage.GetUserByID(c.Request.Context(), token.Owner)
 	if err != nil {
 		utils.RespondForbiddenOperation(c, utils.MsgUserNotExisted)
 		return
 	}
+	logging.SetUser(c.Request.Context(), user.ID)
 
 	// 删除旧令牌
-	h.tokenCache.Delete(req.AccessToken)
+	h.tokenCache.Delete(c.Request.Context(), req.AccessToken)
 
 	// 确定新令牌的角色绑定
 	profileID := token.ProfileID
@@ -183,6 +187,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	}
 
 	// 生成新的访问令牌
+	logging.SetProfile(c.Request.Context(), profileID)
 	newAccessToken, err := utils.GenerateJWT(user.ID, profileID, 3*24*time.Hour)
 	if err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to generate token")
@@ -199,7 +204,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		ExpiresAt:   time.Now().Add(3 * 24 * time.Hour),
 	}
 
-	if err := h.tokenCache.Store(newToken); err != nil {
+	if err := h.tokenCache.Store(c.Request.Context(), newToken); err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to store token")
 		return
 	}
@@ -231,7 +236,7 @@ func (h *AuthHandler) Validate(c *gin.Context) {
 	}
 
 	// 获取并验证令牌
-	token, err := h.tokenCache.Get(req.AccessToken)
+	token, err := h.tokenCache.Get(c.Request.Context(), req.AccessToken)
 	if err != nil || !token.IsValid() {
 		utils.RespondInvalidToken(c)
 		return
@@ -243,6 +248,9 @@ func (h *AuthHandler) Validate(c *gin.Context) {
 		return
 	}
 
+	logging.SetUser(c.Request.Context(), token.Owner)
+	logging.SetProfile(c.Request.Context(), token.ProfileID)
+
 	// 令牌有效，返回204
 	utils.RespondNoContent(c)
 }
@@ -256,7 +264,7 @@ func (h *AuthHandler) Invalidate(c *gin.Context) {
 	}
 
 	// 删除令牌（无论是否存在都返回204）
-	h.tokenCache.Delete(req.AccessToken)
+	h.tokenCache.Delete(c.Request.Context(), req.AccessToken)
 	utils.RespondNoContent(c)
 }
 
@@ -269,13 +277,14 @@ func (h *AuthHandler) Signout(c *gin.Context) {
 	}
 
 	// 验证用户凭据（使用统一的认证方法）
-	user, err := h.storage.AuthenticateUser(req.Username, req.Password)
+	user, err := h.storage.AuthenticateUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
 		utils.RespondInvalidCredentials(c)
 		return
 	}
 
 	// 删除用户的所有令牌
-	h.tokenCache.DeleteUserTokens(user.ID)
+	logging.SetUser(c.Request.Context(), user.ID)
+	h.tokenCache.DeleteUserTokens(c.Request.Context(), user.ID)
 	utils.RespondNoContent(c)
 }
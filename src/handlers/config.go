@@ -0,0 +1,101 @@
+// Package handlers 配置管理处理器
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/logging"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigHandler 配置管理处理器（查看热重载记录、手动触发重载，需要管理员的访问令牌或管理命令签发的管理令牌）
+type ConfigHandler struct {
+	storage    storage.Storage
+	tokenCache cache.TokenCache
+	reloader   *config.Reloader
+}
+
+// NewConfigHandler 创建配置管理处理器
+func NewConfigHandler(storage storage.Storage, tokenCache cache.TokenCache, reloader *config.Reloader) *ConfigHandler {
+	return &ConfigHandler{
+		storage:    storage,
+		tokenCache: tokenCache,
+		reloader:   reloader,
+	}
+}
+
+// GetReloadStatus 获取最近的配置重载记录
+func (h *ConfigHandler) GetReloadStatus(c *gin.Context) {
+	if !authorizeAdmin(c, h.storage, h.tokenCache) {
+		return
+	}
+
+	utils.RespondJSON(c, gin.H{
+		"history": h.reloader.History(),
+	})
+}
+
+// Reload 立即重新加载配置文件（配置无效时返回422，继续使用原配置）
+func (h *ConfigHandler) Reload(c *gin.Context) {
+	if !authorizeAdmin(c, h.storage, h.tokenCache) {
+		return
+	}
+
+	result := h.reloader.Reload(config.ReloadTriggerAPI)
+	status := http.StatusOK
+	if !result.Success {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, result)
+}
+
+// adminTokenMaxLifetime 管理令牌的最长有效期（管理命令每次调用时签发）
+const adminTokenMaxLifetime = 5 * time.Minute
+
+// authorizeAdmin 验证请求的令牌（Authorization: Bearer）属于管理员
+// 接受管理员的Yggdrasil访问令牌，或以auth.jwt_secret签名的管理令牌（管理命令使用，服务器使用memory令牌缓存时命令行无法写入访问令牌）
+func authorizeAdmin(c *gin.Context, store storage.Storage, tokenCache cache.TokenCache) bool {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		utils.RespondUnauthorized(c, "Authorization header required")
+		return false
+	}
+	accessToken := strings.TrimPrefix(authHeader, "Bearer ")
+
+	// 管理令牌没有用户ID（Yggdrasil访问令牌总是带有用户ID），且有效期不超过adminTokenMaxLifetime
+	if claims, err := utils.ValidateJWT(accessToken); err == nil && claims.UserID == "" {
+		if !claims.IsAdmin || claims.IssuedAt == nil || claims.ExpiresAt == nil ||
+			claims.ExpiresAt.Sub(claims.IssuedAt.Time) > adminTokenMaxLifetime {
+			utils.RespondForbiddenOperation(c, "Admin privileges required")
+			return false
+		}
+		logging.SetUser(c.Request.Context(), claims.Username)
+		return true
+	}
+
+	token, err := tokenCache.Get(c.Request.Context(), accessToken)
+	if err != nil || !token.IsValid() {
+		utils.RespondUnauthorized(c, utils.MsgInvalidToken)
+		return false
+	}
+
+	user, err := store.GetUserByID(c.Request.Context(), token.Owner)
+	if err != nil {
+		utils.RespondUnauthorized(c, utils.MsgInvalidToken)
+		return false
+	}
+	logging.SetUser(c.Request.Context(), user.ID)
+
+	if !user.IsAdmin {
+		utils.RespondForbiddenOperation(c, "Admin privileges required")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+// Package handlers 健康检查处理器
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// signingKeyCheckTTL 签名密钥检查结果的缓存时间（避免每次就绪检查都读取密钥）
+const signingKeyCheckTTL = 10 * time.Second
+
+// HealthHandler 健康检查处理器
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler 创建健康检查处理器，并注册存储、Token/Session缓存和签名密钥的就绪检查
+func NewHealthHandler(checker *health.Checker, metaHandler *MetaHandler, tokenCache cache.TokenCache, sessionCache cache.SessionCache) *HealthHandler {
+	checker.Register("storage", func(ctx context.Context) error {
+		return metaHandler.storage.Ping()
+	})
+	checker.Register("token_cache", tokenCache.Ping)
+	checker.Register("session_cache", sessionCache.Ping)
+	checker.Register("signing_key", health.Cached(func(ctx context.Context) error {
+		if _, _, err := GetCachedRSAKeyPair(); err == nil {
+			return nil
+		}
+		// 密钥对在首次请求API元数据时才加载，未加载时在这里加载一次
+		_, _, err := metaHandler.loadSignatureKeyPair()
+		return err
+	}, signingKeyCheckTTL))
+
+	return &HealthHandler{checker: checker}
+}
+
+// Liveness 存活检查（进程能处理请求即返回200，不检查依赖）
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness 就绪检查（所有依赖可用时返回200，否则返回503，正在关闭时同样返回503）
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.checker.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -31,9 +31,13 @@ func NewMetaHandler(storage storage.Storage, cfg *config.Config) *MetaHandler {
 func (h *MetaHandler) GetAPIMetadata(c *gin.Context) {
 	// 尝试从缓存获取响应
 	cacheKey := "api_metadata_" + c.Request.Host
-	if cached, exists := utils.GetCachedResponse(cacheKey); exists {
-		c.Data(200, "application/json", cached)
-		return
+	cfg := currentConfig(h.config)
+	cacheEnabled := cfg.Cache.Response.APIMetadata
+	if cacheEnabled {
+		if cached, exists := utils.GetCachedResponse(cacheKey); exists {
+			c.Data(200, "application/json", cached)
+			return
+		}
 	}
 
 	// 获取请求的Host头
@@ -44,16 +48,16 @@ func (h *MetaHandler) GetAPIMetadata(c *gin.Context) {
 
 	// 动态生成链接
 	links := make(map[string]string)
-	for key := range h.config.Yggdrasil.Meta.Links {
-		links[key] = h.config.GetLinkURL(key, host)
+	for key := range cfg.Yggdrasil.Meta.Links {
+		links[key] = cfg.GetLinkURL(key, host)
 	}
 
 	// 如果配置中没有基本链接，添加默认链接
 	if _, exists := links["homepage"]; !exists {
-		links["homepage"] = h.config.GetLinkURL("homepage", host)
+		links["homepage"] = cfg.GetLinkURL("homepage", host)
 	}
 	if _, exists := links["register"]; !exists {
-		links["register"] = h.config.GetLinkURL("register", host)
+		links["register"] = cfg.GetLinkURL("register", host)
 	}
 
 	// 加载密钥对（只需要公钥用于API元数据）
@@ -65,20 +69,22 @@ func (h *MetaHandler) GetAPIMetadata(c *gin.Context) {
 
 	metadata := yggdrasil.APIMetadata{
 		Meta: yggdrasil.MetaInfo{
-			ServerName:            h.config.Yggdrasil.Meta.ServerName,
-			ImplementationName:    h.config.Yggdrasil.Meta.ImplementationName,
-			ImplementationVersion: h.config.Yggdrasil.Meta.ImplementationVersion,
+			ServerName:            cfg.Yggdrasil.Meta.ServerName,
+			ImplementationName:    cfg.Yggdrasil.Meta.ImplementationName,
+			ImplementationVersion: cfg.Yggdrasil.Meta.ImplementationVersion,
 			Links:                 links,
-			FeatureNonEmailLogin:  h.config.Yggdrasil.Features.NonEmailLogin,
+			FeatureNonEmailLogin:  cfg.Yggdrasil.Features.NonEmailLogin,
 		},
-		SkinDomains:        h.config.Yggdrasil.SkinDomains,
+		SkinDomains:        cfg.Yggdrasil.SkinDomains,
 		SignaturePublicKey: publicKey,
 	}
 
 	// 使用高性能JSON响应并缓存结果
 	if jsonData, err := utils.FastMarshal(metadata); err == nil {
-		// 缓存响应（5分钟）
-		utils.SetCachedResponse(cacheKey, jsonData)
+		// 缓存响应（有效期由cache.response.cache_duration决定）
+		if cacheEnabled {
+			utils.SetCachedResponse(cacheKey, jsonData, utils.APIMetadataResponseTag)
+		}
 		c.Data(200, "application/json", jsonData)
 	} else {
 		// 降级到标准JSON
@@ -86,6 +92,14 @@ func (h *MetaHandler) GetAPIMetadata(c *gin.Context) {
 	}
 }
 
+// currentConfig 当前生效的配置（配置热重载后返回新配置）
+func currentConfig(cfg *config.Config) *config.Config {
+	if current := config.Current(); current != nil {
+		return current
+	}
+	return cfg
+}
+
 // 缓存的密钥对
 var (
 	cachedPrivateKey    string
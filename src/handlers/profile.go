@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"yggdrasil-api-go/src/config"
 	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/tracing"
 	"yggdrasil-api-go/src/utils"
 
 	"github.com/gin-gonic/gin"
@@ -41,8 +44,18 @@ func (h *ProfileHandler) GetProfileByUUID(c *gin.Context) {
 		}
 	}
 
+	// 尝试从响应缓存获取（角色材质变化时按角色标签失效）
+	cacheEnabled := currentConfig(h.config).Cache.Response.ProfileResponses
+	cacheKey := "profile_" + strings.ToLower(utils.RemoveUUIDHyphens(uuid)) + "_" + strconv.FormatBool(unsigned)
+	if cacheEnabled {
+		if cached, exists := utils.GetCachedResponse(cacheKey); exists {
+			c.Data(200, "application/json", cached)
+			return
+		}
+	}
+
 	// 获取角色信息
-	profile, err := h.storage.GetProfileByUUID(uuid)
+	profile, err := h.storage.GetProfileByUUID(c.Request.Context(), uuid)
 	if err != nil {
 		// 角色不存在，返回204
 		utils.RespondNoContent(c)
@@ -60,7 +73,7 @@ func (h *ProfileHandler) GetProfileByUUID(c *gin.Context) {
 		for i := range profile.Properties {
 			if profile.Properties[i].Signature == "" {
 				// 生成签名
-				signature, err := h.generateSignature(profile.Properties[i].Value)
+				signature, err := h.generateSignature(c.Request.Context(), profile.Properties[i].Value)
 				if err != nil {
 					// 签名生成失败，记录错误但不影响响应
 					// 可以选择返回错误或继续返回无签名的数据
@@ -71,11 +84,22 @@ func (h *ProfileHandler) GetProfileByUUID(c *gin.Context) {
 		}
 	}
 
+	if cacheEnabled {
+		if jsonData, err := utils.FastMarshal(profile); err == nil {
+			utils.SetCachedResponseWithTTL(cacheKey, jsonData, currentConfig(h.config).Cache.Response.ProfileDuration, utils.ProfileResponseTag(profile.ID))
+			c.Data(200, "application/json", jsonData)
+			return
+		}
+	}
+
 	utils.RespondJSONFast(c, profile)
 }
 
 // generateSignature 生成属性值的数字签名（高性能版本）
-func (h *ProfileHandler) generateSignature(value string) (string, error) {
+func (h *ProfileHandler) generateSignature(ctx context.Context, value string) (signature string, err error) {
+	_, span := tracing.Start(ctx, "yggdrasil.SignProperty")
+	defer func() { tracing.End(span, err) }()
+
 	// 尝试获取缓存的RSA密钥对
 	rsaPrivateKey, _, err := GetCachedRSAKeyPair()
 	if err != nil {
@@ -121,7 +145,7 @@ func (h *ProfileHandler) SearchMultipleProfiles(c *gin.Context) {
 	}
 
 	// 批量查询角色
-	profiles, err := h.storage.GetProfilesByNames(names)
+	profiles, err := h.storage.GetProfilesByNames(c.Request.Context(), names)
 	if err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to query profiles")
 		return
@@ -148,8 +172,18 @@ func (h *ProfileHandler) SearchSingleProfile(c *gin.Context) {
 		return
 	}
 
+	// 尝试从响应缓存获取
+	cacheEnabled := currentConfig(h.config).Cache.Response.ProfileResponses
+	cacheKey := "profile_name_" + strings.ToLower(username)
+	if cacheEnabled {
+		if cached, exists := utils.GetCachedResponse(cacheKey); exists {
+			c.Data(200, "application/json", cached)
+			return
+		}
+	}
+
 	// 获取角色信息
-	profile, err := h.storage.GetProfileByName(username)
+	profile, err := h.storage.GetProfileByName(c.Request.Context(), username)
 	if err != nil {
 		// 角色不存在，返回204
 		utils.RespondNoContent(c)
@@ -162,5 +196,13 @@ func (h *ProfileHandler) SearchSingleProfile(c *gin.Context) {
 		"name": profile.Name,
 	}
 
+	if cacheEnabled {
+		if jsonData, err := utils.FastMarshal(result); err == nil {
+			utils.SetCachedResponseWithTTL(cacheKey, jsonData, currentConfig(h.config).Cache.Response.ProfileDuration, utils.ProfileResponseTag(profile.ID))
+			c.Data(200, "application/json", jsonData)
+			return
+		}
+	}
+
 	utils.RespondJSONFast(c, result)
 }
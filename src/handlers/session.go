@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"net"
 	"time"
 
 	"yggdrasil-api-go/src/cache"
 	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/logging"
 	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/tracing"
 	"yggdrasil-api-go/src/utils"
 	"yggdrasil-api-go/src/yggdrasil"
 
@@ -51,18 +56,19 @@ func (h *SessionHandler) Join(c *gin.Context) {
 		utils.RespondForbiddenOperation(c, "Selected profile does not match token")
 		return
 	}
+	logging.SetProfile(c.Request.Context(), claims.ProfileID)
 
 	// 创建会话记录（使用JWT中的信息，无需查询数据库）
 	session := &yggdrasil.Session{
 		ServerID:    req.ServerID,
-		AccessToken: req.AccessToken, // session缓存会从中提取用户信息
+		AccessToken: utils.HashAccessToken(req.AccessToken), // 只保存令牌哈希
 		ProfileID:   claims.ProfileID,
 		ClientIP:    c.ClientIP(),
 		CreatedAt:   time.Now(),
 	}
 
 	// 存储会话
-	if err := h.sessionCache.Store(req.ServerID, session); err != nil {
+	if err := h.sessionCache.Store(c.Request.Context(), req.ServerID, session); err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to store session")
 		return
 	}
@@ -81,8 +87,8 @@ func (h *SessionHandler) HasJoined(c *gin.Context) {
 		return
 	}
 
-	// 获取会话信息
-	session, err := h.sessionCache.Get(serverID)
+	// 原子地取出会话（一次性使用，并发验证同一serverId时只有一个请求能取到；后续验证失败时会话同样作废）
+	session, err := h.sessionCache.Consume(c.Request.Context(), serverID)
 	if err != nil || !session.IsValid() {
 		// 会话不存在或已过期，返回204
 		utils.RespondNoContent(c)
@@ -90,7 +96,7 @@ func (h *SessionHandler) HasJoined(c *gin.Context) {
 	}
 
 	// 通过用户名获取角色信息
-	profile, err := h.storage.GetProfileByName(username)
+	profile, err := h.storage.GetProfileByName(c.Request.Context(), username)
 	if err != nil {
 		utils.RespondNoContent(c)
 		return
@@ -101,20 +107,27 @@ func (h *SessionHandler) HasJoined(c *gin.Context) {
 		utils.RespondNoContent(c)
 		return
 	}
+	logging.SetProfile(c.Request.Context(), profile.ID)
 
 	// 如果提供了IP参数，验证IP是否匹配
-	if clientIP != "" && session.ClientIP != clientIP {
+	if clientIP != "" && !sameIP(session.ClientIP, clientIP) {
 		utils.RespondNoContent(c)
 		return
 	}
 
-	// 验证成功，删除会话（一次性使用）
-	h.sessionCache.Delete(serverID)
+	// 验证成功，允许重复验证时放回会话并增加计数（保留原创建时间，不延长有效期）
+	// 放回失败只影响后续的重复验证，本次验证仍然成功
+	if session.Checks < currentConfig(h.config).Yggdrasil.Features.HasJoinedRechecks {
+		session.Checks++
+		if err := h.sessionCache.Store(c.Request.Context(), serverID, session); err != nil {
+			slog.WarnContext(c.Request.Context(), "Failed to store session for recheck", "server_id", serverID, "error", err)
+		}
+	}
 
 	// 为角色属性生成数字签名（根据Yggdrasil规范要求）
 	for i := range profile.Properties {
 		if profile.Properties[i].Signature == "" {
-			signature, err := h.generateSignature(profile.Properties[i].Value)
+			signature, err := h.generateSignature(c.Request.Context(), profile.Properties[i].Value)
 			if err != nil {
 				// 签名生成失败，记录错误但不影响响应
 				// 继续返回无签名的数据
@@ -128,8 +141,19 @@ func (h *SessionHandler) HasJoined(c *gin.Context) {
 	utils.RespondJSON(c, profile)
 }
 
+// sameIP 比较两个IP地址（IPv4映射的IPv6地址与对应的IPv4地址视为相同）
+func sameIP(a, b string) bool {
+	if ipA, ipB := net.ParseIP(a), net.ParseIP(b); ipA != nil && ipB != nil {
+		return ipA.Equal(ipB)
+	}
+	return a == b
+}
+
 // generateSignature 生成属性值的数字签名（高性能版本）
-func (h *SessionHandler) generateSignature(value string) (string, error) {
+func (h *SessionHandler) generateSignature(ctx context.Context, value string) (signature string, err error) {
+	_, span := tracing.Start(ctx, "yggdrasil.SignProperty")
+	defer func() { tracing.End(span, err) }()
+
 	// 尝试获取缓存的RSA密钥对
 	rsaPrivateKey, _, err := GetCachedRSAKeyPair()
 	if err != nil {
@@ -2,28 +2,94 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/importer"
+	"yggdrasil-api-go/src/logging"
 	storage "yggdrasil-api-go/src/storage/interface"
 	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
 
 	"github.com/gin-gonic/gin"
 )
 
 // TextureHandler 材质处理器
 type TextureHandler struct {
-	storage storage.Storage
+	storage    storage.Storage
+	tokenCache cache.TokenCache
+	importer   *importer.Importer // 材质导入器（未启用导入时为nil）
+	config     *config.Config
+}
+
+// ImportTextureRequest 材质导入请求
+type ImportTextureRequest struct {
+	Upstream string   `json:"upstream" binding:"required"` // 上游名称
+	Source   string   `json:"source" binding:"required"`   // 上游角色名或UUID
+	Types    []string `json:"types,omitempty"`             // 导入的材质类型，为空时导入所有
 }
 
 // NewTextureHandler 创建新的材质处理器
-func NewTextureHandler(storage storage.Storage) *TextureHandler {
+func NewTextureHandler(storage storage.Storage, tokenCache cache.TokenCache, importer *importer.Importer, cfg *config.Config) *TextureHandler {
 	return &TextureHandler{
-		storage: storage,
+		storage:    storage,
+		tokenCache: tokenCache,
+		importer:   importer,
+		config:     cfg,
+	}
+}
+
+// authorizeProfile 验证请求的访问令牌（Authorization: Bearer）且角色属于令牌所有者（管理员可操作任意角色）
+func (h *TextureHandler) authorizeProfile(c *gin.Context, profileUUID string) (*yggdrasil.User, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		utils.RespondUnauthorized(c, "Authorization header required")
+		return nil, false
+	}
+
+	token, err := h.tokenCache.Get(c.Request.Context(), strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil || !token.IsValid() {
+		utils.RespondUnauthorized(c, utils.MsgInvalidToken)
+		return nil, false
+	}
+
+	user, err := h.storage.GetUserByID(c.Request.Context(), token.Owner)
+	if err != nil {
+		utils.RespondUnauthorized(c, utils.MsgInvalidToken)
+		return nil, false
+	}
+	logging.SetUser(c.Request.Context(), user.ID)
+	logging.SetProfile(c.Request.Context(), profileUUID)
+
+	if user.IsAdmin {
+		return user, true
 	}
+
+	for _, profile := range user.Profiles {
+		if profile.ID == profileUUID {
+			return user, true
+		}
+	}
+
+	utils.RespondForbiddenOperation(c, "Profile does not belong to the user")
+	return nil, false
+}
+
+// textureChangeContext 携带操作者信息的context（存储覆盖材质前把原状态写入历史记录）
+func textureChangeContext(c *gin.Context, user *yggdrasil.User, action string) context.Context {
+	return storage.WithTextureChange(c.Request.Context(), storage.TextureChange{
+		Action:  action,
+		Actor:   user.ID,
+		ActorIP: c.ClientIP(),
+	})
 }
 
 // UploadTexture 通用材质上传 (符合Yggdrasil规范)
@@ -31,17 +97,17 @@ func (h *TextureHandler) UploadTexture(c *gin.Context) {
 	uuid := c.Param("uuid")
 	textureType := c.Param("textureType")
 
-	// 验证材质类型
-	var storageTextureType storage.TextureType
-	switch strings.ToLower(textureType) {
-	case "skin":
-		storageTextureType = storage.TextureTypeSkin
-	case "cape":
-		storageTextureType = storage.TextureTypeCape
-	default:
+	// 验证材质类型（由配置注册）
+	textureDef, ok := storage.LookupTextureType(textureType)
+	if !ok {
 		utils.RespondError(c, 400, "BadRequest", "Invalid texture type")
 		return
 	}
+	if !textureDef.Upload {
+		utils.RespondForbiddenOperation(c, "Texture type is not uploadable")
+		return
+	}
+	storageTextureType := textureDef.Type
 
 	// 检查是否支持上传
 	if !h.storage.IsUploadSupported() {
@@ -49,6 +115,12 @@ func (h *TextureHandler) UploadTexture(c *gin.Context) {
 		return
 	}
 
+	// 验证令牌和角色归属
+	user, ok := h.authorizeProfile(c, uuid)
+	if !ok {
+		return
+	}
+
 	// 获取上传的文件
 	file, _, err := c.Request.FormFile("file")
 	if err != nil {
@@ -57,19 +129,27 @@ func (h *TextureHandler) UploadTexture(c *gin.Context) {
 	}
 	defer file.Close()
 
-	// 读取文件内容
-	data, err := io.ReadAll(file)
+	// 读取文件内容（按texture.max_file_size限制大小）
+	maxSize := h.config.Texture.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = 1024 * 1024
+	}
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
 	if err != nil {
 		utils.RespondError(c, 500, "InternalServerError", "Failed to read file")
 		return
 	}
-
-	// 验证文件大小
-	if len(data) > 1024*1024 { // 1MB限制
+	if int64(len(data)) > maxSize {
 		utils.RespondError(c, 413, "PayloadTooLarge", "File too large")
 		return
 	}
 
+	// 验证图片尺寸
+	if err := textureDef.ValidateImage(data); err != nil {
+		utils.RespondIllegalArgument(c, err.Error())
+		return
+	}
+
 	// 创建材质元数据
 	metadata := &storage.TextureMetadata{
 		FileSize:   int64(len(data)),
@@ -77,8 +157,17 @@ func (h *TextureHandler) UploadTexture(c *gin.Context) {
 		// Hash 将在存储层计算
 	}
 
+	// 皮肤模型（Yggdrasil规范：slim为纤细模型，空为默认模型）
+	if storageTextureType == storage.TextureTypeSkin {
+		if model := c.PostForm("model"); model == "slim" || model == "alex" {
+			metadata.Model = "slim"
+			metadata.Slim = true
+		}
+	}
+
 	// 上传材质
-	textureInfo, err := h.storage.UploadTexture(storageTextureType, uuid, data, metadata)
+	ctx := textureChangeContext(c, user, storage.TextureActionUpload)
+	textureInfo, err := h.storage.UploadTexture(ctx, storageTextureType, uuid, data, metadata)
 	if err != nil {
 		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to upload texture: %v", err))
 		return
@@ -96,173 +185,224 @@ func (h *TextureHandler) UploadTexture(c *gin.Context) {
 	utils.RespondJSONFast(c, response)
 }
 
-// UploadSkin 上传皮肤
-func (h *TextureHandler) UploadSkin(c *gin.Context) {
-	h.uploadTexture(c, storage.TextureTypeSkin)
-}
-
-// UploadCape 上传披风
-func (h *TextureHandler) UploadCape(c *gin.Context) {
-	h.uploadTexture(c, storage.TextureTypeCape)
-}
-
-// uploadTexture 通用材质上传处理
-func (h *TextureHandler) uploadTexture(c *gin.Context, textureType storage.TextureType) {
-	// 检查是否支持上传
-	if !h.storage.IsUploadSupported() {
-		utils.RespondError(c, 501, "NotImplemented", "Texture upload is not supported")
-		return
-	}
-
-	// 获取玩家UUID
+// GetTexture 获取材质
+func (h *TextureHandler) GetTexture(c *gin.Context) {
 	playerUUID := c.Param("uuid")
-	if playerUUID == "" {
-		utils.RespondError(c, 400, "BadRequest", "Player UUID is required")
+
+	// 验证参数
+	textureDef, ok := storage.LookupTextureType(c.Param("type"))
+	if !ok {
+		utils.RespondError(c, 400, "BadRequest", "Invalid texture type")
 		return
 	}
+	textureType := textureDef.Type
 
-	// 验证UUID格式
 	if !utils.IsValidUUID(playerUUID) {
 		utils.RespondError(c, 400, "BadRequest", "Invalid UUID format")
 		return
 	}
 
-	// 获取上传的文件
-	file, header, err := c.Request.FormFile("file")
+	// 获取材质信息
+	textureInfo, err := h.storage.GetTexture(c.Request.Context(), textureType, playerUUID)
 	if err != nil {
-		utils.RespondError(c, 400, "BadRequest", "No file uploaded")
+		utils.RespondError(c, 404, "NotFound", "Texture not found")
 		return
 	}
-	defer file.Close()
 
-	// 检查文件大小
-	if header.Size > 1024*1024 { // 1MB限制
-		utils.RespondError(c, 413, "PayloadTooLarge", "File too large")
-		return
-	}
+	// 重定向到材质URL
+	c.Redirect(http.StatusFound, textureInfo.URL)
+}
 
-	// 检查文件类型
-	contentType := header.Header.Get("Content-Type")
-	if !isAllowedContentType(contentType) {
-		utils.RespondError(c, 415, "UnsupportedMediaType", "Unsupported file type")
+// DeleteTexture 删除材质
+func (h *TextureHandler) DeleteTexture(c *gin.Context) {
+	// 检查是否支持上传（删除也需要上传功能）
+	if !h.storage.IsUploadSupported() {
+		utils.RespondError(c, 501, "NotImplemented", "Texture management is not supported")
 		return
 	}
 
-	// 读取文件数据
-	data, err := io.ReadAll(file)
-	if err != nil {
-		utils.RespondError(c, 500, "InternalServerError", "Failed to read file")
-		return
-	}
+	playerUUID := c.Param("uuid")
 
-	// 创建材质元数据
-	metadata := &storage.TextureMetadata{
-		UploadedAt: time.Now(),
-		FileSize:   header.Size,
-		Hash:       utils.CalculateHash(data),
+	// 验证参数
+	textureDef, ok := storage.LookupTextureType(c.Param("textureType"))
+	if !ok || !textureDef.Upload {
+		utils.RespondError(c, 400, "BadRequest", "Invalid texture type")
+		return
 	}
+	textureType := textureDef.Type
 
-	// 如果是皮肤，检查模型类型
-	if textureType == storage.TextureTypeSkin {
-		model := c.PostForm("model")
-		if model == "alex" || model == "slim" {
-			metadata.Model = model
-			metadata.Slim = true
-		} else {
-			metadata.Model = "steve"
-			metadata.Slim = false
-		}
+	// 验证令牌和角色归属
+	user, ok := h.authorizeProfile(c, playerUUID)
+	if !ok {
+		return
 	}
 
-	// 上传材质
-	textureInfo, err := h.storage.UploadTexture(textureType, playerUUID, data, metadata)
-	if err != nil {
-		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to upload texture: %v", err))
+	// 删除材质
+	ctx := textureChangeContext(c, user, storage.TextureActionDelete)
+	if err := h.storage.DeleteTexture(ctx, textureType, playerUUID); err != nil {
+		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to delete texture: %v", err))
 		return
 	}
 
 	// 返回成功响应
 	utils.RespondJSON(c, gin.H{
 		"success": true,
-		"texture": textureInfo,
+		"message": "Texture deleted successfully",
 	})
 }
 
-// GetTexture 获取材质
-func (h *TextureHandler) GetTexture(c *gin.Context) {
-	textureType := storage.TextureType(strings.ToUpper(c.Param("type")))
+// GetTextureHistory 获取角色的材质变更历史
+func (h *TextureHandler) GetTextureHistory(c *gin.Context) {
 	playerUUID := c.Param("uuid")
 
-	// 验证参数
-	if textureType != storage.TextureTypeSkin && textureType != storage.TextureTypeCape {
+	textureDef, ok := storage.LookupTextureType(c.Param("textureType"))
+	if !ok {
 		utils.RespondError(c, 400, "BadRequest", "Invalid texture type")
 		return
 	}
 
-	if !utils.IsValidUUID(playerUUID) {
-		utils.RespondError(c, 400, "BadRequest", "Invalid UUID format")
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			utils.RespondIllegalArgument(c, "Invalid limit")
+			return
+		}
+		limit = parsed
+	}
+
+	// 验证令牌和角色归属
+	if _, ok := h.authorizeProfile(c, playerUUID); !ok {
 		return
 	}
 
-	// 获取材质信息
-	textureInfo, err := h.storage.GetTexture(textureType, playerUUID)
+	entries, err := h.storage.GetTextureHistory(c.Request.Context(), playerUUID, textureDef.Type, limit)
 	if err != nil {
-		utils.RespondError(c, 404, "NotFound", "Texture not found")
+		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to get texture history: %v", err))
 		return
 	}
 
-	// 重定向到材质URL
-	c.Redirect(http.StatusFound, textureInfo.URL)
+	utils.RespondJSON(c, gin.H{
+		"history": entries,
+	})
 }
 
-// DeleteTexture 删除材质
-func (h *TextureHandler) DeleteTexture(c *gin.Context) {
-	// 检查是否支持上传（删除也需要上传功能）
+// RevertTexture 将角色材质恢复到指定历史记录的状态
+func (h *TextureHandler) RevertTexture(c *gin.Context) {
+	// 检查是否支持上传（恢复也需要上传功能）
 	if !h.storage.IsUploadSupported() {
 		utils.RespondError(c, 501, "NotImplemented", "Texture management is not supported")
 		return
 	}
 
-	textureType := storage.TextureType(strings.ToUpper(c.Param("type")))
 	playerUUID := c.Param("uuid")
 
-	// 验证参数
-	if textureType != storage.TextureTypeSkin && textureType != storage.TextureTypeCape {
+	textureDef, ok := storage.LookupTextureType(c.Param("textureType"))
+	if !ok || !textureDef.Upload {
 		utils.RespondError(c, 400, "BadRequest", "Invalid texture type")
 		return
 	}
 
-	if !utils.IsValidUUID(playerUUID) {
-		utils.RespondError(c, 400, "BadRequest", "Invalid UUID format")
+	entryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || entryID <= 0 {
+		utils.RespondIllegalArgument(c, "Invalid history entry ID")
 		return
 	}
 
-	// 删除材质
-	err := h.storage.DeleteTexture(textureType, playerUUID)
+	// 验证令牌和角色归属
+	user, ok := h.authorizeProfile(c, playerUUID)
+	if !ok {
+		return
+	}
+
+	// 只允许恢复当前路径指定类型的历史记录
+	entries, err := h.storage.GetTextureHistory(c.Request.Context(), playerUUID, textureDef.Type, 0)
 	if err != nil {
-		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to delete texture: %v", err))
+		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to get texture history: %v", err))
+		return
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.ID == entryID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		utils.RespondNotFound(c, "History entry not found")
+		return
+	}
+
+	ctx := textureChangeContext(c, user, storage.TextureActionRevert)
+	entry, err := h.storage.RevertTexture(ctx, playerUUID, entryID)
+	if err != nil {
+		utils.RespondError(c, 500, "InternalServerError", fmt.Sprintf("Failed to revert texture: %v", err))
 		return
 	}
 
-	// 返回成功响应
 	utils.RespondJSON(c, gin.H{
-		"success": true,
-		"message": "Texture deleted successfully",
+		"success":  true,
+		"reverted": entry,
 	})
 }
 
-// isAllowedContentType 检查是否为允许的文件类型
-func isAllowedContentType(contentType string) bool {
-	allowedTypes := []string{
-		"image/png",
-		"image/jpeg",
-		"image/jpg",
+// ImportTexture 从上游Yggdrasil/Mojang服务器导入角色材质
+func (h *TextureHandler) ImportTexture(c *gin.Context) {
+	if h.importer == nil || !h.storage.IsUploadSupported() {
+		utils.RespondError(c, 501, "NotImplemented", "Texture import is not supported")
+		return
+	}
+
+	playerUUID := c.Param("uuid")
+
+	var req ImportTextureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondIllegalArgument(c, "Invalid request format")
+		return
 	}
 
-	for _, allowed := range allowedTypes {
-		if contentType == allowed {
-			return true
+	var textureTypes []storage.TextureType
+	for _, name := range req.Types {
+		textureDef, ok := storage.LookupTextureType(name)
+		if !ok || !textureDef.Upload {
+			utils.RespondError(c, 400, "BadRequest", "Invalid texture type")
+			return
 		}
+		textureTypes = append(textureTypes, textureDef.Type)
 	}
-	return false
+
+	// 验证令牌和角色归属
+	user, ok := h.authorizeProfile(c, playerUUID)
+	if !ok {
+		return
+	}
+
+	result, err := h.importer.Import(c.Request.Context(), &importer.Request{
+		Upstream:  req.Upstream,
+		Source:    req.Source,
+		ProfileID: playerUUID,
+		Types:     textureTypes,
+		Actor:     user.ID,
+		ActorIP:   c.ClientIP(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, importer.ErrUpstreamNotFound):
+			utils.RespondIllegalArgument(c, "Unknown upstream")
+		case errors.Is(err, importer.ErrProfileNotFound):
+			utils.RespondNotFound(c, "Upstream profile not found")
+		case errors.Is(err, importer.ErrNoTextures):
+			utils.RespondNotFound(c, "No importable textures")
+		case errors.Is(err, importer.ErrInvalidSignature), errors.Is(err, importer.ErrDomainNotAllowed):
+			utils.RespondForbiddenOperation(c, err.Error())
+		default:
+			utils.RespondError(c, 502, "BadGateway", fmt.Sprintf("Failed to import texture: %v", err))
+		}
+		return
+	}
+
+	utils.RespondJSON(c, gin.H{
+		"success": true,
+		"import":  result,
+	})
 }
@@ -0,0 +1,53 @@
+// Package handlers 令牌管理处理器
+package handlers
+
+import (
+	"yggdrasil-api-go/src/cache"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenAdminHandler 令牌管理处理器（撤销用户的所有令牌，管理命令在令牌缓存只存在于服务器进程中时调用）
+type TokenAdminHandler struct {
+	storage    storage.Storage
+	tokenCache cache.TokenCache
+}
+
+// NewTokenAdminHandler 创建令牌管理处理器
+func NewTokenAdminHandler(storage storage.Storage, tokenCache cache.TokenCache) *TokenAdminHandler {
+	return &TokenAdminHandler{
+		storage:    storage,
+		tokenCache: tokenCache,
+	}
+}
+
+// RevokeUserTokens 撤销用户的所有令牌，返回撤销的数量
+func (h *TokenAdminHandler) RevokeUserTokens(c *gin.Context) {
+	if !authorizeAdmin(c, h.storage, h.tokenCache) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.storage.GetUserByID(ctx, c.Param("id"))
+	if err != nil {
+		utils.RespondNotFound(c, "User not found")
+		return
+	}
+
+	count, err := h.tokenCache.GetUserTokenCount(ctx, user.ID)
+	if err != nil {
+		utils.RespondError(c, 500, "InternalServerError", "Failed to count tokens")
+		return
+	}
+	if err := h.tokenCache.DeleteUserTokens(ctx, user.ID); err != nil {
+		utils.RespondError(c, 500, "InternalServerError", "Failed to revoke tokens")
+		return
+	}
+
+	utils.RespondJSON(c, gin.H{
+		"user_id":        user.ID,
+		"tokens_revoked": count,
+	})
+}
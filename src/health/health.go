@@ -0,0 +1,185 @@
+// Package health 存活和就绪检查（依赖组件的状态和延迟）
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 组件和整体状态
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// defaultTimeout 未配置时每个检查的超时时间
+const defaultTimeout = 2 * time.Second
+
+// CheckFunc 依赖检查函数（返回nil表示可用）
+type CheckFunc func(ctx context.Context) error
+
+// Cached 在ttl内复用上次的检查结果（用于开销较大、状态很少变化的检查）
+func Cached(fn CheckFunc, ttl time.Duration) CheckFunc {
+	var (
+		mu        sync.Mutex
+		err       error
+		checkedAt time.Time
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+			return err
+		}
+		err = fn(ctx)
+		checkedAt = time.Now()
+		return err
+	}
+}
+
+// ComponentStatus 单个依赖的检查结果
+type ComponentStatus struct {
+	Status    string  `json:"status"`          // up, down
+	LatencyMs float64 `json:"latency_ms"`      // 检查耗时（毫秒）
+	Error     string  `json:"error,omitempty"` // 失败原因
+}
+
+// Report 就绪检查结果
+type Report struct {
+	Status     string                     `json:"status"` // ready, not_ready, shutting_down
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Ready 是否就绪
+func (r *Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// check 已注册的检查
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker 就绪检查器：并发执行所有已注册的检查，每个检查有独立的超时
+// 开始优雅关闭后立即返回未就绪，让负载均衡器停止转发新请求
+type Checker struct {
+	mu           sync.RWMutex
+	checks       []check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewChecker 创建就绪检查器
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register 注册依赖检查（同名检查会被替换）
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.checks {
+		if c.checks[i].name == name {
+			c.checks[i].fn = fn
+			return
+		}
+	}
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// SetShuttingDown 标记服务正在关闭（之后的就绪检查均返回未就绪）
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// ShuttingDown 服务是否正在关闭
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Check 执行所有依赖检查
+func (c *Checker) Check(ctx context.Context) *Report {
+	report := &Report{
+		Status:     StatusReady,
+		Components: make(map[string]ComponentStatus),
+	}
+	if c.ShuttingDown() {
+		report.Status = StatusShuttingDown
+		return report
+	}
+
+	c.mu.RLock()
+	checks := make([]check, len(c.checks))
+	copy(checks, c.checks)
+	c.mu.RUnlock()
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].name < checks[j].name
+	})
+
+	results := make([]ComponentStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk.fn)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	for i, chk := range checks {
+		report.Components[chk.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+// run 执行单个检查（检查函数不响应context时同样按超时返回）
+func (c *Checker) run(ctx context.Context, fn CheckFunc) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %v", c.timeout)
+	}
+
+	status := ComponentStatus{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		wantStatus string
+		wantDown   map[string]string
+	}{
+		{name: "all up", checks: map[string]CheckFunc{"storage": up, "token_cache": up}, wantStatus: StatusReady},
+		{name: "no checks", wantStatus: StatusReady},
+		{
+			name:       "dependency down",
+			checks:     map[string]CheckFunc{"storage": up, "token_cache": func(context.Context) error { return errors.New("connection refused") }},
+			wantStatus: StatusNotReady,
+			wantDown:   map[string]string{"token_cache": "connection refused"},
+		},
+		{
+			// 检查函数不响应context时同样按超时返回
+			name:       "timeout",
+			checks:     map[string]CheckFunc{"storage": func(context.Context) error { time.Sleep(time.Second); return nil }},
+			wantStatus: StatusNotReady,
+			wantDown:   map[string]string{"storage": "timed out after 20ms"},
+		},
+		{
+			name:       "panic",
+			checks:     map[string]CheckFunc{"signing_key": func(context.Context) error { panic("no key") }},
+			wantStatus: StatusNotReady,
+			wantDown:   map[string]string{"signing_key": "check panicked: no key"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(20 * time.Millisecond)
+			for name, fn := range tt.checks {
+				c.Register(name, fn)
+			}
+
+			report := c.Check(context.Background())
+			if report.Status != tt.wantStatus || len(report.Components) != len(tt.checks) {
+				t.Fatalf("Check = %+v; want status %s with %d components", report, tt.wantStatus, len(tt.checks))
+			}
+			for name, component := range report.Components {
+				wantErr, down := tt.wantDown[name]
+				if down && (component.Status != StatusDown || component.Error != wantErr) {
+					t.Fatalf("%s = %+v; want down with %q", name, component, wantErr)
+				}
+				if !down && component.Status != StatusUp {
+					t.Fatalf("%s = %+v; want up", name, component)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerShuttingDown(t *testing.T) {
+	c := NewChecker(0)
+	c.Register("storage", func(context.Context) error { return errors.New("down") })
+	c.Register("storage", func(context.Context) error { return nil }) // 同名检查被替换
+	if report := c.Check(context.Background()); !report.Ready() || len(report.Components) != 1 {
+		t.Fatalf("Check = %+v; want ready with the replaced check", report)
+	}
+
+	c.SetShuttingDown()
+	if report := c.Check(context.Background()); report.Ready() || report.Status != StatusShuttingDown {
+		t.Fatalf("Check after SetShuttingDown = %+v; want shutting_down", report)
+	}
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(func(context.Context) error {
+		calls++
+		return errors.New("down")
+	}, 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if err := check(context.Background()); err == nil {
+			t.Fatal("cached check lost the error")
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("check ran %d times within the ttl; want 1", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	check(context.Background())
+	if calls != 2 {
+		t.Fatalf("check ran %d times after the ttl; want 2", calls)
+	}
+}
@@ -0,0 +1,539 @@
+// Package importer 从上游Yggdrasil/Mojang服务器导入材质
+package importer
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	defaultTimeout  = 10 * time.Second
+	keyCacheTTL     = time.Hour   // 上游公钥缓存时间
+	maxResponseSize = 1024 * 1024 // 上游API响应大小上限
+	userAgent       = "yggdrasil-api-go"
+)
+
+var (
+	// ErrUpstreamNotFound 未配置的上游
+	ErrUpstreamNotFound = errors.New("upstream not found")
+	// ErrProfileNotFound 上游角色不存在
+	ErrProfileNotFound = errors.New("upstream profile not found")
+	// ErrInvalidSignature 上游材质签名无效
+	ErrInvalidSignature = errors.New("invalid textures signature")
+	// ErrDomainNotAllowed 材质地址不在皮肤域名白名单中
+	ErrDomainNotAllowed = errors.New("texture domain is not allowed")
+	// ErrNoTextures 没有可导入的材质
+	ErrNoTextures = errors.New("no importable textures")
+)
+
+// Request 材质导入请求
+type Request struct {
+	Upstream  string                // 上游名称
+	Source    string                // 上游角色名或UUID
+	ProfileID string                // 目标角色UUID
+	Types     []storage.TextureType // 导入的材质类型，为空时导入所有可上传的类型
+	Actor     string                // 操作者（写入材质变更历史）
+	ActorIP   string                // 操作者IP
+}
+
+// Result 材质导入结果
+type Result struct {
+	Upstream   string                                       `json:"upstream"`
+	SourceID   string                                       `json:"source_id"`
+	SourceName string                                       `json:"source_name"`
+	Textures   map[storage.TextureType]*storage.TextureInfo `json:"textures"`
+}
+
+// Importer 材质导入器
+type Importer struct {
+	cfg            *config.Config
+	storage        storage.Storage
+	client         *http.Client // 请求上游API
+	downloadClient *http.Client // 下载材质（重定向同样校验白名单）
+	upstreams      map[string]*upstream
+}
+
+// upstream 上游服务器（已推导地址）
+type upstream struct {
+	name          string
+	profileURL    string
+	sessionURL    string
+	publicKeyURL  string
+	publicKeyPath string
+
+	mu            sync.Mutex
+	keys          []*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewImporter 创建材质导入器
+func NewImporter(cfg *config.Config, store storage.Storage) (*Importer, error) {
+	timeout := cfg.Texture.Import.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	i := &Importer{
+		cfg:       cfg,
+		storage:   store,
+		client:    &http.Client{Timeout: timeout},
+		upstreams: make(map[string]*upstream),
+	}
+	i.downloadClient = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("too many redirects")
+			}
+			return i.checkTextureURL(req.URL)
+		},
+	}
+
+	for _, upstreamConfig := range cfg.Texture.Import.Upstreams {
+		u, err := newUpstream(upstreamConfig)
+		if err != nil {
+			return nil, err
+		}
+		i.upstreams[u.name] = u
+	}
+
+	return i, nil
+}
+
+// newUpstream 根据配置推导上游地址（api_root按authlib-injector规范推导）
+func newUpstream(cfg config.TextureUpstreamConfig) (*upstream, error) {
+	u := &upstream{
+		name:          cfg.Name,
+		profileURL:    strings.TrimRight(cfg.ProfileURL, "/"),
+		sessionURL:    strings.TrimRight(cfg.SessionURL, "/"),
+		publicKeyURL:  cfg.PublicKeyURL,
+		publicKeyPath: cfg.PublicKeyPath,
+	}
+
+	if cfg.APIRoot != "" {
+		root := strings.TrimRight(cfg.APIRoot, "/")
+		if u.profileURL == "" {
+			u.profileURL = root + "/api/users/profiles/minecraft"
+		}
+		if u.sessionURL == "" {
+			u.sessionURL = root + "/sessionserver/session/minecraft/profile"
+		}
+		if u.publicKeyURL == "" && u.publicKeyPath == "" {
+			u.publicKeyURL = root + "/"
+		}
+	}
+
+	if u.sessionURL == "" {
+		return nil, fmt.Errorf("texture import upstream %s: session url is required", cfg.Name)
+	}
+	if u.publicKeyURL == "" && u.publicKeyPath == "" {
+		return nil, fmt.Errorf("texture import upstream %s: public key is required", cfg.Name)
+	}
+
+	return u, nil
+}
+
+// GetUpstreams 获取已配置的上游名称
+func (i *Importer) GetUpstreams() []string {
+	names := make([]string, 0, len(i.cfg.Texture.Import.Upstreams))
+	for _, upstreamConfig := range i.cfg.Texture.Import.Upstreams {
+		names = append(names, upstreamConfig.Name)
+	}
+	return names
+}
+
+// Import 从上游导入材质：查询角色、校验textures签名、经白名单下载材质，再走正常上传流程
+func (i *Importer) Import(ctx context.Context, req *Request) (*Result, error) {
+	u, ok := i.upstreams[req.Upstream]
+	if !ok {
+		return nil, ErrUpstreamNotFound
+	}
+
+	// 查询上游角色UUID
+	sourceID, err := i.resolveProfileID(ctx, u, req.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取并校验textures属性
+	payload, sourceName, err := i.fetchTextures(ctx, u, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先下载并校验所有材质，避免只导入一部分
+	type pendingTexture struct {
+		def  *storage.TextureTypeDefinition
+		data []byte
+		slim bool
+	}
+	var pending []pendingTexture
+	for name, texture := range payload.Textures {
+		def, ok := storage.LookupTextureType(name)
+		if !ok || !def.Upload || !wantTextureType(req.Types, def.Type) {
+			continue
+		}
+
+		data, err := i.download(ctx, texture.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", strings.ToLower(string(def.Type)), err)
+		}
+		if err := def.ValidateImage(data); err != nil {
+			return nil, err
+		}
+
+		pending = append(pending, pendingTexture{
+			def:  def,
+			data: data,
+			slim: def.Type == storage.TextureTypeSkin && texture.Metadata["model"] == "slim",
+		})
+	}
+
+	if len(pending) == 0 {
+		return nil, ErrNoTextures
+	}
+
+	result := &Result{
+		Upstream:   u.name,
+		SourceID:   sourceID,
+		SourceName: sourceName,
+		Textures:   make(map[storage.TextureType]*storage.TextureInfo),
+	}
+
+	// 覆盖前的材质由存储写入历史记录
+	uploadCtx := storage.WithTextureChange(ctx, storage.TextureChange{
+		Action:  storage.TextureActionImport,
+		Actor:   req.Actor,
+		ActorIP: req.ActorIP,
+	})
+	for _, p := range pending {
+		metadata := &storage.TextureMetadata{
+			FileSize:   int64(len(p.data)),
+			UploadedAt: time.Now(),
+			Slim:       p.slim,
+		}
+		if p.slim {
+			metadata.Model = "slim"
+		}
+
+		textureInfo, err := i.storage.UploadTexture(uploadCtx, p.def.Type, req.ProfileID, p.data, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", strings.ToLower(string(p.def.Type)), err)
+		}
+		result.Textures[p.def.Type] = textureInfo
+	}
+
+	return result, nil
+}
+
+// wantTextureType 检查是否需要导入该材质类型
+func wantTextureType(types []storage.TextureType, textureType storage.TextureType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == textureType {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveProfileID 将上游角色名或UUID解析为无符号UUID
+func (i *Importer) resolveProfileID(ctx context.Context, u *upstream, source string) (string, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return "", ErrProfileNotFound
+	}
+
+	if id, ok := normalizeUUID(source); ok {
+		return id, nil
+	}
+
+	if u.profileURL == "" {
+		return "", fmt.Errorf("upstream %s does not support name lookup", u.name)
+	}
+
+	var profile struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := i.getJSON(ctx, u.profileURL+"/"+url.PathEscape(source), &profile); err != nil {
+		return "", err
+	}
+	id, ok := normalizeUUID(profile.ID)
+	if !ok {
+		return "", ErrProfileNotFound
+	}
+
+	return id, nil
+}
+
+// normalizeUUID 将UUID转换为无符号小写格式，非UUID时返回false
+func normalizeUUID(s string) (string, bool) {
+	id := strings.ToLower(utils.RemoveUUIDHyphens(s))
+	if len(id) != 32 {
+		return "", false
+	}
+	for _, ch := range id {
+		if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// texturesPayload textures属性解码后的内容
+type texturesPayload struct {
+	Timestamp   int64  `json:"timestamp"`
+	ProfileID   string `json:"profileId"`
+	ProfileName string `json:"profileName"`
+	Textures    map[string]struct {
+		URL      string            `json:"url"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"textures"`
+}
+
+// fetchTextures 获取上游角色档案并校验textures属性签名
+func (i *Importer) fetchTextures(ctx context.Context, u *upstream, profileID string) (*texturesPayload, string, error) {
+	var profile struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Properties []struct {
+			Name      string `json:"name"`
+			Value     string `json:"value"`
+			Signature string `json:"signature"`
+		} `json:"properties"`
+	}
+	if err := i.getJSON(ctx, u.sessionURL+"/"+profileID+"?unsigned=false", &profile); err != nil {
+		return nil, "", err
+	}
+
+	for _, property := range profile.Properties {
+		if property.Name != "textures" {
+			continue
+		}
+
+		if property.Signature == "" {
+			return nil, "", fmt.Errorf("%w: property is not signed", ErrInvalidSignature)
+		}
+		if err := i.verifySignature(ctx, u, property.Value, property.Signature); err != nil {
+			return nil, "", err
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(property.Value)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid textures property: %w", err)
+		}
+
+		var payload texturesPayload
+		if err := sonic.Unmarshal(decoded, &payload); err != nil {
+			return nil, "", fmt.Errorf("invalid textures property: %w", err)
+		}
+
+		// 签名的内容必须属于查询的角色
+		if id, _ := normalizeUUID(payload.ProfileID); id != profileID {
+			return nil, "", fmt.Errorf("%w: profile mismatch", ErrInvalidSignature)
+		}
+
+		return &payload, profile.Name, nil
+	}
+
+	return nil, "", ErrNoTextures
+}
+
+// verifySignature 使用上游公钥校验签名（校验失败时刷新一次公钥，以应对上游更换密钥）
+func (i *Importer) verifySignature(ctx context.Context, u *upstream, value, signature string) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		keys, fresh, err := i.publicKeys(ctx, u, attempt > 0)
+		if err != nil {
+			return fmt.Errorf("failed to load upstream public key: %w", err)
+		}
+
+		for _, key := range keys {
+			if utils.VerifySignatureWithRSAKey(value, signature, key) == nil {
+				return nil
+			}
+		}
+
+		if fresh {
+			break
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// publicKeys 获取上游签名公钥（带缓存），返回值fresh表示是否为本次新加载
+func (i *Importer) publicKeys(ctx context.Context, u *upstream, refresh bool) ([]*rsa.PublicKey, bool, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !refresh && len(u.keys) > 0 && time.Since(u.keysFetchedAt) < keyCacheTTL {
+		return u.keys, false, nil
+	}
+
+	var keys []*rsa.PublicKey
+	if u.publicKeyPath != "" {
+		data, err := os.ReadFile(u.publicKeyPath)
+		if err != nil {
+			return nil, false, err
+		}
+		key, err := utils.ParsePublicKey(string(data))
+		if err != nil {
+			return nil, false, err
+		}
+		keys = append(keys, key)
+	} else {
+		// 兼容authlib-injector API元数据（signaturePublickey）和Mojang publickeys（profilePropertyKeys）
+		var published struct {
+			SignaturePublickey  string `json:"signaturePublickey"`
+			ProfilePropertyKeys []struct {
+				PublicKey string `json:"publicKey"`
+			} `json:"profilePropertyKeys"`
+		}
+		if err := i.getJSON(ctx, u.publicKeyURL, &published); err != nil {
+			return nil, false, err
+		}
+
+		if published.SignaturePublickey != "" {
+			key, err := utils.ParsePublicKey(published.SignaturePublickey)
+			if err != nil {
+				return nil, false, err
+			}
+			keys = append(keys, key)
+		}
+		for _, propertyKey := range published.ProfilePropertyKeys {
+			der, err := base64.StdEncoding.DecodeString(propertyKey.PublicKey)
+			if err != nil {
+				continue
+			}
+			key, err := x509.ParsePKIXPublicKey(der)
+			if err != nil {
+				continue
+			}
+			if rsaKey, ok := key.(*rsa.PublicKey); ok {
+				keys = append(keys, rsaKey)
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, false, fmt.Errorf("no public key published")
+	}
+
+	u.keys = keys
+	u.keysFetchedAt = time.Now()
+	return keys, true, nil
+}
+
+// getJSON 请求上游API并解析JSON（204/404视为角色不存在）
+func (i *Importer) getJSON(ctx context.Context, rawURL string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upstream request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound:
+		return ErrProfileNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read upstream response: %w", err)
+	}
+
+	if err := sonic.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("invalid upstream response: %w", err)
+	}
+	return nil
+}
+
+// checkTextureURL 检查材质地址是否在皮肤域名白名单中
+func (i *Importer) checkTextureURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrDomainNotAllowed, u.Scheme)
+	}
+	// 皮肤域名白名单可热重载
+	cfg := config.Current()
+	if cfg == nil {
+		cfg = i.cfg
+	}
+	if host := u.Hostname(); host == "" || !cfg.IsAllowedSkinDomain(host) {
+		return fmt.Errorf("%w: %s", ErrDomainNotAllowed, host)
+	}
+	return nil
+}
+
+// download 下载材质文件（限制地址和大小）
+func (i *Importer) download(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid texture url: %w", err)
+	}
+	if err := i.checkTextureURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := i.downloadClient.Do(req)
+	if err != nil {
+		// 重定向被拒绝时保留白名单错误
+		if errors.Is(err, ErrDomainNotAllowed) {
+			return nil, ErrDomainNotAllowed
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("texture server returned status %d", resp.StatusCode)
+	}
+
+	maxSize := i.cfg.Texture.MaxFileSize
+	if maxSize <= 0 {
+		maxSize = 1024 * 1024
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("texture file too large")
+	}
+
+	return data, nil
+}
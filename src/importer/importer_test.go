@@ -0,0 +1,273 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yggdrasil-api-go/src/config"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	stubProfileID   = "069a79f444e94726a5befca90e38aaf5"
+	stubProfileName = "Notch"
+	targetProfileID = "5627dd98e6be3c21b8a8e92344183641"
+)
+
+// stubUpstream 本地上游服务器（authlib-injector API布局）
+type stubUpstream struct {
+	server     *httptest.Server
+	privateKey string // 签名textures属性的私钥
+	publicKey  string // API元数据中发布的公钥
+
+	signWith      string // 不为空时用该私钥签名（模拟签名无效）
+	unsigned      bool   // 不返回签名
+	payloadID     string // 不为空时textures属性中的profileId
+	textureHost   string // 不为空时材质地址使用的主机
+	redirectSkin  string // 不为空时皮肤地址重定向到该地址
+	textureImages map[string][]byte
+}
+
+func newStubUpstream(t *testing.T) *stubUpstream {
+	t.Helper()
+	privateKey, publicKey, err := utils.GenerateKeyPair(1024)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+
+	s := &stubUpstream{
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		textureImages: map[string][]byte{
+			"skin": encodePNG(t, 64, 64),
+			"cape": encodePNG(t, 64, 32),
+		},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *stubUpstream) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/":
+		writeJSON(w, map[string]any{"signaturePublickey": s.publicKey})
+	case strings.HasPrefix(r.URL.Path, "/api/users/profiles/minecraft/"):
+		if strings.TrimPrefix(r.URL.Path, "/api/users/profiles/minecraft/") != stubProfileName {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, map[string]any{"id": stubProfileID, "name": stubProfileName})
+	case r.URL.Path == "/sessionserver/session/minecraft/profile/"+stubProfileID:
+		s.serveProfile(w)
+	case strings.HasPrefix(r.URL.Path, "/textures/"):
+		name := strings.TrimPrefix(r.URL.Path, "/textures/")
+		if name == "skin" && s.redirectSkin != "" {
+			http.Redirect(w, r, s.redirectSkin, http.StatusFound)
+			return
+		}
+		data, ok := s.textureImages[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *stubUpstream) serveProfile(w http.ResponseWriter) {
+	host := s.server.Listener.Addr().String()
+	if s.textureHost != "" {
+		host = s.textureHost
+	}
+	payloadID := stubProfileID
+	if s.payloadID != "" {
+		payloadID = s.payloadID
+	}
+
+	payload, _ := sonic.Marshal(map[string]any{
+		"timestamp":   1,
+		"profileId":   payloadID,
+		"profileName": stubProfileName,
+		"textures": map[string]any{
+			"SKIN": map[string]any{"url": "http://" + host + "/textures/skin", "metadata": map[string]string{"model": "slim"}},
+			"CAPE": map[string]any{"url": "http://" + host + "/textures/cape"},
+		},
+	})
+	value := base64.StdEncoding.EncodeToString(payload)
+
+	property := map[string]string{"name": "textures", "value": value}
+	if !s.unsigned {
+		key := s.privateKey
+		if s.signWith != "" {
+			key = s.signWith
+		}
+		signature, err := utils.SignData(value, key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		property["signature"] = signature
+	}
+
+	writeJSON(w, map[string]any{
+		"id":         stubProfileID,
+		"name":       stubProfileName,
+		"properties": []map[string]string{property},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	data, _ := sonic.Marshal(v)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// recordingStorage 记录上传的材质（只实现导入使用的UploadTexture）
+type recordingStorage struct {
+	storage.Storage
+	uploads map[storage.TextureType]*storage.TextureMetadata
+	changes []storage.TextureChange
+}
+
+func (r *recordingStorage) UploadTexture(ctx context.Context, textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
+	if playerUUID != targetProfileID {
+		return nil, fmt.Errorf("unexpected profile %s", playerUUID)
+	}
+	r.uploads[textureType] = metadata
+	r.changes = append(r.changes, storage.TextureChangeFromContext(ctx, storage.TextureActionUpload))
+	return &storage.TextureInfo{Type: textureType, Metadata: metadata}, nil
+}
+
+func newTestImporter(t *testing.T, upstream *stubUpstream, skinDomains []string) (*Importer, *recordingStorage) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Texture.MaxFileSize = 1024 * 1024
+	cfg.Texture.Import.Upstreams = []config.TextureUpstreamConfig{{Name: "stub", APIRoot: upstream.server.URL}}
+	cfg.Yggdrasil.SkinDomains = skinDomains
+
+	store := &recordingStorage{uploads: make(map[storage.TextureType]*storage.TextureMetadata)}
+	importer, err := NewImporter(cfg, store)
+	if err != nil {
+		t.Fatalf("NewImporter: %v", err)
+	}
+	return importer, store
+}
+
+func TestImport(t *testing.T) {
+	otherKey, _, err := utils.GenerateKeyPair(1024)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		source      string
+		skinDomains []string
+		setup       func(s *stubUpstream)
+		wantErr     error
+	}{
+		{name: "by name", source: stubProfileName, skinDomains: []string{"127.0.0.1"}},
+		{name: "by hyphenated uuid", source: "069a79f4-44e9-4726-a5be-fca90e38aaf5", skinDomains: []string{"127.0.0.1"}},
+		{name: "unknown name", source: "Nobody", wantErr: ErrProfileNotFound},
+		{name: "signed by another key", source: stubProfileName, setup: func(s *stubUpstream) { s.signWith = otherKey }, wantErr: ErrInvalidSignature},
+		{name: "unsigned property", source: stubProfileName, setup: func(s *stubUpstream) { s.unsigned = true }, wantErr: ErrInvalidSignature},
+		{name: "payload of another profile", source: stubProfileName, setup: func(s *stubUpstream) { s.payloadID = targetProfileID }, wantErr: ErrInvalidSignature},
+		{name: "empty skin domains", source: stubProfileName, wantErr: ErrDomainNotAllowed},
+		{name: "texture domain not allowed", source: stubProfileName, skinDomains: []string{"textures.example.com"}, wantErr: ErrDomainNotAllowed},
+		{name: "redirect to domain not allowed", source: stubProfileName, skinDomains: []string{"127.0.0.1"}, setup: func(s *stubUpstream) {
+			s.redirectSkin = "http://localhost:1/textures/skin"
+		}, wantErr: ErrDomainNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := newStubUpstream(t)
+			if tt.setup != nil {
+				tt.setup(upstream)
+			}
+			importer, store := newTestImporter(t, upstream, tt.skinDomains)
+
+			result, err := importer.Import(context.Background(), &Request{
+				Upstream:  "stub",
+				Source:    tt.source,
+				ProfileID: targetProfileID,
+				Actor:     "1",
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Import error = %v; want %v", err, tt.wantErr)
+				}
+				if len(store.uploads) != 0 {
+					t.Fatalf("uploaded %d textures after failed import", len(store.uploads))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			if result.SourceID != stubProfileID || result.SourceName != stubProfileName {
+				t.Fatalf("source = %s/%s; want %s/%s", result.SourceID, result.SourceName, stubProfileID, stubProfileName)
+			}
+			skin, cape := store.uploads[storage.TextureTypeSkin], store.uploads[storage.TextureTypeCape]
+			if skin == nil || !skin.Slim || skin.Model != "slim" || cape == nil || cape.Slim {
+				t.Fatalf("uploads = skin %+v, cape %+v; want slim skin and cape", skin, cape)
+			}
+			for _, change := range store.changes {
+				if change.Action != storage.TextureActionImport || change.Actor != "1" {
+					t.Fatalf("texture change = %+v; want import by 1", change)
+				}
+			}
+		})
+	}
+}
+
+func TestImportUnknownUpstream(t *testing.T) {
+	importer, _ := newTestImporter(t, newStubUpstream(t), nil)
+
+	_, err := importer.Import(context.Background(), &Request{Upstream: "mojang", Source: stubProfileName, ProfileID: targetProfileID})
+	if !errors.Is(err, ErrUpstreamNotFound) {
+		t.Fatalf("Import error = %v; want ErrUpstreamNotFound", err)
+	}
+}
+
+func TestImportReloadedSkinDomains(t *testing.T) {
+	importer, store := newTestImporter(t, newStubUpstream(t), []string{"127.0.0.1"})
+
+	reloaded := *importer.cfg
+	reloaded.Yggdrasil.SkinDomains = []string{"textures.example.com"}
+	config.SetCurrent(&reloaded)
+	t.Cleanup(func() { config.SetCurrent(nil) })
+
+	_, err := importer.Import(context.Background(), &Request{Upstream: "stub", Source: stubProfileName, ProfileID: targetProfileID})
+	if !errors.Is(err, ErrDomainNotAllowed) {
+		t.Fatalf("Import error = %v; want ErrDomainNotAllowed", err)
+	}
+	if len(store.uploads) != 0 {
+		t.Fatalf("uploaded %d textures after failed import", len(store.uploads))
+	}
+}
@@ -0,0 +1,98 @@
+// Package logging 请求范围的日志字段
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// fieldsKey 请求字段在context中的键
+type fieldsKey struct{}
+
+// RequestFields 请求范围的日志字段
+// 由请求日志中间件创建，处理器认证后补充用户ID和角色ID，同一请求内的日志自动带上这些字段
+type RequestFields struct {
+	mu        sync.RWMutex
+	requestID string
+	route     string
+	clientIP  string
+	userID    string
+	profileID string
+}
+
+// NewContext 在context中附加请求字段
+func NewContext(ctx context.Context, requestID, route, clientIP string) (context.Context, *RequestFields) {
+	fields := &RequestFields{
+		requestID: requestID,
+		route:     route,
+		clientIP:  clientIP,
+	}
+	return context.WithValue(ctx, fieldsKey{}, fields), fields
+}
+
+// FieldsFromContext 获取context中的请求字段（不在请求中时返回nil）
+func FieldsFromContext(ctx context.Context) *RequestFields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(*RequestFields)
+	return fields
+}
+
+// SetUser 记录当前请求的用户ID
+func SetUser(ctx context.Context, userID string) {
+	if fields := FieldsFromContext(ctx); fields != nil && userID != "" {
+		fields.mu.Lock()
+		fields.userID = userID
+		fields.mu.Unlock()
+	}
+}
+
+// SetProfile 记录当前请求的角色ID
+func SetProfile(ctx context.Context, profileID string) {
+	if fields := FieldsFromContext(ctx); fields != nil && profileID != "" {
+		fields.mu.Lock()
+		fields.profileID = profileID
+		fields.mu.Unlock()
+	}
+}
+
+// RequestID 请求ID
+func (f *RequestFields) RequestID() string {
+	return f.requestID
+}
+
+// UserID 用户ID
+func (f *RequestFields) UserID() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.userID
+}
+
+// ProfileID 角色ID
+func (f *RequestFields) ProfileID() string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.profileID
+}
+
+// Attrs 转换为日志属性（省略空字段）
+func (f *RequestFields) Attrs() []slog.Attr {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	attrs := make([]slog.Attr, 0, 5)
+	for _, field := range []struct{ key, value string }{
+		{"request_id", f.requestID},
+		{"route", f.route},
+		{"client_ip", f.clientIP},
+		{"user_id", f.userID},
+		{"profile_id", f.profileID},
+	} {
+		if field.value != "" {
+			attrs = append(attrs, slog.String(field.key, field.value))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,220 @@
+// Package logging 结构化日志（log/slog），按logging配置选择级别、格式和输出
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm/logger"
+)
+
+// level 全局日志级别（可在运行时调整）
+var level = new(slog.LevelVar)
+
+// Init 按配置初始化全局日志记录器，返回关闭函数（退出前调用以关闭日志文件）
+// 本项目的日志均直接调用slog；标准库log只剩第三方库（如net/http的连接错误），以Warn级别转发
+func Init(cfg config.LoggingConfig) (func() error, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, err
+	}
+
+	var (
+		out     io.Writer
+		closeFn = func() error { return nil }
+	)
+	switch cfg.Output {
+	case "", "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	case "file":
+		file, err := NewRotatingFile(cfg.File, cfg.MaxSize, cfg.MaxBackups, cfg.MaxAge, cfg.Compress)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+		closeFn = file.Close
+	default:
+		return nil, fmt.Errorf("unsupported logging output: %s", cfg.Output)
+	}
+
+	handler, err := NewHandler(out, cfg.Format)
+	if err != nil {
+		closeFn()
+		return nil, err
+	}
+
+	slog.SetDefault(slog.New(handler))
+
+	// slog.SetDefault会把标准库log转发到slog（固定为Info级别），第三方库通过log输出的多为异常，改为Warn级别
+	log.SetFlags(0)
+	log.SetOutput(StdWriter(slog.LevelWarn))
+
+	return closeFn, nil
+}
+
+// NewHandler 创建带脱敏和请求字段的日志Handler
+func NewHandler(out io.Writer, format string) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("unsupported logging format: %s", format)
+	}
+	return &contextHandler{Handler: handler}, nil
+}
+
+// SetLevel 设置日志级别（debug, info, warn, error）
+func SetLevel(name string) error {
+	switch strings.ToLower(name) {
+	case "debug":
+		level.Set(slog.LevelDebug)
+	case "", "info":
+		level.Set(slog.LevelInfo)
+	case "warn", "warning":
+		level.Set(slog.LevelWarn)
+	case "error":
+		level.Set(slog.LevelError)
+	default:
+		return fmt.Errorf("unsupported logging level: %s", name)
+	}
+	return nil
+}
+
+// Enabled 判断指定级别的日志是否会被输出
+func Enabled(l slog.Level) bool {
+	return l >= level.Level()
+}
+
+// contextHandler 记录时附加context中的请求字段和Trace ID，并对消息文本脱敏
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle 处理日志记录
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if redactedMessage := Redact(record.Message); redactedMessage != record.Message {
+		clone := slog.NewRecord(record.Time, record.Level, redactedMessage, record.PC)
+		record.Attrs(func(attr slog.Attr) bool {
+			clone.AddAttrs(attr)
+			return true
+		})
+		record = clone
+	}
+
+	if fields := FieldsFromContext(ctx); fields != nil {
+		record.AddAttrs(fields.Attrs()...)
+	}
+	// 启用链路追踪时关联Trace ID
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 返回附加属性的Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 返回带分组的Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// redactAttr 敏感字段整体替换，字符串和错误值中的密码和令牌脱敏
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if IsSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			return slog.String(attr.Key, Redact(err.Error()))
+		}
+	}
+	return attr
+}
+
+// stdWriter 将标准库log和gin的输出转发到slog
+type stdWriter struct {
+	level slog.Level
+}
+
+// StdWriter 创建以固定级别转发到slog的Writer
+func StdWriter(l slog.Level) io.Writer {
+	return &stdWriter{level: l}
+}
+
+// Write 按行写入日志
+func (w *stdWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		message := strings.TrimSpace(string(line))
+		if message == "" {
+			continue
+		}
+		emit(w.level, message)
+	}
+	return len(p), nil
+}
+
+// emit 以指定级别写入一条日志
+func emit(l slog.Level, message string) {
+	if !Enabled(l) {
+		return
+	}
+	slog.Default().Handler().Handle(context.Background(), slog.NewRecord(time.Now(), l, message, 0))
+}
+
+// gormWriter 将GORM日志转发到slog
+type gormWriter struct{}
+
+// Printf 写入GORM日志（GORM的日志为"调用位置 [错误]\n[耗时] [行数] SQL"格式，合并为一行）
+func (gormWriter) Printf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	location, _, _ := strings.Cut(message, "\n")
+
+	l := slog.LevelInfo
+	switch {
+	case strings.Contains(message, "[error]"):
+		l = slog.LevelError
+	case strings.Contains(message, "SLOW SQL"), strings.Contains(message, "[warn]"):
+		l = slog.LevelWarn
+	case strings.Contains(location, " "):
+		// 调用位置后带有错误信息
+		l = slog.LevelError
+	}
+
+	emit(l, strings.ReplaceAll(message, "\n", " "))
+}
+
+// NewGormLogger 创建输出到全局日志记录器的GORM日志（SQL语句为Info级别，慢查询和错误为Warn/Error级别）
+func NewGormLogger(logLevel logger.LogLevel) logger.Interface {
+	return logger.New(gormWriter{}, logger.Config{
+		SlowThreshold:             200 * time.Millisecond,
+		LogLevel:                  logLevel,
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  false,
+	})
+}
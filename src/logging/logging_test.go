@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestHandler(t *testing.T) {
+	if err := SetLevel("info"); err != nil {
+		t.Fatalf("SetLevel: %v", err)
+	}
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, "json")
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	logger := slog.New(handler)
+
+	ctx, fields := NewContext(context.Background(), "req-1", "/authserver/authenticate", "127.0.0.1")
+	SetUser(ctx, "1")
+	logger.DebugContext(ctx, "not logged at info level")
+	logger.InfoContext(ctx, "login failed password=hunter2", "accessToken", "abc", "error", errors.New(`{"password":"hunter2"}`))
+
+	var record map[string]any
+	if err := sonic.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log output %q is not a single JSON record: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":         "login failed password=[REDACTED]",
+		"accessToken": "[REDACTED]",
+		"error":       `{"password":"[REDACTED]"}`,
+		"request_id":  "req-1",
+		"route":       "/authserver/authenticate",
+		"client_ip":   "127.0.0.1",
+		"user_id":     "1",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v; want %v", key, record[key], value)
+		}
+	}
+	if _, ok := record["profile_id"]; ok || fields.ProfileID() != "" {
+		t.Error("empty profile_id was logged")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	defer SetLevel("info")
+
+	if err := SetLevel("WARNING"); err != nil || Enabled(slog.LevelInfo) || !Enabled(slog.LevelWarn) {
+		t.Fatalf("SetLevel(WARNING) = %v; want info disabled and warn enabled", err)
+	}
+	if err := SetLevel("verbose"); err == nil {
+		t.Fatal("SetLevel accepted an unknown level")
+	}
+	if _, err := NewHandler(&bytes.Buffer{}, "xml"); err == nil || !strings.Contains(err.Error(), "format") {
+		t.Fatalf("NewHandler(xml) = %v; want unsupported format error", err)
+	}
+}
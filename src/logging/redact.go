@@ -0,0 +1,55 @@
+// Package logging 日志脱敏（密码和访问令牌）
+package logging
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redacted 脱敏后的占位符
+const redacted = "[REDACTED]"
+
+// sensitiveKeys 需要脱敏的字段名（小写，去掉下划线和连字符后比较）
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"newpassword":   true,
+	"oldpassword":   true,
+	"accesstoken":   true,
+	"clienttoken":   true,
+	"refreshtoken":  true,
+	"token":         true,
+	"authorization": true,
+	"secret":        true,
+	"jwtsecret":     true,
+	"privatekey":    true,
+}
+
+var (
+	// JSON字段："password": "xxx"（截断的请求体中未闭合的值同样脱敏）
+	jsonFieldPattern = regexp.MustCompile(`(?i)("(?:password|new_?password|old_?password|access_?token|client_?token|refresh_?token|token|secret|authorization)"\s*:\s*)"(?:[^"\\]|\\.)*(?:"|\\?$)`)
+	// 查询参数和表单：password=xxx
+	queryFieldPattern = regexp.MustCompile(`(?i)\b((?:password|access_?token|client_?token|refresh_?token|token)=)[^&\s"]+`)
+	// Authorization请求头：Bearer xxx
+	bearerPattern = regexp.MustCompile(`(?i)\b(Bearer\s+)[A-Za-z0-9._~+/=-]+`)
+	// JWT格式的访问令牌
+	jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+// IsSensitiveKey 判断字段名是否需要脱敏
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	key = strings.NewReplacer("_", "", "-", "").Replace(key)
+	return sensitiveKeys[key]
+}
+
+// Redact 去除文本中的密码和访问令牌（请求体、错误信息等）
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	s = jsonFieldPattern.ReplaceAllString(s, `$1"`+redacted+`"`)
+	s = queryFieldPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	return s
+}
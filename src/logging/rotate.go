@@ -0,0 +1,282 @@
+// Package logging 按大小轮转的日志文件
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 轮转文件名中的时间格式（文件名中不能包含冒号）
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile 按大小轮转的日志文件
+// 当前文件超过MaxSize时重命名为 name-<时间>.ext，并按MaxBackups/MaxAge清理旧文件，可选gzip压缩
+type RotatingFile struct {
+	Filename   string // 日志文件路径
+	MaxSize    int    // 单个文件最大大小（MB），0表示不按大小轮转
+	MaxBackups int    // 保留的旧文件数量，0表示不限制
+	MaxAge     int    // 旧文件保留天数，0表示不限制
+	Compress   bool   // 是否gzip压缩旧文件
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	cleanupMu sync.Mutex // 清理在后台执行，同一时间只执行一个
+}
+
+// NewRotatingFile 创建按大小轮转的日志文件
+func NewRotatingFile(filename string, maxSize, maxBackups, maxAge int, compress bool) (*RotatingFile, error) {
+	f := &RotatingFile{
+		Filename:   filename,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+		Compress:   compress,
+	}
+
+	// 启动时打开文件，路径不可写时尽早报错
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write 写入日志，超过大小上限时先轮转
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if limit := f.maxBytes(); limit > 0 && f.size > 0 && f.size+int64(len(p)) > limit {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即轮转日志文件
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Close 关闭日志文件
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// maxBytes 单个文件最大字节数
+func (f *RotatingFile) maxBytes() int64 {
+	return int64(f.MaxSize) * 1024 * 1024
+}
+
+// open 以追加方式打开日志文件（调用方持有锁）
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Filename), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate 重命名当前文件并打开新文件（调用方持有锁）
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		f.file = nil
+	}
+
+	if _, err := os.Stat(f.Filename); err == nil {
+		// 同一毫秒内多次轮转时顺延时间，避免覆盖已有的旧文件
+		backupTime := time.Now()
+		for f.backupExists(backupTime) {
+			backupTime = backupTime.Add(time.Millisecond)
+		}
+		if err := os.Rename(f.Filename, f.backupName(backupTime)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	go f.cleanup()
+	return nil
+}
+
+// backupName 轮转后的文件名
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	return filepath.Join(dir, prefix+t.Format(backupTimeFormat)+ext)
+}
+
+// backupExists 检查指定时间的旧文件（含压缩后的文件）是否已存在
+func (f *RotatingFile) backupExists(t time.Time) bool {
+	name := f.backupName(t)
+	for _, path := range []string{name, name + ".gz"} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// nameParts 拆分日志文件名：目录、旧文件前缀（name-）和扩展名
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.Filename)
+	base := filepath.Base(f.Filename)
+	ext = filepath.Ext(base)
+	prefix = strings.TrimSuffix(base, ext) + "-"
+	return dir, prefix, ext
+}
+
+// backupFile 旧日志文件
+type backupFile struct {
+	path      string
+	timestamp time.Time
+}
+
+// listBackups 列出旧日志文件（按时间倒序）
+func (f *RotatingFile) listBackups() ([]backupFile, error) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		switch {
+		case strings.HasSuffix(stamp, ext+".gz"):
+			stamp = strings.TrimSuffix(stamp, ext+".gz")
+		case strings.HasSuffix(stamp, ext):
+			stamp = strings.TrimSuffix(stamp, ext)
+		default:
+			continue
+		}
+
+		t, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), timestamp: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups, nil
+}
+
+// cleanup 按数量和保留天数删除旧文件，并压缩未压缩的旧文件
+func (f *RotatingFile) cleanup() {
+	f.cleanupMu.Lock()
+	defer f.cleanupMu.Unlock()
+
+	backups, err := f.listBackups()
+	if err != nil {
+		return
+	}
+
+	var cutoff time.Time
+	if f.MaxAge > 0 {
+		cutoff = time.Now().Add(-time.Duration(f.MaxAge) * 24 * time.Hour)
+	}
+
+	for i, backup := range backups {
+		if (f.MaxBackups > 0 && i >= f.MaxBackups) || (!cutoff.IsZero() && backup.timestamp.Before(cutoff)) {
+			os.Remove(backup.path)
+			continue
+		}
+		if f.Compress && !strings.HasSuffix(backup.path, ".gz") {
+			compressFile(backup.path)
+		}
+	}
+}
+
+// compressFile gzip压缩文件并删除原文件
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewRotatingFile(filepath.Join(dir, "logs", "app.log"), 1, 2, 0, true)
+	if err != nil {
+		t.Fatalf("NewRotatingFile: %v", err)
+	}
+	defer f.Close()
+
+	// 超过1MB时轮转
+	chunk := []byte(strings.Repeat("x", 600*1024))
+	for i := 0; i < 2; i++ {
+		if _, err := f.Write(chunk); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+
+	// 等待后台清理：只保留2个旧文件，且均已压缩
+	var backups []backupFile
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		backups, err = f.listBackups()
+		if err != nil {
+			t.Fatalf("listBackups: %v", err)
+		}
+		compressed := 0
+		for _, backup := range backups {
+			if strings.HasSuffix(backup.path, ".gz") {
+				compressed++
+			}
+		}
+		if len(backups) == 2 && compressed == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("backups = %+v; want 2 compressed files", backups)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	info, err := os.Stat(f.Filename)
+	if err != nil || info.Size() != 0 {
+		t.Fatalf("current log file = %v, %v; want an empty file after rotation", info, err)
+	}
+}
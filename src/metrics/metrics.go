@@ -0,0 +1,134 @@
+// Package metrics Prometheus指标（/metrics按Accept协商或/metrics/prometheus输出文本格式）
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 指标名前缀
+const namespace = "yggdrasil"
+
+// Registry 指标注册表（不使用默认注册表，避免第三方库注册的指标混入）
+var Registry = prometheus.NewRegistry()
+
+var (
+	// requestDuration 请求耗时（按路由模板、方法和状态码）
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request duration by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// dbQueryDuration 数据库查询耗时（按连接名和操作类型）
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query duration by connection and operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"database", "operation"})
+
+	// cacheRequests 缓存查询次数（按缓存名、后端和结果）
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache name, backend and result (hit or miss).",
+	}, []string{"cache", "backend", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		dbQueryDuration,
+		cacheRequests,
+	)
+}
+
+// ObserveRequest 记录请求耗时（route为路由模板，未匹配的请求使用unmatched，避免路径作为标签）
+func ObserveRequest(route, method string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	requestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveDBQuery 记录数据库查询耗时
+func ObserveDBQuery(database, operation string, duration time.Duration) {
+	dbQueryDuration.WithLabelValues(database, operation).Observe(duration.Seconds())
+}
+
+// RecordCache 记录缓存命中或未命中
+func RecordCache(cache, backend string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(cache, backend, result).Inc()
+}
+
+// RegisterDB 注册连接池指标（打开/使用中/空闲连接数、等待次数等，按连接名区分）
+func RegisterDB(name string, db *sql.DB) {
+	register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterGauge 注册在采集时计算的指标（如Token/Session数量）
+func RegisterGauge(name, help string, labels prometheus.Labels, value func() float64) {
+	register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	}, value))
+}
+
+// register 注册采集器（重复注册时忽略，例如同名连接被多次打开）
+func register(collector prometheus.Collector) {
+	if err := Registry.Register(collector); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			panic(err)
+		}
+	}
+}
+
+// Handler Prometheus文本格式（支持OpenMetrics协商）
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// WantsPrometheus 根据Accept头判断是否请求Prometheus/OpenMetrics格式（Prometheus抓取时会发送）
+func WantsPrometheus(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "prometheus" {
+		return true
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		if containsMediaType(accept, "application/openmetrics-text") || containsMediaType(accept, "text/plain") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsMediaType 判断Accept头是否包含指定媒体类型（忽略参数和大小写）
+func containsMediaType(accept, mediaType string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		if i := strings.Index(part, ";"); i >= 0 {
+			part = part[:i]
+		}
+		if strings.EqualFold(strings.TrimSpace(part), mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWantsPrometheus(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   bool
+	}{
+		{name: "json", target: "/metrics", accept: "application/json"},
+		{name: "no accept", target: "/metrics"},
+		{name: "query", target: "/metrics?format=prometheus", want: true},
+		{name: "prometheus scraper", target: "/metrics", accept: "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1", want: true},
+		{name: "text plain", target: "/metrics", accept: "Text/Plain; version=0.0.4", want: true},
+		{name: "media type prefix", target: "/metrics", accept: "text/plainish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := WantsPrometheus(r); got != tt.want {
+				t.Fatalf("WantsPrometheus = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ObserveRequest("/api/profiles/minecraft/:uuid", http.MethodGet, http.StatusOK, 10*time.Millisecond)
+	ObserveRequest("", http.MethodGet, http.StatusNotFound, time.Millisecond)
+	ObserveDBQuery("storage", "query", time.Millisecond)
+	RecordCache("user", "memory", true)
+	RecordCache("user", "memory", false)
+
+	// 重复注册同名指标时忽略
+	for i := 0; i < 2; i++ {
+		RegisterGauge("test_entries", "Test entries.", map[string]string{"cache": "token"}, func() float64 { return 3 })
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/prometheus", nil))
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+
+	for _, want := range []string{
+		`yggdrasil_http_request_duration_seconds_count{method="GET",route="/api/profiles/minecraft/:uuid",status="200"} 1`,
+		`yggdrasil_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		`yggdrasil_db_query_duration_seconds_count{database="storage",operation="query"} 1`,
+		`yggdrasil_cache_requests_total{backend="memory",cache="user",result="hit"} 1`,
+		`yggdrasil_cache_requests_total{backend="memory",cache="user",result="miss"} 1`,
+		`yggdrasil_test_entries{cache="token"} 3`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("exposition is missing %s", want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Package middleware 请求体大小限制中间件
+package middleware
+
+import (
+	"net/http"
+
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxRequestSize 限制请求体大小的中间件（security.max_request_size）
+// Content-Length超出限制时直接返回413，未声明长度的请求体读取超出限制时返回错误
+// routeLimits按路由（gin的FullPath）覆盖限制，如材质上传需要容纳max_file_size；limit为0表示不限制
+func MaxRequestSize(limit int64, routeLimits map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxSize := limit
+		if routeLimit, ok := routeLimits[c.FullPath()]; ok {
+			maxSize = routeLimit
+		}
+		if maxSize <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > maxSize {
+			utils.RespondError(c, http.StatusRequestEntityTooLarge,
+				utils.ErrPayloadTooLarge, utils.MsgRequestTooLarge)
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+		c.Next()
+	}
+}
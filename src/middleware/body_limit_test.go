@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxRequestSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(MaxRequestSize(8, map[string]int64{"/upload": 32}))
+	read := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+	router.POST("/login", read)
+	router.POST("/upload", read)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{name: "within limit", path: "/login", body: "12345678", want: http.StatusNoContent},
+		{name: "content length over limit", path: "/login", body: "123456789", want: http.StatusRequestEntityTooLarge},
+		// 未声明长度的请求体在读取时截断
+		{name: "chunked over limit", path: "/login", body: "123456789", chunked: true, want: http.StatusBadRequest},
+		{name: "route override", path: "/upload", body: strings.Repeat("x", 32), want: http.StatusNoContent},
+		{name: "route override over limit", path: "/upload", body: strings.Repeat("x", 33), want: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, r)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d; want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Package middleware 客户端证书（mTLS）认证中间件
+package middleware
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"yggdrasil-api-go/src/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireClientCert 要求指定路径前缀下的请求提供已验证的客户端证书（用于管理接口的mTLS认证）
+// 证书在TLS握手时已由client_ca_file验证，这里只检查是否存在验证通过的证书链；其他路径不受影响
+func RequireClientCert(prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasPathPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			utils.RespondError(c, http.StatusForbidden,
+				utils.ErrForbiddenOperation, utils.MsgClientCertRequired)
+			c.Abort()
+			return
+		}
+
+		// 记录客户端证书主题，便于审计
+		c.Set("client_cert_subject", c.Request.TLS.VerifiedChains[0][0].Subject.String())
+		c.Next()
+	}
+}
+
+// hasPathPrefix 检查路径是否位于任一前缀下（按路径段匹配，/api/administrator 不匹配 /api/admin）
+// 路径先按路由器的规则规范化（多余的斜杠和 ./.. 不能绕过检查）
+func hasPathPrefix(requestPath string, prefixes []string) bool {
+	requestPath = path.Clean("/" + requestPath)
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHasPathPrefix(t *testing.T) {
+	prefixes := []string{"/api/admin", "/metrics/"}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/api/admin", want: true},
+		{path: "/api/admin/", want: true},
+		{path: "/api/admin/users", want: true},
+		{path: "/metrics", want: true},
+		{path: "/api/administrator", want: false},
+		{path: "/api", want: false},
+		{path: "//api//admin/users", want: true},
+		{path: "/api/./admin", want: true},
+		{path: "/api/x/../admin/users", want: true},
+		{path: "api/admin", want: true},
+		{path: "/api/admin/../profiles", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := hasPathPrefix(tt.path, prefixes); got != tt.want {
+				t.Fatalf("hasPathPrefix(%q) = %v; want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verified := &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "ops"}}}},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		tls         *tls.ConnectionState
+		wantStatus  int
+		wantSubject string
+	}{
+		{name: "other path", path: "/authserver/validate", wantStatus: http.StatusOK},
+		{name: "plain http", path: "/api/admin/config", wantStatus: http.StatusForbidden},
+		{name: "no client certificate", path: "/api/admin/config", tls: &tls.ConnectionState{}, wantStatus: http.StatusForbidden},
+		{name: "verified", path: "/api/admin/config", tls: verified, wantStatus: http.StatusOK, wantSubject: "CN=ops"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			router := gin.New()
+			router.Use(RequireClientCert("/api/admin"))
+			router.NoRoute(func(c *gin.Context) {
+				subject = c.GetString("client_cert_subject")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.TLS = tt.tls
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || subject != tt.wantSubject {
+				t.Fatalf("status = %d, subject = %q; want %d, %q", w.Code, subject, tt.wantStatus, tt.wantSubject)
+			}
+		})
+	}
+}
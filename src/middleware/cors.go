@@ -2,11 +2,15 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+
+	"yggdrasil-api-go/src/config"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CORS 跨域资源共享中间件（性能优化版）
+// middleware.cors.allowed_origins 为空或包含"*"时允许所有来源，否则只允许列出的来源（支持配置热重载）
 func CORS() gin.HandlerFunc {
 	// 预定义常用的CORS头，避免重复字符串分配
 	const (
@@ -23,6 +27,17 @@ func CORS() gin.HandlerFunc {
 	)
 
 	return func(c *gin.Context) {
+		origin := c.Request.Header.Get("Origin")
+		if origin != "" && !originAllowed(origin) {
+			// 不允许的来源不返回CORS头，浏览器会拒绝跨域请求
+			if c.Request.Method == http.MethodOptions {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
 		// 快速处理预检请求
 		if c.Request.Method == http.MethodOptions {
 			c.Header(allowOrigin, "*")
@@ -35,7 +50,6 @@ func CORS() gin.HandlerFunc {
 		}
 
 		// 设置CORS头（优化：减少Header调用次数）
-		origin := c.Request.Header.Get("Origin")
 		if origin != "" {
 			c.Header(allowOrigin, origin)
 		} else {
@@ -50,3 +64,17 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// originAllowed 检查请求来源是否在允许列表中（每次读取当前配置，热重载后立即生效）
+func originAllowed(origin string) bool {
+	cfg := config.Current()
+	if cfg == nil || len(cfg.Middleware.CORS.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range cfg.Middleware.CORS.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
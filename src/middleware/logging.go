@@ -0,0 +1,132 @@
+// Package middleware 请求日志中间件
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"yggdrasil-api-go/src/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求ID请求头（上游反向代理传入时沿用，否则生成新的ID）
+const RequestIDHeader = "X-Request-ID"
+
+// maxLoggedBodySize Debug级别下记录的请求体最大长度
+const maxLoggedBodySize = 4096
+
+// RequestLogger 请求日志中间件（替代gin.Logger）
+// 为每个请求分配请求ID并写入请求的Context，处理器中的日志自动带上请求ID、路由、客户端IP、用户ID和角色ID
+// Debug级别下同时记录脱敏后的请求体
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx, fields := logging.NewContext(c.Request.Context(), requestID, route, c.ClientIP())
+		c.Request = c.Request.WithContext(ctx)
+
+		var body string
+		if logging.Enabled(slog.LevelDebug) {
+			body = peekBody(c)
+		}
+
+		c.Next()
+
+		// JWT中间件认证的管理接口请求
+		if fields.UserID() == "" {
+			logging.SetUser(ctx, c.GetString("user_uuid"))
+		}
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+		}
+		if c.Request.URL.RawQuery != "" {
+			attrs = append(attrs, slog.String("query", c.Request.URL.RawQuery))
+		}
+		if body != "" {
+			attrs = append(attrs, slog.String("body", body))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", strings.TrimSpace(c.Errors.String())))
+		}
+
+		slog.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// validRequestID 检查上游传入的请求ID（限制长度和字符，避免日志注入）
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成随机请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// peekBody 读取请求体的前一部分用于日志（读取的内容放回请求体，不影响处理器）
+func peekBody(c *gin.Context) string {
+	if c.Request.Body == nil || c.Request.ContentLength == 0 {
+		return ""
+	}
+	contentType := c.ContentType()
+	if contentType != "application/json" && contentType != "application/x-www-form-urlencoded" {
+		// 材质上传等二进制请求体不记录
+		return ""
+	}
+
+	head, err := io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBodySize+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), c.Request.Body), c.Request.Body}
+	if err != nil {
+		return ""
+	}
+
+	body := strings.TrimSpace(string(head))
+	if len(head) > maxLoggedBodySize {
+		body = strings.TrimSpace(string(head[:maxLoggedBodySize])) + "...(truncated)"
+	}
+	return logging.Redact(body)
+}
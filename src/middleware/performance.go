@@ -4,6 +4,7 @@ package middleware
 import (
 	"time"
 
+	"yggdrasil-api-go/src/metrics"
 	"yggdrasil-api-go/src/utils"
 
 	"github.com/gin-gonic/gin"
@@ -20,38 +21,53 @@ func PerformanceMonitor() gin.HandlerFunc {
 
 		// 记录性能指标
 		duration := time.Since(start)
-		isError := c.Writer.Status() >= 400
+		status := c.Writer.Status()
+		isError := status >= 400
 
 		// 记录到全局性能监控
 		utils.GlobalMetrics.RecordRequest(duration, isError)
+
+		// 按路由模板记录直方图（使用FullPath而不是实际路径，避免UUID等参数导致标签爆炸）
+		metrics.ObserveRequest(c.FullPath(), c.Request.Method, status, duration)
 	}
 }
 
 // DatabaseQueryMonitor 数据库查询监控装饰器
 // 用于包装数据库查询方法，自动记录查询性能
 func DatabaseQueryMonitor(queryFunc func() error) error {
+	return DatabaseQueryMonitorNamed("default", "query", queryFunc)
+}
+
+// DatabaseQueryMonitorNamed 数据库查询监控装饰器（指定连接名和操作类型，用于直方图标签）
+func DatabaseQueryMonitorNamed(database, operation string, queryFunc func() error) error {
 	start := time.Now()
 	err := queryFunc()
 	duration := time.Since(start)
 
 	// 记录数据库查询性能
 	utils.GlobalMetrics.RecordDBQuery(duration)
+	metrics.ObserveDBQuery(database, operation, duration)
 
 	return err
 }
 
 // CacheMonitor 缓存监控工具
-type CacheMonitor struct{}
+type CacheMonitor struct {
+	Cache   string // 缓存名（用于指标标签）
+	Backend string // 缓存后端（用于指标标签）
+}
 
 // RecordHit 记录缓存命中
 func (cm *CacheMonitor) RecordHit() {
 	utils.GlobalMetrics.RecordCacheHit()
+	metrics.RecordCache(cm.Cache, cm.Backend, true)
 }
 
 // RecordMiss 记录缓存未命中
 func (cm *CacheMonitor) RecordMiss() {
 	utils.GlobalMetrics.RecordCacheMiss()
+	metrics.RecordCache(cm.Cache, cm.Backend, false)
 }
 
-// 全局缓存监控实例
-var GlobalCacheMonitor = &CacheMonitor{}
+// 全局缓存监控实例（用户缓存）
+var GlobalCacheMonitor = &CacheMonitor{Cache: "user", Backend: "memory"}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"yggdrasil-api-go/src/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPerformanceMonitorRouteLabel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(PerformanceMonitor())
+	router.GET("/perf-test/:uuid", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, uuid := range []string{"a", "b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/perf-test/"+uuid, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics/prometheus", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	// 同一路由模板的请求记录在同一序列中，实际路径不作为标签
+	want := `yggdrasil_http_request_duration_seconds_count{method="GET",route="/perf-test/:uuid",status="204"} 2`
+	if !strings.Contains(string(body), want) {
+		t.Fatalf("exposition is missing %s", want)
+	}
+	if strings.Contains(string(body), `route="/perf-test/a"`) {
+		t.Fatal("request path was used as a route label")
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
-	"github.com/NewNanCity/YggdrasilGo/src/models"
-	"github.com/NewNanCity/YggdrasilGo/src/utils"
+	"yggdrasil-api-go/src/models"
+	"yggdrasil-api-go/src/utils"
 )
 
 // PermissionMiddleware 权限验证中间件
@@ -183,7 +183,7 @@ func (pm *PermissionMiddleware) CheckProfileLimit() gin.HandlerFunc {
 		}
 
 		// 检查是否可以创建角色
-		canCreate, _, _, err := user.CanCreateProfile(pm.db)
+		canCreate, _, err := user.CanCreateProfile(pm.db)
 		if err != nil {
 			utils.RespondError(c, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to check profile limit")
 			c.Abort()
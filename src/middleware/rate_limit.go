@@ -16,6 +16,12 @@ type RateLimiter struct {
 	interval time.Duration        // 请求间隔
 }
 
+// 已创建的速率限制器（由后台任务统一清理）
+var (
+	rateLimiters   []*RateLimiter
+	rateLimitersMu sync.Mutex
+)
+
 // NewRateLimiter 创建新的速率限制器
 func NewRateLimiter(interval time.Duration) *RateLimiter {
 	limiter := &RateLimiter{
@@ -23,25 +29,40 @@ func NewRateLimiter(interval time.Duration) *RateLimiter {
 		interval: interval,
 	}
 
-	// 启动清理协程
-	go limiter.cleanup()
+	rateLimitersMu.Lock()
+	rateLimiters = append(rateLimiters, limiter)
+	rateLimitersMu.Unlock()
 	return limiter
 }
 
-// cleanup 定期清理过期的请求记录
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-		for key, lastTime := range rl.requests {
-			if now.Sub(lastTime) > rl.interval*2 {
-				delete(rl.requests, key)
-			}
+// SetInterval 调整请求间隔（配置热重载时调用），0表示不限制
+func (rl *RateLimiter) SetInterval(interval time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.interval = interval
+}
+
+// Cleanup 清理过期的请求记录
+func (rl *RateLimiter) Cleanup() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	for key, lastTime := range rl.requests {
+		if now.Sub(lastTime) > rl.interval*2 {
+			delete(rl.requests, key)
 		}
-		rl.mu.Unlock()
+	}
+}
+
+// CleanupRateLimiters 清理所有速率限制器的过期记录（每个实例独立执行）
+func CleanupRateLimiters() {
+	rateLimitersMu.Lock()
+	limiters := append([]*RateLimiter(nil), rateLimiters...)
+	rateLimitersMu.Unlock()
+
+	for _, limiter := range limiters {
+		limiter.Cleanup()
 	}
 }
 
@@ -50,6 +71,10 @@ func (rl *RateLimiter) Allow(identifier string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
+	if rl.interval <= 0 {
+		return true
+	}
+
 	now := time.Now()
 	lastTime, exists := rl.requests[identifier]
 
@@ -63,8 +88,11 @@ func (rl *RateLimiter) Allow(identifier string) bool {
 
 // RateLimit 速率限制中间件（性能优化版）
 func RateLimit(interval time.Duration) gin.HandlerFunc {
-	limiter := NewRateLimiter(interval)
+	return RateLimitWith(NewRateLimiter(interval))
+}
 
+// RateLimitWith 使用指定速率限制器的中间件（需要在运行时调整间隔时使用）
+func RateLimitWith(limiter *RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 使用客户端IP作为标识符（避免消耗请求体）
 		identifier := c.ClientIP()
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTracing(t *testing.T) {
+	if _, err := tracing.Init(config.TracingConfig{}); err != nil {
+		t.Fatalf("tracing.Init: %v", err)
+	}
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Tracing())
+	var handlerSpan trace.SpanContext
+	router.GET("/profiles/:uuid", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+
+	// 上游传入traceparent时作为其子Span
+	r := httptest.NewRequest(http.MethodGet, "/profiles/abc", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans; want 1", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /profiles/:uuid" || span.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("span = %s (%s); want server span GET /profiles/:uuid", span.Name(), span.SpanKind())
+	}
+	if got := span.Parent().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" || span.SpanContext().TraceID().String() != got {
+		t.Fatalf("trace id = %s; want the incoming traceparent", span.SpanContext().TraceID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Fatal("request context does not carry the server span")
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("status = %v; want error for HTTP 500", span.Status())
+	}
+	var statusCode attribute.Value
+	for _, attr := range span.Attributes() {
+		if attr.Key == "http.response.status_code" {
+			statusCode = attr.Value
+		}
+	}
+	if statusCode.AsInt64() != http.StatusInternalServerError {
+		t.Fatalf("http.response.status_code = %v; want 500", statusCode.Emit())
+	}
+}
@@ -0,0 +1,197 @@
+// Package proxyproto 解析HAProxy PROXY协议（v1/v2）的监听器
+// 负载均衡器以TCP方式转发时，通过PROXY头传递客户端的真实地址
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHeaderTimeout 未配置时读取PROXY头的超时时间
+const defaultHeaderTimeout = 5 * time.Second
+
+// v1MaxLength v1头部的最大长度（包括结尾的CRLF）
+const v1MaxLength = 107
+
+// v2Signature v2头部的固定前缀
+var v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// Listener PROXY协议监听器
+// 来自可信来源的连接必须以PROXY头开始，连接的RemoteAddr替换为头中的客户端地址；
+// 其他来源的连接不解析PROXY头，按直接连接处理（防止客户端伪造地址）
+type Listener struct {
+	net.Listener
+	trusted []*net.IPNet
+	timeout time.Duration
+}
+
+// NewListener 包装监听器（trusted为空时所有连接都必须发送PROXY头）
+func NewListener(inner net.Listener, trusted []*net.IPNet, timeout time.Duration) *Listener {
+	if timeout <= 0 {
+		timeout = defaultHeaderTimeout
+	}
+	return &Listener{Listener: inner, trusted: trusted, timeout: timeout}
+}
+
+// Accept 接受连接（PROXY头在第一次读取或获取RemoteAddr时解析，不阻塞Accept）
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.isTrusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &Conn{Conn: conn, reader: bufio.NewReader(conn), timeout: l.timeout}, nil
+}
+
+// isTrusted 连接来源是否为可信代理
+func (l *Listener) isTrusted(addr net.Addr) bool {
+	if len(l.trusted) == 0 {
+		return true
+	}
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, network := range l.trusted {
+		if network.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn 带PROXY头的连接
+type Conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+
+	once   sync.Once
+	remote net.Addr // PROXY头中的客户端地址（LOCAL或UNKNOWN时为nil）
+	err    error
+}
+
+// Read 读取PROXY头之后的数据（PROXY头无效时返回错误，由服务器关闭连接）
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr 返回PROXY头中的客户端地址（没有时返回连接地址）
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readHeader 在超时时间内读取PROXY头
+func (c *Conn) readHeader() {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	c.remote, c.err = parseHeader(c.reader)
+	c.Conn.SetReadDeadline(time.Time{})
+
+	if c.err != nil {
+		slog.Warn("Rejected PROXY protocol connection", "remote_addr", c.Conn.RemoteAddr().String(), "error", c.err)
+	}
+}
+
+// parseHeader 读取PROXY头，返回其中的客户端地址
+// LOCAL命令（如负载均衡器的健康检查）和UNKNOWN、UNIX等地址族返回nil，表示使用连接地址
+func parseHeader(r *bufio.Reader) (net.Addr, error) {
+	prefix, err := r.Peek(len(v2Signature))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol header: %w", err)
+	}
+
+	switch {
+	case bytes.Equal(prefix, v2Signature):
+		return readV2(r)
+	case bytes.HasPrefix(prefix, []byte("PROXY ")):
+		return readV1(r)
+	default:
+		return nil, errors.New("missing PROXY protocol header")
+	}
+}
+
+// readV1 解析文本格式的v1头：PROXY TCP4 <源地址> <目标地址> <源端口> <目标端口>\r\n
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 header: %w", err)
+	}
+	if len(line) > v1MaxLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("invalid PROXY protocol v1 header")
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 header: %q", line)
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 source address: %s %s", fields[2], fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readV2 解析二进制格式的v2头（忽略地址之后的TLV扩展）
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(v2Signature)+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol v2 header: %w", err)
+	}
+	versionCommand, family := header[12], header[13]
+	if versionCommand>>4 != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version: %d", versionCommand>>4)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol v2 header: %w", err)
+	}
+
+	switch versionCommand & 0x0F {
+	case 0x0: // LOCAL
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol v2 command: %d", versionCommand&0x0F)
+	}
+
+	switch family >> 4 {
+	case 0x1: // AF_INET：源地址、目标地址各4字节，源端口、目标端口各2字节
+		if len(payload) < 12 {
+			return nil, errors.New("invalid PROXY protocol v2 IPv4 address block")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x2: // AF_INET6：源地址、目标地址各16字节，源端口、目标端口各2字节
+		if len(payload) < 36 {
+			return nil, errors.New("invalid PROXY protocol v2 IPv6 address block")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	default: // AF_UNSPEC、AF_UNIX
+		return nil, nil
+	}
+}
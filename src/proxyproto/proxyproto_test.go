@@ -0,0 +1,144 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// v2Header 构造v2头（command: 0为LOCAL，1为PROXY；family为地址族和传输协议字节）
+func v2Header(version, command, family byte, addresses []byte) []byte {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, version<<4|command, family)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(addresses)))
+	return append(header, addresses...)
+}
+
+func TestParseHeader(t *testing.T) {
+	ipv4 := []byte{203, 0, 113, 7, 10, 0, 0, 1}
+	ipv4 = binary.BigEndian.AppendUint16(ipv4, 51234)
+	ipv4 = binary.BigEndian.AppendUint16(ipv4, 443)
+	ipv6 := append(net.ParseIP("2001:db8::7").To16(), net.ParseIP("2001:db8::1").To16()...)
+	ipv6 = binary.BigEndian.AppendUint16(ipv6, 51234)
+	ipv6 = binary.BigEndian.AppendUint16(ipv6, 443)
+	tlv := append(append([]byte{}, ipv4...), 0x04, 0x00, 0x01, 0xFF)
+
+	tests := []struct {
+		name     string
+		header   []byte
+		wantAddr string // 为空表示使用连接地址
+		wantErr  bool
+	}{
+		{name: "v1 tcp4", header: []byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n"), wantAddr: "203.0.113.7:51234"},
+		{name: "v1 tcp6", header: []byte("PROXY TCP6 2001:db8::7 2001:db8::1 51234 443\r\n"), wantAddr: "[2001:db8::7]:51234"},
+		{name: "v1 unknown", header: []byte("PROXY UNKNOWN\r\n")},
+		{name: "v1 family mismatch", header: []byte("PROXY TCP4 2001:db8::7 10.0.0.1 51234 443\r\n"), wantErr: true},
+		{name: "v1 invalid port", header: []byte("PROXY TCP4 203.0.113.7 10.0.0.1 70000 443\r\n"), wantErr: true},
+		{name: "v1 missing crlf", header: []byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\n"), wantErr: true},
+		{name: "v1 too long", header: []byte("PROXY TCP4 " + strings.Repeat("1", v1MaxLength) + "\r\n"), wantErr: true},
+		{name: "v2 ipv4", header: v2Header(2, 1, 0x11, ipv4), wantAddr: "203.0.113.7:51234"},
+		{name: "v2 ipv6", header: v2Header(2, 1, 0x21, ipv6), wantAddr: "[2001:db8::7]:51234"},
+		{name: "v2 tlv ignored", header: v2Header(2, 1, 0x11, tlv), wantAddr: "203.0.113.7:51234"},
+		{name: "v2 local", header: v2Header(2, 0, 0x00, nil)},
+		{name: "v2 unspec", header: v2Header(2, 1, 0x00, nil)},
+		{name: "v2 short address block", header: v2Header(2, 1, 0x11, ipv4[:8]), wantErr: true},
+		{name: "v2 truncated", header: v2Header(2, 1, 0x11, ipv4)[:20], wantErr: true},
+		{name: "v2 unsupported version", header: v2Header(1, 1, 0x11, ipv4), wantErr: true},
+		{name: "v2 unsupported command", header: v2Header(2, 2, 0x11, ipv4), wantErr: true},
+		{name: "missing header", header: []byte("GET / HTTP/1.1\r\n\r\n"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(io.MultiReader(bytes.NewReader(tt.header), strings.NewReader("payload")))
+			addr, err := parseHeader(r)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseHeader = %v; want error", addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHeader: %v", err)
+			}
+
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != tt.wantAddr {
+				t.Fatalf("parseHeader = %q; want %q", got, tt.wantAddr)
+			}
+			if rest, _ := io.ReadAll(r); string(rest) != "payload" {
+				t.Fatalf("data after header = %q; want payload", rest)
+			}
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	_, other, _ := net.ParseCIDR("192.0.2.0/24")
+
+	tests := []struct {
+		name     string
+		trusted  []*net.IPNet
+		send     string
+		wantAddr string // 为空表示连接地址
+		wantData string
+		wantErr  bool
+	}{
+		{name: "trusted", trusted: []*net.IPNet{loopback}, send: "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\nping", wantAddr: "203.0.113.7:51234", wantData: "ping"},
+		{name: "trusted without header", trusted: []*net.IPNet{loopback}, send: "ping ping ping", wantErr: true},
+		{name: "untrusted header not parsed", trusted: []*net.IPNet{other}, send: "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n", wantData: "PROXY TCP4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Listen: %v", err)
+			}
+			listener := NewListener(inner, tt.trusted, time.Second)
+			defer listener.Close()
+
+			client, err := net.Dial("tcp", inner.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer client.Close()
+			if _, err := client.Write([]byte(tt.send)); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			conn, err := listener.Accept()
+			if err != nil {
+				t.Fatalf("Accept: %v", err)
+			}
+			defer conn.Close()
+
+			wantAddr := tt.wantAddr
+			if wantAddr == "" {
+				wantAddr = client.LocalAddr().String()
+			}
+			if got := conn.RemoteAddr().String(); got != wantAddr {
+				t.Fatalf("RemoteAddr = %s; want %s", got, wantAddr)
+			}
+
+			buf := make([]byte, max(len(tt.wantData), 1))
+			n, err := io.ReadFull(conn, buf)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Read succeeded; want error")
+				}
+				return
+			}
+			if err != nil || string(buf[:n]) != tt.wantData {
+				t.Fatalf("Read = %q, %v; want %q", buf[:n], err, tt.wantData)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Package scheduler 数据库任务锁
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"yggdrasil-api-go/src/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobLock 任务锁表结构（每个任务一行）
+type JobLock struct {
+	Name      string    `gorm:"primaryKey;column:name;size:100"`
+	Owner     string    `gorm:"column:owner;size:255;not null"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null"`
+}
+
+// jobLockMigrations 锁表的迁移
+var jobLockMigrations = []database.Migration{
+	{
+		Version: 1,
+		Name:    "create job lock table",
+		SQL: map[string][]string{
+			database.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	name varchar(100) NOT NULL,
+	owner varchar(255) NOT NULL,
+	expires_at datetime(3) NOT NULL,
+	PRIMARY KEY (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			database.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	name varchar(100) NOT NULL,
+	owner varchar(255) NOT NULL,
+	expires_at timestamptz NOT NULL,
+	PRIMARY KEY (name)
+)`},
+			database.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	name text NOT NULL,
+	owner text NOT NULL,
+	expires_at datetime NOT NULL,
+	PRIMARY KEY (name)
+)`},
+		},
+	},
+}
+
+// DatabaseLocker 基于锁表的任务锁（锁行过期后可被其他实例接管）
+type DatabaseLocker struct {
+	db        *gorm.DB
+	tableName string
+	owner     string
+}
+
+// NewDatabaseLocker 创建数据库任务锁（连接选项与数据库缓存相同：dsn、table_prefix、debug，支持MySQL、PostgreSQL、SQLite）
+func NewDatabaseLocker(options map[string]any) (*DatabaseLocker, error) {
+	dsn, ok := options["dsn"].(string)
+	if !ok || dsn == "" {
+		return nil, fmt.Errorf("database DSN is required")
+	}
+
+	tablePrefix, _ := options["table_prefix"].(string)
+	tableName := "job_locks"
+	if tablePrefix != "" {
+		tableName = tablePrefix + "job_locks"
+	}
+
+	debug, _ := options["debug"].(bool)
+	db, dialect, err := database.Open(dsn, debug)
+	if err != nil {
+		return nil, err
+	}
+	database.Instrument(db, "job_lock")
+
+	if err := database.Migrate(db, dialect, tableName, jobLockMigrations); err != nil {
+		return nil, err
+	}
+
+	return &DatabaseLocker{
+		db:        db,
+		tableName: tableName,
+		owner:     newOwnerID(),
+	}, nil
+}
+
+// TryLock 获取锁：接管已过期的锁行，或插入新的锁行
+func (l *DatabaseLocker) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	db := l.db.WithContext(ctx).Table(l.tableName)
+
+	result := db.Where("name = ? AND expires_at < ?", name, now).
+		Updates(map[string]any{"owner": l.owner, "expires_at": expiresAt})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to acquire database job lock: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// 锁行不存在时插入（已存在且未过期时不做任何修改）
+	result = l.db.WithContext(ctx).Table(l.tableName).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&JobLock{Name: name, Owner: l.owner, ExpiresAt: expiresAt})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to acquire database job lock: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Type 锁类型
+func (l *DatabaseLocker) Type() string {
+	return "database"
+}
+
+// Close 关闭数据库连接
+func (l *DatabaseLocker) Close() error {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
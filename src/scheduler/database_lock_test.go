@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDatabaseLocker(t *testing.T) {
+	options := map[string]any{"dsn": "sqlite://" + filepath.Join(t.TempDir(), "locks.db"), "table_prefix": "ygg_"}
+
+	// 两个锁模拟两个实例
+	var lockers []*DatabaseLocker
+	for i := 0; i < 2; i++ {
+		l, err := NewDatabaseLocker(options)
+		if err != nil {
+			t.Fatalf("NewDatabaseLocker: %v", err)
+		}
+		defer l.Close()
+		lockers = append(lockers, l)
+	}
+
+	ctx := context.Background()
+	if ok, err := lockers[0].TryLock(ctx, "cleanup", 50*time.Millisecond); err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v; want acquired", ok, err)
+	}
+	if ok, err := lockers[1].TryLock(ctx, "cleanup", time.Minute); err != nil || ok {
+		t.Fatalf("TryLock while held = %v, %v; want not acquired", ok, err)
+	}
+	// 锁在任务结束后不释放，持有者本身在有效期内也无法再次获取
+	if ok, _ := lockers[0].TryLock(ctx, "cleanup", time.Minute); ok {
+		t.Fatal("TryLock reacquired an unexpired lock")
+	}
+	if ok, err := lockers[1].TryLock(ctx, "gc", time.Minute); err != nil || !ok {
+		t.Fatalf("TryLock(gc) = %v, %v; want acquired", ok, err)
+	}
+
+	// 过期后由其他实例接管
+	time.Sleep(60 * time.Millisecond)
+	if ok, err := lockers[1].TryLock(ctx, "cleanup", time.Minute); err != nil || !ok {
+		t.Fatalf("TryLock after expiry = %v, %v; want acquired", ok, err)
+	}
+}
@@ -0,0 +1,68 @@
+// Package scheduler 任务锁（本地、Redis、数据库）
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Locker 任务锁：TryLock成功后在ttl内其他实例无法获取同名锁
+// 锁不在任务结束时释放，以保证同一调度周期内集群只执行一次
+type Locker interface {
+	TryLock(ctx context.Context, name string, ttl time.Duration) (bool, error)
+	Type() string
+	Close() error
+}
+
+// NewLocker 根据类型创建任务锁
+func NewLocker(lockType string, options map[string]any) (Locker, error) {
+	switch lockType {
+	case "", "local":
+		return NewLocalLocker(), nil
+	case "redis":
+		return NewRedisLocker(options)
+	case "database":
+		return NewDatabaseLocker(options)
+	default:
+		return nil, fmt.Errorf("unsupported job lock type: %s", lockType)
+	}
+}
+
+// LocalLocker 本地锁（单实例部署，始终获取成功）
+type LocalLocker struct{}
+
+// NewLocalLocker 创建本地锁
+func NewLocalLocker() *LocalLocker {
+	return &LocalLocker{}
+}
+
+// TryLock 获取锁
+func (l *LocalLocker) TryLock(_ context.Context, _ string, _ time.Duration) (bool, error) {
+	return true, nil
+}
+
+// Type 锁类型
+func (l *LocalLocker) Type() string {
+	return "local"
+}
+
+// Close 关闭锁
+func (l *LocalLocker) Close() error {
+	return nil
+}
+
+// newOwnerID 生成锁持有者标识（主机名-进程号-随机数）
+func newOwnerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "unknown"
+	}
+
+	random := make([]byte, 4)
+	rand.Read(random)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(random))
+}
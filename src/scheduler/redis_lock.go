@@ -0,0 +1,53 @@
+// Package scheduler Redis任务锁
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cacheredis "yggdrasil-api-go/src/cache/redis"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisLocker 基于SET NX PX的任务锁
+type RedisLocker struct {
+	client redis.UniversalClient
+	prefix string
+	owner  string
+}
+
+// NewRedisLocker 创建Redis任务锁（连接选项与Redis缓存相同）
+func NewRedisLocker(options map[string]any) (*RedisLocker, error) {
+	client, err := cacheredis.NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, _ := options["key_prefix"].(string)
+	return &RedisLocker{
+		client: client,
+		prefix: prefix + "yggdrasil-job-lock:",
+		owner:  newOwnerID(),
+	}, nil
+}
+
+// TryLock 获取锁
+func (l *RedisLocker) TryLock(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, l.prefix+name, l.owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire Redis job lock: %w", err)
+	}
+	return acquired, nil
+}
+
+// Type 锁类型
+func (l *RedisLocker) Type() string {
+	return "redis"
+}
+
+// Close 关闭Redis连接
+func (l *RedisLocker) Close() error {
+	return l.client.Close()
+}
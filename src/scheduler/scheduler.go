@@ -0,0 +1,273 @@
+// Package scheduler 后台任务调度（固定间隔或cron表达式，可选分布式锁）
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job 后台任务
+type Job struct {
+	Name      string                          // 任务名（同时作为锁名）
+	Schedule  string                          // "@every 5m"、"@hourly"或5段cron表达式
+	Singleton bool                            // 是否集群内只执行一次（需要分布式锁）
+	Timeout   time.Duration                   // 单次执行超时（0表示不限制）
+	Run       func(ctx context.Context) error // 任务函数
+}
+
+// JobStatus 任务状态
+type JobStatus struct {
+	Name         string    `json:"name"`
+	Schedule     string    `json:"schedule"`
+	Singleton    bool      `json:"singleton"`
+	Running      bool      `json:"running"`
+	Runs         int64     `json:"runs"`
+	Failures     int64     `json:"failures"`
+	Skipped      int64     `json:"skipped"` // 锁被其他实例持有或上次执行未结束
+	LastRun      time.Time `json:"last_run"`
+	LastDuration float64   `json:"last_duration_ms"`
+	LastError    string    `json:"last_error,omitempty"`
+	NextRun      time.Time `json:"next_run"`
+}
+
+// jobEntry 已注册的任务
+type jobEntry struct {
+	job      Job
+	schedule cron.Schedule
+	entryID  cron.EntryID
+	status   JobStatus
+	mu       sync.Mutex
+}
+
+// Scheduler 任务调度器
+type Scheduler struct {
+	cron   *cron.Cron
+	locker Locker
+	jobs   map[string]*jobEntry
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+}
+
+// NewScheduler 创建任务调度器（locker为nil时所有任务只在本实例内调度）
+// ctx取消时停止调度，正在执行的任务通过其Context收到取消信号
+func NewScheduler(ctx context.Context, locker Locker) *Scheduler {
+	if locker == nil {
+		locker = NewLocalLocker()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Scheduler{
+		cron:   cron.New(),
+		locker: locker,
+		jobs:   make(map[string]*jobEntry),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register 注册任务
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("job name cannot be empty")
+	}
+	if job.Run == nil {
+		return fmt.Errorf("job %s: run function is required", job.Name)
+	}
+
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job %s already registered", job.Name)
+	}
+
+	entry := &jobEntry{
+		job:      job,
+		schedule: schedule,
+		status: JobStatus{
+			Name:      job.Name,
+			Schedule:  job.Schedule,
+			Singleton: job.Singleton,
+		},
+	}
+	entry.entryID = s.cron.Schedule(schedule, cron.FuncJob(func() { s.execute(entry) }))
+	s.jobs[job.Name] = entry
+	return nil
+}
+
+// Start 启动调度
+func (s *Scheduler) Start() {
+	s.cron.Start()
+
+	go func() {
+		<-s.ctx.Done()
+		s.cron.Stop()
+	}()
+}
+
+// Stop 停止调度并等待正在执行的任务结束
+func (s *Scheduler) Stop() error {
+	s.cancel()
+	<-s.cron.Stop().Done()
+	return s.locker.Close()
+}
+
+// RunNow 立即执行任务（同样遵守分布式锁）
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.RLock()
+	entry, exists := s.jobs[name]
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("job %s not found", name)
+	}
+
+	s.execute(entry)
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.status.LastError != "" {
+		return fmt.Errorf("%s", entry.status.LastError)
+	}
+	return nil
+}
+
+// execute 执行一次任务并记录状态
+func (s *Scheduler) execute(entry *jobEntry) {
+	entry.mu.Lock()
+	if entry.status.Running {
+		entry.status.Skipped++
+		entry.mu.Unlock()
+		return
+	}
+	entry.status.Running = true
+	entry.mu.Unlock()
+
+	defer func() {
+		entry.mu.Lock()
+		entry.status.Running = false
+		entry.mu.Unlock()
+	}()
+
+	if entry.job.Singleton {
+		acquired, err := s.locker.TryLock(s.ctx, entry.job.Name, s.lockTTL(entry))
+		if err != nil {
+			slog.Warn("Failed to acquire job lock", "job", entry.job.Name, "error", err)
+		}
+		if !acquired {
+			entry.mu.Lock()
+			entry.status.Skipped++
+			if err != nil {
+				entry.status.LastError = err.Error()
+			}
+			entry.mu.Unlock()
+			return
+		}
+	}
+
+	ctx := s.ctx
+	if entry.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, entry.job.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := runJob(ctx, entry.job)
+	duration := time.Since(start)
+
+	entry.mu.Lock()
+	entry.status.Runs++
+	entry.status.LastRun = start
+	entry.status.LastDuration = float64(duration.Nanoseconds()) / 1e6
+	entry.status.LastError = ""
+	if err != nil {
+		entry.status.Failures++
+		entry.status.LastError = err.Error()
+	}
+	entry.mu.Unlock()
+
+	if err != nil {
+		slog.Error("Job failed", "job", entry.job.Name, "duration", duration, "error", err)
+	}
+}
+
+// runJob 执行任务函数（panic转换为错误，避免影响调度器）
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// lockTTL 锁有效期：调度周期的90%（其他实例在本周期内的触发都会被跳过），不短于执行超时
+func (s *Scheduler) lockTTL(entry *jobEntry) time.Duration {
+	next := entry.schedule.Next(time.Now())
+	period := entry.schedule.Next(next).Sub(next)
+
+	ttl := period * 9 / 10
+	if ttl < entry.job.Timeout {
+		ttl = entry.job.Timeout
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+// Status 获取所有任务状态（按任务名排序）
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for _, entry := range s.jobs {
+		entry.mu.Lock()
+		status := entry.status
+		entry.mu.Unlock()
+
+		status.NextRun = s.cron.Entry(entry.entryID).Next
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// GetStats 获取任务统计（用于/metrics）
+func (s *Scheduler) GetStats() map[string]any {
+	return map[string]any{
+		"lock": s.locker.Type(),
+		"jobs": s.Status(),
+	}
+}
+
+// ResolveSchedule 解析配置中的调度覆盖（未配置时使用默认值，"off"表示禁用）
+func ResolveSchedule(overrides map[string]string, name, defaultSchedule string) (string, bool) {
+	schedule := defaultSchedule
+	if v, ok := overrides[name]; ok && strings.TrimSpace(v) != "" {
+		schedule = strings.TrimSpace(v)
+	}
+
+	switch strings.ToLower(schedule) {
+	case "", "off", "disabled", "false":
+		return "", false
+	}
+	return schedule, true
+}
@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// denyLocker 锁始终被其他实例持有
+type denyLocker struct{}
+
+func (denyLocker) TryLock(context.Context, string, time.Duration) (bool, error) { return false, nil }
+func (denyLocker) Type() string                                                 { return "deny" }
+func (denyLocker) Close() error                                                 { return nil }
+
+func TestSchedulerRegister(t *testing.T) {
+	s := NewScheduler(context.Background(), nil)
+	defer s.Stop()
+
+	run := func(context.Context) error { return nil }
+	tests := []struct {
+		name    string
+		job     Job
+		wantErr bool
+	}{
+		{name: "valid", job: Job{Name: "cleanup", Schedule: "@every 5m", Run: run}},
+		{name: "duplicate", job: Job{Name: "cleanup", Schedule: "@hourly", Run: run}, wantErr: true},
+		{name: "empty name", job: Job{Schedule: "@hourly", Run: run}, wantErr: true},
+		{name: "no run function", job: Job{Name: "noop", Schedule: "@hourly"}, wantErr: true},
+		{name: "invalid schedule", job: Job{Name: "bad", Schedule: "every five minutes", Run: run}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Register(tt.job); (err != nil) != tt.wantErr {
+				t.Fatalf("Register error = %v; want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedulerRunNow(t *testing.T) {
+	s := NewScheduler(context.Background(), nil)
+	defer s.Stop()
+
+	var runs atomic.Int32
+	jobs := []Job{
+		{Name: "ok", Schedule: "@hourly", Run: func(context.Context) error { runs.Add(1); return nil }},
+		{Name: "fail", Schedule: "@hourly", Run: func(context.Context) error { return errors.New("boom") }},
+		{Name: "panic", Schedule: "@hourly", Run: func(context.Context) error { panic("oops") }},
+		{Name: "timeout", Schedule: "@hourly", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+	for _, job := range jobs {
+		if err := s.Register(job); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+	}
+
+	if err := s.RunNow("ok"); err != nil || runs.Load() != 1 {
+		t.Fatalf("RunNow(ok) = %v with %d runs; want success", err, runs.Load())
+	}
+	if err := s.RunNow("fail"); err == nil || err.Error() != "boom" {
+		t.Fatalf("RunNow(fail) = %v; want boom", err)
+	}
+	if err := s.RunNow("panic"); err == nil || err.Error() != "panic: oops" {
+		t.Fatalf("RunNow(panic) = %v; want the recovered panic", err)
+	}
+	if err := s.RunNow("timeout"); err == nil {
+		t.Fatal("RunNow(timeout) succeeded; want deadline error")
+	}
+	if err := s.RunNow("missing"); err == nil {
+		t.Fatal("RunNow(missing) succeeded; want error")
+	}
+
+	statuses := s.Status()
+	if len(statuses) != 4 || statuses[0].Name != "fail" || statuses[0].Failures != 1 {
+		t.Fatalf("Status = %+v; want jobs sorted by name with failure counts", statuses)
+	}
+}
+
+func TestSchedulerSingletonLock(t *testing.T) {
+	s := NewScheduler(context.Background(), denyLocker{})
+	defer s.Stop()
+
+	var runs atomic.Int32
+	run := func(context.Context) error { runs.Add(1); return nil }
+	if err := s.Register(Job{Name: "singleton", Schedule: "@hourly", Singleton: true, Run: run}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := s.Register(Job{Name: "local", Schedule: "@hourly", Run: run}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// 锁被其他实例持有时跳过，非Singleton任务不需要锁
+	s.RunNow("singleton")
+	s.RunNow("local")
+	statuses := s.Status()
+	if runs.Load() != 1 || statuses[1].Skipped != 1 || statuses[1].Runs != 0 {
+		t.Fatalf("runs = %d, status = %+v; want only the local job to run", runs.Load(), statuses)
+	}
+}
+
+func TestLockTTL(t *testing.T) {
+	s := NewScheduler(context.Background(), nil)
+	defer s.Stop()
+
+	run := func(context.Context) error { return nil }
+	tests := []struct {
+		job  Job
+		want time.Duration
+	}{
+		{job: Job{Name: "hourly", Schedule: "@every 1h", Run: run}, want: 54 * time.Minute},
+		{job: Job{Name: "long timeout", Schedule: "@every 1m", Timeout: 5 * time.Minute, Run: run}, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if err := s.Register(tt.job); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if got := s.lockTTL(s.jobs[tt.job.Name]); got != tt.want {
+			t.Fatalf("lockTTL(%s) = %v; want %v", tt.job.Name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSchedule(t *testing.T) {
+	overrides := map[string]string{"cleanup": " @every 1m ", "gc": "off", "empty": " "}
+	tests := []struct {
+		name        string
+		want        string
+		wantEnabled bool
+	}{
+		{name: "cleanup", want: "@every 1m", wantEnabled: true},
+		{name: "gc"},
+		{name: "empty", want: "@hourly", wantEnabled: true},
+		{name: "missing", want: "@hourly", wantEnabled: true},
+	}
+	for _, tt := range tests {
+		got, enabled := ResolveSchedule(overrides, tt.name, "@hourly")
+		if got != tt.want || enabled != tt.wantEnabled {
+			t.Fatalf("ResolveSchedule(%s) = %q, %v; want %q, %v", tt.name, got, enabled, tt.want, tt.wantEnabled)
+		}
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"gorm.io/gorm"
 
-	"github.com/NewNanCity/YggdrasilGo/src/models"
-	"github.com/NewNanCity/YggdrasilGo/src/utils"
+	"yggdrasil-api-go/src/models"
+	"yggdrasil-api-go/src/utils"
 )
 
 var (
@@ -304,7 +305,7 @@ func (s *PlayerRegistrationService) logUserAction(userUUID, action string, detai
 	go func() {
 		if err := models.LogUserAction(s.db, userUUID, action, details, ipAddress, userAgent); err != nil {
 			// 记录日志失败，可以在这里添加错误处理
-			fmt.Printf("Failed to log user action: %v\n", err)
+			slog.Warn("Failed to log user action", "user_uuid", userUUID, "action", action, "error", err)
 		}
 	}()
 }
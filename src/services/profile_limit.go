@@ -3,10 +3,11 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 
-	"github.com/NewNanCity/YggdrasilGo/src/models"
+	"yggdrasil-api-go/src/models"
 )
 
 var (
@@ -3,11 +3,12 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"gorm.io/gorm"
 
-	"github.com/NewNanCity/YggdrasilGo/src/models"
+	"yggdrasil-api-go/src/models"
 )
 
 var (
@@ -82,7 +83,7 @@ func (s *UserBanService) BanUser(targetUserUUID, adminUUID, reason string) error
 	}
 	if err := s.db.Create(&logEntry).Error; err != nil {
 		// 记录日志失败，但不影响封禁操作
-		fmt.Printf("Failed to log ban action: %v\n", err)
+		slog.Warn("Failed to log ban action", "user_uuid", targetUserUUID, "error", err)
 	}
 
 	return nil
@@ -142,7 +143,7 @@ func (s *UserBanService) UnbanUser(targetUserUUID, adminUUID string) error {
 	}
 	if err := s.db.Create(&logEntry).Error; err != nil {
 		// 记录日志失败，但不影响解封操作
-		fmt.Printf("Failed to log unban action: %v\n", err)
+		slog.Warn("Failed to log unban action", "user_uuid", targetUserUUID, "error", err)
 	}
 
 	return nil
@@ -251,7 +252,7 @@ func (s *UserBanService) ResetUserPassword(targetUserUUID, adminUUID, newPasswor
 		},
 	}
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		fmt.Printf("Failed to log password reset action: %v\n", err)
+		slog.Warn("Failed to log password reset action", "user_uuid", targetUserUUID, "error", err)
 	}
 
 	return nil
@@ -300,7 +301,7 @@ func (s *UserBanService) UpdateUserMaxProfiles(targetUserUUID, adminUUID string,
 		},
 	}
 	if err := s.db.Create(&logEntry).Error; err != nil {
-		fmt.Printf("Failed to log max profiles update action: %v\n", err)
+		slog.Warn("Failed to log max profiles update action", "user_uuid", targetUserUUID, "error", err)
 	}
 
 	return nil
@@ -0,0 +1,269 @@
+// Package blessing_skin BlessingSkin用户和角色管理（管理命令使用，直接读写users/players/uuid表）
+package blessing_skin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"gorm.io/gorm"
+)
+
+// BlessingSkin的用户权限值
+const (
+	permissionBanned = -1
+	permissionNormal = 0
+	permissionAdmin  = 1
+)
+
+// bcryptPasswords 当前密码加密方法是否接受bcrypt哈希（管理命令写入的密码为bcrypt哈希）
+func (s *Storage) bcryptPasswords() error {
+	switch strings.ToUpper(s.config.PwdMethod) {
+	case "ARGON2I", "MD5", "SALTED2MD5", "SHA256", "SALTED2SHA256", "SHA512", "SALTED2SHA512":
+		return fmt.Errorf("setting passwords requires pwd_method BCRYPT, current method is %s", s.config.PwdMethod)
+	}
+	return nil
+}
+
+// ListAccounts 列出所有用户（按用户ID排序）
+func (s *Storage) ListAccounts(ctx context.Context) ([]*storage.UserAccount, error) {
+	var users []User
+	if err := s.db.WithContext(ctx).Order("uid").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	var players []Player
+	if err := s.db.WithContext(ctx).Select("pid, uid, name").Find(&players).Error; err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(players))
+	for _, player := range players {
+		names = append(names, player.Name)
+	}
+	uuids, err := s.uuidGen.GetUUIDsByNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[int][]storage.AccountProfile)
+	for _, player := range players {
+		profiles[player.UID] = append(profiles[player.UID], storage.AccountProfile{ID: uuids[player.Name], Name: player.Name})
+	}
+
+	accounts := make([]*storage.UserAccount, 0, len(users))
+	for _, user := range users {
+		account := &storage.UserAccount{
+			ID:       strconv.FormatUint(uint64(user.UID), 10),
+			Email:    user.Email,
+			Admin:    user.Permission >= permissionAdmin,
+			Banned:   user.Permission == permissionBanned,
+			Profiles: profiles[int(user.UID)],
+		}
+		if account.Profiles == nil {
+			account.Profiles = []storage.AccountProfile{}
+		}
+		sort.Slice(account.Profiles, func(i, j int) bool { return account.Profiles[i].Name < account.Profiles[j].Name })
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// CreateAccount 创建用户（初始积分使用皮肤站的user_initial_score设置）
+func (s *Storage) CreateAccount(ctx context.Context, email, passwordHash string, admin bool) (*storage.UserAccount, error) {
+	if err := s.bcryptPasswords(); err != nil {
+		return nil, err
+	}
+
+	score, err := strconv.Atoi(s.optionsMgr.GetOptionWithDefault("user_initial_score", "1000"))
+	if err != nil {
+		score = 1000
+	}
+	permission := permissionNormal
+	if admin {
+		permission = permissionAdmin
+	}
+
+	now := time.Now()
+	user := &User{
+		Email:      email,
+		Nickname:   email,
+		Score:      score,
+		Password:   passwordHash,
+		Permission: permission,
+		LastSignAt: now,
+		RegisterAt: now,
+		Verified:   true,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("user already exists")
+		}
+		return tx.Create(user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.UserAccount{
+		ID:       strconv.FormatUint(uint64(user.UID), 10),
+		Email:    user.Email,
+		Admin:    admin,
+		Profiles: []storage.AccountProfile{},
+	}, nil
+}
+
+// SetPassword 修改用户密码
+func (s *Storage) SetPassword(ctx context.Context, email, passwordHash string) error {
+	if err := s.bcryptPasswords(); err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Model(&User{}).Where("email = ?", email).Update("password", passwordHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// SetBanned 封禁或解封用户（解封后恢复为普通用户）
+func (s *Storage) SetBanned(ctx context.Context, email string, banned bool) error {
+	var user User
+	if err := s.db.WithContext(ctx).Select("uid, permission").Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("user not found")
+		}
+		return err
+	}
+
+	switch {
+	case banned && user.Permission != permissionBanned:
+		user.Permission = permissionBanned
+	case !banned && user.Permission == permissionBanned:
+		user.Permission = permissionNormal
+	default:
+		return nil
+	}
+	return s.db.WithContext(ctx).Model(&User{}).Where("uid = ?", user.UID).Update("permission", user.Permission).Error
+}
+
+// AddProfile 为用户添加角色
+// 角色名已有UUID映射时（如删除后重新添加）沿用已有UUID，并写回profile.ID
+func (s *Storage) AddProfile(ctx context.Context, email string, profile *yggdrasil.Profile) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user User
+		if err := tx.Select("uid").Where("email = ?", email).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("user not found")
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&Player{}).Where("name = ?", profile.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("profile name already exists")
+		}
+
+		var mapping UUIDMapping
+		err := tx.Where("name = ?", profile.Name).First(&mapping).Error
+		switch {
+		case err == nil:
+			profile.ID = mapping.UUID
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Model(&UUIDMapping{}).Where("uuid = ?", profile.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("profile UUID already exists")
+			}
+			if err := tx.Create(&UUIDMapping{Name: profile.Name, UUID: profile.ID}).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(&Player{
+			UID:          int(user.UID),
+			Name:         profile.Name,
+			TIDSkin:      0,
+			TIDCape:      0,
+			LastModified: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.uuidGen.cache.PutMapping(profile.Name, profile.ID)
+	return nil
+}
+
+// RenameProfile 修改角色名称（同时更新UUID映射，UUID保持不变）
+func (s *Storage) RenameProfile(ctx context.Context, uuid, name string) error {
+	player, err := s.GetPlayerByUUID(ctx, uuid)
+	if err != nil {
+		return err
+	}
+	oldName := player.Name
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Player{}).Where("name = ? AND pid <> ?", name, player.PID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("profile name already exists")
+		}
+		if err := tx.Model(&UUIDMapping{}).Where("name = ? AND uuid <> ?", name, uuid).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("profile name is mapped to another UUID")
+		}
+
+		if err := tx.Model(&Player{}).Where("pid = ?", player.PID).
+			Updates(map[string]any{"name": name, "last_modified": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&UUIDMapping{}).Where("uuid = ?", uuid).Update("name", name).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.uuidGen.cache.DeleteMapping(oldName, uuid)
+	s.uuidGen.cache.PutMapping(name, uuid)
+	storage.NotifyProfileChanged(uuid)
+	return nil
+}
+
+// RemoveProfile 删除角色（保留UUID映射，重新添加同名角色时沿用原UUID，与皮肤站行为一致）
+func (s *Storage) RemoveProfile(ctx context.Context, uuid string) error {
+	player, err := s.GetPlayerByUUID(ctx, uuid)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Where("pid = ?", player.PID).Delete(&Player{}).Error; err != nil {
+		return err
+	}
+	storage.NotifyProfileChanged(uuid)
+	return nil
+}
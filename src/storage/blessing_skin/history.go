@@ -0,0 +1,203 @@
+// Package blessing_skin BlessingSkin材质变更历史
+package blessing_skin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+
+	"gorm.io/gorm"
+)
+
+// currentTexture 角色当前的材质记录（没有时返回nil）
+func (s *Storage) currentTexture(ctx context.Context, player *Player, textureType storage.TextureType) *Texture {
+	tid, err := s.playerTextureID(ctx, player, textureType)
+	if err != nil || tid <= 0 {
+		return nil
+	}
+
+	var texture Texture
+	if err := s.db.WithContext(ctx).First(&texture, tid).Error; err != nil {
+		return nil
+	}
+	return &texture
+}
+
+// recordTextureHistory 记录被覆盖的材质状态（写入ygg_texture_history表），并按保留策略清理旧记录
+// prior为变更前的材质（没有时为nil），在皮肤站中设置的材质同样会被记录；失败时只记录日志，不影响材质写入
+func (s *Storage) recordTextureHistory(ctx context.Context, player *Player, textureType storage.TextureType, prior *Texture, defaultAction string) {
+	if s.textureConfig == nil || !s.textureConfig.History.Enabled {
+		return
+	}
+
+	change := storage.TextureChangeFromContext(ctx, defaultAction)
+	record := &TextureHistory{
+		PID:       player.PID,
+		Type:      string(textureType),
+		Action:    change.Action,
+		Actor:     change.Actor,
+		IP:        change.ActorIP,
+		CreatedAt: time.Now(),
+	}
+	if prior != nil {
+		record.Hash = prior.Hash
+		if prior.Type == "alex" {
+			record.Model = "slim"
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Create(record).Error; err != nil {
+		slog.WarnContext(ctx, "Failed to record texture history", "pid", player.PID, "error", err)
+		return
+	}
+	if err := s.pruneTextureHistory(ctx, player.PID, string(textureType)); err != nil {
+		slog.WarnContext(ctx, "Failed to prune texture history", "pid", player.PID, "error", err)
+	}
+}
+
+// pruneTextureHistory 按保留策略清理角色指定材质类型的历史记录
+func (s *Storage) pruneTextureHistory(ctx context.Context, pid uint, textureType string) error {
+	history := s.textureConfig.History
+
+	// 清理过期记录
+	if history.MaxAge > 0 {
+		err := s.db.WithContext(ctx).Where("pid = ? AND type = ? AND created_at < ?", pid, textureType, time.Now().Add(-history.MaxAge)).
+			Delete(&TextureHistory{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 只保留最新的MaxEntries条
+	if history.MaxEntries > 0 {
+		var cutoff []int64
+		err := s.db.WithContext(ctx).Model(&TextureHistory{}).
+			Where("pid = ? AND type = ?", pid, textureType).
+			Order("id DESC").Offset(history.MaxEntries-1).Limit(1).
+			Pluck("id", &cutoff).Error
+		if err != nil {
+			return err
+		}
+		if len(cutoff) > 0 {
+			return s.db.WithContext(ctx).Where("pid = ? AND type = ? AND id < ?", pid, textureType, cutoff[0]).
+				Delete(&TextureHistory{}).Error
+		}
+	}
+
+	return nil
+}
+
+// GetTextureHistory 获取角色的材质变更历史
+func (s *Storage) GetTextureHistory(ctx context.Context, playerUUID string, textureType storage.TextureType, limit int) ([]*storage.TextureHistoryEntry, error) {
+	player, err := s.GetPlayerByUUID(ctx, playerUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.WithContext(ctx).Where("pid = ?", player.PID)
+	if textureType != "" {
+		query = query.Where("type = ?", string(textureType))
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var records []TextureHistory
+	if err := query.Order("id DESC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query texture history: %w", err)
+	}
+
+	entries := make([]*storage.TextureHistoryEntry, 0, len(records))
+	for i := range records {
+		entries = append(entries, toTextureHistoryEntry(&records[i], playerUUID))
+	}
+	return entries, nil
+}
+
+// RevertTexture 将角色材质恢复到指定历史记录的状态（只更新角色关联，不重新上传文件）
+func (s *Storage) RevertTexture(ctx context.Context, playerUUID string, entryID int64) (*storage.TextureHistoryEntry, error) {
+	if !s.IsUploadSupported() {
+		return nil, fmt.Errorf("texture management is disabled")
+	}
+
+	player, err := s.GetPlayerByUUID(ctx, playerUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	var record TextureHistory
+	err = s.db.WithContext(ctx).Where("id = ? AND pid = ?", entryID, player.PID).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("history entry not found")
+		}
+		return nil, err
+	}
+
+	textureType := storage.TextureType(record.Type)
+	if !s.supportsTextureType(textureType) {
+		return nil, fmt.Errorf("unsupported texture type")
+	}
+
+	// 查找历史材质对应的textures记录
+	tid := 0
+	if record.Hash != "" {
+		var texture Texture
+		err := s.db.WithContext(ctx).Where("hash = ? AND type = ?", record.Hash, historyTextureType(textureType, record.Model)).
+			Order("tid").First(&texture).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("texture is no longer available")
+			}
+			return nil, err
+		}
+		tid = int(texture.TID)
+	}
+
+	// 恢复前的状态同样写入历史，以便撤销本次恢复
+	prior := s.currentTexture(ctx, player, textureType)
+	if err := s.setPlayerTexture(s.db.WithContext(ctx), player, textureType, tid, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to update player: %w", err)
+	}
+	if prior == nil || int(prior.TID) != tid {
+		s.recordTextureHistory(ctx, player, textureType, prior, storage.TextureActionRevert)
+	}
+	storage.NotifyProfileChanged(playerUUID)
+
+	return toTextureHistoryEntry(&record, playerUUID), nil
+}
+
+// historyTextureType 根据材质类型和模型确定textures表中的类型（steve/alex/cape/扩展类型小写名）
+func historyTextureType(textureType storage.TextureType, model string) string {
+	switch textureType {
+	case storage.TextureTypeSkin:
+		if model == "slim" {
+			return "alex"
+		}
+		return "steve"
+	case storage.TextureTypeCape:
+		return "cape"
+	default:
+		return strings.ToLower(string(textureType))
+	}
+}
+
+// toTextureHistoryEntry 将数据库记录转换为历史记录
+func toTextureHistoryEntry(record *TextureHistory, playerUUID string) *storage.TextureHistoryEntry {
+	return &storage.TextureHistoryEntry{
+		ID:        record.ID,
+		ProfileID: playerUUID,
+		Type:      storage.TextureType(record.Type),
+		Action:    record.Action,
+		Hash:      record.Hash,
+		Model:     record.Model,
+		Actor:     record.Actor,
+		ActorIP:   record.IP,
+		CreatedAt: record.CreatedAt,
+	}
+}
@@ -0,0 +1,49 @@
+package blessing_skin
+
+import (
+	"context"
+	"testing"
+
+	"yggdrasil-api-go/src/config"
+	storage "yggdrasil-api-go/src/storage/interface"
+)
+
+func TestTextureHistory(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t, &Config{TextureBaseURLOverride: true}, config.TextureHistoryConfig{Enabled: true, MaxEntries: 2})
+
+	first, err := s.UploadTexture(ctx, storage.TextureTypeSkin, testPlayerUUID, []byte("first"), &storage.TextureMetadata{Slim: true})
+	if err != nil {
+		t.Fatalf("UploadTexture: %v", err)
+	}
+	if _, err := s.UploadTexture(ctx, storage.TextureTypeSkin, testPlayerUUID, []byte("second"), nil); err != nil {
+		t.Fatalf("UploadTexture: %v", err)
+	}
+
+	history, err := s.GetTextureHistory(ctx, testPlayerUUID, storage.TextureTypeSkin, 0)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("GetTextureHistory = %d entries, %v; want 2", len(history), err)
+	}
+	if history[0].Hash != first.Metadata.Hash || history[0].Model != "slim" || history[1].Hash != "" {
+		t.Fatalf("history = %+v, %+v; want slim upload over empty", history[0], history[1])
+	}
+
+	// 恢复到第一次上传的材质，恢复前的状态同样写入历史
+	if _, err := s.RevertTexture(ctx, testPlayerUUID, history[0].ID); err != nil {
+		t.Fatalf("RevertTexture: %v", err)
+	}
+	texture, err := s.GetTexture(ctx, storage.TextureTypeSkin, testPlayerUUID)
+	if err != nil || texture.Metadata.Hash != first.Metadata.Hash || !texture.Metadata.Slim {
+		t.Fatalf("GetTexture after revert = %+v, %v", texture, err)
+	}
+
+	// 历史记录按max_entries清理
+	history, _ = s.GetTextureHistory(ctx, testPlayerUUID, "", 0)
+	if len(history) != 2 || history[0].Action != storage.TextureActionRevert {
+		t.Fatalf("history after revert = %d entries; want 2 with the revert first", len(history))
+	}
+
+	if _, err := s.RevertTexture(ctx, testPlayerUUID, 12345); err == nil {
+		t.Fatal("RevertTexture with an unknown entry succeeded; want error")
+	}
+}
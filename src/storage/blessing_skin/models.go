@@ -64,6 +64,48 @@ func (Texture) TableName() string {
 	return "textures"
 }
 
+// UserCloset 衣柜模型（对应user_closet表，该表没有时间戳列）
+type UserCloset struct {
+	UserUID    int    `gorm:"primaryKey;column:user_uid;autoIncrement:false"`
+	TextureTID int    `gorm:"primaryKey;column:texture_tid;autoIncrement:false"`
+	ItemName   string `gorm:"column:item_name;size:255;not null"`
+}
+
+func (UserCloset) TableName() string {
+	return "user_closet"
+}
+
+// PlayerTexture 角色扩展材质模型（对应ygg_player_textures表）
+// 用于SKIN/CAPE之外通过配置注册的材质类型（如ELYTRA），表需手动创建，见docs/blessingskin.md
+type PlayerTexture struct {
+	PID       uint      `gorm:"primaryKey;column:pid;autoIncrement:false"`
+	Type      string    `gorm:"primaryKey;column:type;size:32"`
+	TID       uint      `gorm:"column:tid;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at;not null"`
+}
+
+func (PlayerTexture) TableName() string {
+	return "ygg_player_textures"
+}
+
+// TextureHistory 材质变更历史模型（对应ygg_texture_history表）
+// 记录角色每次材质变更后的状态，表需手动创建，见docs/blessingskin.md
+type TextureHistory struct {
+	ID        int64     `gorm:"primaryKey;column:id;autoIncrement"`
+	PID       uint      `gorm:"column:pid;not null;index:idx_pid_type"`
+	Type      string    `gorm:"column:type;size:32;not null;index:idx_pid_type"`
+	Action    string    `gorm:"column:action;size:16;not null"`
+	Hash      string    `gorm:"column:hash;size:64;not null;default:''"`
+	Model     string    `gorm:"column:model;size:16;not null;default:''"`
+	Actor     string    `gorm:"column:actor;size:64;not null;default:''"`
+	IP        string    `gorm:"column:ip;size:255;not null;default:''"`
+	CreatedAt time.Time `gorm:"column:created_at;not null"`
+}
+
+func (TextureHistory) TableName() string {
+	return "ygg_texture_history"
+}
+
 // UUIDMapping UUID映射模型（对应uuid表）
 type UUIDMapping struct {
 	ID   uint   `gorm:"primaryKey;column:id;autoIncrement"`
@@ -4,7 +4,7 @@ package blessing_skin
 import (
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 
 	"gorm.io/gorm"
@@ -39,7 +39,7 @@ func (om *OptionsManager) loadAllOptions() {
 	var options []Option
 	err := om.storage.db.Where("option_name LIKE 'ygg_%' OR option_name = 'site_url'").Find(&options).Error
 	if err != nil {
-		log.Printf("⚠️  Failed to load options: %v", err)
+		slog.Warn("Failed to load BlessingSkin options", "error", err)
 		return
 	}
 
@@ -48,7 +48,7 @@ func (om *OptionsManager) loadAllOptions() {
 		om.options[option.OptionName] = option.OptionValue
 	}
 
-	log.Printf("✅ Loaded %d options into memory", len(om.options))
+	slog.Info("Loaded BlessingSkin options", "count", len(om.options))
 }
 
 // YggdrasilOptions Yggdrasil配置项及其默认值（仅包含实际存在的配置项）
@@ -90,13 +90,13 @@ func (om *OptionsManager) InitializeOptions() error {
 
 	for optionName := range YggdrasilOptions {
 		if !existingMap[optionName] {
-			log.Printf("⚠️  Option '%s' not found in database, using default behavior", optionName)
+			slog.Warn("BlessingSkin option not found, using default behavior", "option", optionName)
 		}
 	}
 
 	// 只读模式：不生成或修改RSA密钥和JWT密钥
 	// BlessingSkin数据库中已有完整的Yggdrasil配置
-	log.Println("✅ BlessingSkin options initialized in read-only mode")
+	slog.Info("BlessingSkin options initialized in read-only mode")
 	return nil
 }
 
@@ -2,6 +2,7 @@
 package blessing_skin
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -12,14 +13,14 @@ import (
 )
 
 // GetProfileByUUID 根据UUID获取角色（单查询优化版）
-func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
+func (s *Storage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
 	// 一次性查询UUID映射和角色信息
 	var result struct {
 		PlayerName string `gorm:"column:player_name"`
 		UUID       string `gorm:"column:uuid"`
 	}
 
-	err := s.db.Table("uuid u").
+	err := s.db.WithContext(ctx).Table("uuid u").
 		Select("p.name as player_name, u.uuid").
 		Joins("JOIN players p ON u.name = p.name").
 		Where("u.uuid = ?", uuid).
@@ -32,7 +33,7 @@ func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
 	}
 
 	// 获取角色的材质信息
-	textures, err := s.GetPlayerTextures(result.UUID)
+	textures, err := s.GetPlayerTextures(ctx, result.UUID)
 	if err != nil {
 		// 如果获取材质失败，仍然返回角色信息，但properties为空
 		return &yggdrasil.Profile{
@@ -42,23 +43,8 @@ func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
 		}, nil
 	}
 
-	// 提取皮肤和披风URL
-	var skinURL, capeURL string
-	var isSlim bool
-
-	if skinInfo, exists := textures[storage.TextureTypeSkin]; exists {
-		skinURL = skinInfo.URL
-		if skinInfo.Metadata != nil {
-			isSlim = skinInfo.Metadata.Slim
-		}
-	}
-
-	if capeInfo, exists := textures[storage.TextureTypeCape]; exists {
-		capeURL = capeInfo.URL
-	}
-
-	// 生成properties
-	properties, err := yggdrasil.GenerateProfileProperties(result.UUID, result.PlayerName, skinURL, capeURL, isSlim)
+	// 生成properties（包含所有已注册的材质类型）
+	properties, err := storage.BuildProfileProperties(result.UUID, result.PlayerName, textures)
 	if err != nil {
 		// 如果生成properties失败，返回空properties
 		properties = []yggdrasil.ProfileProperty{}
@@ -72,14 +58,14 @@ func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
 }
 
 // GetProfileByName 根据名称获取角色（单查询优化版）
-func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
+func (s *Storage) GetProfileByName(ctx context.Context, name string) (*yggdrasil.Profile, error) {
 	// 一次性查询角色信息和UUID映射
 	var result struct {
 		PlayerName string `gorm:"column:name"`
 		UUID       string `gorm:"column:uuid"`
 	}
 
-	err := s.db.Table("players p").
+	err := s.db.WithContext(ctx).Table("players p").
 		Select("p.name, u.uuid").
 		Joins("LEFT JOIN uuid u ON p.name = u.name").
 		Where("p.name = ?", name).
@@ -101,7 +87,7 @@ func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
 	}
 
 	// 获取角色的材质信息
-	textures, err := s.GetPlayerTextures(uuid)
+	textures, err := s.GetPlayerTextures(ctx, uuid)
 	if err != nil {
 		// 如果获取材质失败，仍然返回角色信息，但properties为空
 		return &yggdrasil.Profile{
@@ -111,23 +97,8 @@ func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
 		}, nil
 	}
 
-	// 提取皮肤和披风URL
-	var skinURL, capeURL string
-	var isSlim bool
-
-	if skinInfo, exists := textures[storage.TextureTypeSkin]; exists {
-		skinURL = skinInfo.URL
-		if skinInfo.Metadata != nil {
-			isSlim = skinInfo.Metadata.Slim
-		}
-	}
-
-	if capeInfo, exists := textures[storage.TextureTypeCape]; exists {
-		capeURL = capeInfo.URL
-	}
-
-	// 生成properties
-	properties, err := yggdrasil.GenerateProfileProperties(uuid, result.PlayerName, skinURL, capeURL, isSlim)
+	// 生成properties（包含所有已注册的材质类型）
+	properties, err := storage.BuildProfileProperties(uuid, result.PlayerName, textures)
 	if err != nil {
 		// 如果生成properties失败，返回空properties
 		properties = []yggdrasil.ProfileProperty{}
@@ -141,14 +112,14 @@ func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
 }
 
 // GetProfilesByNames 根据名称列表批量获取角色（优化版，自动创建UUID）
-func (s *Storage) GetProfilesByNames(names []string) ([]*yggdrasil.Profile, error) {
+func (s *Storage) GetProfilesByNames(ctx context.Context, names []string) ([]*yggdrasil.Profile, error) {
 	if len(names) == 0 {
 		return []*yggdrasil.Profile{}, nil
 	}
 
 	// 1. 批量查询角色是否存在
 	var players []Player
-	err := s.db.Where("name IN ?", names).Find(&players).Error
+	err := s.db.WithContext(ctx).Where("name IN ?", names).Find(&players).Error
 	if err != nil {
 		return nil, err
 	}
@@ -196,10 +167,10 @@ func (s *Storage) GetProfilesByNames(names []string) ([]*yggdrasil.Profile, erro
 }
 
 // GetProfilesByUserEmail 获取用户的所有角色（优化版）
-func (s *Storage) GetProfilesByUserEmail(userEmail string) ([]*yggdrasil.Profile, error) {
+func (s *Storage) GetProfilesByUserEmail(ctx context.Context, userEmail string) ([]*yggdrasil.Profile, error) {
 	// 获取用户
 	var user User
-	err := s.db.Where("email = ?", userEmail).First(&user).Error
+	err := s.db.WithContext(ctx).Where("email = ?", userEmail).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return []*yggdrasil.Profile{}, nil
@@ -209,7 +180,7 @@ func (s *Storage) GetProfilesByUserEmail(userEmail string) ([]*yggdrasil.Profile
 
 	// 获取用户的所有角色
 	var players []Player
-	err = s.db.Where("uid = ?", user.UID).Find(&players).Error
+	err = s.db.WithContext(ctx).Where("uid = ?", user.UID).Find(&players).Error
 	if err != nil {
 		return nil, err
 	}
@@ -256,9 +227,9 @@ func (s *Storage) GetProfilesByUserEmail(userEmail string) ([]*yggdrasil.Profile
 }
 
 // GetPlayerByName 根据名称获取BlessingSkin Player（内部使用）
-func (s *Storage) GetPlayerByName(name string) (*Player, error) {
+func (s *Storage) GetPlayerByName(ctx context.Context, name string) (*Player, error) {
 	var player Player
-	err := s.db.Preload("Skin").Preload("Cape").Where("name = ?", name).First(&player).Error
+	err := s.db.WithContext(ctx).Preload("Skin").Preload("Cape").Where("name = ?", name).First(&player).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("player not found")
@@ -269,19 +240,19 @@ func (s *Storage) GetPlayerByName(name string) (*Player, error) {
 }
 
 // GetPlayerByUUID 根据UUID获取BlessingSkin Player（内部使用）
-func (s *Storage) GetPlayerByUUID(uuid string) (*Player, error) {
+func (s *Storage) GetPlayerByUUID(ctx context.Context, uuid string) (*Player, error) {
 	playerName, err := s.uuidGen.GetNameByUUID(uuid)
 	if err != nil {
 		return nil, fmt.Errorf("player not found")
 	}
-	return s.GetPlayerByName(playerName)
+	return s.GetPlayerByName(ctx, playerName)
 }
 
 // GetUserProfiles 根据用户UUID获取角色
-func (s *Storage) GetUserProfiles(userUUID string) ([]*yggdrasil.Profile, error) {
+func (s *Storage) GetUserProfiles(ctx context.Context, userUUID string) ([]*yggdrasil.Profile, error) {
 	// 根据UUID找到用户
 	var user User
-	err := s.db.Where("uuid = ?", userUUID).First(&user).Error
+	err := s.db.WithContext(ctx).Where("uuid = ?", userUUID).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return []*yggdrasil.Profile{}, nil
@@ -291,7 +262,7 @@ func (s *Storage) GetUserProfiles(userUUID string) ([]*yggdrasil.Profile, error)
 
 	// 获取用户的所有角色
 	var players []Player
-	err = s.db.Where("uid = ?", user.UID).Find(&players).Error
+	err = s.db.WithContext(ctx).Where("uid = ?", user.UID).Find(&players).Error
 	if err != nil {
 		return nil, err
 	}
@@ -2,9 +2,13 @@
 package blessing_skin
 
 import (
+	"log/slog"
 	"fmt"
 	"time"
 
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/database"
+	"yggdrasil-api-go/src/logging"
 	storage "yggdrasil-api-go/src/storage/interface"
 
 	"gorm.io/driver/mysql"
@@ -24,7 +28,10 @@ type Storage struct {
 
 // TextureConfig 材质配置（从全局配置传入）
 type TextureConfig struct {
-	BaseURL string // 材质基础URL
+	BaseURL       string                      // 材质基础URL
+	UploadEnabled bool                        // 是否启用上传
+	Blobs         storage.BlobStore           // 材质文件存储（上传必需；提供直接地址时优先使用）
+	History       config.TextureHistoryConfig // 材质变更历史配置
 }
 
 // Config BlessingSkin存储配置
@@ -35,6 +42,9 @@ type Config struct {
 	Salt                   string // 密码加密盐值 (对应BlessingSkin的SALT)
 	PwdMethod              string // 密码加密方法 (对应BlessingSkin的PWD_METHOD)
 	AppKey                 string // 应用密钥 (对应BlessingSkin的APP_KEY)
+	UploadPublic           bool   // 上传的材质是否公开到皮肤库
+	AddToCloset            bool   // 上传后是否加入用户衣柜
+	ExtensionTextures      bool   // 是否支持SKIN/CAPE之外的材质类型
 }
 
 // NewStorage 创建BlessingSkin存储实例
@@ -74,14 +84,27 @@ func NewStorage(options map[string]any, textureConfig *TextureConfig) (storage.S
 		cfg.AppKey = "base64:your_app_key_here" // 默认应用密钥
 	}
 
+	// 解析材质上传配置
+	if uploadPublic, ok := options["upload_public"].(bool); ok {
+		cfg.UploadPublic = uploadPublic
+	}
+
+	if addToCloset, ok := options["add_to_closet"].(bool); ok {
+		cfg.AddToCloset = addToCloset
+	}
+
+	if extensionTextures, ok := options["extension_textures"].(bool); ok {
+		cfg.ExtensionTextures = extensionTextures
+	}
+
 	// 连接数据库
 	gormConfig := &gorm.Config{
 		DisableForeignKeyConstraintWhenMigrating: true,
-		Logger:                                   logger.Default.LogMode(logger.Silent), // 禁用GORM日志避免干扰
+		Logger:                                   logging.NewGormLogger(logger.Silent), // 禁用GORM日志避免干扰
 	}
 
 	if cfg.Debug {
-		gormConfig.Logger = logger.Default.LogMode(logger.Info)
+		gormConfig.Logger = logging.NewGormLogger(logger.Info)
 	}
 
 	db, err := gorm.Open(mysql.Open(cfg.DatabaseDSN), gormConfig)
@@ -93,6 +116,7 @@ func NewStorage(options map[string]any, textureConfig *TextureConfig) (storage.S
 	if err := optimizeDBConnection(db); err != nil {
 		return nil, fmt.Errorf("failed to optimize database connection: %w", err)
 	}
+	database.Instrument(db, "blessing_skin")
 
 	// 使用传入的缓存实例
 
@@ -113,7 +137,7 @@ func NewStorage(options map[string]any, textureConfig *TextureConfig) (storage.S
 	// UUID缓存预热
 	if err := storage.preloadUUIDs(); err != nil {
 		// 预热失败不影响启动，只记录警告
-		fmt.Printf("⚠️  UUID cache preload failed: %v\n", err)
+		slog.Warn("UUID cache preload failed", "error", err)
 	}
 
 	return storage, nil
@@ -166,7 +190,7 @@ func optimizeDBConnection(db *gorm.DB) error {
 	sqlDB.SetConnMaxLifetime(time.Hour)        // 连接最大生存时间
 	sqlDB.SetConnMaxIdleTime(10 * time.Minute) // 空闲连接最大时间
 
-	fmt.Printf("🔧 Database connection pool optimized: MaxOpen=%d, MaxIdle=%d\n", 100, 10)
+	slog.Debug("Database connection pool configured", "max_open", 100, "max_idle", 10)
 	return nil
 }
 
@@ -199,7 +223,7 @@ func (s *Storage) preloadUUIDs() error {
 	}
 
 	if preloadCount > 0 {
-		fmt.Printf("🚀 UUID cache preloaded: %d mappings (max cache: %d)\n", preloadCount, maxCacheSize)
+		slog.Info("UUID cache preloaded", "mappings", preloadCount, "max_cache", maxCacheSize)
 	}
 
 	return nil
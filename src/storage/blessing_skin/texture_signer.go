@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	storage "yggdrasil-api-go/src/storage/interface"
 	"yggdrasil-api-go/src/yggdrasil"
 
 	"github.com/bytedance/sonic"
@@ -116,7 +117,7 @@ func (ts *TextureSigner) SignProfile(profile *yggdrasil.Profile, unsigned bool)
 	if ts.storage.IsUploadSupported() {
 		properties = append(properties, yggdrasil.ProfileProperty{
 			Name:  "uploadableTextures",
-			Value: "skin,cape",
+			Value: storage.GetUploadableTextureTypes(),
 		})
 	}
 
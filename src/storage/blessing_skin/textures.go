@@ -2,33 +2,226 @@
 package blessing_skin
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	storage "yggdrasil-api-go/src/storage/interface"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// UploadTexture BlessingSkin存储不支持材质上传
-func (s *Storage) UploadTexture(textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
-	return nil, fmt.Errorf("texture upload is not supported in BlessingSkin storage")
-}
+// UploadTexture 上传材质（与BlessingSkin皮肤库上传流程保持一致）
+// 文件以内容SHA256命名写入材质目录，textures表按hash去重，并更新角色的tid_skin/tid_cape
+// 注意：不扣除BlessingSkin积分
+func (s *Storage) UploadTexture(ctx context.Context, textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
+	if !s.IsUploadSupported() {
+		return nil, fmt.Errorf("texture upload is disabled")
+	}
 
-// GetTexture 获取材质信息
-func (s *Storage) GetTexture(textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
-	// 根据UUID获取角色
-	player, err := s.GetPlayerByUUID(playerUUID)
+	// 确定BlessingSkin材质类型（steve/alex/cape，扩展类型使用小写类型名）
+	var bsType string
+	switch textureType {
+	case storage.TextureTypeSkin:
+		bsType = "steve"
+		if metadata != nil && metadata.Slim {
+			bsType = "alex"
+		}
+	case storage.TextureTypeCape:
+		bsType = "cape"
+	default:
+		if !s.supportsTextureType(textureType) || len(textureType) > 10 {
+			return nil, fmt.Errorf("unsupported texture type")
+		}
+		bsType = strings.ToLower(string(textureType))
+	}
+
+	player, err := s.GetPlayerByUUID(ctx, playerUUID)
 	if err != nil {
-		return nil, fmt.Errorf("player not found")
+		return nil, err
+	}
+
+	// BlessingSkin使用文件内容的SHA256作为文件名
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	// 写入材质文件（已存在则跳过）
+	blobs := s.textureConfig.Blobs
+	exists, err := blobs.Exists(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check texture file: %w", err)
+	}
+	if !exists {
+		if err := blobs.Put(hash, data, "image/png"); err != nil {
+			return nil, fmt.Errorf("failed to save texture file: %w", err)
+		}
+	}
+
+	// 覆盖前的材质（包括在皮肤站中设置的）写入历史记录
+	prior := s.currentTexture(ctx, player, textureType)
+
+	now := time.Now()
+	var texture Texture
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 去重：复用相同hash和类型的公开材质或本人上传的材质
+		err := tx.Where("hash = ? AND type = ? AND (public = 1 OR uploader = ?)", hash, bsType, player.UID).
+			Order("tid").First(&texture).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			public := int8(0)
+			if s.config.UploadPublic {
+				public = 1
+			}
+			texture = Texture{
+				Name:     truncateName(player.Name, 50),
+				Type:     bsType,
+				Hash:     hash,
+				Size:     (len(data) + 1023) / 1024, // BlessingSkin以KB为单位记录大小
+				Uploader: player.UID,
+				Public:   public,
+				UploadAt: now,
+			}
+			if err := tx.Create(&texture).Error; err != nil {
+				return fmt.Errorf("failed to create texture: %w", err)
+			}
+		} else if err != nil {
+			return err
+		}
+
+		// 更新角色材质
+		if err := s.setPlayerTexture(tx, player, textureType, int(texture.TID), now); err != nil {
+			return fmt.Errorf("failed to update player: %w", err)
+		}
+
+		// 加入用户衣柜
+		if s.config.AddToCloset {
+			return s.addToCloset(tx, player.UID, int(texture.TID), texture.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	if prior == nil || prior.TID != texture.TID {
+		s.recordTextureHistory(ctx, player, textureType, prior, storage.TextureActionUpload)
+	}
+	storage.NotifyProfileChanged(playerUUID)
+
+	return &storage.TextureInfo{
+		Type: textureType,
+		URL:  s.getTextureURL(hash),
+		Metadata: &storage.TextureMetadata{
+			Model:      bsType,
+			Slim:       bsType == "alex",
+			Hash:       hash,
+			FileSize:   int64(len(data)),
+			UploadedAt: texture.UploadAt,
+		},
+	}, nil
+}
 
-	var textureID int
+// setPlayerTexture 设置角色材质（tid为0时清除），SKIN/CAPE写入players表，扩展类型写入ygg_player_textures表
+func (s *Storage) setPlayerTexture(tx *gorm.DB, player *Player, textureType storage.TextureType, tid int, now time.Time) error {
+	updates := map[string]any{"last_modified": now}
 	switch textureType {
 	case storage.TextureTypeSkin:
-		textureID = player.TIDSkin
+		updates["tid_skin"] = tid
 	case storage.TextureTypeCape:
-		textureID = player.TIDCape
+		updates["tid_cape"] = tid
 	default:
-		return nil, fmt.Errorf("unsupported texture type")
+		if tid > 0 {
+			err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&PlayerTexture{
+				PID:       player.PID,
+				Type:      string(textureType),
+				TID:       uint(tid),
+				UpdatedAt: now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		} else {
+			err := tx.Where("pid = ? AND type = ?", player.PID, string(textureType)).Delete(&PlayerTexture{}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Model(&Player{}).Where("pid = ?", player.PID).Updates(updates).Error
+}
+
+// playerTextureID 获取角色当前使用的材质ID
+func (s *Storage) playerTextureID(ctx context.Context, player *Player, textureType storage.TextureType) (int, error) {
+	switch textureType {
+	case storage.TextureTypeSkin:
+		return player.TIDSkin, nil
+	case storage.TextureTypeCape:
+		return player.TIDCape, nil
+	}
+
+	if !s.supportsTextureType(textureType) {
+		return 0, fmt.Errorf("unsupported texture type")
+	}
+
+	var playerTexture PlayerTexture
+	err := s.db.WithContext(ctx).Where("pid = ? AND type = ?", player.PID, string(textureType)).First(&playerTexture).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return int(playerTexture.TID), nil
+}
+
+// addToCloset 将材质加入用户衣柜（已存在则跳过），与皮肤站收藏一样增加材质的收藏数
+func (s *Storage) addToCloset(tx *gorm.DB, uid, tid int, name string) error {
+	var count int64
+	if err := tx.Model(&UserCloset{}).Where("user_uid = ? AND texture_tid = ?", uid, tid).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	item := &UserCloset{
+		UserUID:    uid,
+		TextureTID: tid,
+		ItemName:   name,
+	}
+	if err := tx.Create(item).Error; err != nil {
+		return fmt.Errorf("failed to add texture to closet: %w", err)
+	}
+	if err := tx.Model(&Texture{}).Where("tid = ?", tid).UpdateColumn("likes", gorm.Expr("likes + 1")).Error; err != nil {
+		return fmt.Errorf("failed to update texture likes: %w", err)
+	}
+	return nil
+}
+
+// truncateName 按字符截断名称
+func truncateName(name string, maxLen int) string {
+	runes := []rune(name)
+	if len(runes) > maxLen {
+		return string(runes[:maxLen])
+	}
+	return name
+}
+
+// GetTexture 获取材质信息
+func (s *Storage) GetTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
+	// 根据UUID获取角色
+	player, err := s.GetPlayerByUUID(ctx, playerUUID)
+	if err != nil {
+		return nil, fmt.Errorf("player not found")
+	}
+
+	textureID, err := s.playerTextureID(ctx, player, textureType)
+	if err != nil {
+		return nil, err
 	}
 
 	if textureID <= 0 {
@@ -37,7 +230,7 @@ func (s *Storage) GetTexture(textureType storage.TextureType, playerUUID string)
 
 	// 获取材质记录
 	var texture Texture
-	err = s.db.First(&texture, textureID).Error
+	err = s.db.WithContext(ctx).First(&texture, textureID).Error
 	if err != nil {
 		return nil, fmt.Errorf("texture not found")
 	}
@@ -49,34 +242,47 @@ func (s *Storage) GetTexture(textureType storage.TextureType, playerUUID string)
 			Hash:       texture.Hash,
 			FileSize:   int64(texture.Size),
 			UploadedAt: texture.UploadAt,
+			Slim:       texture.Type == "alex",
 		},
 	}, nil
 }
 
-// DeleteTexture BlessingSkin存储不支持材质删除
-func (s *Storage) DeleteTexture(textureType storage.TextureType, playerUUID string) error {
-	return fmt.Errorf("texture deletion is not supported in BlessingSkin storage")
+// DeleteTexture 重置角色材质（与BlessingSkin一致，只解除关联，不删除textures记录和文件）
+func (s *Storage) DeleteTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) error {
+	if !s.IsUploadSupported() {
+		return fmt.Errorf("texture management is disabled")
+	}
+
+	player, err := s.GetPlayerByUUID(ctx, playerUUID)
+	if err != nil {
+		return err
+	}
+
+	if !s.supportsTextureType(textureType) {
+		return fmt.Errorf("unsupported texture type")
+	}
+
+	prior := s.currentTexture(ctx, player, textureType)
+	if err := s.setPlayerTexture(s.db.WithContext(ctx), player, textureType, 0, time.Now()); err != nil {
+		return err
+	}
+	if prior != nil {
+		s.recordTextureHistory(ctx, player, textureType, prior, storage.TextureActionDelete)
+	}
+	storage.NotifyProfileChanged(playerUUID)
+	return nil
 }
 
 // GetTextureURL 计算材质URL
 func (s *Storage) GetTextureURL(textureType storage.TextureType, playerUUID string) string {
 	// 根据UUID获取角色
-	player, err := s.GetPlayerByUUID(playerUUID)
+	player, err := s.GetPlayerByUUID(context.Background(), playerUUID)
 	if err != nil {
 		return ""
 	}
 
-	var textureID int
-	switch textureType {
-	case storage.TextureTypeSkin:
-		textureID = player.TIDSkin
-	case storage.TextureTypeCape:
-		textureID = player.TIDCape
-	default:
-		return ""
-	}
-
-	if textureID <= 0 {
+	textureID, err := s.playerTextureID(context.Background(), player, textureType)
+	if err != nil || textureID <= 0 {
 		return ""
 	}
 
@@ -90,13 +296,20 @@ func (s *Storage) GetTextureURL(textureType storage.TextureType, playerUUID stri
 	return s.getTextureURL(texture.Hash)
 }
 
-// IsUploadSupported BlessingSkin存储不支持材质上传
+// IsUploadSupported 检查是否支持材质上传（需启用上传并配置材质文件存储）
 func (s *Storage) IsUploadSupported() bool {
-	return false
+	return s.textureConfig != nil && s.textureConfig.UploadEnabled && s.textureConfig.Blobs != nil
 }
 
 // getTextureURL 获取材质URL
 func (s *Storage) getTextureURL(hash string) string {
+	// 对象存储提供直接地址（公开桶/CDN/预签名）时优先使用
+	if s.textureConfig != nil && s.textureConfig.Blobs != nil {
+		if url, err := s.textureConfig.Blobs.URL(hash); err == nil && url != "" {
+			return url
+		}
+	}
+
 	// 如果配置了texture_base_url_override，使用全局texture.base_url配置
 	if s.config.TextureBaseURLOverride && s.textureConfig != nil && s.textureConfig.BaseURL != "" {
 		return fmt.Sprintf("%s/%s", strings.TrimRight(s.textureConfig.BaseURL, "/"), hash)
@@ -113,9 +326,9 @@ func (s *Storage) getTextureURL(hash string) string {
 }
 
 // GetTextureByHash 根据哈希获取材质（内部使用）
-func (s *Storage) GetTextureByHash(hash string) (*Texture, error) {
+func (s *Storage) GetTextureByHash(ctx context.Context, hash string) (*Texture, error) {
 	var texture Texture
-	err := s.db.Where("hash = ?", hash).First(&texture).Error
+	err := s.db.WithContext(ctx).Where("hash = ?", hash).First(&texture).Error
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +336,7 @@ func (s *Storage) GetTextureByHash(hash string) (*Texture, error) {
 }
 
 // GetPlayerTextures 获取角色的所有材质（优化版）
-func (s *Storage) GetPlayerTextures(playerUUID string) (map[storage.TextureType]*storage.TextureInfo, error) {
+func (s *Storage) GetPlayerTextures(ctx context.Context, playerUUID string) (map[storage.TextureType]*storage.TextureInfo, error) {
 	// 根据UUID获取角色名
 	playerName, err := s.uuidGen.GetNameByUUID(playerUUID)
 	if err != nil {
@@ -145,7 +358,7 @@ func (s *Storage) GetPlayerTextures(playerUUID string) (map[storage.TextureType]
 		CapeTime string `gorm:"column:cape_time"`
 	}
 
-	err = s.db.Table("players p").
+	err = s.db.WithContext(ctx).Table("players p").
 		Select(`p.pid, p.name, p.tid_skin, p.tid_cape,
 			s.hash as skin_hash, s.size as skin_size, s.type as skin_type, s.upload_at as skin_time,
 			c.hash as cape_hash, c.size as cape_size, c.upload_at as cape_time`).
@@ -190,5 +403,57 @@ func (s *Storage) GetPlayerTextures(playerUUID string) (map[storage.TextureType]
 		}
 	}
 
+	// 处理扩展材质类型（如ELYTRA）
+	if s.config.ExtensionTextures && hasExtraTextureTypes() {
+		var extras []struct {
+			Type     string    `gorm:"column:type"`
+			Hash     string    `gorm:"column:hash"`
+			Size     int       `gorm:"column:size"`
+			UploadAt time.Time `gorm:"column:upload_at"`
+		}
+		// ygg_player_textures表不存在时忽略错误，只返回SKIN/CAPE
+		err := s.db.WithContext(ctx).Table("ygg_player_textures pt").
+			Select("pt.type, t.hash, t.size, t.upload_at").
+			Joins("JOIN textures t ON pt.tid = t.tid").
+			Where("pt.pid = ?", result.PID).
+			Find(&extras).Error
+		if err == nil {
+			for _, extra := range extras {
+				def, ok := storage.LookupTextureType(extra.Type)
+				if !ok || extra.Hash == "" {
+					continue
+				}
+				textures[def.Type] = &storage.TextureInfo{
+					Type: def.Type,
+					URL:  s.getTextureURL(extra.Hash),
+					Metadata: &storage.TextureMetadata{
+						Hash:       extra.Hash,
+						FileSize:   int64(extra.Size),
+						UploadedAt: extra.UploadAt,
+					},
+				}
+			}
+		}
+	}
+
 	return textures, nil
 }
+
+// supportsTextureType 是否支持该材质类型
+// 扩展类型（如ELYTRA）与SKIN/CAPE一样写入textures表，皮肤站不识别这些类型，需开启extension_textures
+func (s *Storage) supportsTextureType(textureType storage.TextureType) bool {
+	if _, ok := storage.LookupTextureType(string(textureType)); !ok {
+		return false
+	}
+	return textureType == storage.TextureTypeSkin || textureType == storage.TextureTypeCape || s.config.ExtensionTextures
+}
+
+// hasExtraTextureTypes 是否注册了SKIN/CAPE之外的材质类型
+func hasExtraTextureTypes() bool {
+	for _, def := range storage.GetTextureTypes() {
+		if def.Type != storage.TextureTypeSkin && def.Type != storage.TextureTypeCape {
+			return true
+		}
+	}
+	return false
+}
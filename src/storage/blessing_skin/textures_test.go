@@ -0,0 +1,153 @@
+package blessing_skin
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/storage/blob"
+	storage "yggdrasil-api-go/src/storage/interface"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testPlayerName = "Steve"
+	testPlayerUUID = "5627dd98e6be3c21b8a8e92344183641"
+)
+
+// newTestStorage 使用SQLite创建BlessingSkin表结构和一个角色
+func newTestStorage(t *testing.T, cfg *Config, history config.TextureHistoryConfig) *Storage {
+	t.Helper()
+	dir := t.TempDir()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "bs.db")), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&User{}, &Player{}, &Texture{}, &UserCloset{}, &PlayerTexture{}, &TextureHistory{}, &UUIDMapping{}, &Option{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+
+	now := time.Now()
+	user := &User{Email: "steve@example.com", Password: "x", IP: "127.0.0.1", LastSignAt: now, RegisterAt: now}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := db.Create(&Player{UID: int(user.UID), Name: testPlayerName, TIDSkin: -1, LastModified: now}).Error; err != nil {
+		t.Fatalf("create player: %v", err)
+	}
+	if err := db.Create(&UUIDMapping{Name: testPlayerName, UUID: testPlayerUUID}).Error; err != nil {
+		t.Fatalf("create uuid: %v", err)
+	}
+
+	blobs, err := blob.NewLocalStore(map[string]any{"root": filepath.Join(dir, "textures")})
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+
+	s := &Storage{
+		db:     db,
+		config: cfg,
+		textureConfig: &TextureConfig{
+			BaseURL:       "http://localhost/textures",
+			UploadEnabled: true,
+			Blobs:         blobs,
+			History:       history,
+		},
+	}
+	s.uuidGen = NewUUIDGenerator(s)
+	s.optionsMgr = NewOptionsManager(s)
+	return s
+}
+
+func TestUploadTexture(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStorage(t, &Config{TextureBaseURLOverride: true, AddToCloset: true}, config.TextureHistoryConfig{})
+
+	skin, err := s.UploadTexture(ctx, storage.TextureTypeSkin, testPlayerUUID, []byte("skin"), &storage.TextureMetadata{Slim: true})
+	if err != nil {
+		t.Fatalf("UploadTexture: %v", err)
+	}
+	if skin.Metadata.Model != "alex" || skin.URL != "http://localhost/textures/"+skin.Metadata.Hash {
+		t.Fatalf("uploaded skin = %+v, %+v", skin, skin.Metadata)
+	}
+
+	// 相同内容复用textures记录，衣柜不重复添加
+	if _, err := s.UploadTexture(ctx, storage.TextureTypeSkin, testPlayerUUID, []byte("skin"), &storage.TextureMetadata{Slim: true}); err != nil {
+		t.Fatalf("UploadTexture again: %v", err)
+	}
+	var textures, closet int64
+	s.db.Model(&Texture{}).Count(&textures)
+	s.db.Model(&UserCloset{}).Count(&closet)
+	if textures != 1 || closet != 1 {
+		t.Fatalf("textures = %d, closet = %d; want 1 and 1", textures, closet)
+	}
+
+	player, err := s.GetPlayerByName(ctx, testPlayerName)
+	if err != nil {
+		t.Fatalf("GetPlayerByName: %v", err)
+	}
+	if player.Skin == nil || player.Skin.Hash != skin.Metadata.Hash || player.Skin.Type != "alex" {
+		t.Fatalf("player skin = %+v; want the uploaded alex skin", player.Skin)
+	}
+	if ok, _ := s.textureConfig.Blobs.Exists(skin.Metadata.Hash); !ok {
+		t.Fatal("texture file was not written")
+	}
+
+	if err := s.DeleteTexture(ctx, storage.TextureTypeSkin, testPlayerUUID); err != nil {
+		t.Fatalf("DeleteTexture: %v", err)
+	}
+	if _, err := s.GetTexture(ctx, storage.TextureTypeSkin, testPlayerUUID); err == nil {
+		t.Fatal("GetTexture after delete succeeded; want error")
+	}
+}
+
+func TestUploadExtensionTexture(t *testing.T) {
+	if err := storage.RegisterTextureTypes([]config.TextureTypeConfig{{Name: "ELYTRA", Upload: true}}); err != nil {
+		t.Fatalf("RegisterTextureTypes: %v", err)
+	}
+	t.Cleanup(func() { _ = storage.RegisterTextureTypes(nil) })
+
+	tests := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "disabled"},
+		{name: "enabled", enabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestStorage(t, &Config{TextureBaseURLOverride: true, ExtensionTextures: tt.enabled}, config.TextureHistoryConfig{})
+
+			_, err := s.UploadTexture(ctx, "ELYTRA", testPlayerUUID, []byte("elytra"), nil)
+			var textures int64
+			s.db.Model(&Texture{}).Count(&textures)
+			if !tt.enabled {
+				if err == nil || textures != 0 {
+					t.Fatalf("UploadTexture = %v with %d textures; want error and no textures row", err, textures)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UploadTexture: %v", err)
+			}
+
+			textureMap, err := s.GetPlayerTextures(ctx, testPlayerUUID)
+			if err != nil {
+				t.Fatalf("GetPlayerTextures: %v", err)
+			}
+			if textureMap["ELYTRA"] == nil {
+				t.Fatalf("GetPlayerTextures = %v; want ELYTRA", textureMap)
+			}
+		})
+	}
+}
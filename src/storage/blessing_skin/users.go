@@ -2,6 +2,7 @@
 package blessing_skin
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -18,17 +19,18 @@ import (
 )
 
 // GetUserByID 根据用户ID获取用户（单查询优化版）
-func (s *Storage) GetUserByID(userID string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error) {
 	// 一次性查询用户信息、角色列表和UUID映射
 	var results []struct {
 		UID        uint   `gorm:"column:uid"`
 		Email      string `gorm:"column:email"`
+		Permission int    `gorm:"column:permission"`
 		PlayerName string `gorm:"column:player_name"`
 		UUID       string `gorm:"column:uuid"`
 	}
 
-	err := s.db.Table("users u").
-		Select("u.uid, u.email, p.name as player_name, uuid.uuid").
+	err := s.db.WithContext(ctx).Table("users u").
+		Select("u.uid, u.email, u.permission, p.name as player_name, uuid.uuid").
 		Joins("LEFT JOIN players p ON u.uid = p.uid").
 		Joins("LEFT JOIN uuid ON p.name = uuid.name").
 		Where("u.uid = ?", userID).
@@ -85,11 +87,12 @@ func (s *Storage) GetUserByID(userID string) (*yggdrasil.User, error) {
 		Email:    userInfo.Email,
 		Password: "", // 不返回密码
 		Profiles: profiles,
+		IsAdmin:  userInfo.Permission >= 1, // BlessingSkin: -1封禁, 0普通, 1管理员, 2超级管理员
 	}, nil
 }
 
 // GetUserByEmail 根据邮箱获取用户（单查询优化版）
-func (s *Storage) GetUserByEmail(email string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error) {
 	// 一次性查询用户信息、角色列表和UUID映射
 	var results []struct {
 		UID        uint   `gorm:"column:uid"`
@@ -98,7 +101,7 @@ func (s *Storage) GetUserByEmail(email string) (*yggdrasil.User, error) {
 		UUID       string `gorm:"column:uuid"`
 	}
 
-	err := s.db.Table("users u").
+	err := s.db.WithContext(ctx).Table("users u").
 		Select("u.uid, u.email, p.name as player_name, uuid.uuid").
 		Joins("LEFT JOIN players p ON u.uid = p.uid").
 		Joins("LEFT JOIN uuid ON p.name = uuid.name").
@@ -160,7 +163,7 @@ func (s *Storage) GetUserByEmail(email string) (*yggdrasil.User, error) {
 }
 
 // GetUserByPlayerName 根据角色名获取用户（单查询优化版）
-func (s *Storage) GetUserByPlayerName(playerName string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByPlayerName(ctx context.Context, playerName string) (*yggdrasil.User, error) {
 	// 一次性查询用户信息、所有角色和UUID映射
 	var results []struct {
 		UID        uint   `gorm:"column:uid"`
@@ -169,7 +172,7 @@ func (s *Storage) GetUserByPlayerName(playerName string) (*yggdrasil.User, error
 		UUID       string `gorm:"column:uuid"`
 	}
 
-	err := s.db.Table("players p1").
+	err := s.db.WithContext(ctx).Table("players p1").
 		Select("u.uid, u.email, p2.name as player_name, uuid.uuid").
 		Joins("JOIN users u ON p1.uid = u.uid").
 		Joins("LEFT JOIN players p2 ON u.uid = p2.uid").
@@ -232,7 +235,7 @@ func (s *Storage) GetUserByPlayerName(playerName string) (*yggdrasil.User, error
 }
 
 // GetUserByUUID 根据UUID获取用户（单查询优化版）
-func (s *Storage) GetUserByUUID(uuid string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByUUID(ctx context.Context, uuid string) (*yggdrasil.User, error) {
 	// 一次性查询用户信息、角色列表和UUID映射
 	var results []struct {
 		UID        uint   `gorm:"column:uid"`
@@ -241,7 +244,7 @@ func (s *Storage) GetUserByUUID(uuid string) (*yggdrasil.User, error) {
 		UUID       string `gorm:"column:uuid"`
 	}
 
-	err := s.db.Table("uuid u1").
+	err := s.db.WithContext(ctx).Table("uuid u1").
 		Select("users.uid, users.email, p.name as player_name, u2.uuid").
 		Joins("JOIN players p1 ON u1.name = p1.name").
 		Joins("JOIN users ON p1.uid = users.uid").
@@ -305,7 +308,7 @@ func (s *Storage) GetUserByUUID(uuid string) (*yggdrasil.User, error) {
 }
 
 // AuthenticateUser 用户认证（单查询优化版）
-func (s *Storage) AuthenticateUser(username, password string) (*yggdrasil.User, error) {
+func (s *Storage) AuthenticateUser(ctx context.Context, username, password string) (*yggdrasil.User, error) {
 	// 一次性查询用户信息、角色列表和UUID映射
 	var results []struct {
 		UID        uint   `gorm:"column:uid"`
@@ -320,7 +323,7 @@ func (s *Storage) AuthenticateUser(username, password string) (*yggdrasil.User,
 	var err error
 	if strings.Contains(username, "@") {
 		// 邮箱登录
-		err = s.db.Table("users u").
+		err = s.db.WithContext(ctx).Table("users u").
 			Select("u.uid, u.email, u.password, u.permission, u.verified, p.name as player_name, uuid.uuid").
 			Joins("LEFT JOIN players p ON u.uid = p.uid").
 			Joins("LEFT JOIN uuid ON p.name = uuid.name").
@@ -328,7 +331,7 @@ func (s *Storage) AuthenticateUser(username, password string) (*yggdrasil.User,
 			Find(&results).Error
 	} else {
 		// 角色名登录
-		err = s.db.Table("players p1").
+		err = s.db.WithContext(ctx).Table("players p1").
 			Select("u.uid, u.email, u.password, u.permission, u.verified, p2.name as player_name, uuid.uuid").
 			Joins("JOIN users u ON p1.uid = u.uid").
 			Joins("LEFT JOIN players p2 ON u.uid = p2.uid").
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/google/uuid"
@@ -243,7 +244,7 @@ func (g *UUIDGenerator) GetUUIDsByNames(names []string) (map[string]string, erro
 			err = g.storage.db.Create(&newMappings).Error
 			if err != nil {
 				// 记录错误但不影响返回结果
-				fmt.Printf("⚠️  Failed to batch create UUID mappings: %v\n", err)
+				slog.Warn("Failed to batch create UUID mappings", "count", len(newMappings), "error", err)
 			}
 		}
 	}
@@ -0,0 +1,58 @@
+// Package blob 材质文件（对象）存储实现
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+
+	"yggdrasil-api-go/src/config"
+	storage "yggdrasil-api-go/src/storage/interface"
+)
+
+// NewBlobStore 根据配置创建对象存储实例
+// defaultRoot 为本地存储未配置 root 选项时使用的根目录（由各存储后端决定）
+func NewBlobStore(cfg *config.BlobStoreConfig, defaultRoot string) (storage.BlobStore, error) {
+	options := cfg.Options
+	if options == nil {
+		options = map[string]any{}
+	}
+
+	switch cfg.Type {
+	case "", "local":
+		if _, ok := options["root"].(string); !ok {
+			// 复制一份，避免修改配置中的原始选项
+			merged := make(map[string]any, len(options)+1)
+			for k, v := range options {
+				merged[k] = v
+			}
+			merged["root"] = defaultRoot
+			options = merged
+		}
+		return NewLocalStore(options)
+	case "s3":
+		return NewS3Store(options)
+	default:
+		return nil, fmt.Errorf("unsupported blob store type: %s", cfg.Type)
+	}
+}
+
+// GetSupportedTypes 获取支持的对象存储类型
+func GetSupportedTypes() []string {
+	return []string{"local", "s3"}
+}
+
+// joinURL 拼接基础URL与对象key
+func joinURL(baseURL, key string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+// HashKey 哈希分桶的对象key（"/"分隔）：baseDir/前2个字符/后2个字符/sha256(key)+扩展名
+// 文件存储和数据库存储使用相同的布局，两者可以共用材质文件
+func HashKey(baseDir, key, extension string) string {
+	hash := sha256.Sum256([]byte(key))
+	hashStr := hex.EncodeToString(hash[:])
+	return path.Join(baseDir, hashStr[:2], hashStr[2:4], hashStr+extension)
+}
@@ -0,0 +1,170 @@
+// Package blob 本地文件系统对象存储
+package blob
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore 本地文件系统对象存储
+type LocalStore struct {
+	root      string // 根目录
+	publicURL string // 公开访问地址前缀（如Nginx/CDN），为空时不提供直接地址
+}
+
+// NewLocalStore 创建本地文件系统对象存储（目录在首次写入时创建）
+func NewLocalStore(options map[string]any) (*LocalStore, error) {
+	root := "data"
+	if dir, ok := options["root"].(string); ok && dir != "" {
+		root = dir
+	}
+
+	publicURL := ""
+	if url, ok := options["public_url"].(string); ok {
+		publicURL = url
+	}
+
+	return &LocalStore{
+		root:      root,
+		publicURL: publicURL,
+	}, nil
+}
+
+// path 将key转换为本地文件路径（禁止跳出根目录）
+func (s *LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + filepath.FromSlash(key))
+	if cleaned == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return filepath.Join(s.root, cleaned), nil
+}
+
+// Put 写入对象（先写临时文件再重命名，避免读到不完整的文件）
+func (s *LocalStore) Put(key string, data []byte, contentType string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), ".blob-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	return nil
+}
+
+// Get 读取对象
+func (s *LocalStore) Get(key string) ([]byte, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filePath)
+}
+
+// Delete 删除对象
+func (s *LocalStore) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Exists 检查对象是否存在
+func (s *LocalStore) Exists(key string) (bool, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// List 列出指定前缀下的所有对象key（跳过写入中的临时文件）
+func (s *LocalStore) List(prefix string) ([]string, error) {
+	// 只遍历前缀所在的目录
+	start := s.root
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir, err := s.path(prefix[:i])
+		if err != nil {
+			return nil, err
+		}
+		start = dir
+	}
+
+	var keys []string
+	err := filepath.WalkDir(start, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && filePath == start {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".blob-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, filePath)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+	return keys, nil
+}
+
+// URL 获取对象的公开访问地址
+func (s *LocalStore) URL(key string) (string, error) {
+	if s.publicURL == "" {
+		return "", nil
+	}
+	return joinURL(s.publicURL, strings.TrimPrefix(key, "/")), nil
+}
+
+// GetBlobStoreType 获取对象存储类型
+func (s *LocalStore) GetBlobStoreType() string {
+	return "local"
+}
@@ -0,0 +1,470 @@
+// Package blob S3兼容对象存储（AWS S3 / MinIO / R2 等，使用SigV4签名）
+package blob
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3Service         = "s3"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3Store S3兼容对象存储
+type S3Store struct {
+	endpoint      *url.URL      // 服务端点
+	region        string        // 区域
+	bucket        string        // 存储桶
+	accessKey     string        // 访问密钥ID
+	secretKey     string        // 访问密钥
+	pathStyle     bool          // 是否使用路径风格（MinIO等通常需要）
+	prefix        string        // key前缀
+	publicURL     string        // 公开访问地址前缀（CDN或公开桶），优先于预签名
+	presign       bool          // 是否生成预签名URL
+	presignExpiry time.Duration // 预签名URL有效期
+	client        *http.Client
+}
+
+// NewS3Store 创建S3兼容对象存储
+func NewS3Store(options map[string]any) (*S3Store, error) {
+	region := "us-east-1"
+	if r, ok := options["region"].(string); ok && r != "" {
+		region = r
+	}
+
+	bucket, _ := options["bucket"].(string)
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 blob store requires bucket")
+	}
+
+	endpoint := fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	if e, ok := options["endpoint"].(string); ok && e != "" {
+		endpoint = e
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint: %s", endpoint)
+	}
+
+	store := &S3Store{
+		endpoint:      endpointURL,
+		region:        region,
+		bucket:        bucket,
+		presignExpiry: time.Hour,
+		client:        &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if ak, ok := options["access_key"].(string); ok {
+		store.accessKey = ak
+	}
+	if sk, ok := options["secret_key"].(string); ok {
+		store.secretKey = sk
+	}
+	if ps, ok := options["path_style"].(bool); ok {
+		store.pathStyle = ps
+	}
+	if p, ok := options["prefix"].(string); ok {
+		store.prefix = strings.Trim(p, "/")
+	}
+	if u, ok := options["public_url"].(string); ok {
+		store.publicURL = u
+	}
+	if ps, ok := options["presign"].(bool); ok {
+		store.presign = ps
+	}
+	if exp, ok := options["presign_expiry"].(string); ok && exp != "" {
+		d, err := time.ParseDuration(exp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 presign_expiry: %w", err)
+		}
+		// SigV4预签名最长7天
+		if d <= 0 || d > 7*24*time.Hour {
+			return nil, fmt.Errorf("s3 presign_expiry must be between 1s and 168h")
+		}
+		store.presignExpiry = d
+	}
+	if timeout, ok := options["timeout"].(string); ok && timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid s3 timeout: %w", err)
+		}
+		store.client.Timeout = d
+	}
+
+	return store, nil
+}
+
+// objectKey 添加前缀后的完整对象key
+func (s *S3Store) objectKey(key string) string {
+	key = strings.TrimLeft(key, "/")
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+// objectURL 构建对象地址（返回已转义路径）
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimRight(u.Path, "/")
+	escapedKey := s3EscapePath(s.objectKey(key))
+
+	if s.pathStyle {
+		u.RawPath = basePath + "/" + s3EscapePath(s.bucket) + "/" + escapedKey
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.RawPath = basePath + "/" + escapedKey
+	}
+	u.Path, _ = url.PathUnescape(u.RawPath)
+	u.RawQuery = ""
+	return &u
+}
+
+// Put 写入对象
+func (s *S3Store) Put(key string, data []byte, contentType string) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.ContentLength = int64(len(data))
+
+	resp, err := s.do(req, sha256Hex(data))
+	if err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error("put", key, resp)
+	}
+	return nil
+}
+
+// Get 读取对象
+func (s *S3Store) Get(key string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, sha256Hex(nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("blob %s: %w", key, os.ErrNotExist)
+	default:
+		return nil, s3Error("get", key, resp)
+	}
+}
+
+// Delete 删除对象
+func (s *S3Store) Delete(key string) error {
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, sha256Hex(nil))
+	if err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s3Error("delete", key, resp)
+	}
+}
+
+// Exists 检查对象是否存在
+func (s *S3Store) Exists(key string) (bool, error) {
+	req, err := http.NewRequest(http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.do(req, sha256Hex(nil))
+	if err != nil {
+		return false, fmt.Errorf("failed to stat blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, s3Error("head", key, resp)
+	}
+}
+
+// s3ListResult ListObjectsV2响应
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List 列出指定前缀下的所有对象key（ListObjectsV2，自动翻页）
+func (s *S3Store) List(prefix string) ([]string, error) {
+	bucketURL := *s.endpoint
+	basePath := strings.TrimRight(bucketURL.Path, "/")
+	if s.pathStyle {
+		bucketURL.RawPath = basePath + "/" + s3EscapePath(s.bucket) + "/"
+	} else {
+		bucketURL.Host = s.bucket + "." + bucketURL.Host
+		bucketURL.RawPath = basePath + "/"
+	}
+	bucketURL.Path, _ = url.PathUnescape(bucketURL.RawPath)
+
+	keyPrefix := ""
+	if s.prefix != "" {
+		keyPrefix = s.prefix + "/"
+	}
+
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", keyPrefix+strings.TrimLeft(prefix, "/"))
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		bucketURL.RawQuery = s3CanonicalQuery(query)
+
+		req, err := http.NewRequest(http.MethodGet, bucketURL.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req, sha256Hex(nil))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error("list", prefix, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse s3 list response: %w", err)
+		}
+
+		for _, object := range result.Contents {
+			keys = append(keys, strings.TrimPrefix(object.Key, keyPrefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// URL 获取对象的直接访问地址
+// 配置了public_url时返回公开地址；启用presign时返回预签名GET地址；否则返回空字符串
+// 注意：材质URL会被写入签名后的textures属性并被客户端缓存，预签名有效期不宜过短
+func (s *S3Store) URL(key string) (string, error) {
+	if s.publicURL != "" {
+		return joinURL(s.publicURL, s3EscapePath(s.objectKey(key))), nil
+	}
+	if !s.presign {
+		return "", nil
+	}
+	return s.presignGet(key, time.Now())
+}
+
+// GetBlobStoreType 获取对象存储类型
+func (s *S3Store) GetBlobStoreType() string {
+	return "s3"
+}
+
+// do 签名并发送请求
+func (s *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// 参与签名的头部（小写、排序）
+	signedHeaderNames := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("Content-Type") != "" {
+		signedHeaderNames = append(signedHeaderNames, "content-type")
+	}
+	sort.Strings(signedHeaderNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaderNames {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, scope, signedHeaders, signature))
+
+	return s.client.Do(req)
+}
+
+// presignGet 生成预签名GET地址（查询参数签名）
+func (s *S3Store) presignGet(key string, now time.Time) (string, error) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	u := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int64(s.presignExpiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalQuery := s3CanonicalQuery(query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+
+	signature := s.signature(now, amzDate, scope, canonicalRequest)
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String(), nil
+}
+
+// scope 签名作用域
+func (s *S3Store) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+// signature 计算SigV4签名
+func (s *S3Store) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// s3Error 构建包含响应内容的错误
+func s3Error(op, key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s %s failed: status %d: %s", op, key, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// s3CanonicalQuery 构建规范化查询字符串（按key排序，RFC3986编码）
+func s3CanonicalQuery(values url.Values) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath 按S3规则转义对象路径（保留"/"）
+func s3EscapePath(path string) string {
+	return s3Escape(path, true)
+}
+
+// s3Escape RFC3986编码，仅保留非保留字符
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sha256Hex 计算SHA256十六进制摘要
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 计算HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,321 @@
+// Package cached 带用户缓存的存储装饰器
+package cached
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"yggdrasil-api-go/src/cache"
+	cacheredis "yggdrasil-api-go/src/cache/redis"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/utils"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// 跨实例失效消息类型
+const (
+	kindUser    = "user_cache"    // 用户的所有条目（用户ID）
+	kindProfile = "profile_cache" // 角色及其所属用户的条目（角色UUID）
+)
+
+// Storage 带缓存的存储：用户和角色读取优先使用UserCache，写操作后按用户ID/角色UUID失效
+// 认证结果不缓存（修改密码和封禁必须立即生效）；未覆盖的方法直接委托给底层存储
+type Storage struct {
+	storage.Storage
+	cache       *cache.UserCache
+	broadcaster *cacheredis.Broadcaster // 通知其他实例失效（可为nil，此时其他实例依赖缓存过期时间）
+}
+
+// NewStorage 创建带缓存的存储，并订阅其他实例（或命令行）发出的失效消息和底层存储的变更通知
+func NewStorage(inner storage.Storage, userCache *cache.UserCache, broadcaster *cacheredis.Broadcaster) *Storage {
+	s := &Storage{
+		Storage:     inner,
+		cache:       userCache,
+		broadcaster: broadcaster,
+	}
+
+	broadcaster.Subscribe(kindUser, func(userID string) {
+		s.cache.DeleteTag(userTag(userID))
+	})
+	broadcaster.Subscribe(kindProfile, func(profileUUID string) {
+		s.cache.DeleteTag(profileTag(profileUUID))
+	})
+
+	// 底层存储发现的外部修改（如文件存储重新加载命令行写入的数据）只需使本实例的缓存失效
+	storage.OnUserChanged(func(userID string) {
+		s.cache.DeleteTag(userTag(userID))
+	})
+	storage.OnProfileChanged(func(profileUUID string) {
+		s.cache.DeleteTag(profileTag(profileUUID))
+	})
+	return s
+}
+
+// Unwrap 返回被装饰的存储
+func (s *Storage) Unwrap() storage.Storage {
+	return s.Storage
+}
+
+// userTag 用户相关条目的标签
+func userTag(userID string) string {
+	return "user:" + userID
+}
+
+// profileTag 角色相关条目的标签
+func profileTag(profileUUID string) string {
+	return "profile:" + normalizeUUID(profileUUID)
+}
+
+// normalizeUUID 统一为无连字符小写格式
+func normalizeUUID(uuid string) string {
+	return strings.ToLower(utils.RemoveUUIDHyphens(uuid))
+}
+
+// userTags 用户条目的标签（用户ID和其所有角色，角色改名时同样失效）
+func userTags(user *yggdrasil.User) []string {
+	tags := make([]string, 0, len(user.Profiles)+1)
+	tags = append(tags, userTag(user.ID))
+	for _, profile := range user.Profiles {
+		tags = append(tags, profileTag(profile.ID))
+	}
+	return tags
+}
+
+// cachedUser 从缓存获取用户，未命中时调用lookup并写入缓存
+func (s *Storage) cachedUser(key string, lookup func() (*yggdrasil.User, error)) (*yggdrasil.User, error) {
+	if user, ok := s.cache.Get(key); ok {
+		return copyUser(user), nil
+	}
+
+	user, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Set(key, copyUser(user), userTags(user)...)
+	return user, nil
+}
+
+// cachedProfile 从缓存获取角色，未命中时调用lookup并写入缓存
+func (s *Storage) cachedProfile(key string, lookup func() (*yggdrasil.Profile, error)) (*yggdrasil.Profile, error) {
+	if profile, ok := s.cache.GetProfile(key); ok {
+		return copyProfile(profile), nil
+	}
+
+	profile, err := lookup()
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.SetProfile(key, copyProfile(profile), profileTag(profile.ID))
+	return profile, nil
+}
+
+// GetUserByEmail 根据邮箱获取用户
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error) {
+	return s.cachedUser("email:"+strings.ToLower(email), func() (*yggdrasil.User, error) {
+		return s.Storage.GetUserByEmail(ctx, email)
+	})
+}
+
+// GetUserByID 根据用户ID获取用户（每次/refresh都会调用）
+func (s *Storage) GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error) {
+	return s.cachedUser("id:"+userID, func() (*yggdrasil.User, error) {
+		return s.Storage.GetUserByID(ctx, userID)
+	})
+}
+
+// GetUserByPlayerName 根据角色名获取用户
+func (s *Storage) GetUserByPlayerName(ctx context.Context, playerName string) (*yggdrasil.User, error) {
+	return s.cachedUser("player:"+strings.ToLower(playerName), func() (*yggdrasil.User, error) {
+		return s.Storage.GetUserByPlayerName(ctx, playerName)
+	})
+}
+
+// GetUserByUUID 根据UUID获取用户
+func (s *Storage) GetUserByUUID(ctx context.Context, uuid string) (*yggdrasil.User, error) {
+	return s.cachedUser("uuid:"+normalizeUUID(uuid), func() (*yggdrasil.User, error) {
+		return s.Storage.GetUserByUUID(ctx, uuid)
+	})
+}
+
+// GetProfileByUUID 根据UUID获取角色
+func (s *Storage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
+	return s.cachedProfile("profile:"+normalizeUUID(uuid), func() (*yggdrasil.Profile, error) {
+		return s.Storage.GetProfileByUUID(ctx, uuid)
+	})
+}
+
+// GetProfileByName 根据名称获取角色
+func (s *Storage) GetProfileByName(ctx context.Context, name string) (*yggdrasil.Profile, error) {
+	return s.cachedProfile("profile_name:"+strings.ToLower(name), func() (*yggdrasil.Profile, error) {
+		return s.Storage.GetProfileByName(ctx, name)
+	})
+}
+
+// UploadTexture 上传材质文件（成功后使角色缓存失效）
+func (s *Storage) UploadTexture(ctx context.Context, textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
+	info, err := s.Storage.UploadTexture(ctx, textureType, playerUUID, data, metadata)
+	if err == nil {
+		s.InvalidateProfile(playerUUID)
+	}
+	return info, err
+}
+
+// DeleteTexture 删除材质文件（成功后使角色缓存失效）
+func (s *Storage) DeleteTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) error {
+	err := s.Storage.DeleteTexture(ctx, textureType, playerUUID)
+	if err == nil {
+		s.InvalidateProfile(playerUUID)
+	}
+	return err
+}
+
+// RevertTexture 恢复历史材质（成功后使角色缓存失效）
+func (s *Storage) RevertTexture(ctx context.Context, playerUUID string, entryID int64) (*storage.TextureHistoryEntry, error) {
+	entry, err := s.Storage.RevertTexture(ctx, playerUUID, entryID)
+	if err == nil {
+		s.InvalidateProfile(playerUUID)
+	}
+	return entry, err
+}
+
+// accountManager 底层存储的用户管理能力
+func (s *Storage) accountManager() (storage.AccountManager, error) {
+	manager, ok := storage.As[storage.AccountManager](s.Storage)
+	if !ok {
+		return nil, fmt.Errorf("user and profile management is not supported by %s storage", s.GetStorageType())
+	}
+	return manager, nil
+}
+
+// ListAccounts 列出所有用户
+func (s *Storage) ListAccounts(ctx context.Context) ([]*storage.UserAccount, error) {
+	manager, err := s.accountManager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.ListAccounts(ctx)
+}
+
+// CreateAccount 创建用户（新用户没有缓存条目，无需失效）
+func (s *Storage) CreateAccount(ctx context.Context, email, passwordHash string, admin bool) (*storage.UserAccount, error) {
+	manager, err := s.accountManager()
+	if err != nil {
+		return nil, err
+	}
+	return manager.CreateAccount(ctx, email, passwordHash, admin)
+}
+
+// SetPassword 修改用户密码（成功后使用户缓存失效）
+func (s *Storage) SetPassword(ctx context.Context, email, passwordHash string) error {
+	manager, err := s.accountManager()
+	if err != nil {
+		return err
+	}
+	if err := manager.SetPassword(ctx, email, passwordHash); err != nil {
+		return err
+	}
+	s.invalidateEmail(ctx, email)
+	return nil
+}
+
+// SetBanned 封禁或解封用户（成功后使用户缓存失效）
+func (s *Storage) SetBanned(ctx context.Context, email string, banned bool) error {
+	manager, err := s.accountManager()
+	if err != nil {
+		return err
+	}
+	if err := manager.SetBanned(ctx, email, banned); err != nil {
+		return err
+	}
+	s.invalidateEmail(ctx, email)
+	return nil
+}
+
+// AddProfile 为用户添加角色（成功后使用户缓存失效）
+func (s *Storage) AddProfile(ctx context.Context, email string, profile *yggdrasil.Profile) error {
+	manager, err := s.accountManager()
+	if err != nil {
+		return err
+	}
+	if err := manager.AddProfile(ctx, email, profile); err != nil {
+		return err
+	}
+	s.invalidateEmail(ctx, email)
+	return nil
+}
+
+// RenameProfile 修改角色名称（成功后使角色及其所属用户的缓存失效）
+func (s *Storage) RenameProfile(ctx context.Context, uuid, name string) error {
+	manager, err := s.accountManager()
+	if err != nil {
+		return err
+	}
+	if err := manager.RenameProfile(ctx, uuid, name); err != nil {
+		return err
+	}
+	s.InvalidateProfile(uuid)
+	return nil
+}
+
+// RemoveProfile 删除角色（成功后使角色及其所属用户的缓存失效）
+func (s *Storage) RemoveProfile(ctx context.Context, uuid string) error {
+	manager, err := s.accountManager()
+	if err != nil {
+		return err
+	}
+	if err := manager.RemoveProfile(ctx, uuid); err != nil {
+		return err
+	}
+	s.InvalidateProfile(uuid)
+	return nil
+}
+
+// invalidateEmail 使邮箱对应用户的缓存失效（绕过缓存查询用户ID）
+func (s *Storage) invalidateEmail(ctx context.Context, email string) {
+	s.cache.Delete("email:" + strings.ToLower(email))
+	if user, err := s.Storage.GetUserByEmail(ctx, email); err == nil {
+		s.InvalidateUser(user.ID)
+	}
+}
+
+// InvalidateUser 使用户的所有缓存条目失效，并通知其他实例（修改密码、封禁、新建角色后调用）
+func (s *Storage) InvalidateUser(userID string) {
+	s.cache.DeleteTag(userTag(userID))
+	s.broadcaster.Publish(kindUser, userID)
+}
+
+// InvalidateProfile 使角色及其所属用户的缓存条目失效，并通知其他实例（材质变化、改名后调用）
+func (s *Storage) InvalidateProfile(profileUUID string) {
+	s.cache.DeleteTag(profileTag(profileUUID))
+	s.broadcaster.Publish(kindProfile, normalizeUUID(profileUUID))
+}
+
+// GetCacheStats 获取用户缓存统计
+func (s *Storage) GetCacheStats() map[string]interface{} {
+	return s.cache.GetStats()
+}
+
+// copyUser 复制用户（调用方可能修改返回值，缓存中保留独立副本）
+func copyUser(user *yggdrasil.User) *yggdrasil.User {
+	copied := *user
+	if user.Profiles != nil {
+		copied.Profiles = make([]yggdrasil.Profile, len(user.Profiles))
+		for i := range user.Profiles {
+			copied.Profiles[i] = *copyProfile(&user.Profiles[i])
+		}
+	}
+	return &copied
+}
+
+// copyProfile 复制角色（处理器会就地修改属性签名）
+func copyProfile(profile *yggdrasil.Profile) *yggdrasil.Profile {
+	copied := *profile
+	if profile.Properties != nil {
+		copied.Properties = make([]yggdrasil.ProfileProperty, len(profile.Properties))
+		copy(copied.Properties, profile.Properties)
+	}
+	return &copied
+}
@@ -0,0 +1,120 @@
+package cached
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/cache"
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/storage/blob"
+	"yggdrasil-api-go/src/storage/database"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+const testProfileUUID = "5627dd98e6be3c21b8a8e92344183641"
+
+// countingStorage 统计底层存储的查询次数
+type countingStorage struct {
+	*database.Storage
+	lookups int
+}
+
+func (s *countingStorage) GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error) {
+	s.lookups++
+	return s.Storage.GetUserByEmail(ctx, email)
+}
+
+func (s *countingStorage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
+	s.lookups++
+	return s.Storage.GetProfileByUUID(ctx, uuid)
+}
+
+// newTestStorage 使用SQLite数据库存储创建带缓存的存储和一个用户
+func newTestStorage(t *testing.T) (*Storage, *countingStorage) {
+	t.Helper()
+	dir := t.TempDir()
+	blobs, err := blob.NewLocalStore(map[string]any{"root": dir})
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	options := map[string]any{
+		"database_dsn": "sqlite://" + filepath.Join(dir, "ygg.db"),
+		"table_prefix": "ygg_",
+		"blob_store":   blobs,
+	}
+	inner, err := database.NewStorage(options, &config.TextureConfig{BaseURL: "http://textures.test"})
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	t.Cleanup(func() { inner.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	counting := &countingStorage{Storage: inner}
+	s := NewStorage(counting, cache.NewUserCache(ctx, time.Minute, 100, time.Hour), nil)
+
+	if _, err := s.CreateAccount(context.Background(), "steve@example.com", "hash1", false); err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	if err := s.AddProfile(context.Background(), "steve@example.com", &yggdrasil.Profile{ID: testProfileUUID, Name: "Steve"}); err != nil {
+		t.Fatalf("AddProfile: %v", err)
+	}
+	counting.lookups = 0
+	return s, counting
+}
+
+func TestStorageUserCache(t *testing.T) {
+	s, inner := newTestStorage(t)
+	ctx := context.Background()
+
+	user, err := s.GetUserByEmail(ctx, "steve@example.com")
+	if err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	user.Password = "modified" // 修改返回值不影响缓存
+	user, err = s.GetUserByEmail(ctx, "steve@example.com")
+	if err != nil || user.Password != "hash1" || inner.lookups != 1 {
+		t.Fatalf("GetUserByEmail = %+v, %v after %d lookups; want cached hash1 after 1 lookup", user, err, inner.lookups)
+	}
+
+	// 修改密码后缓存立即失效
+	if err := s.SetPassword(ctx, "steve@example.com", "hash2"); err != nil {
+		t.Fatalf("SetPassword: %v", err)
+	}
+	user, err = s.GetUserByEmail(ctx, "steve@example.com")
+	if err != nil || user.Password != "hash2" {
+		t.Fatalf("GetUserByEmail after SetPassword = %+v, %v; want hash2", user, err)
+	}
+}
+
+func TestStorageProfileCache(t *testing.T) {
+	s, inner := newTestStorage(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetProfileByUUID(ctx, testProfileUUID); err != nil {
+			t.Fatalf("GetProfileByUUID: %v", err)
+		}
+	}
+	if inner.lookups != 1 {
+		t.Fatalf("inner lookups = %d; want 1", inner.lookups)
+	}
+
+	// 改名后角色及其所属用户的缓存失效
+	if _, err := s.GetUserByEmail(ctx, "steve@example.com"); err != nil {
+		t.Fatalf("GetUserByEmail: %v", err)
+	}
+	if err := s.RenameProfile(ctx, testProfileUUID, "Alex"); err != nil {
+		t.Fatalf("RenameProfile: %v", err)
+	}
+	profile, err := s.GetProfileByUUID(ctx, testProfileUUID)
+	if err != nil || profile.Name != "Alex" {
+		t.Fatalf("GetProfileByUUID after rename = %+v, %v; want Alex", profile, err)
+	}
+	user, err := s.GetUserByEmail(ctx, "steve@example.com")
+	if err != nil || len(user.Profiles) != 1 || user.Profiles[0].Name != "Alex" {
+		t.Fatalf("GetUserByEmail after rename = %+v, %v; want profile Alex", user, err)
+	}
+}
@@ -0,0 +1,264 @@
+// Package database 数据库存储账号管理和材质清理（管理命令使用）
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"gorm.io/gorm"
+)
+
+// ListAccounts 列出所有用户（按用户ID排序）
+func (s *Storage) ListAccounts(ctx context.Context) ([]*storage.UserAccount, error) {
+	var users []User
+	if err := s.query(ctx, "users").Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []Profile
+	if err := s.query(ctx, "profiles").Order("name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	profiles := make(map[int64][]storage.AccountProfile)
+	for _, profile := range rows {
+		profiles[profile.UserID] = append(profiles[profile.UserID], storage.AccountProfile{ID: profile.UUID, Name: profile.Name})
+	}
+
+	accounts := make([]*storage.UserAccount, 0, len(users))
+	for i := range users {
+		account := userAccount(&users[i])
+		if userProfiles := profiles[users[i].ID]; userProfiles != nil {
+			account.Profiles = userProfiles
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// CreateAccount 创建用户
+func (s *Storage) CreateAccount(ctx context.Context, email, passwordHash string, admin bool) (*storage.UserAccount, error) {
+	now := time.Now()
+	user := &User{
+		Email:     email,
+		Password:  passwordHash,
+		Admin:     admin,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Table(s.table("users")).Where("email = ?", email).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("user already exists")
+		}
+		return tx.Table(s.table("users")).Create(user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userAccount(user), nil
+}
+
+// SetPassword 修改用户密码
+func (s *Storage) SetPassword(ctx context.Context, email, passwordHash string) error {
+	return s.updateUser(ctx, email, map[string]any{"password": passwordHash, "updated_at": time.Now()})
+}
+
+// SetBanned 封禁或解封用户
+func (s *Storage) SetBanned(ctx context.Context, email string, banned bool) error {
+	return s.updateUser(ctx, email, map[string]any{"banned": banned, "updated_at": time.Now()})
+}
+
+// updateUser 按邮箱更新用户
+func (s *Storage) updateUser(ctx context.Context, email string, values map[string]any) error {
+	result := s.query(ctx, "users").Where("email = ?", email).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// AddProfile 为用户添加角色
+func (s *Storage) AddProfile(ctx context.Context, email string, profile *yggdrasil.Profile) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user User
+		if err := tx.Table(s.table("users")).Select("id").Where("email = ?", email).Take(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("user not found")
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.Table(s.table("profiles")).Where("name = ?", profile.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("profile name already exists")
+		}
+		if err := tx.Table(s.table("profiles")).Where("uuid = ?", profile.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("profile UUID already exists")
+		}
+
+		now := time.Now()
+		return tx.Table(s.table("profiles")).Create(&Profile{
+			UUID:      profile.ID,
+			UserID:    user.ID,
+			Name:      profile.Name,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).Error
+	})
+}
+
+// RenameProfile 修改角色名称
+func (s *Storage) RenameProfile(ctx context.Context, uuid, name string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Table(s.table("profiles")).Where("name = ? AND uuid <> ?", name, uuid).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("profile name already exists")
+		}
+
+		result := tx.Table(s.table("profiles")).Where("uuid = ?", uuid).
+			Updates(map[string]any{"name": name, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("profile not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	storage.NotifyProfileChanged(uuid)
+	return nil
+}
+
+// RemoveProfile 删除角色（角色的材质记录和历史由材质清理删除）
+func (s *Storage) RemoveProfile(ctx context.Context, uuid string) error {
+	result := s.query(ctx, "profiles").Where("uuid = ?", uuid).Delete(&Profile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("profile not found")
+	}
+
+	storage.NotifyProfileChanged(uuid)
+	return nil
+}
+
+// userAccount 转换为管理命令输出的用户信息（不含角色）
+func userAccount(user *User) *storage.UserAccount {
+	return &storage.UserAccount{
+		ID:       strconv.FormatInt(user.ID, 10),
+		Email:    user.Email,
+		Admin:    user.Admin,
+		Banned:   user.Banned,
+		Profiles: []storage.AccountProfile{},
+	}
+}
+
+// CollectTextures 清理已删除角色的材质记录和历史记录，以及不再被引用的材质文件
+// 启用材质历史时，历史记录引用的材质文件同样保留（用于恢复）
+func (s *Storage) CollectTextures(ctx context.Context, dryRun bool) (*storage.TextureGCResult, error) {
+	lister, ok := s.blobs.(storage.BlobLister)
+	if !ok {
+		return nil, fmt.Errorf("%s blob store does not support listing", s.blobs.GetBlobStoreType())
+	}
+
+	result := &storage.TextureGCResult{DryRun: dryRun, OrphanBlobs: []string{}}
+	orphaned := fmt.Sprintf("profile_uuid NOT IN (SELECT uuid FROM %s)", s.table("profiles"))
+
+	// 已删除角色的材质记录和历史记录
+	var staleMetadata, staleHistory int64
+	if err := s.query(ctx, "profile_textures").Where(orphaned).Count(&staleMetadata).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stale texture records: %w", err)
+	}
+	if err := s.query(ctx, "texture_history").Where(orphaned).Count(&staleHistory).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stale texture history: %w", err)
+	}
+	result.StaleMetadata = int(staleMetadata)
+	result.StaleHistory = int(staleHistory)
+	if !dryRun {
+		if err := s.query(ctx, "profile_textures").Where(orphaned).Delete(&ProfileTexture{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to remove stale texture records: %w", err)
+		}
+		if err := s.query(ctx, "texture_history").Where(orphaned).Delete(&TextureHistory{}).Error; err != nil {
+			return nil, fmt.Errorf("failed to remove stale texture history: %w", err)
+		}
+	}
+
+	// 仍被引用的材质（dry run时排除将被清理的记录）
+	referenced := make(map[string]bool)
+	reference := func(textureType, hash string) {
+		textureDir := textureType + "s"
+		for _, extension := range textureExtensions {
+			referenced[textureKey(textureDir, hash, extension)] = true
+		}
+	}
+	var textures []ProfileTexture
+	if err := s.query(ctx, "profile_textures").Select("type, hash").Not(orphaned).Find(&textures).Error; err != nil {
+		return nil, err
+	}
+	for _, texture := range textures {
+		reference(texture.Type, texture.Hash)
+	}
+	var history []TextureHistory
+	if err := s.query(ctx, "texture_history").Select("type, hash").Where("hash <> ''").Not(orphaned).Find(&history).Error; err != nil {
+		return nil, err
+	}
+	for _, entry := range history {
+		reference(entry.Type, entry.Hash)
+	}
+
+	textureDirs := make(map[string]bool)
+	for _, def := range storage.GetTextureTypes() {
+		textureDirs[string(def.Type)+"s"] = true
+	}
+
+	// 材质文件：只处理材质类型目录下的图片
+	keys, err := lister.List("textures/")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts := strings.Split(key, "/")
+		ext := path.Ext(key)
+		if len(parts) < 3 || !textureDirs[parts[1]] || (ext != ".png" && ext != ".jpg") || referenced[key] {
+			continue
+		}
+		result.OrphanBlobs = append(result.OrphanBlobs, key)
+		if !dryRun {
+			if err := s.blobs.Delete(key); err != nil {
+				return nil, fmt.Errorf("failed to delete texture file %s: %w", key, err)
+			}
+		}
+	}
+
+	return result, nil
+}
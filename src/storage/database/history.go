@@ -0,0 +1,157 @@
+// Package database 数据库存储材质变更历史
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+
+	"gorm.io/gorm"
+)
+
+// recordTextureHistory 记录被覆盖的材质状态，并按保留策略清理旧记录
+// prior为变更前的材质（没有时为nil）；失败时只记录日志，不影响材质写入
+func (s *Storage) recordTextureHistory(ctx context.Context, textureType storage.TextureType, playerUUID string, prior *ProfileTexture, defaultAction string) {
+	if !s.textureConfig.History.Enabled {
+		return
+	}
+
+	change := storage.TextureChangeFromContext(ctx, defaultAction)
+	record := &TextureHistory{
+		ProfileUUID: playerUUID,
+		Type:        string(textureType),
+		Action:      change.Action,
+		Actor:       change.Actor,
+		ActorIP:     change.ActorIP,
+		CreatedAt:   time.Now(),
+	}
+	if prior != nil {
+		record.Hash = prior.Hash
+		if prior.Slim {
+			record.Model = "slim"
+		}
+	}
+
+	if err := s.query(ctx, "texture_history").Create(record).Error; err != nil {
+		slog.WarnContext(ctx, "Failed to record texture history", "profile_id", playerUUID, "error", err)
+		return
+	}
+	if err := s.pruneTextureHistory(ctx, playerUUID, string(textureType)); err != nil {
+		slog.WarnContext(ctx, "Failed to prune texture history", "profile_id", playerUUID, "error", err)
+	}
+}
+
+// pruneTextureHistory 按保留策略清理角色指定材质类型的历史记录
+func (s *Storage) pruneTextureHistory(ctx context.Context, playerUUID, textureType string) error {
+	history := s.textureConfig.History
+
+	// 清理过期记录
+	if history.MaxAge > 0 {
+		err := s.query(ctx, "texture_history").
+			Where("profile_uuid = ? AND type = ? AND created_at < ?", playerUUID, textureType, time.Now().Add(-history.MaxAge)).
+			Delete(&TextureHistory{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 只保留最新的MaxEntries条
+	if history.MaxEntries > 0 {
+		var cutoff []int64
+		err := s.query(ctx, "texture_history").
+			Where("profile_uuid = ? AND type = ?", playerUUID, textureType).
+			Order("id DESC").Offset(history.MaxEntries-1).Limit(1).
+			Pluck("id", &cutoff).Error
+		if err != nil {
+			return err
+		}
+		if len(cutoff) > 0 {
+			return s.query(ctx, "texture_history").
+				Where("profile_uuid = ? AND type = ? AND id < ?", playerUUID, textureType, cutoff[0]).
+				Delete(&TextureHistory{}).Error
+		}
+	}
+
+	return nil
+}
+
+// GetTextureHistory 获取角色的材质变更历史
+func (s *Storage) GetTextureHistory(ctx context.Context, playerUUID string, textureType storage.TextureType, limit int) ([]*storage.TextureHistoryEntry, error) {
+	query := s.query(ctx, "texture_history").Where("profile_uuid = ?", playerUUID)
+	if textureType != "" {
+		query = query.Where("type = ?", string(textureType))
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var records []TextureHistory
+	if err := query.Order("id DESC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to query texture history: %w", err)
+	}
+
+	entries := make([]*storage.TextureHistoryEntry, 0, len(records))
+	for i := range records {
+		entries = append(entries, toTextureHistoryEntry(&records[i]))
+	}
+	return entries, nil
+}
+
+// RevertTexture 将角色材质恢复到指定历史记录的状态
+func (s *Storage) RevertTexture(ctx context.Context, playerUUID string, entryID int64) (*storage.TextureHistoryEntry, error) {
+	var record TextureHistory
+	err := s.query(ctx, "texture_history").Where("id = ? AND profile_uuid = ?", entryID, playerUUID).Take(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("history entry not found")
+		}
+		return nil, err
+	}
+	target := toTextureHistoryEntry(&record)
+
+	// 恢复前的状态同样写入历史，以便撤销本次恢复
+	ctx = storage.WithTextureChange(ctx, storage.TextureChangeFromContext(ctx, storage.TextureActionRevert))
+
+	// 恢复到删除状态
+	if target.Hash == "" {
+		if err := s.DeleteTexture(ctx, target.Type, playerUUID); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+
+	// 从材质文件存储读取历史材质并重新设置
+	data, err := s.getTextureBlob(string(target.Type)+"s", target.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("texture file is no longer available: %w", err)
+	}
+
+	metadata := &storage.TextureMetadata{
+		Model: target.Model,
+		Slim:  target.Model == "slim",
+	}
+	if _, err := s.UploadTexture(ctx, target.Type, playerUUID, data, metadata); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// toTextureHistoryEntry 将数据库记录转换为历史记录
+func toTextureHistoryEntry(record *TextureHistory) *storage.TextureHistoryEntry {
+	return &storage.TextureHistoryEntry{
+		ID:        record.ID,
+		ProfileID: record.ProfileUUID,
+		Type:      storage.TextureType(record.Type),
+		Action:    record.Action,
+		Hash:      record.Hash,
+		Model:     record.Model,
+		Actor:     record.Actor,
+		ActorIP:   record.ActorIP,
+		CreatedAt: record.CreatedAt,
+	}
+}
@@ -0,0 +1,183 @@
+// Package database 数据库存储的表结构迁移（按方言）
+package database
+
+import (
+	sqldb "yggdrasil-api-go/src/database"
+)
+
+// storageTables 数据库存储的表及其迁移（按依赖顺序执行）
+var storageTables = []struct {
+	name       string
+	migrations []sqldb.Migration
+}{
+	{name: "users", migrations: userMigrations},
+	{name: "profiles", migrations: profileMigrations},
+	{name: "profile_textures", migrations: profileTextureMigrations},
+	{name: "texture_history", migrations: textureHistoryMigrations},
+}
+
+// userMigrations 用户表的迁移
+var userMigrations = []sqldb.Migration{
+	{
+		Version: 1,
+		Name:    "create user table",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	id bigint NOT NULL AUTO_INCREMENT,
+	email varchar(255) NOT NULL,
+	password varchar(255) NOT NULL,
+	admin boolean NOT NULL DEFAULT false,
+	banned boolean NOT NULL DEFAULT false,
+	created_at datetime(3) NOT NULL,
+	updated_at datetime(3) NOT NULL,
+	PRIMARY KEY (id),
+	UNIQUE KEY idx_{{table}}_email (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			sqldb.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	id bigserial PRIMARY KEY,
+	email varchar(255) NOT NULL,
+	password varchar(255) NOT NULL,
+	admin boolean NOT NULL DEFAULT false,
+	banned boolean NOT NULL DEFAULT false,
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL
+)`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_{{table}}_email ON {{table}} (email)`},
+			sqldb.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	email text NOT NULL,
+	password text NOT NULL,
+	admin numeric NOT NULL DEFAULT false,
+	banned numeric NOT NULL DEFAULT false,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL
+)`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_{{table}}_email ON {{table}} (email)`},
+		},
+	},
+}
+
+// profileMigrations 角色表的迁移
+var profileMigrations = []sqldb.Migration{
+	{
+		Version: 1,
+		Name:    "create profile table",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	uuid varchar(36) NOT NULL,
+	user_id bigint NOT NULL,
+	name varchar(32) NOT NULL,
+	created_at datetime(3) NOT NULL,
+	updated_at datetime(3) NOT NULL,
+	PRIMARY KEY (uuid),
+	UNIQUE KEY idx_{{table}}_name (name),
+	KEY idx_{{table}}_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			sqldb.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	uuid varchar(36) PRIMARY KEY,
+	user_id bigint NOT NULL,
+	name varchar(32) NOT NULL,
+	created_at timestamptz NOT NULL,
+	updated_at timestamptz NOT NULL
+)`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_{{table}}_name ON {{table}} (name)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_user_id ON {{table}} (user_id)`},
+			sqldb.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	uuid text PRIMARY KEY,
+	user_id integer NOT NULL,
+	name text NOT NULL,
+	created_at datetime NOT NULL,
+	updated_at datetime NOT NULL
+)`,
+				`CREATE UNIQUE INDEX IF NOT EXISTS idx_{{table}}_name ON {{table}} (name)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_user_id ON {{table}} (user_id)`},
+		},
+	},
+}
+
+// profileTextureMigrations 角色材质表的迁移
+var profileTextureMigrations = []sqldb.Migration{
+	{
+		Version: 1,
+		Name:    "create profile texture table",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	profile_uuid varchar(36) NOT NULL,
+	type varchar(32) NOT NULL,
+	hash char(64) NOT NULL,
+	extension varchar(8) NOT NULL,
+	file_size bigint NOT NULL,
+	slim boolean NOT NULL DEFAULT false,
+	uploaded_at datetime(3) NOT NULL,
+	PRIMARY KEY (profile_uuid, type)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			sqldb.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	profile_uuid varchar(36) NOT NULL,
+	type varchar(32) NOT NULL,
+	hash char(64) NOT NULL,
+	extension varchar(8) NOT NULL,
+	file_size bigint NOT NULL,
+	slim boolean NOT NULL DEFAULT false,
+	uploaded_at timestamptz NOT NULL,
+	PRIMARY KEY (profile_uuid, type)
+)`},
+			sqldb.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	profile_uuid text NOT NULL,
+	type text NOT NULL,
+	hash text NOT NULL,
+	extension text NOT NULL,
+	file_size integer NOT NULL,
+	slim numeric NOT NULL DEFAULT false,
+	uploaded_at datetime NOT NULL,
+	PRIMARY KEY (profile_uuid, type)
+)`},
+		},
+	},
+}
+
+// textureHistoryMigrations 材质变更历史表的迁移
+var textureHistoryMigrations = []sqldb.Migration{
+	{
+		Version: 1,
+		Name:    "create texture history table",
+		SQL: map[string][]string{
+			sqldb.DialectMySQL: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	id bigint NOT NULL AUTO_INCREMENT,
+	profile_uuid varchar(36) NOT NULL,
+	type varchar(32) NOT NULL,
+	action varchar(16) NOT NULL,
+	hash varchar(64) NOT NULL DEFAULT '',
+	model varchar(16) NOT NULL DEFAULT '',
+	actor varchar(64) NOT NULL DEFAULT '',
+	actor_ip varchar(45) NOT NULL DEFAULT '',
+	created_at datetime(3) NOT NULL,
+	PRIMARY KEY (id),
+	KEY idx_{{table}}_profile (profile_uuid, type, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`},
+			sqldb.DialectPostgres: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	id bigserial PRIMARY KEY,
+	profile_uuid varchar(36) NOT NULL,
+	type varchar(32) NOT NULL,
+	action varchar(16) NOT NULL,
+	hash varchar(64) NOT NULL DEFAULT '',
+	model varchar(16) NOT NULL DEFAULT '',
+	actor varchar(64) NOT NULL DEFAULT '',
+	actor_ip varchar(45) NOT NULL DEFAULT '',
+	created_at timestamptz NOT NULL
+)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_profile ON {{table}} (profile_uuid, type, id)`},
+			sqldb.DialectSQLite: {`CREATE TABLE IF NOT EXISTS {{table}} (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	profile_uuid text NOT NULL,
+	type text NOT NULL,
+	action text NOT NULL,
+	hash text NOT NULL DEFAULT '',
+	model text NOT NULL DEFAULT '',
+	actor text NOT NULL DEFAULT '',
+	actor_ip text NOT NULL DEFAULT '',
+	created_at datetime NOT NULL
+)`,
+				`CREATE INDEX IF NOT EXISTS idx_{{table}}_profile ON {{table}} (profile_uuid, type, id)`},
+		},
+	},
+}
@@ -0,0 +1,94 @@
+// Package database 数据库存储角色管理
+package database
+
+import (
+	"context"
+	"fmt"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// GetProfileByUUID 根据UUID获取角色
+func (s *Storage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
+	profile, err := s.findProfile(ctx, "uuid = ?", uuid)
+	if err != nil {
+		return nil, err
+	}
+	return s.profileWithProperties(ctx, profile), nil
+}
+
+// GetProfileByName 根据角色名获取角色
+func (s *Storage) GetProfileByName(ctx context.Context, name string) (*yggdrasil.Profile, error) {
+	profile, err := s.findProfile(ctx, "name = ?", name)
+	if err != nil {
+		return nil, err
+	}
+	return s.profileWithProperties(ctx, profile), nil
+}
+
+// profileWithProperties 构建包含材质属性的角色（获取材质失败时properties为空）
+func (s *Storage) profileWithProperties(ctx context.Context, profile *Profile) *yggdrasil.Profile {
+	result := toYggdrasilProfile(profile)
+
+	textures, err := s.GetPlayerTextures(ctx, profile.UUID)
+	if err != nil {
+		return result
+	}
+
+	// 生成properties（包含所有已注册的材质类型）
+	if properties, err := storage.BuildProfileProperties(profile.UUID, profile.Name, textures); err == nil {
+		result.Properties = properties
+	}
+	return result
+}
+
+// GetProfilesByNames 根据名称列表批量获取角色
+func (s *Storage) GetProfilesByNames(ctx context.Context, names []string) ([]*yggdrasil.Profile, error) {
+	if len(names) == 0 {
+		return []*yggdrasil.Profile{}, nil
+	}
+
+	var rows []Profile
+	if err := s.query(ctx, "profiles").Where("name IN ?", names).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*yggdrasil.Profile, 0, len(rows))
+	for i := range rows {
+		profiles = append(profiles, toYggdrasilProfile(&rows[i]))
+	}
+	return profiles, nil
+}
+
+// GetProfilesByUserEmail 获取用户的所有角色
+func (s *Storage) GetProfilesByUserEmail(ctx context.Context, userEmail string) ([]*yggdrasil.Profile, error) {
+	var user User
+	if err := s.query(ctx, "users").Select("id").Where("email = ?", userEmail).Take(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	return s.profilesOfUser(ctx, user.ID)
+}
+
+// GetUserProfiles 根据角色UUID获取其所属用户的所有角色
+func (s *Storage) GetUserProfiles(ctx context.Context, userUUID string) ([]*yggdrasil.Profile, error) {
+	profile, err := s.findProfile(ctx, "uuid = ?", userUUID)
+	if err != nil {
+		return nil, fmt.Errorf("player not found")
+	}
+	return s.profilesOfUser(ctx, profile.UserID)
+}
+
+// profilesOfUser 获取用户的所有角色（不含属性）
+func (s *Storage) profilesOfUser(ctx context.Context, userID int64) ([]*yggdrasil.Profile, error) {
+	var rows []Profile
+	if err := s.query(ctx, "profiles").Where("user_id = ?", userID).Order("name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	profiles := make([]*yggdrasil.Profile, 0, len(rows))
+	for i := range rows {
+		profiles = append(profiles, toYggdrasilProfile(&rows[i]))
+	}
+	return profiles, nil
+}
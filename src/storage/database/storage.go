@@ -0,0 +1,175 @@
+// Package database 数据库存储实现（MySQL、PostgreSQL、SQLite，根据DSN选择驱动）
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+	sqldb "yggdrasil-api-go/src/database"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"gorm.io/gorm"
+)
+
+// User 用户表
+type User struct {
+	ID        int64     `gorm:"primaryKey;column:id"`
+	Email     string    `gorm:"column:email"`
+	Password  string    `gorm:"column:password"` // bcrypt哈希
+	Admin     bool      `gorm:"column:admin"`
+	Banned    bool      `gorm:"column:banned"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// Profile 角色表
+type Profile struct {
+	UUID      string    `gorm:"primaryKey;column:uuid"` // 无符号UUID
+	UserID    int64     `gorm:"column:user_id"`
+	Name      string    `gorm:"column:name"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+// ProfileTexture 角色当前的材质（每个角色每种类型一行）
+type ProfileTexture struct {
+	ProfileUUID string    `gorm:"primaryKey;column:profile_uuid"`
+	Type        string    `gorm:"primaryKey;column:type"`
+	Hash        string    `gorm:"column:hash"`
+	Extension   string    `gorm:"column:extension"` // 材质文件扩展名（.png或.jpg）
+	FileSize    int64     `gorm:"column:file_size"`
+	Slim        bool      `gorm:"column:slim"`
+	UploadedAt  time.Time `gorm:"column:uploaded_at"`
+}
+
+// TextureHistory 材质变更历史表
+type TextureHistory struct {
+	ID          int64     `gorm:"primaryKey;column:id"`
+	ProfileUUID string    `gorm:"column:profile_uuid"`
+	Type        string    `gorm:"column:type"`
+	Action      string    `gorm:"column:action"`
+	Hash        string    `gorm:"column:hash"`
+	Model       string    `gorm:"column:model"`
+	Actor       string    `gorm:"column:actor"`
+	ActorIP     string    `gorm:"column:actor_ip"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+// Storage 数据库存储
+type Storage struct {
+	db            *gorm.DB
+	dialect       string
+	tablePrefix   string
+	blobs         storage.BlobStore     // 材质文件存储
+	textureConfig *config.TextureConfig // 材质配置
+}
+
+// NewStorage 创建数据库存储实例
+func NewStorage(options map[string]any, textureConfig *config.TextureConfig) (*Storage, error) {
+	dsn, ok := options["database_dsn"].(string)
+	if !ok || dsn == "" {
+		return nil, fmt.Errorf("database_dsn is required for database storage")
+	}
+
+	blobs, ok := options["blob_store"].(storage.BlobStore)
+	if !ok || blobs == nil {
+		return nil, fmt.Errorf("blob_store is required for database storage")
+	}
+
+	tablePrefix, _ := options["table_prefix"].(string)
+	debug, _ := options["debug"].(bool)
+
+	// 根据DSN的scheme选择数据库驱动（postgres://、mysql://、sqlite://）
+	db, dialect, err := sqldb.Open(dsn, debug)
+	if err != nil {
+		return nil, err
+	}
+	sqldb.Instrument(db, "storage")
+
+	s := &Storage{
+		db:            db,
+		dialect:       dialect,
+		tablePrefix:   tablePrefix,
+		blobs:         blobs,
+		textureConfig: textureConfig,
+	}
+
+	// 按方言执行表结构迁移
+	for _, table := range storageTables {
+		if err := sqldb.Migrate(db, dialect, s.table(table.name), table.migrations); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// table 带前缀的表名
+func (s *Storage) table(name string) string {
+	return s.tablePrefix + name
+}
+
+// query 指定表的查询（表名带前缀）
+func (s *Storage) query(ctx context.Context, name string) *gorm.DB {
+	return s.db.WithContext(ctx).Table(s.table(name))
+}
+
+// Close 关闭存储连接
+func (s *Storage) Close() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Ping 检查存储连接
+func (s *Storage) Ping() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		return fmt.Errorf("database ping failed: %w", err)
+	}
+	return nil
+}
+
+// GetStorageType 获取存储类型
+func (s *Storage) GetStorageType() string {
+	return "database"
+}
+
+// GetSignatureKeyPair 获取签名用的密钥对（数据库存储不管理密钥）
+func (s *Storage) GetSignatureKeyPair() (privateKey string, publicKey string, err error) {
+	return "", "", fmt.Errorf("signature key pair not available in database storage, use config file")
+}
+
+// toYggdrasilUser 转换为yggdrasil.User（profiles为用户的角色）
+func toYggdrasilUser(user *User, profiles []Profile) *yggdrasil.User {
+	result := &yggdrasil.User{
+		ID:       strconv.FormatInt(user.ID, 10),
+		Email:    user.Email,
+		Password: user.Password,
+		Profiles: make([]yggdrasil.Profile, 0, len(profiles)),
+		IsAdmin:  user.Admin,
+	}
+	for _, profile := range profiles {
+		result.Profiles = append(result.Profiles, yggdrasil.Profile{ID: profile.UUID, Name: profile.Name})
+	}
+	return result
+}
+
+// toYggdrasilProfile 转换为不含属性的yggdrasil.Profile
+func toYggdrasilProfile(profile *Profile) *yggdrasil.Profile {
+	return &yggdrasil.Profile{
+		ID:         profile.UUID,
+		Name:       profile.Name,
+		Properties: []yggdrasil.ProfileProperty{}, // 初始化为空数组而不是nil
+	}
+}
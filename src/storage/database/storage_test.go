@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/storage/blob"
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testProfileUUID = "5627dd98e6be3c21b8a8e92344183641"
+
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	dir := t.TempDir()
+	blobs, err := blob.NewLocalStore(map[string]any{"root": dir})
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+
+	textureConfig := &config.TextureConfig{
+		BaseURL:       "http://textures.test",
+		UploadEnabled: true,
+		MaxFileSize:   1 << 20,
+		History:       config.TextureHistoryConfig{Enabled: true, MaxEntries: 2},
+	}
+	options := map[string]any{
+		"database_dsn": "sqlite://" + filepath.Join(dir, "ygg.db"),
+		"table_prefix": "ygg_",
+		"blob_store":   blobs,
+	}
+	s, err := NewStorage(options, textureConfig)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	ctx := context.Background()
+	if _, err := s.CreateAccount(ctx, "steve@example.com", string(hash), false); err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	if err := s.AddProfile(ctx, "steve@example.com", &yggdrasil.Profile{ID: testProfileUUID, Name: "Steve"}); err != nil {
+		t.Fatalf("AddProfile: %v", err)
+	}
+	return s
+}
+
+func TestStorageAccounts(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		run      func() error
+		password string
+		wantErr  string
+	}{
+		{name: "authenticate", password: "secret"},
+		{name: "wrong password", password: "wrong", wantErr: "authentication failed"},
+		{name: "duplicate email", run: func() error {
+			_, err := s.CreateAccount(ctx, "steve@example.com", "x", false)
+			return err
+		}, wantErr: "user already exists"},
+		{name: "duplicate profile name", run: func() error {
+			return s.AddProfile(ctx, "steve@example.com", &yggdrasil.Profile{ID: "00000000000000000000000000000001", Name: "Steve"})
+		}, wantErr: "profile name already exists"},
+		{name: "banned", run: func() error {
+			return s.SetBanned(ctx, "steve@example.com", true)
+		}, password: "secret", wantErr: "user is banned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.run != nil {
+				err = tt.run()
+			}
+			if err == nil && tt.password != "" {
+				var user *yggdrasil.User
+				user, err = s.AuthenticateUser(ctx, "steve@example.com", tt.password)
+				if err == nil && (len(user.Profiles) != 1 || user.Profiles[0].ID != testProfileUUID) {
+					t.Fatalf("profiles = %+v; want Steve", user.Profiles)
+				}
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v; want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStorageTextures(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+	first := []byte("\x89PNG first")
+	second := []byte("\x89PNG second")
+
+	if _, err := s.UploadTexture(ctx, storage.TextureTypeSkin, testProfileUUID, first, &storage.TextureMetadata{Slim: true}); err != nil {
+		t.Fatalf("UploadTexture: %v", err)
+	}
+	if _, err := s.UploadTexture(ctx, storage.TextureTypeSkin, testProfileUUID, second, nil); err != nil {
+		t.Fatalf("UploadTexture: %v", err)
+	}
+
+	profile, err := s.GetProfileByName(ctx, "Steve")
+	if err != nil || len(profile.Properties) == 0 {
+		t.Fatalf("GetProfileByName = %+v, %v; want textures property", profile, err)
+	}
+
+	history, err := s.GetTextureHistory(ctx, testProfileUUID, storage.TextureTypeSkin, 0)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("GetTextureHistory = %d entries, %v; want 2", len(history), err)
+	}
+	if history[0].Hash == "" || history[0].Model != "slim" || history[1].Hash != "" {
+		t.Fatalf("history = %+v, %+v; want slim upload over empty", history[0], history[1])
+	}
+
+	// 恢复到第一次上传的材质
+	if _, err := s.RevertTexture(ctx, testProfileUUID, history[0].ID); err != nil {
+		t.Fatalf("RevertTexture: %v", err)
+	}
+	texture, err := s.GetTexture(ctx, storage.TextureTypeSkin, testProfileUUID)
+	if err != nil || texture.Metadata.Hash != history[0].Hash || !texture.Metadata.Slim {
+		t.Fatalf("GetTexture after revert = %+v, %v", texture, err)
+	}
+
+	// 历史记录按max_entries清理
+	history, _ = s.GetTextureHistory(ctx, testProfileUUID, "", 0)
+	if len(history) != 2 {
+		t.Fatalf("history after prune = %d entries; want 2", len(history))
+	}
+
+	if err := s.DeleteTexture(ctx, storage.TextureTypeSkin, testProfileUUID); err != nil {
+		t.Fatalf("DeleteTexture: %v", err)
+	}
+	if _, err := s.GetTexture(ctx, storage.TextureTypeSkin, testProfileUUID); err == nil {
+		t.Fatal("texture still present after delete")
+	}
+
+	// 删除角色后其历史记录过期，不再被引用的材质文件被清理
+	if err := s.RemoveProfile(ctx, testProfileUUID); err != nil {
+		t.Fatalf("RemoveProfile: %v", err)
+	}
+	result, err := s.CollectTextures(ctx, false)
+	if err != nil {
+		t.Fatalf("CollectTextures: %v", err)
+	}
+	if result.StaleHistory != 2 || len(result.OrphanBlobs) != 2 {
+		t.Fatalf("CollectTextures = %+v; want 2 stale history entries and 2 orphan blobs", result)
+	}
+}
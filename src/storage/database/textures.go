@@ -0,0 +1,210 @@
+// Package database 数据库存储材质管理
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	sqldb "yggdrasil-api-go/src/database"
+	"yggdrasil-api-go/src/storage/blob"
+	storage "yggdrasil-api-go/src/storage/interface"
+
+	"gorm.io/gorm"
+)
+
+// textureExtensions 材质文件可能的扩展名（上传时按文件内容确定）
+var textureExtensions = []string{".png", ".jpg"}
+
+// UploadTexture 上传材质文件
+func (s *Storage) UploadTexture(ctx context.Context, textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
+	if !s.textureConfig.UploadEnabled {
+		return nil, fmt.Errorf("texture upload is disabled")
+	}
+
+	if int64(len(data)) > s.textureConfig.MaxFileSize {
+		return nil, fmt.Errorf("texture file too large")
+	}
+
+	if _, err := s.findProfile(ctx, "uuid = ?", playerUUID); err != nil {
+		return nil, err
+	}
+
+	// 计算文件哈希
+	hash := sha256.Sum256(data)
+	hashStr := hex.EncodeToString(hash[:])
+
+	// 确定文件扩展名
+	extension := ".png"
+	contentType := "image/png"
+	if len(data) > 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF {
+		extension = ".jpg"
+		contentType = "image/jpeg"
+	}
+
+	// 保存材质文件
+	textureDir := string(textureType) + "s"
+	key := textureKey(textureDir, hashStr, extension)
+	if err := s.blobs.Put(key, data, contentType); err != nil {
+		return nil, fmt.Errorf("failed to save texture file: %w", err)
+	}
+
+	texture := &ProfileTexture{
+		ProfileUUID: playerUUID,
+		Type:        string(textureType),
+		Hash:        hashStr,
+		Extension:   extension,
+		FileSize:    int64(len(data)),
+		UploadedAt:  time.Now(),
+	}
+	if metadata != nil {
+		texture.Slim = metadata.Slim
+	}
+
+	// 覆盖前的材质状态写入历史记录
+	prior := s.currentTexture(ctx, textureType, playerUUID)
+
+	if err := s.query(ctx, "profile_textures").Clauses(sqldb.Upsert("profile_uuid", "type")).Create(texture).Error; err != nil {
+		return nil, fmt.Errorf("failed to save texture metadata: %w", err)
+	}
+	if prior == nil || prior.Hash != hashStr || prior.Slim != texture.Slim {
+		s.recordTextureHistory(ctx, textureType, playerUUID, prior, storage.TextureActionUpload)
+	}
+	storage.NotifyProfileChanged(playerUUID)
+
+	return s.textureInfo(textureType, texture), nil
+}
+
+// GetTexture 获取材质信息
+func (s *Storage) GetTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
+	texture := s.currentTexture(ctx, textureType, playerUUID)
+	if texture == nil {
+		return nil, fmt.Errorf("texture not found")
+	}
+	return s.textureInfo(textureType, texture), nil
+}
+
+// GetPlayerTextures 获取角色的所有材质（包括配置注册的扩展类型，如ELYTRA）
+func (s *Storage) GetPlayerTextures(ctx context.Context, playerUUID string) (map[storage.TextureType]*storage.TextureInfo, error) {
+	var rows []ProfileTexture
+	if err := s.query(ctx, "profile_textures").Where("profile_uuid = ?", playerUUID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	textures := make(map[storage.TextureType]*storage.TextureInfo, len(rows))
+	for i := range rows {
+		def, ok := storage.LookupTextureType(rows[i].Type)
+		if !ok {
+			continue // 配置中已移除的材质类型
+		}
+		textures[def.Type] = s.textureInfo(def.Type, &rows[i])
+	}
+	return textures, nil
+}
+
+// DeleteTexture 删除材质
+func (s *Storage) DeleteTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) error {
+	texture := s.currentTexture(ctx, textureType, playerUUID)
+	if texture == nil {
+		return fmt.Errorf("texture not found")
+	}
+
+	result := s.query(ctx, "profile_textures").Where("profile_uuid = ? AND type = ?", playerUUID, string(textureType)).Delete(&ProfileTexture{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("texture not found")
+	}
+
+	// 删除材质文件（启用历史时保留，以便恢复；其他角色仍在使用时同样保留）
+	if !s.textureConfig.History.Enabled {
+		var count int64
+		if err := s.query(ctx, "profile_textures").Where("type = ? AND hash = ?", string(textureType), texture.Hash).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := s.blobs.Delete(textureKey(string(textureType)+"s", texture.Hash, texture.Extension)); err != nil {
+				return fmt.Errorf("failed to delete texture file: %w", err)
+			}
+		}
+	}
+
+	s.recordTextureHistory(ctx, textureType, playerUUID, texture, storage.TextureActionDelete)
+	storage.NotifyProfileChanged(playerUUID)
+	return nil
+}
+
+// GetTextureURL 计算材质URL（优先使用对象存储的直接地址）
+func (s *Storage) GetTextureURL(textureType storage.TextureType, playerUUID string) string {
+	if textureInfo, err := s.GetTexture(context.Background(), textureType, playerUUID); err == nil {
+		return textureInfo.URL
+	}
+	return fmt.Sprintf("%s/textures/%s/%s", s.textureConfig.BaseURL, textureType, playerUUID)
+}
+
+// IsUploadSupported 检查是否支持材质上传
+func (s *Storage) IsUploadSupported() bool {
+	return s.textureConfig.UploadEnabled
+}
+
+// currentTexture 角色当前的材质（没有时返回nil）
+func (s *Storage) currentTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) *ProfileTexture {
+	var texture ProfileTexture
+	err := s.query(ctx, "profile_textures").Where("profile_uuid = ? AND type = ?", playerUUID, string(textureType)).Take(&texture).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.WarnContext(ctx, "Failed to query texture", "profile_id", playerUUID, "type", textureType, "error", err)
+		}
+		return nil
+	}
+	return &texture
+}
+
+// textureInfo 转换为材质信息
+func (s *Storage) textureInfo(textureType storage.TextureType, texture *ProfileTexture) *storage.TextureInfo {
+	textureDir := string(textureType) + "s"
+	return &storage.TextureInfo{
+		Type: textureType,
+		URL:  s.textureURL(textureDir, texture.Hash, texture.Extension),
+		Metadata: &storage.TextureMetadata{
+			Hash:       texture.Hash,
+			FileSize:   texture.FileSize,
+			UploadedAt: texture.UploadedAt,
+			Slim:       texture.Slim,
+		},
+	}
+}
+
+// textureKey 获取材质文件在对象存储中的key（与文件存储的布局一致）
+func textureKey(textureDir, hash, extension string) string {
+	return blob.HashKey("textures/"+textureDir, hash, extension)
+}
+
+// textureURL 构建材质URL（对象存储提供直接地址时使用该地址）
+func (s *Storage) textureURL(textureDir, hash, extension string) string {
+	if url, err := s.blobs.URL(textureKey(textureDir, hash, extension)); err == nil && url != "" {
+		return url
+	}
+	return fmt.Sprintf("%s/textures/%s/%s%s", s.textureConfig.BaseURL, textureDir, hash, extension)
+}
+
+// getTextureBlob 按材质哈希读取材质文件（历史记录不含扩展名，依次尝试png和jpg）
+func (s *Storage) getTextureBlob(textureDir, hash string) ([]byte, error) {
+	var err error
+	for _, extension := range textureExtensions {
+		var data []byte
+		if data, err = s.blobs.Get(textureKey(textureDir, hash, extension)); err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,93 @@
+// Package database 数据库存储用户管理
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// findUser 按条件查找用户及其角色
+func (s *Storage) findUser(ctx context.Context, query any, args ...any) (*yggdrasil.User, error) {
+	var user User
+	if err := s.query(ctx, "users").Where(query, args...).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, err
+	}
+
+	var profiles []Profile
+	if err := s.query(ctx, "profiles").Where("user_id = ?", user.ID).Order("name").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return toYggdrasilUser(&user, profiles), nil
+}
+
+// findProfile 按条件查找角色
+func (s *Storage) findProfile(ctx context.Context, query any, args ...any) (*Profile, error) {
+	var profile Profile
+	if err := s.query(ctx, "profiles").Where(query, args...).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("profile not found")
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetUserByEmail 根据邮箱获取用户
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error) {
+	return s.findUser(ctx, "email = ?", email)
+}
+
+// GetUserByID 根据用户ID获取用户
+func (s *Storage) GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error) {
+	return s.findUser(ctx, "id = ?", userID)
+}
+
+// GetUserByPlayerName 根据角色名获取用户
+func (s *Storage) GetUserByPlayerName(ctx context.Context, playerName string) (*yggdrasil.User, error) {
+	profile, err := s.findProfile(ctx, "name = ?", playerName)
+	if err != nil {
+		return nil, fmt.Errorf("player not found")
+	}
+	return s.findUser(ctx, "id = ?", profile.UserID)
+}
+
+// GetUserByUUID 根据角色UUID获取用户
+func (s *Storage) GetUserByUUID(ctx context.Context, uuid string) (*yggdrasil.User, error) {
+	profile, err := s.findProfile(ctx, "uuid = ?", uuid)
+	if err != nil {
+		return nil, fmt.Errorf("player not found")
+	}
+	return s.findUser(ctx, "id = ?", profile.UserID)
+}
+
+// AuthenticateUser 用户认证（密码为bcrypt哈希）
+func (s *Storage) AuthenticateUser(ctx context.Context, username, password string) (*yggdrasil.User, error) {
+	var user User
+	if err := s.query(ctx, "users").Where("email = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("authentication failed")
+		}
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+	if user.Banned {
+		return nil, fmt.Errorf("user is banned")
+	}
+
+	var profiles []Profile
+	if err := s.query(ctx, "profiles").Where("user_id = ?", user.ID).Order("name").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return toYggdrasilUser(&user, profiles), nil
+}
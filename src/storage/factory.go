@@ -6,6 +6,8 @@ import (
 
 	"yggdrasil-api-go/src/config"
 	"yggdrasil-api-go/src/storage/blessing_skin"
+	"yggdrasil-api-go/src/storage/blob"
+	"yggdrasil-api-go/src/storage/database"
 	"yggdrasil-api-go/src/storage/file"
 	storage "yggdrasil-api-go/src/storage/interface"
 )
@@ -39,15 +41,39 @@ func (f *DefaultStorageFactory) GetSupportedTypes() []string {
 
 // createFileStorage 创建文件存储
 func (f *DefaultStorageFactory) createFileStorage(config *config.StorageConfig, textureConfig *config.TextureConfig) (storage.Storage, error) {
+	dataDir := config.FileOptions.DataDir
+	if dataDir == "" {
+		dataDir = "data"
+	}
+
+	// 材质文件存储（本地存储默认使用数据目录，保持原有目录结构）
+	blobs, err := blob.NewBlobStore(&textureConfig.Blob, dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create texture blob store: %w", err)
+	}
+
 	options := map[string]any{
-		"data_dir": config.FileOptions.DataDir,
+		"data_dir":   dataDir,
+		"blob_store": blobs,
 	}
 	return file.NewStorage(options, textureConfig)
 }
 
-// createDatabaseStorage 创建数据库存储（待实现）
+// createDatabaseStorage 创建数据库存储
 func (f *DefaultStorageFactory) createDatabaseStorage(config *config.StorageConfig, textureConfig *config.TextureConfig) (storage.Storage, error) {
-	return nil, fmt.Errorf("database storage not implemented yet")
+	// 材质文件存储（本地存储默认使用data目录，与文件存储的目录结构一致）
+	blobs, err := blob.NewBlobStore(&textureConfig.Blob, "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create texture blob store: %w", err)
+	}
+
+	options := map[string]any{
+		"database_dsn": config.DatabaseOptions.DatabaseDSN,
+		"debug":        config.DatabaseOptions.Debug,
+		"table_prefix": config.DatabaseOptions.TablePrefix,
+		"blob_store":   blobs,
+	}
+	return database.NewStorage(options, textureConfig)
 }
 
 // createBlessingSkinStorage 创建BlessingSkin存储
@@ -57,14 +83,30 @@ func (f *DefaultStorageFactory) createBlessingSkinStorage(config *config.Storage
 		"database_dsn":              config.BlessingSkinOptions.DatabaseDSN,
 		"debug":                     config.BlessingSkinOptions.Debug,
 		"texture_base_url_override": config.BlessingSkinOptions.TextureBaseURLOverride,
+		"upload_public":             config.BlessingSkinOptions.UploadPublic,
+		"add_to_closet":             config.BlessingSkinOptions.AddToCloset,
+		"extension_textures":        config.BlessingSkinOptions.ExtensionTextures,
 		"salt":                      config.BlessingSkinOptions.Security.Salt,
 		"pwd_method":                config.BlessingSkinOptions.Security.PwdMethod,
 		"app_key":                   config.BlessingSkinOptions.Security.AppKey,
 	}
 
 	// 准备材质配置
+	// 材质文件存储（本地存储默认使用BlessingSkin的材质目录）
+	texturesDir := config.BlessingSkinOptions.TexturesDir
+	if texturesDir == "" {
+		texturesDir = "storage/textures"
+	}
+	blobs, err := blob.NewBlobStore(&textureConfig.Blob, texturesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create texture blob store: %w", err)
+	}
+
 	bsTextureConfig := &blessing_skin.TextureConfig{
-		BaseURL: textureConfig.BaseURL,
+		BaseURL:       textureConfig.BaseURL,
+		UploadEnabled: textureConfig.UploadEnabled,
+		Blobs:         blobs,
+		History:       textureConfig.History,
 	}
 
 	// 创建BlessingSkin存储
@@ -0,0 +1,247 @@
+// Package file 文件存储账号管理和材质清理（管理命令使用）
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// permissionBanned 被封禁用户的权限值（与BlessingSkin一致）
+const permissionBanned = -1
+
+// ListAccounts 列出所有用户（按用户ID排序）
+func (s *Storage) ListAccounts(ctx context.Context) ([]*storage.UserAccount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	accounts := make([]*storage.UserAccount, 0, len(s.users))
+	for _, user := range s.users {
+		accounts = append(accounts, s.userAccount(user))
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		a, _ := strconv.Atoi(accounts[i].ID)
+		b, _ := strconv.Atoi(accounts[j].ID)
+		return a < b
+	})
+	return accounts, nil
+}
+
+// CreateAccount 创建用户
+func (s *Storage) CreateAccount(ctx context.Context, email, passwordHash string, admin bool) (*storage.UserAccount, error) {
+	s.lockData()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[email]; exists {
+		return nil, fmt.Errorf("user already exists")
+	}
+
+	uid := 0
+	for _, user := range s.users {
+		if user.UID > uid {
+			uid = user.UID
+		}
+	}
+
+	permission := 0
+	if admin {
+		permission = 1
+	}
+	now := time.Now().Format("2006-01-02 15:04:05")
+	user := &FileUser{
+		UID:        uid + 1,
+		Email:      email,
+		Password:   passwordHash,
+		Nickname:   email,
+		Score:      1000,
+		Permission: permission,
+		Verified:   true,
+		RegisterAt: now,
+		LastSignAt: now,
+	}
+
+	s.users[email] = user
+	s.userProfiles[email] = make([]string, 0)
+	if err := s.saveUsers(); err != nil {
+		delete(s.users, email)
+		delete(s.userProfiles, email)
+		return nil, err
+	}
+	return s.userAccount(user), nil
+}
+
+// SetPassword 修改用户密码
+func (s *Storage) SetPassword(ctx context.Context, email, passwordHash string) error {
+	s.lockData()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[email]
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	user.Password = passwordHash
+	return s.saveUsers()
+}
+
+// SetBanned 封禁或解封用户（解封后恢复为普通用户）
+func (s *Storage) SetBanned(ctx context.Context, email string, banned bool) error {
+	s.lockData()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[email]
+	if !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	switch {
+	case banned && user.Permission != permissionBanned:
+		user.Permission = permissionBanned
+	case !banned && user.Permission == permissionBanned:
+		user.Permission = 0
+	default:
+		return nil
+	}
+	return s.saveUsers()
+}
+
+// AddProfile 为用户添加角色
+func (s *Storage) AddProfile(ctx context.Context, email string, profile *yggdrasil.Profile) error {
+	return s.CreateProfile(email, profile)
+}
+
+// RenameProfile 修改角色名称
+func (s *Storage) RenameProfile(ctx context.Context, uuid, name string) error {
+	return s.UpdateProfile(&yggdrasil.Profile{ID: uuid, Name: name})
+}
+
+// RemoveProfile 删除角色
+func (s *Storage) RemoveProfile(ctx context.Context, uuid string) error {
+	return s.DeleteProfile(uuid)
+}
+
+// userAccount 转换为管理命令输出的用户信息（调用方需持有锁）
+func (s *Storage) userAccount(user *FileUser) *storage.UserAccount {
+	account := &storage.UserAccount{
+		ID:       strconv.Itoa(user.UID),
+		Email:    user.Email,
+		Admin:    user.Permission >= 1,
+		Banned:   user.Permission == permissionBanned,
+		Profiles: []storage.AccountProfile{},
+	}
+	for _, player := range s.players {
+		if player.UID == user.UID {
+			account.Profiles = append(account.Profiles, storage.AccountProfile{ID: player.UUID, Name: player.Name})
+		}
+	}
+	sort.Slice(account.Profiles, func(i, j int) bool { return account.Profiles[i].Name < account.Profiles[j].Name })
+	return account
+}
+
+// CollectTextures 清理已删除角色的材质元数据和历史记录，以及不再被引用的材质文件
+// 启用材质历史时，历史记录引用的材质文件同样保留（用于恢复）
+func (s *Storage) CollectTextures(ctx context.Context, dryRun bool) (*storage.TextureGCResult, error) {
+	lister, ok := s.blobs.(storage.BlobLister)
+	if !ok {
+		return nil, fmt.Errorf("%s blob store does not support listing", s.blobs.GetBlobStoreType())
+	}
+
+	s.lockData()
+	defer s.mu.Unlock()
+
+	result := &storage.TextureGCResult{DryRun: dryRun, OrphanBlobs: []string{}}
+	referenced := make(map[string]bool)
+	textureDirs := make(map[string]bool)
+	reference := func(textureType storage.TextureType, hash string) {
+		textureDir := string(textureType) + "s"
+		referenced[s.textureKey(textureDir, hash, ".png")] = true
+		referenced[s.textureKey(textureDir, hash, ".jpg")] = true
+	}
+
+	// 材质元数据：角色已删除的，以及被同角色更新的上传取代的（旧版本遗留）视为过期
+	// 扫描全部元数据文件而不只是索引，索引之外的遗留文件同样清理
+	for _, def := range storage.GetTextureTypes() {
+		textureDir := string(def.Type) + "s"
+		textureDirs[textureDir] = true
+
+		matches, err := filepath.Glob(filepath.Join(s.dataDir, "textures", textureDir, "*", "*", "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to search texture metadata: %w", err)
+		}
+		for _, metadataPath := range matches {
+			metadata, err := s.loadTextureMetadata(metadataPath)
+			if err != nil {
+				continue
+			}
+			_, indexedPath := s.findTextureMetadata(metadata.Type, metadata.PlayerUUID)
+			_, exists := s.players[metadata.PlayerUUID]
+			if exists && indexedPath == metadataPath {
+				reference(metadata.Type, metadata.Hash)
+				continue
+			}
+			result.StaleMetadata++
+			if !dryRun {
+				if err := os.Remove(metadataPath); err != nil && !os.IsNotExist(err) {
+					return nil, fmt.Errorf("failed to remove texture metadata: %w", err)
+				}
+				if indexedPath == metadataPath {
+					s.indexTexture(metadata.Type, metadata.PlayerUUID, nil, "")
+				}
+			}
+		}
+	}
+
+	// 材质历史：角色已删除的记录一并清理
+	historyChanged := false
+	for profileID, entries := range s.history {
+		if _, exists := s.players[profileID]; !exists {
+			result.StaleHistory += len(entries)
+			if !dryRun {
+				delete(s.history, profileID)
+				historyChanged = true
+			}
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Hash != "" {
+				reference(entry.Type, entry.Hash)
+			}
+		}
+	}
+	if historyChanged {
+		if err := s.saveTextureHistory(); err != nil {
+			return nil, fmt.Errorf("failed to save texture history: %w", err)
+		}
+	}
+
+	// 材质文件：只处理材质类型目录下的图片
+	keys, err := lister.List("textures/")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts := strings.Split(key, "/")
+		ext := path.Ext(key)
+		if len(parts) < 3 || !textureDirs[parts[1]] || (ext != ".png" && ext != ".jpg") || referenced[key] {
+			continue
+		}
+		result.OrphanBlobs = append(result.OrphanBlobs, key)
+		if !dryRun {
+			if err := s.blobs.Delete(key); err != nil {
+				return nil, fmt.Errorf("failed to delete texture file %s: %w", key, err)
+			}
+		}
+	}
+
+	return result, nil
+}
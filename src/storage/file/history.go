@@ -0,0 +1,188 @@
+// Package file 文件存储材质变更历史
+package file
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+
+	"github.com/bytedance/sonic"
+)
+
+// loadTextureHistory 加载材质变更历史
+func (s *Storage) loadTextureHistory() error {
+	historyFile := filepath.Join(s.dataDir, "texture_history.json")
+
+	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := os.ReadFile(historyFile)
+	if err != nil {
+		return err
+	}
+
+	var entries []*storage.TextureHistoryEntry
+	if err := sonic.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	// 加载到缓存
+	for _, entry := range entries {
+		s.history[entry.ProfileID] = append(s.history[entry.ProfileID], entry)
+		if entry.ID > s.historyNextID {
+			s.historyNextID = entry.ID
+		}
+	}
+
+	return nil
+}
+
+// saveTextureHistory 保存材质变更历史
+func (s *Storage) saveTextureHistory() error {
+	var entries []*storage.TextureHistoryEntry
+	for _, profileEntries := range s.history {
+		entries = append(entries, profileEntries...)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	data, err := sonic.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	historyFile := filepath.Join(s.dataDir, "texture_history.json")
+	return s.writeDataFile(historyFile, data)
+}
+
+// recordTextureHistory 记录被覆盖的材质状态（prior为变更前的材质，没有时为nil；调用方需持有写锁）
+func (s *Storage) recordTextureHistory(ctx context.Context, textureType storage.TextureType, playerUUID string, prior *TextureMetadata, defaultAction string) {
+	if !s.textureConfig.History.Enabled {
+		return
+	}
+
+	change := storage.TextureChangeFromContext(ctx, defaultAction)
+	entry := &storage.TextureHistoryEntry{
+		ProfileID: playerUUID,
+		Type:      textureType,
+		Action:    change.Action,
+		Actor:     change.Actor,
+		ActorIP:   change.ActorIP,
+		CreatedAt: time.Now(),
+	}
+	if prior != nil {
+		entry.Hash = prior.Hash
+		if prior.Slim {
+			entry.Model = "slim"
+		}
+	}
+
+	s.historyNextID++
+	entry.ID = s.historyNextID
+	s.history[playerUUID] = s.pruneTextureHistory(append(s.history[playerUUID], entry))
+
+	// 历史记录失败不影响材质写入
+	if err := s.saveTextureHistory(); err != nil {
+		slog.WarnContext(ctx, "Failed to record texture history", "profile_id", playerUUID, "error", err)
+	}
+}
+
+// pruneTextureHistory 按保留策略清理角色的历史记录（entries按时间正序）
+func (s *Storage) pruneTextureHistory(entries []*storage.TextureHistoryEntry) []*storage.TextureHistoryEntry {
+	maxEntries := s.textureConfig.History.MaxEntries
+	maxAge := s.textureConfig.History.MaxAge
+
+	counts := make(map[storage.TextureType]int)
+	kept := make([]*storage.TextureHistoryEntry, 0, len(entries))
+
+	// 从新到旧遍历，保留每种材质最新的maxEntries条
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if maxAge > 0 && time.Since(entry.CreatedAt) > maxAge {
+			continue
+		}
+		if maxEntries > 0 && counts[entry.Type] >= maxEntries {
+			continue
+		}
+		counts[entry.Type]++
+		kept = append(kept, entry)
+	}
+
+	// 恢复时间正序
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	return kept
+}
+
+// GetTextureHistory 获取角色的材质变更历史
+func (s *Storage) GetTextureHistory(ctx context.Context, playerUUID string, textureType storage.TextureType, limit int) ([]*storage.TextureHistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.history[playerUUID]
+	result := make([]*storage.TextureHistoryEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		if textureType != "" && entries[i].Type != textureType {
+			continue
+		}
+		entry := *entries[i]
+		result = append(result, &entry)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// RevertTexture 将角色材质恢复到指定历史记录的状态
+func (s *Storage) RevertTexture(ctx context.Context, playerUUID string, entryID int64) (*storage.TextureHistoryEntry, error) {
+	s.mu.RLock()
+	var target *storage.TextureHistoryEntry
+	for _, entry := range s.history[playerUUID] {
+		if entry.ID == entryID {
+			copied := *entry
+			target = &copied
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if target == nil {
+		return nil, fmt.Errorf("history entry not found")
+	}
+
+	// 恢复前的状态同样写入历史，以便撤销本次恢复
+	ctx = storage.WithTextureChange(ctx, storage.TextureChangeFromContext(ctx, storage.TextureActionRevert))
+
+	// 恢复到删除状态
+	if target.Hash == "" {
+		if err := s.DeleteTexture(ctx, target.Type, playerUUID); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+
+	// 从材质文件存储读取历史材质并重新设置
+	data, err := s.getTextureBlob(string(target.Type)+"s", target.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("texture file is no longer available: %w", err)
+	}
+
+	metadata := &storage.TextureMetadata{
+		Model: target.Model,
+		Slim:  target.Model == "slim",
+	}
+	if _, err := s.UploadTexture(ctx, target.Type, playerUUID, data, metadata); err != nil {
+		return nil, err
+	}
+
+	return target, nil
+}
@@ -2,6 +2,7 @@
 package file
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -60,7 +61,7 @@ func (s *Storage) savePlayers() error {
 	}
 
 	playersFile := filepath.Join(s.dataDir, "players.json")
-	return os.WriteFile(playersFile, data, 0644)
+	return s.writeDataFile(playersFile, data)
 }
 
 // createDefaultPlayers 创建默认角色数据
@@ -104,13 +105,13 @@ func (s *Storage) createDefaultPlayers() error {
 }
 
 // GetProfileByUUID 根据UUID获取角色
-func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
+func (s *Storage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	if player, exists := s.players[uuid]; exists {
 		// 获取角色的材质信息
-		textures, err := s.GetPlayerTextures(uuid)
+		textures, err := s.GetPlayerTextures(ctx, uuid)
 		if err != nil {
 			// 如果获取材质失败，仍然返回角色信息，但properties为空
 			return &yggdrasil.Profile{
@@ -120,23 +121,8 @@ func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
 			}, nil
 		}
 
-		// 提取皮肤和披风URL
-		var skinURL, capeURL string
-		var isSlim bool
-
-		if skinInfo, exists := textures[storage.TextureTypeSkin]; exists {
-			skinURL = skinInfo.URL
-			if skinInfo.Metadata != nil {
-				isSlim = skinInfo.Metadata.Slim
-			}
-		}
-
-		if capeInfo, exists := textures[storage.TextureTypeCape]; exists {
-			capeURL = capeInfo.URL
-		}
-
-		// 生成properties
-		properties, err := yggdrasil.GenerateProfileProperties(player.UUID, player.Name, skinURL, capeURL, isSlim)
+		// 生成properties（包含所有已注册的材质类型）
+		properties, err := storage.BuildProfileProperties(player.UUID, player.Name, textures)
 		if err != nil {
 			// 如果生成properties失败，返回空properties
 			properties = []yggdrasil.ProfileProperty{}
@@ -153,14 +139,14 @@ func (s *Storage) GetProfileByUUID(uuid string) (*yggdrasil.Profile, error) {
 }
 
 // GetProfileByName 根据角色名获取角色
-func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
+func (s *Storage) GetProfileByName(ctx context.Context, name string) (*yggdrasil.Profile, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	for _, player := range s.players {
 		if player.Name == name {
 			// 获取角色的材质信息
-			textures, err := s.GetPlayerTextures(player.UUID)
+			textures, err := s.GetPlayerTextures(ctx, player.UUID)
 			if err != nil {
 				// 如果获取材质失败，仍然返回角色信息，但properties为空
 				return &yggdrasil.Profile{
@@ -170,23 +156,8 @@ func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
 				}, nil
 			}
 
-			// 提取皮肤和披风URL
-			var skinURL, capeURL string
-			var isSlim bool
-
-			if skinInfo, exists := textures[storage.TextureTypeSkin]; exists {
-				skinURL = skinInfo.URL
-				if skinInfo.Metadata != nil {
-					isSlim = skinInfo.Metadata.Slim
-				}
-			}
-
-			if capeInfo, exists := textures[storage.TextureTypeCape]; exists {
-				capeURL = capeInfo.URL
-			}
-
-			// 生成properties
-			properties, err := yggdrasil.GenerateProfileProperties(player.UUID, player.Name, skinURL, capeURL, isSlim)
+			// 生成properties（包含所有已注册的材质类型）
+			properties, err := storage.BuildProfileProperties(player.UUID, player.Name, textures)
 			if err != nil {
 				// 如果生成properties失败，返回空properties
 				properties = []yggdrasil.ProfileProperty{}
@@ -204,7 +175,7 @@ func (s *Storage) GetProfileByName(name string) (*yggdrasil.Profile, error) {
 }
 
 // GetProfilesByNames 根据名称列表批量获取角色
-func (s *Storage) GetProfilesByNames(names []string) ([]*yggdrasil.Profile, error) {
+func (s *Storage) GetProfilesByNames(ctx context.Context, names []string) ([]*yggdrasil.Profile, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -226,7 +197,7 @@ func (s *Storage) GetProfilesByNames(names []string) ([]*yggdrasil.Profile, erro
 }
 
 // GetProfilesByUserEmail 获取用户的所有角色
-func (s *Storage) GetProfilesByUserEmail(userEmail string) ([]*yggdrasil.Profile, error) {
+func (s *Storage) GetProfilesByUserEmail(ctx context.Context, userEmail string) ([]*yggdrasil.Profile, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -253,7 +224,7 @@ func (s *Storage) GetProfilesByUserEmail(userEmail string) ([]*yggdrasil.Profile
 
 // CreateProfile 创建角色
 func (s *Storage) CreateProfile(userEmail string, profile *yggdrasil.Profile) error {
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	// 检查角色名是否已存在
@@ -274,9 +245,15 @@ func (s *Storage) CreateProfile(userEmail string, profile *yggdrasil.Profile) er
 		return fmt.Errorf("user not found")
 	}
 
-	// 创建新角色
+	// 创建新角色（PID取当前最大值加一，删除角色后不会重复）
+	pid := 0
+	for _, player := range s.players {
+		if player.PID > pid {
+			pid = player.PID
+		}
+	}
 	newPlayer := &FilePlayer{
-		PID:        len(s.players) + 1, // 简单的ID生成
+		PID:        pid + 1,
 		UID:        user.UID,
 		Name:       profile.Name,
 		UUID:       profile.ID,
@@ -293,7 +270,7 @@ func (s *Storage) CreateProfile(userEmail string, profile *yggdrasil.Profile) er
 
 // UpdateProfile 更新角色
 func (s *Storage) UpdateProfile(profile *yggdrasil.Profile) error {
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	player, exists := s.players[profile.ID]
@@ -311,12 +288,16 @@ func (s *Storage) UpdateProfile(profile *yggdrasil.Profile) error {
 	player.Name = profile.Name
 	player.LastModify = time.Now().Format("2006-01-02 15:04:05")
 
-	return s.savePlayers()
+	if err := s.savePlayers(); err != nil {
+		return err
+	}
+	storage.NotifyProfileChanged(profile.ID)
+	return nil
 }
 
 // DeleteProfile 删除角色
 func (s *Storage) DeleteProfile(uuid string) error {
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	if _, exists := s.players[uuid]; !exists {
@@ -334,7 +315,11 @@ func (s *Storage) DeleteProfile(uuid string) error {
 	}
 
 	delete(s.players, uuid)
-	return s.savePlayers()
+	if err := s.savePlayers(); err != nil {
+		return err
+	}
+	storage.NotifyProfileChanged(uuid)
+	return nil
 }
 
 // ListProfiles 列出所有角色（分页）
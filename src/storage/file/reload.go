@@ -0,0 +1,163 @@
+// Package file 数据文件变更检测（命令行或手工编辑修改用户、角色和材质数据后重新加载）
+package file
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+)
+
+// defaultReloadInterval 默认的数据文件检查间隔
+const defaultReloadInterval = 2 * time.Second
+
+// dataFileStamp 数据文件的修改时间和大小（用于判断是否被其他进程修改）
+type dataFileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// statDataFile 读取数据文件的当前状态
+func statDataFile(path string) (dataFileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return dataFileStamp{}, false
+	}
+	return dataFileStamp{modTime: info.ModTime(), size: info.Size()}, true
+}
+
+// recordDataFile 记录数据文件的当前状态（加载或本进程写入后调用，调用方需持有写锁）
+func (s *Storage) recordDataFile(path string) {
+	if stamp, ok := statDataFile(path); ok {
+		s.dataStamps[path] = stamp
+	}
+}
+
+// dataFileModified 数据文件是否在上次加载或写入后被修改（文件不存在时视为未修改）
+func (s *Storage) dataFileModified(path string) bool {
+	stamp, ok := statDataFile(path)
+	return ok && stamp != s.dataStamps[path]
+}
+
+// accountFilesModified users.json或players.json是否被其他进程修改（调用方需持有锁）
+func (s *Storage) accountFilesModified() bool {
+	return s.dataFileModified(filepath.Join(s.dataDir, "users.json")) ||
+		s.dataFileModified(filepath.Join(s.dataDir, "players.json"))
+}
+
+// reloadAccountsIfModified 如果users.json或players.json被其他进程修改，重新加载用户和角色数据（调用方需持有写锁）
+// 写操作在修改内存数据前调用，避免用旧数据覆盖命令行写入的内容
+func (s *Storage) reloadAccountsIfModified() error {
+	if !s.accountFilesModified() {
+		return nil
+	}
+	usersFile := filepath.Join(s.dataDir, "users.json")
+	playersFile := filepath.Join(s.dataDir, "players.json")
+
+	// 无论成功与否都记录新状态：解析失败时保留当前数据，下次写入时用它覆盖损坏的文件
+	defer s.recordDataFile(usersFile)
+	defer s.recordDataFile(playersFile)
+
+	oldUsers, oldPlayers, oldProfiles := s.users, s.players, s.userProfiles
+	s.users = make(map[string]*FileUser)
+	s.players = make(map[string]*FilePlayer)
+	s.userProfiles = make(map[string][]string)
+
+	err := s.loadUsers()
+	if err == nil {
+		err = s.loadPlayers()
+	}
+	if err != nil {
+		s.users, s.players, s.userProfiles = oldUsers, oldPlayers, oldProfiles
+		return fmt.Errorf("failed to reload account data: %w", err)
+	}
+
+	notifyAccountChanges(oldUsers, s.users, oldPlayers, s.players)
+	return nil
+}
+
+// notifyAccountChanges 通知重新加载后发生变化的用户和角色（使缓存失效）
+func notifyAccountChanges(oldUsers, newUsers map[string]*FileUser, oldPlayers, newPlayers map[string]*FilePlayer) {
+	changedUsers := make(map[int]struct{})
+	for email, user := range oldUsers {
+		if updated, exists := newUsers[email]; !exists || *updated != *user {
+			changedUsers[user.UID] = struct{}{}
+		}
+	}
+	for email, user := range newUsers {
+		if _, exists := oldUsers[email]; !exists {
+			changedUsers[user.UID] = struct{}{}
+		}
+	}
+
+	for uuid, player := range oldPlayers {
+		if updated, exists := newPlayers[uuid]; !exists || *updated != *player {
+			changedUsers[player.UID] = struct{}{}
+			storage.NotifyProfileChanged(uuid)
+		}
+	}
+	for uuid, player := range newPlayers {
+		if _, exists := oldPlayers[uuid]; !exists {
+			changedUsers[player.UID] = struct{}{}
+			storage.NotifyProfileChanged(uuid)
+		}
+	}
+
+	for uid := range changedUsers {
+		storage.NotifyUserChanged(strconv.Itoa(uid))
+	}
+}
+
+// watchDataFiles 定期检查数据文件，使运行中的服务器读到命令行写入的修改
+func (s *Storage) watchDataFiles(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopWatch:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				return
+			}
+			err := s.reloadIfModified()
+			s.mu.Unlock()
+			if err != nil {
+				slog.Warn("Failed to reload file storage data, keeping previous data", "data_dir", s.dataDir, "error", err)
+			}
+		}
+	}
+}
+
+// reloadIfModified 重新加载被其他进程修改的用户、角色和材质数据（调用方需持有写锁）
+func (s *Storage) reloadIfModified() error {
+	return errors.Join(s.reloadAccountsIfModified(), s.reloadTexturesIfModified())
+}
+
+// lockData 获取写锁，并先加载其他进程对数据文件的修改（用户、角色和材质的写操作使用）
+func (s *Storage) lockData() {
+	s.mu.Lock()
+	if err := s.reloadIfModified(); err != nil {
+		slog.Warn("Failed to reload file storage data, keeping previous data", "data_dir", s.dataDir, "error", err)
+	}
+}
+
+// refreshAccounts 读取前检查数据文件，有修改时立即重新加载（认证使用，命令行封禁和修改密码无需等待定期检查）
+func (s *Storage) refreshAccounts() {
+	s.mu.RLock()
+	modified := s.accountFilesModified()
+	s.mu.RUnlock()
+
+	if modified {
+		s.lockData()
+		s.mu.Unlock()
+	}
+}
@@ -2,26 +2,30 @@
 package file
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
+	"crypto/subtle"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/storage/blob"
 	storage "yggdrasil-api-go/src/storage/interface"
 	"yggdrasil-api-go/src/yggdrasil"
 
 	"github.com/bytedance/sonic"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Storage 文件存储实现（仿照BlessingSkin表结构）
 type Storage struct {
 	dataDir       string                // 数据目录
 	textureConfig *config.TextureConfig // 材质配置
+	blobs         storage.BlobStore     // 材质文件存储
 	mu            sync.RWMutex          // 读写锁
 
 	// 数据文件（仿照BlessingSkin表结构）
@@ -30,7 +34,17 @@ type Storage struct {
 	textures map[string]*FileTexture // 材质数据 (textures.json)
 
 	// 缓存映射
-	userProfiles map[string][]string // 用户角色映射缓存
+	userProfiles map[string][]string                                // 用户角色映射缓存
+	textureIndex map[string]map[storage.TextureType]*indexedTexture // 上传材质索引：角色UUID -> 材质类型 -> 元数据
+
+	// 材质变更历史 (texture_history.json)
+	history       map[string][]*storage.TextureHistoryEntry // 角色UUID -> 历史记录（按时间正序）
+	historyNextID int64                                     // 最近分配的历史记录ID
+
+	dataStamps map[string]dataFileStamp // 数据文件上次加载或写入时的状态（检测其他进程的修改）
+	stopWatch  chan struct{}            // 停止数据文件检查
+
+	closed bool // 已关闭（关闭后拒绝写入，避免退出过程中写出不完整的数据文件）
 }
 
 // FileUser 文件存储的用户结构（对应BlessingSkin的users表）
@@ -87,6 +101,7 @@ func (s *Storage) convertFileUserToYggdrasilUser(fileUser *FileUser) (*yggdrasil
 		Email:    fileUser.Email,
 		Password: fileUser.Password,
 		Profiles: profiles,
+		IsAdmin:  fileUser.Permission >= 1,
 	}, nil
 }
 
@@ -119,26 +134,58 @@ func NewStorage(options map[string]any, textureConfig *config.TextureConfig) (*S
 		dataDir = dir
 	}
 
-	storage := &Storage{
+	// 材质文件存储（未指定时使用数据目录下的本地存储）
+	blobs, ok := options["blob_store"].(storage.BlobStore)
+	if !ok || blobs == nil {
+		localStore, err := blob.NewLocalStore(map[string]any{"root": dataDir})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create blob store: %w", err)
+		}
+		blobs = localStore
+	}
+
+	// 数据文件检查间隔（命令行修改用户和角色后，运行中的服务器在该间隔内重新加载；0表示不检查）
+	reloadInterval := defaultReloadInterval
+	if v, ok := options["reload_interval"].(string); ok && v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid reload_interval: %s", v)
+		}
+		reloadInterval = d
+	}
+
+	s := &Storage{
 		dataDir:       dataDir,
+		blobs:         blobs,
 		textureConfig: textureConfig,
 		users:         make(map[string]*FileUser),
 		players:       make(map[string]*FilePlayer),
 		textures:      make(map[string]*FileTexture),
 		userProfiles:  make(map[string][]string),
+		history:       make(map[string][]*storage.TextureHistoryEntry),
+		dataStamps:    make(map[string]dataFileStamp),
+		stopWatch:     make(chan struct{}),
 	}
 
 	// 创建必要的目录
-	if err := storage.initDirectories(); err != nil {
+	if err := s.initDirectories(); err != nil {
 		return nil, fmt.Errorf("failed to initialize directories: %w", err)
 	}
 
 	// 加载数据到缓存
-	if err := storage.loadData(); err != nil {
+	if err := s.loadData(); err != nil {
 		return nil, fmt.Errorf("failed to load data: %w", err)
 	}
+	s.recordDataFile(filepath.Join(dataDir, "users.json"))
+	s.recordDataFile(filepath.Join(dataDir, "players.json"))
+	s.recordDataFile(filepath.Join(dataDir, "texture_history.json"))
+	s.recordDataFile(s.textureIndexMarker())
+
+	if reloadInterval > 0 {
+		go s.watchDataFiles(reloadInterval)
+	}
 
-	return storage, nil
+	return s, nil
 }
 
 // initDirectories 初始化目录结构
@@ -175,7 +222,13 @@ func (s *Storage) loadData() error {
 		return err
 	}
 
-	return nil
+	// 加载材质变更历史
+	if err := s.loadTextureHistory(); err != nil {
+		return err
+	}
+
+	// 建立上传材质索引
+	return s.loadTextureIndex()
 }
 
 // loadTexturesData 加载材质数据
@@ -224,20 +277,17 @@ func (s *Storage) saveTexturesData() error {
 	}
 
 	texturesFile := filepath.Join(s.dataDir, "textures.json")
-	return os.WriteFile(texturesFile, data, 0644)
+	return s.writeDataFile(texturesFile, data)
 }
 
 // getHashPath 获取哈希分桶路径
 func (s *Storage) getHashPath(baseDir, key, extension string) string {
-	hash := sha256.Sum256([]byte(key))
-	hashStr := hex.EncodeToString(hash[:])
-
-	// 两级分桶：前2个字符/后2个字符/完整哈希.扩展名
-	level1 := hashStr[:2]
-	level2 := hashStr[2:4]
-	filename := hashStr + extension
+	return filepath.Join(s.dataDir, filepath.FromSlash(s.getHashKey(filepath.ToSlash(baseDir), key, extension)))
+}
 
-	return filepath.Join(s.dataDir, baseDir, level1, level2, filename)
+// getHashKey 获取哈希分桶的相对key（"/"分隔，用于对象存储）
+func (s *Storage) getHashKey(baseDir, key, extension string) string {
+	return blob.HashKey(baseDir, key, extension)
 }
 
 // ensureDir 确保目录存在
@@ -248,7 +298,52 @@ func (s *Storage) ensureDir(filePath string) error {
 
 // Close 关闭存储连接
 func (s *Storage) Close() error {
-	// 文件存储无需特殊关闭操作
+	// 获取写锁，等待进行中的写入完成（所有数据文件在每次修改时已同步写入磁盘）
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.closed {
+		close(s.stopWatch)
+	}
+	s.closed = true
+	return nil
+}
+
+// writeDataFile 原子地写入数据文件（先写入临时文件再重命名，进程中断时不会留下不完整的JSON），调用方需持有写锁
+func (s *Storage) writeDataFile(path string, data []byte) error {
+	if s.closed {
+		return fmt.Errorf("file storage is closed")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	s.recordDataFile(path)
 	return nil
 }
 
@@ -265,7 +360,7 @@ func (s *Storage) GetStorageType() string {
 }
 
 // GetUserByUUID 根据UUID获取用户
-func (s *Storage) GetUserByUUID(uuid string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByUUID(ctx context.Context, uuid string) (*yggdrasil.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -293,20 +388,32 @@ func (s *Storage) GetUserByUUID(uuid string) (*yggdrasil.User, error) {
 }
 
 // AuthenticateUser 用户认证
-func (s *Storage) AuthenticateUser(username, password string) (*yggdrasil.User, error) {
+func (s *Storage) AuthenticateUser(ctx context.Context, username, password string) (*yggdrasil.User, error) {
+	s.refreshAccounts()
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, user := range s.users {
-		if user.Email == username && user.Password == password {
-			return s.convertFileUserToYggdrasilUser(user)
-		}
+	user, exists := s.users[username]
+	if !exists || !checkPassword(user.Password, password) {
+		return nil, fmt.Errorf("authentication failed")
+	}
+	if user.Permission == permissionBanned {
+		return nil, fmt.Errorf("user is banned")
 	}
-	return nil, fmt.Errorf("authentication failed")
+	return s.convertFileUserToYggdrasilUser(user)
+}
+
+// checkPassword 验证密码（管理命令写入bcrypt哈希，手工编辑的明文密码保持兼容）
+func checkPassword(stored, password string) bool {
+	if strings.HasPrefix(stored, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
 }
 
 // GetUserProfiles 根据用户UUID获取角色
-func (s *Storage) GetUserProfiles(userUUID string) ([]*yggdrasil.Profile, error) {
+func (s *Storage) GetUserProfiles(ctx context.Context, userUUID string) ([]*yggdrasil.Profile, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -339,7 +446,9 @@ func (s *Storage) GetUserProfiles(userUUID string) ([]*yggdrasil.Profile, error)
 }
 
 // GetPlayerTextures 获取角色的所有材质
-func (s *Storage) GetPlayerTextures(playerUUID string) (map[storage.TextureType]*storage.TextureInfo, error) {
+// 通过API上传的材质优先；没有上传时使用SkinTID/CapeTID引用的textures.json材质（导入的皮肤站数据），
+// 删除材质时两者一并清除
+func (s *Storage) GetPlayerTextures(ctx context.Context, playerUUID string) (map[storage.TextureType]*storage.TextureInfo, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -351,45 +460,46 @@ func (s *Storage) GetPlayerTextures(playerUUID string) (map[storage.TextureType]
 		return textures, nil // 角色不存在，返回空材质
 	}
 
-	// 获取皮肤材质
-	if player.SkinTID > 0 {
-		for _, texture := range s.textures {
-			if texture.TID == player.SkinTID {
-				textures[storage.TextureTypeSkin] = &storage.TextureInfo{
-					Type: storage.TextureTypeSkin,
-					URL:  s.textureConfig.BaseURL + "skin/" + texture.Hash + ".png",
-					Metadata: &storage.TextureMetadata{
-						Hash:       texture.Hash,
-						FileSize:   int64(texture.Size),
-						UploadedAt: parseTime(texture.UploadAt),
-					},
-				}
-				break
-			}
+	// 通过API上传的材质（包括配置注册的扩展类型，如ELYTRA）
+	for _, def := range storage.GetTextureTypes() {
+		if textureInfo, err := s.findUploadedTexture(def.Type, playerUUID); err == nil {
+			textures[def.Type] = textureInfo
 		}
 	}
 
-	// 获取披风材质
-	if player.CapeTID > 0 {
-		for _, texture := range s.textures {
-			if texture.TID == player.CapeTID {
-				textures[storage.TextureTypeCape] = &storage.TextureInfo{
-					Type: storage.TextureTypeCape,
-					URL:  s.textureConfig.BaseURL + "cape/" + texture.Hash + ".png",
-					Metadata: &storage.TextureMetadata{
-						Hash:       texture.Hash,
-						FileSize:   int64(texture.Size),
-						UploadedAt: parseTime(texture.UploadAt),
-					},
-				}
-				break
-			}
+	// 皮肤站材质
+	if _, exists := textures[storage.TextureTypeSkin]; !exists && player.SkinTID > 0 {
+		if textureInfo := s.referencedTexture(storage.TextureTypeSkin, "skin", player.SkinTID); textureInfo != nil {
+			textures[storage.TextureTypeSkin] = textureInfo
+		}
+	}
+	if _, exists := textures[storage.TextureTypeCape]; !exists && player.CapeTID > 0 {
+		if textureInfo := s.referencedTexture(storage.TextureTypeCape, "cape", player.CapeTID); textureInfo != nil {
+			textures[storage.TextureTypeCape] = textureInfo
 		}
 	}
 
 	return textures, nil
 }
 
+// referencedTexture 按TID查找textures.json中的材质（没有时返回nil，调用方需持有锁）
+func (s *Storage) referencedTexture(textureType storage.TextureType, urlDir string, tid int) *storage.TextureInfo {
+	for _, texture := range s.textures {
+		if texture.TID == tid {
+			return &storage.TextureInfo{
+				Type: textureType,
+				URL:  s.textureConfig.BaseURL + urlDir + "/" + texture.Hash + ".png",
+				Metadata: &storage.TextureMetadata{
+					Hash:       texture.Hash,
+					FileSize:   int64(texture.Size),
+					UploadedAt: parseTime(texture.UploadAt),
+				},
+			}
+		}
+	}
+	return nil
+}
+
 // parseTime 解析时间字符串
 func parseTime(timeStr string) time.Time {
 	if t, err := time.Parse("2006-01-02 15:04:05", timeStr); err == nil {
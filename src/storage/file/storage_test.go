@@ -0,0 +1,55 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"yggdrasil-api-go/src/config"
+
+	"github.com/bytedance/sonic"
+)
+
+func TestStorageClose(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStorage(map[string]any{"data_dir": dir, "reload_interval": "0"}, &config.TextureConfig{BaseURL: "http://textures.test"})
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := s.CreateAccount(ctx, "steve@example.com", "hash1", false); err != nil {
+		t.Fatalf("CreateAccount: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// 关闭后拒绝写入，数据文件保持关闭前的完整内容
+	if err := s.SetPassword(ctx, "steve@example.com", "hash2"); err == nil {
+		t.Fatal("SetPassword after Close succeeded; want error")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "users.json"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var users []*FileUser
+	if err := sonic.Unmarshal(data, &users); err != nil {
+		t.Fatalf("users.json is not valid JSON: %v", err)
+	}
+	var password string
+	for _, user := range users {
+		if user.Email == "steve@example.com" {
+			password = user.Password
+		}
+	}
+	if password != "hash1" {
+		t.Fatalf("password in users.json = %q; want the hash written before Close", password)
+	}
+
+	// 原子写入不留下临时文件
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".*.tmp-*")); len(matches) != 0 {
+		t.Fatalf("temporary files left behind: %v", matches)
+	}
+}
@@ -0,0 +1,154 @@
+// Package file 材质元数据索引（角色UUID -> 材质类型 -> 元数据，启动时扫描一次，上传和删除时更新）
+package file
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+)
+
+// indexedTexture 索引中的材质元数据及其文件路径
+type indexedTexture struct {
+	metadata *TextureMetadata
+	path     string
+}
+
+// textureIndexMarker 材质元数据变更标记文件（每次上传或删除后更新，其他进程据此重新加载索引）
+func (s *Storage) textureIndexMarker() string {
+	return filepath.Join(s.dataDir, "textures", ".changed")
+}
+
+// loadTextureIndex 扫描材质元数据文件建立索引
+// 同一角色同类型有多个元数据文件时（旧版本按材质哈希命名）使用最新上传的
+func (s *Storage) loadTextureIndex() error {
+	matches, err := filepath.Glob(filepath.Join(s.dataDir, "textures", "*", "*", "*", "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to search texture metadata: %w", err)
+	}
+
+	index := make(map[string]map[storage.TextureType]*indexedTexture)
+	for _, metadataPath := range matches {
+		metadata, err := s.loadTextureMetadata(metadataPath)
+		if err != nil || metadata.PlayerUUID == "" {
+			continue
+		}
+		if metadata.Extension == "" {
+			s.migrateTextureMetadata(metadataPath, metadata)
+		}
+		types, ok := index[metadata.PlayerUUID]
+		if !ok {
+			types = make(map[storage.TextureType]*indexedTexture)
+			index[metadata.PlayerUUID] = types
+		}
+		if current, ok := types[metadata.Type]; ok && !metadata.UploadedAt.After(current.metadata.UploadedAt) {
+			continue
+		}
+		types[metadata.Type] = &indexedTexture{metadata: metadata, path: metadataPath}
+	}
+
+	s.textureIndex = index
+	return nil
+}
+
+// migrateTextureMetadata 为旧版本的元数据补充材质文件扩展名并写回（只在第一次加载时查询对象存储）
+func (s *Storage) migrateTextureMetadata(metadataPath string, metadata *TextureMetadata) {
+	metadata.Extension = s.probeTextureExtension(string(metadata.Type)+"s", metadata.Hash)
+	if err := s.saveTextureMetadata(metadataPath, metadata); err != nil {
+		slog.Warn("Failed to migrate texture metadata", "path", metadataPath, "error", err)
+	}
+}
+
+// textureMetadataPath 角色指定类型材质的元数据文件路径（按角色UUID分桶，不同角色使用相同材质时互不覆盖）
+func (s *Storage) textureMetadataPath(textureType storage.TextureType, playerUUID string) string {
+	return s.getHashPath(filepath.Join("textures", string(textureType)+"s"), playerUUID, ".json")
+}
+
+// findTextureMetadata 查找角色指定类型的材质元数据及其文件路径（没有时返回nil，调用方需持有锁）
+func (s *Storage) findTextureMetadata(textureType storage.TextureType, playerUUID string) (*TextureMetadata, string) {
+	entry, ok := s.textureIndex[playerUUID][textureType]
+	if !ok {
+		return nil, ""
+	}
+	return entry.metadata, entry.path
+}
+
+// indexTexture 更新索引中角色的材质（metadata为nil时删除，调用方需持有写锁）
+func (s *Storage) indexTexture(textureType storage.TextureType, playerUUID string, metadata *TextureMetadata, metadataPath string) {
+	if metadata == nil {
+		delete(s.textureIndex[playerUUID], textureType)
+		if len(s.textureIndex[playerUUID]) == 0 {
+			delete(s.textureIndex, playerUUID)
+		}
+	} else {
+		types, ok := s.textureIndex[playerUUID]
+		if !ok {
+			types = make(map[storage.TextureType]*indexedTexture)
+			s.textureIndex[playerUUID] = types
+		}
+		types[textureType] = &indexedTexture{metadata: metadata, path: metadataPath}
+	}
+
+	// 通知其他进程（如运行中的服务器）重新加载索引，失败时对方在重启后才能看到变化
+	if err := s.writeDataFile(s.textureIndexMarker(), []byte(time.Now().Format(time.RFC3339Nano))); err != nil {
+		slog.Warn("Failed to update texture index marker", "error", err)
+	}
+}
+
+// reloadTexturesIfModified 如果其他进程修改了材质元数据或材质历史，重新加载索引和历史（调用方需持有写锁）
+func (s *Storage) reloadTexturesIfModified() error {
+	marker := s.textureIndexMarker()
+	historyFile := filepath.Join(s.dataDir, "texture_history.json")
+	if !s.dataFileModified(marker) && !s.dataFileModified(historyFile) {
+		return nil
+	}
+	defer s.recordDataFile(marker)
+	defer s.recordDataFile(historyFile)
+
+	oldIndex := s.textureIndex
+	if err := s.loadTextureIndex(); err != nil {
+		return err
+	}
+
+	oldHistory, oldNextID := s.history, s.historyNextID
+	s.history = make(map[string][]*storage.TextureHistoryEntry)
+	if err := s.loadTextureHistory(); err != nil {
+		s.history, s.historyNextID = oldHistory, oldNextID
+		return fmt.Errorf("failed to reload texture history: %w", err)
+	}
+
+	for playerUUID := range mergedKeys(oldIndex, s.textureIndex) {
+		if !sameTextures(oldIndex[playerUUID], s.textureIndex[playerUUID]) {
+			storage.NotifyProfileChanged(playerUUID)
+		}
+	}
+	return nil
+}
+
+// mergedKeys 两个索引中所有的角色UUID
+func mergedKeys(a, b map[string]map[storage.TextureType]*indexedTexture) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+	return keys
+}
+
+// sameTextures 角色的材质是否相同（比较哈希和模型）
+func sameTextures(a, b map[storage.TextureType]*indexedTexture) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for textureType, entry := range a {
+		other, ok := b[textureType]
+		if !ok || other.metadata.Hash != entry.metadata.Hash || other.metadata.Slim != entry.metadata.Slim {
+			return false
+		}
+	}
+	return true
+}
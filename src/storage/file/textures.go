@@ -2,11 +2,12 @@
 package file
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"time"
 	storage "yggdrasil-api-go/src/storage/interface"
 
@@ -21,10 +22,11 @@ type TextureMetadata struct {
 	FileSize   int64               `json:"file_size"`
 	UploadedAt time.Time           `json:"uploaded_at"`
 	Slim       bool                `json:"slim,omitempty"`
+	Extension  string              `json:"extension,omitempty"` // 材质文件扩展名（.png或.jpg，旧版本的元数据没有此字段）
 }
 
 // UploadTexture 上传材质文件
-func (s *Storage) UploadTexture(textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
+func (s *Storage) UploadTexture(ctx context.Context, textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
 	if !s.textureConfig.UploadEnabled {
 		return nil, fmt.Errorf("texture upload is disabled")
 	}
@@ -33,7 +35,7 @@ func (s *Storage) UploadTexture(textureType storage.TextureType, playerUUID stri
 		return nil, fmt.Errorf("texture file too large")
 	}
 
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	// 计算文件哈希
@@ -48,13 +50,13 @@ func (s *Storage) UploadTexture(textureType storage.TextureType, playerUUID stri
 
 	// 保存材质文件
 	textureDir := string(textureType) + "s" // skins, capes
-	filePath := s.getHashPath(filepath.Join("textures", textureDir), hashStr, extension)
+	key := s.textureKey(textureDir, hashStr, extension)
 
-	if err := s.ensureDir(filePath); err != nil {
-		return nil, fmt.Errorf("failed to create texture directory: %w", err)
+	contentType := "image/png"
+	if extension == ".jpg" {
+		contentType = "image/jpeg"
 	}
-
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := s.blobs.Put(key, data, contentType); err != nil {
 		return nil, fmt.Errorf("failed to save texture file: %w", err)
 	}
 
@@ -65,19 +67,32 @@ func (s *Storage) UploadTexture(textureType storage.TextureType, playerUUID stri
 		Hash:       hashStr,
 		FileSize:   int64(len(data)),
 		UploadedAt: time.Now(),
+		Extension:  extension,
 	}
 
 	if metadata != nil {
 		textureMetadata.Slim = metadata.Slim
 	}
 
-	metadataPath := s.getHashPath(filepath.Join("textures", textureDir), hashStr, ".json")
+	// 覆盖前的材质状态写入历史记录
+	prior, priorPath := s.findTextureMetadata(textureType, playerUUID)
+
+	metadataPath := s.textureMetadataPath(textureType, playerUUID)
 	if err := s.saveTextureMetadata(metadataPath, textureMetadata); err != nil {
 		return nil, fmt.Errorf("failed to save texture metadata: %w", err)
 	}
+	// 旧版本按材质哈希命名的元数据文件
+	if priorPath != "" && priorPath != metadataPath {
+		os.Remove(priorPath)
+	}
+	s.indexTexture(textureType, playerUUID, textureMetadata, metadataPath)
+	if prior == nil || prior.Hash != hashStr || prior.Slim != textureMetadata.Slim {
+		s.recordTextureHistory(ctx, textureType, playerUUID, prior, storage.TextureActionUpload)
+	}
+	storage.NotifyProfileChanged(playerUUID)
 
 	// 构建材质URL
-	textureURL := fmt.Sprintf("%s/textures/%s/%s%s", s.textureConfig.BaseURL, textureDir, hashStr, extension)
+	textureURL := s.textureURL(textureDir, key, hashStr, extension)
 
 	return &storage.TextureInfo{
 		Type: textureType,
@@ -92,91 +107,101 @@ func (s *Storage) UploadTexture(textureType storage.TextureType, playerUUID stri
 }
 
 // GetTexture 获取材质信息
-func (s *Storage) GetTexture(textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
+func (s *Storage) GetTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// 查找材质元数据文件
-	textureDir := string(textureType) + "s"
-	metadataPattern := filepath.Join(s.dataDir, "textures", textureDir, "*", "*", "*.json")
+	return s.findUploadedTexture(textureType, playerUUID)
+}
 
-	matches, err := filepath.Glob(metadataPattern)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search texture metadata: %w", err)
+// findUploadedTexture 查找通过API上传的材质（调用方需持有锁）
+func (s *Storage) findUploadedTexture(textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
+	metadata, _ := s.findTextureMetadata(textureType, playerUUID)
+	if metadata == nil {
+		return nil, fmt.Errorf("texture not found")
 	}
 
-	for _, metadataPath := range matches {
-		metadata, err := s.loadTextureMetadata(metadataPath)
-		if err != nil {
-			continue
-		}
-
-		if metadata.PlayerUUID == playerUUID && metadata.Type == textureType {
-			// 确定文件扩展名
-			extension := ".png"
-			texturePath := metadataPath[:len(metadataPath)-5] + extension // 移除.json，添加.png
-			if _, err := os.Stat(texturePath); os.IsNotExist(err) {
-				texturePath = metadataPath[:len(metadataPath)-5] + ".jpg"
-			}
-
-			textureURL := fmt.Sprintf("%s/textures/%s/%s%s", s.textureConfig.BaseURL, textureDir, metadata.Hash, extension)
-
-			return &storage.TextureInfo{
-				Type: textureType,
-				URL:  textureURL,
-				Metadata: &storage.TextureMetadata{
-					Hash:       metadata.Hash,
-					FileSize:   metadata.FileSize,
-					UploadedAt: metadata.UploadedAt,
-					Slim:       metadata.Slim,
-				},
-			}, nil
-		}
-	}
+	textureDir := string(textureType) + "s"
+	key := s.textureKey(textureDir, metadata.Hash, metadata.Extension)
+	textureURL := s.textureURL(textureDir, key, metadata.Hash, metadata.Extension)
 
-	return nil, fmt.Errorf("texture not found")
+	return &storage.TextureInfo{
+		Type: textureType,
+		URL:  textureURL,
+		Metadata: &storage.TextureMetadata{
+			Hash:       metadata.Hash,
+			FileSize:   metadata.FileSize,
+			UploadedAt: metadata.UploadedAt,
+			Slim:       metadata.Slim,
+		},
+	}, nil
 }
 
 // DeleteTexture 删除材质
-func (s *Storage) DeleteTexture(textureType storage.TextureType, playerUUID string) error {
-	s.mu.Lock()
+// 角色通过SkinTID/CapeTID引用的材质（导入的皮肤站数据）同时解除引用，删除后角色不再有该类型材质
+func (s *Storage) DeleteTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) error {
+	s.lockData()
 	defer s.mu.Unlock()
 
-	// 查找并删除材质文件和元数据
-	textureDir := string(textureType) + "s"
-	metadataPattern := filepath.Join(s.dataDir, "textures", textureDir, "*", "*", "*.json")
-
-	matches, err := filepath.Glob(metadataPattern)
+	metadata, metadataPath := s.findTextureMetadata(textureType, playerUUID)
+	referenced, err := s.clearTextureReference(textureType, playerUUID)
 	if err != nil {
-		return fmt.Errorf("failed to search texture metadata: %w", err)
+		return err
 	}
-
-	for _, metadataPath := range matches {
-		metadata, err := s.loadTextureMetadata(metadataPath)
-		if err != nil {
-			continue
+	if metadata == nil {
+		if !referenced {
+			return fmt.Errorf("texture not found")
 		}
+		storage.NotifyProfileChanged(playerUUID)
+		return nil
+	}
 
-		if metadata.PlayerUUID == playerUUID && metadata.Type == textureType {
-			// 删除材质文件
-			extension := ".png"
-			texturePath := metadataPath[:len(metadataPath)-5] + extension
-			if _, err := os.Stat(texturePath); os.IsNotExist(err) {
-				texturePath = metadataPath[:len(metadataPath)-5] + ".jpg"
-			}
-			os.Remove(texturePath)
-
-			// 删除元数据文件
-			os.Remove(metadataPath)
-			return nil
+	// 删除材质文件（启用历史时保留，以便恢复）
+	if !s.textureConfig.History.Enabled {
+		key := s.textureKey(string(textureType)+"s", metadata.Hash, metadata.Extension)
+		if err := s.blobs.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete texture file: %w", err)
 		}
 	}
 
-	return fmt.Errorf("texture not found")
+	// 删除元数据文件，删除前的材质状态写入历史记录
+	os.Remove(metadataPath)
+	s.indexTexture(textureType, playerUUID, nil, "")
+	s.recordTextureHistory(ctx, textureType, playerUUID, metadata, storage.TextureActionDelete)
+	storage.NotifyProfileChanged(playerUUID)
+	return nil
 }
 
-// GetTextureURL 计算材质URL
+// clearTextureReference 解除角色对textures.json中材质的引用（SkinTID/CapeTID），返回是否有引用（调用方需持有写锁）
+func (s *Storage) clearTextureReference(textureType storage.TextureType, playerUUID string) (bool, error) {
+	player, exists := s.players[playerUUID]
+	if !exists {
+		return false, nil
+	}
+
+	updated := *player
+	switch {
+	case textureType == storage.TextureTypeSkin && player.SkinTID > 0:
+		updated.SkinTID = 0
+	case textureType == storage.TextureTypeCape && player.CapeTID > 0:
+		updated.CapeTID = 0
+	default:
+		return false, nil
+	}
+
+	s.players[playerUUID] = &updated
+	if err := s.savePlayers(); err != nil {
+		s.players[playerUUID] = player
+		return false, fmt.Errorf("failed to save player data: %w", err)
+	}
+	return true, nil
+}
+
+// GetTextureURL 计算材质URL（优先使用对象存储的直接地址）
 func (s *Storage) GetTextureURL(textureType storage.TextureType, playerUUID string) string {
+	if textureInfo, err := s.GetTexture(context.Background(), textureType, playerUUID); err == nil {
+		return textureInfo.URL
+	}
 	return fmt.Sprintf("%s/textures/%s/%s", s.textureConfig.BaseURL, textureType, playerUUID)
 }
 
@@ -185,6 +210,47 @@ func (s *Storage) IsUploadSupported() bool {
 	return s.textureConfig.UploadEnabled
 }
 
+// textureKey 获取材质文件在对象存储中的key
+func (s *Storage) textureKey(textureDir, hash, extension string) string {
+	return s.getHashKey("textures/"+textureDir, hash, extension)
+}
+
+// textureExtensions 材质文件可能的扩展名（上传时按文件内容确定）
+var textureExtensions = []string{".png", ".jpg"}
+
+// getTextureBlob 按材质哈希读取材质文件（历史记录不含扩展名，依次尝试png和jpg）
+func (s *Storage) getTextureBlob(textureDir, hash string) ([]byte, error) {
+	var err error
+	for _, extension := range textureExtensions {
+		var data []byte
+		if data, err = s.blobs.Get(s.textureKey(textureDir, hash, extension)); err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// probeTextureExtension 查找旧版本元数据对应的材质文件扩展名（png优先，其次jpg；仅在建立索引时迁移旧元数据使用）
+func (s *Storage) probeTextureExtension(textureDir, hash string) string {
+	if exists, err := s.blobs.Exists(s.textureKey(textureDir, hash, ".jpg")); err == nil && exists {
+		if exists, err := s.blobs.Exists(s.textureKey(textureDir, hash, ".png")); err == nil && !exists {
+			return ".jpg"
+		}
+	}
+	return ".png"
+}
+
+// textureURL 构建材质URL（对象存储提供直接地址时使用该地址）
+func (s *Storage) textureURL(textureDir, key, hash, extension string) string {
+	if url, err := s.blobs.URL(key); err == nil && url != "" {
+		return url
+	}
+	return fmt.Sprintf("%s/textures/%s/%s%s", s.textureConfig.BaseURL, textureDir, hash, extension)
+}
+
 // saveTextureMetadata 保存材质元数据
 func (s *Storage) saveTextureMetadata(path string, metadata *TextureMetadata) error {
 	if err := s.ensureDir(path); err != nil {
@@ -196,7 +262,7 @@ func (s *Storage) saveTextureMetadata(path string, metadata *TextureMetadata) er
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return s.writeDataFile(path, data)
 }
 
 // loadTextureMetadata 加载材质元数据
@@ -2,10 +2,12 @@
 package file
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	storage "yggdrasil-api-go/src/storage/interface"
 	"yggdrasil-api-go/src/yggdrasil"
 
 	"github.com/bytedance/sonic"
@@ -52,7 +54,7 @@ func (s *Storage) saveUsers() error {
 	}
 
 	usersFile := filepath.Join(s.dataDir, "users.json")
-	return os.WriteFile(usersFile, data, 0644)
+	return s.writeDataFile(usersFile, data)
 }
 
 // createDefaultUsers 创建默认用户数据
@@ -103,7 +105,7 @@ func (s *Storage) createDefaultUsers() error {
 }
 
 // GetUserByEmail 根据邮箱获取用户
-func (s *Storage) GetUserByEmail(email string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -115,7 +117,7 @@ func (s *Storage) GetUserByEmail(email string) (*yggdrasil.User, error) {
 }
 
 // GetUserByPlayerName 根据角色名获取用户
-func (s *Storage) GetUserByPlayerName(playerName string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByPlayerName(ctx context.Context, playerName string) (*yggdrasil.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -143,7 +145,7 @@ func (s *Storage) GetUserByPlayerName(playerName string) (*yggdrasil.User, error
 }
 
 // GetUserByID 根据用户ID获取用户
-func (s *Storage) GetUserByID(userID string) (*yggdrasil.User, error) {
+func (s *Storage) GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -159,7 +161,7 @@ func (s *Storage) GetUserByID(userID string) (*yggdrasil.User, error) {
 
 // CreateUser 创建用户
 func (s *Storage) CreateUser(user *yggdrasil.User) error {
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	if _, exists := s.users[user.Email]; exists {
@@ -179,7 +181,7 @@ func (s *Storage) CreateUser(user *yggdrasil.User) error {
 
 // UpdateUser 更新用户信息
 func (s *Storage) UpdateUser(user *yggdrasil.User) error {
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	if _, exists := s.users[user.Email]; !exists {
@@ -197,7 +199,7 @@ func (s *Storage) UpdateUser(user *yggdrasil.User) error {
 
 // DeleteUser 删除用户
 func (s *Storage) DeleteUser(email string) error {
-	s.mu.Lock()
+	s.lockData()
 	defer s.mu.Unlock()
 
 	// 获取用户信息
@@ -207,9 +209,11 @@ func (s *Storage) DeleteUser(email string) error {
 	}
 
 	// 删除用户的所有角色
+	var removed []string
 	for playerID, player := range s.players {
 		if player.UID == user.UID {
 			delete(s.players, playerID)
+			removed = append(removed, playerID)
 		}
 	}
 
@@ -220,7 +224,18 @@ func (s *Storage) DeleteUser(email string) error {
 	delete(s.users, email)
 
 	// 保存数据
-	return s.saveUsers()
+	if err := s.saveUsers(); err != nil {
+		return err
+	}
+	if len(removed) > 0 {
+		if err := s.savePlayers(); err != nil {
+			return err
+		}
+	}
+	for _, playerID := range removed {
+		storage.NotifyProfileChanged(playerID)
+	}
+	return nil
 }
 
 // ListUsers 列出所有用户（分页）
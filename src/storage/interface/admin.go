@@ -0,0 +1,83 @@
+// Package storage 管理命令使用的存储扩展接口（存储可选实现，通过类型断言检测）
+package storage
+
+import (
+	"context"
+
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// UserAccount 用户账号信息（管理命令输出）
+type UserAccount struct {
+	ID       string           `json:"id"`
+	Email    string           `json:"email"`
+	Admin    bool             `json:"admin"`
+	Banned   bool             `json:"banned"`
+	Profiles []AccountProfile `json:"profiles"`
+}
+
+// AccountProfile 用户拥有的角色
+type AccountProfile struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AccountManager 用户和角色管理（文件存储和BlessingSkin支持）
+type AccountManager interface {
+	// ListAccounts 列出所有用户（按用户ID排序）
+	ListAccounts(ctx context.Context) ([]*UserAccount, error)
+
+	// CreateAccount 创建用户（passwordHash为bcrypt哈希）
+	CreateAccount(ctx context.Context, email, passwordHash string, admin bool) (*UserAccount, error)
+
+	// SetPassword 修改用户密码（passwordHash为bcrypt哈希）
+	SetPassword(ctx context.Context, email, passwordHash string) error
+
+	// SetBanned 封禁或解封用户（被封禁的用户无法登录）
+	SetBanned(ctx context.Context, email string, banned bool) error
+
+	// AddProfile 为用户添加角色
+	AddProfile(ctx context.Context, email string, profile *yggdrasil.Profile) error
+
+	// RenameProfile 修改角色名称
+	RenameProfile(ctx context.Context, uuid, name string) error
+
+	// RemoveProfile 删除角色（材质由调用方先行删除）
+	RemoveProfile(ctx context.Context, uuid string) error
+}
+
+// TextureGCResult 材质清理结果
+type TextureGCResult struct {
+	DryRun        bool     `json:"dry_run"`        // 只统计不删除
+	StaleMetadata int      `json:"stale_metadata"` // 已删除角色的材质记录数
+	StaleHistory  int      `json:"stale_history"`  // 已删除角色的材质历史记录数
+	OrphanBlobs   []string `json:"orphan_blobs"`   // 未被任何角色或历史记录引用的材质文件key
+}
+
+// TextureCollector 清理已删除角色的材质记录和未被引用的材质文件
+type TextureCollector interface {
+	// CollectTextures 清理材质（dryRun为true时只返回将被清理的内容）
+	CollectTextures(ctx context.Context, dryRun bool) (*TextureGCResult, error)
+}
+
+// Wrapper 存储装饰器（链路追踪、用户缓存等），用于查找底层存储实现的可选接口
+type Wrapper interface {
+	// Unwrap 返回被装饰的存储
+	Unwrap() Storage
+}
+
+// As 在存储及其装饰的底层存储中查找实现了T的第一个（如AccountManager、TextureCollector）
+func As[T any](s Storage) (T, bool) {
+	for s != nil {
+		if target, ok := s.(T); ok {
+			return target, true
+		}
+		wrapper, ok := s.(Wrapper)
+		if !ok {
+			break
+		}
+		s = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,58 @@
+// Package storage 角色和用户变更通知
+package storage
+
+import "sync"
+
+// ProfileChangeListener 角色变更监听器（材质或名称变化后调用）
+type ProfileChangeListener func(profileUUID string)
+
+// profileChangeListeners 已注册的角色变更监听器
+var profileChangeListeners = struct {
+	sync.RWMutex
+	listeners []ProfileChangeListener
+}{}
+
+// OnProfileChanged 注册角色变更监听器（如响应缓存失效）
+func OnProfileChanged(listener ProfileChangeListener) {
+	profileChangeListeners.Lock()
+	defer profileChangeListeners.Unlock()
+	profileChangeListeners.listeners = append(profileChangeListeners.listeners, listener)
+}
+
+// NotifyProfileChanged 通知角色已变更（存储实现在写入成功后调用，覆盖HTTP、导入和命令行等所有写入路径）
+func NotifyProfileChanged(profileUUID string) {
+	profileChangeListeners.RLock()
+	listeners := profileChangeListeners.listeners
+	profileChangeListeners.RUnlock()
+
+	for _, listener := range listeners {
+		listener(profileUUID)
+	}
+}
+
+// UserChangeListener 用户变更监听器（密码、权限或角色列表变化后调用）
+type UserChangeListener func(userID string)
+
+// userChangeListeners 已注册的用户变更监听器
+var userChangeListeners = struct {
+	sync.RWMutex
+	listeners []UserChangeListener
+}{}
+
+// OnUserChanged 注册用户变更监听器（如用户缓存失效）
+func OnUserChanged(listener UserChangeListener) {
+	userChangeListeners.Lock()
+	defer userChangeListeners.Unlock()
+	userChangeListeners.listeners = append(userChangeListeners.listeners, listener)
+}
+
+// NotifyUserChanged 通知用户已变更（存储实现发现外部修改时调用，如文件存储重新加载命令行写入的数据）
+func NotifyUserChanged(userID string) {
+	userChangeListeners.RLock()
+	listeners := userChangeListeners.listeners
+	userChangeListeners.RUnlock()
+
+	for _, listener := range listeners {
+		listener(userID)
+	}
+}
@@ -0,0 +1,28 @@
+// Package storage 材质变更的操作者信息
+package storage
+
+import "context"
+
+// TextureChange 材质写入的操作信息（写入历史记录）
+type TextureChange struct {
+	Action  string // 操作：upload, delete, revert, import（为空时按写入方法取upload或delete）
+	Actor   string // 操作者用户ID（命令行为cli）
+	ActorIP string // 操作者IP
+}
+
+// textureChangeKey context中TextureChange的键
+type textureChangeKey struct{}
+
+// WithTextureChange 返回携带操作信息的context（处理器、导入和命令行在写入材质前设置）
+func WithTextureChange(ctx context.Context, change TextureChange) context.Context {
+	return context.WithValue(ctx, textureChangeKey{}, change)
+}
+
+// TextureChangeFromContext 获取context中的操作信息（未设置操作时使用defaultAction）
+func TextureChangeFromContext(ctx context.Context, defaultAction string) TextureChange {
+	change, _ := ctx.Value(textureChangeKey{}).(TextureChange)
+	if change.Action == "" {
+		change.Action = defaultAction
+	}
+	return change
+}
@@ -0,0 +1,211 @@
+// Package storage 材质类型注册表
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // 注册JPEG解码器
+	_ "image/png"  // 注册PNG解码器
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"yggdrasil-api-go/src/config"
+	"yggdrasil-api-go/src/yggdrasil"
+)
+
+// TextureDimension 材质尺寸
+type TextureDimension struct {
+	Width  int
+	Height int
+}
+
+// TextureTypeDefinition 材质类型定义
+type TextureTypeDefinition struct {
+	Type       TextureType        // 类型名称（大写）
+	Upload     bool               // 是否允许上传
+	InProperty bool               // 是否写入textures属性
+	Dimensions []TextureDimension // 允许的尺寸，为空时不限制
+	AllowHD    bool               // 是否允许整数倍尺寸
+}
+
+// textureTypeRegistry 材质类型注册表
+var textureTypeRegistry = struct {
+	sync.RWMutex
+	defs []TextureTypeDefinition
+}{
+	defs: defaultTextureTypes(),
+}
+
+// defaultTextureTypes 默认材质类型（SKIN和CAPE）
+func defaultTextureTypes() []TextureTypeDefinition {
+	return []TextureTypeDefinition{
+		{
+			Type:       TextureTypeSkin,
+			Upload:     true,
+			InProperty: true,
+			Dimensions: []TextureDimension{{64, 64}, {64, 32}},
+			AllowHD:    true,
+		},
+		{
+			Type:       TextureTypeCape,
+			Upload:     true,
+			InProperty: true,
+			Dimensions: []TextureDimension{{64, 32}, {22, 17}},
+			AllowHD:    true,
+		},
+	}
+}
+
+// RegisterTextureTypes 根据配置注册材质类型（替换现有注册表）
+// SKIN和CAPE始终注册：配置中同名的类型覆盖默认定义，其余类型追加在后面
+func RegisterTextureTypes(configs []config.TextureTypeConfig) error {
+	defs := defaultTextureTypes()
+	for _, cfg := range configs {
+		def := TextureTypeDefinition{
+			Type:       TextureType(strings.ToUpper(cfg.Name)),
+			Upload:     cfg.Upload,
+			InProperty: !cfg.Hidden,
+			AllowHD:    cfg.AllowHD,
+		}
+		for _, dim := range cfg.Dimensions {
+			parsed, err := parseTextureDimension(dim)
+			if err != nil {
+				return fmt.Errorf("texture type %s: %w", def.Type, err)
+			}
+			def.Dimensions = append(def.Dimensions, parsed)
+		}
+
+		replaced := false
+		for i := range defs {
+			if defs[i].Type == def.Type {
+				defs[i] = def
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			defs = append(defs, def)
+		}
+	}
+
+	textureTypeRegistry.Lock()
+	textureTypeRegistry.defs = defs
+	textureTypeRegistry.Unlock()
+	return nil
+}
+
+// LookupTextureType 查找材质类型定义（不区分大小写）
+func LookupTextureType(name string) (*TextureTypeDefinition, bool) {
+	textureType := TextureType(strings.ToUpper(name))
+
+	textureTypeRegistry.RLock()
+	defer textureTypeRegistry.RUnlock()
+
+	for i := range textureTypeRegistry.defs {
+		if textureTypeRegistry.defs[i].Type == textureType {
+			def := textureTypeRegistry.defs[i]
+			return &def, true
+		}
+	}
+	return nil, false
+}
+
+// GetTextureTypes 获取所有已注册的材质类型
+func GetTextureTypes() []TextureTypeDefinition {
+	textureTypeRegistry.RLock()
+	defer textureTypeRegistry.RUnlock()
+
+	defs := make([]TextureTypeDefinition, len(textureTypeRegistry.defs))
+	copy(defs, textureTypeRegistry.defs)
+	return defs
+}
+
+// GetUploadableTextureTypes 获取uploadableTextures属性值（如 "skin,cape"）
+func GetUploadableTextureTypes() string {
+	var names []string
+	for _, def := range GetTextureTypes() {
+		if def.Upload {
+			names = append(names, strings.ToLower(string(def.Type)))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ValidateImage 校验材质图片尺寸
+func (d *TextureTypeDefinition) ValidateImage(data []byte) error {
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid image: %w", err)
+	}
+
+	if len(d.Dimensions) == 0 {
+		return nil
+	}
+
+	for _, dim := range d.Dimensions {
+		if imgConfig.Width == dim.Width && imgConfig.Height == dim.Height {
+			return nil
+		}
+		// 高清材质：宽高为同一整数倍
+		if d.AllowHD && imgConfig.Width%dim.Width == 0 && imgConfig.Height%dim.Height == 0 &&
+			imgConfig.Width/dim.Width == imgConfig.Height/dim.Height {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid %s dimensions: %dx%d", strings.ToLower(string(d.Type)), imgConfig.Width, imgConfig.Height)
+}
+
+// BuildProfileProperties 根据角色材质生成角色属性（textures 和 uploadableTextures）
+// 只有已注册且未隐藏的材质类型会写入textures属性
+func BuildProfileProperties(profileID, profileName string, textures map[TextureType]*TextureInfo) ([]yggdrasil.ProfileProperty, error) {
+	entries := make(map[string]yggdrasil.TextureInfo)
+
+	// 按类型名排序，保证输出稳定
+	types := make([]string, 0, len(textures))
+	for textureType := range textures {
+		types = append(types, string(textureType))
+	}
+	sort.Strings(types)
+
+	for _, name := range types {
+		info := textures[TextureType(name)]
+		def, ok := LookupTextureType(name)
+		if !ok || !def.InProperty || info == nil || info.URL == "" {
+			continue
+		}
+
+		entry := yggdrasil.TextureInfo{URL: info.URL}
+		// 仅皮肤支持模型元数据
+		if def.Type == TextureTypeSkin && info.Metadata != nil && info.Metadata.Slim {
+			entry.Metadata = map[string]interface{}{
+				"model": "slim",
+			}
+		}
+		entries[name] = entry
+	}
+
+	return yggdrasil.BuildProfileProperties(profileID, profileName, entries, GetUploadableTextureTypes())
+}
+
+// parseTextureDimension 解析尺寸字符串（如 "64x32"）
+func parseTextureDimension(s string) (TextureDimension, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "x")
+	if len(parts) != 2 {
+		return TextureDimension{}, fmt.Errorf("invalid dimension: %s", s)
+	}
+
+	width, err := strconv.Atoi(parts[0])
+	if err != nil || width <= 0 {
+		return TextureDimension{}, fmt.Errorf("invalid dimension: %s", s)
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil || height <= 0 {
+		return TextureDimension{}, fmt.Errorf("invalid dimension: %s", s)
+	}
+
+	return TextureDimension{Width: width, Height: height}, nil
+}
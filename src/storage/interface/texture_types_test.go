@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"yggdrasil-api-go/src/config"
+)
+
+func TestRegisterTextureTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		configs    []config.TextureTypeConfig
+		wantTypes  []TextureType
+		wantUpload string
+		wantErr    bool
+	}{
+		{name: "default", wantTypes: []TextureType{TextureTypeSkin, TextureTypeCape}, wantUpload: "skin,cape"},
+		{
+			name:       "extension keeps skin and cape",
+			configs:    []config.TextureTypeConfig{{Name: "elytra", Upload: true, Dimensions: []string{"64x32"}}},
+			wantTypes:  []TextureType{TextureTypeSkin, TextureTypeCape, "ELYTRA"},
+			wantUpload: "skin,cape,elytra",
+		},
+		{
+			name:       "override default",
+			configs:    []config.TextureTypeConfig{{Name: "cape", Upload: false}},
+			wantTypes:  []TextureType{TextureTypeSkin, TextureTypeCape},
+			wantUpload: "skin",
+		},
+		{name: "invalid dimension", configs: []config.TextureTypeConfig{{Name: "ELYTRA", Dimensions: []string{"64"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { _ = RegisterTextureTypes(nil) })
+
+			err := RegisterTextureTypes(tt.configs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("RegisterTextureTypes succeeded; want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RegisterTextureTypes: %v", err)
+			}
+
+			var types []TextureType
+			for _, def := range GetTextureTypes() {
+				types = append(types, def.Type)
+			}
+			if !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Fatalf("types = %v; want %v", types, tt.wantTypes)
+			}
+			if got := GetUploadableTextureTypes(); got != tt.wantUpload {
+				t.Fatalf("GetUploadableTextureTypes() = %q; want %q", got, tt.wantUpload)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package storage
 
 import (
+	"context"
 	"time"
 
 	"yggdrasil-api-go/src/config"
@@ -11,52 +12,52 @@ import (
 // UserStorage 用户存储接口
 type UserStorage interface {
 	// GetUserByEmail 根据邮箱获取用户
-	GetUserByEmail(email string) (*yggdrasil.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error)
 
 	// GetUserByID 根据用户ID获取用户
-	GetUserByID(userID string) (*yggdrasil.User, error)
+	GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error)
 
 	// GetUserByPlayerName 根据角色名获取用户
-	GetUserByPlayerName(playerName string) (*yggdrasil.User, error)
+	GetUserByPlayerName(ctx context.Context, playerName string) (*yggdrasil.User, error)
 
 	// GetUserByUUID 根据UUID获取用户
-	GetUserByUUID(uuid string) (*yggdrasil.User, error)
+	GetUserByUUID(ctx context.Context, uuid string) (*yggdrasil.User, error)
 
 	// AuthenticateUser 用户认证
-	AuthenticateUser(username, password string) (*yggdrasil.User, error)
+	AuthenticateUser(ctx context.Context, username, password string) (*yggdrasil.User, error)
 }
 
 // ProfileStorage 角色存储接口
 type ProfileStorage interface {
 	// GetProfileByUUID 根据UUID获取角色
-	GetProfileByUUID(uuid string) (*yggdrasil.Profile, error)
+	GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error)
 
 	// GetProfileByName 根据名称获取角色
-	GetProfileByName(name string) (*yggdrasil.Profile, error)
+	GetProfileByName(ctx context.Context, name string) (*yggdrasil.Profile, error)
 
 	// GetProfilesByNames 根据名称列表批量获取角色
-	GetProfilesByNames(names []string) ([]*yggdrasil.Profile, error)
+	GetProfilesByNames(ctx context.Context, names []string) ([]*yggdrasil.Profile, error)
 
 	// GetProfilesByUserEmail 获取用户的所有角色
-	GetProfilesByUserEmail(userEmail string) ([]*yggdrasil.Profile, error)
+	GetProfilesByUserEmail(ctx context.Context, userEmail string) ([]*yggdrasil.Profile, error)
 
 	// GetUserProfiles 根据用户UUID获取角色
-	GetUserProfiles(userUUID string) ([]*yggdrasil.Profile, error)
+	GetUserProfiles(ctx context.Context, userUUID string) ([]*yggdrasil.Profile, error)
 }
 
 // TextureStorage 材质存储接口
 type TextureStorage interface {
 	// UploadTexture 上传材质文件
-	UploadTexture(textureType TextureType, playerUUID string, data []byte, metadata *TextureMetadata) (*TextureInfo, error)
+	UploadTexture(ctx context.Context, textureType TextureType, playerUUID string, data []byte, metadata *TextureMetadata) (*TextureInfo, error)
 
 	// GetTexture 获取材质文件
-	GetTexture(textureType TextureType, playerUUID string) (*TextureInfo, error)
+	GetTexture(ctx context.Context, textureType TextureType, playerUUID string) (*TextureInfo, error)
 
 	// GetPlayerTextures 获取角色的所有材质
-	GetPlayerTextures(playerUUID string) (map[TextureType]*TextureInfo, error)
+	GetPlayerTextures(ctx context.Context, playerUUID string) (map[TextureType]*TextureInfo, error)
 
 	// DeleteTexture 删除材质文件
-	DeleteTexture(textureType TextureType, playerUUID string) error
+	DeleteTexture(ctx context.Context, textureType TextureType, playerUUID string) error
 
 	// GetTextureURL 计算材质URL
 	GetTextureURL(textureType TextureType, playerUUID string) string
@@ -65,6 +66,38 @@ type TextureStorage interface {
 	IsUploadSupported() bool
 }
 
+// TextureHistoryStorage 材质变更历史接口
+// 历史记录由存储在UploadTexture、DeleteTexture和RevertTexture覆盖材质之前写入（记录被覆盖的状态），
+// 操作者信息通过WithTextureChange随context传入
+type TextureHistoryStorage interface {
+	// GetTextureHistory 获取角色的材质变更历史（按时间倒序，textureType为空时返回所有类型）
+	GetTextureHistory(ctx context.Context, playerUUID string, textureType TextureType, limit int) ([]*TextureHistoryEntry, error)
+
+	// RevertTexture 将角色材质恢复到指定历史记录的状态
+	RevertTexture(ctx context.Context, playerUUID string, entryID int64) (*TextureHistoryEntry, error)
+}
+
+// 材质变更操作
+const (
+	TextureActionUpload = "upload"
+	TextureActionDelete = "delete"
+	TextureActionRevert = "revert"
+	TextureActionImport = "import"
+)
+
+// TextureHistoryEntry 材质变更历史记录（记录被该次变更覆盖的材质状态，恢复时回到这一状态）
+type TextureHistoryEntry struct {
+	ID        int64       `json:"id"`
+	ProfileID string      `json:"profile_id"`         // 角色UUID
+	Type      TextureType `json:"type"`               // 材质类型
+	Action    string      `json:"action"`             // 覆盖该状态的操作：upload, delete, revert, import
+	Hash      string      `json:"hash,omitempty"`     // 变更前的材质哈希（变更前没有材质时为空）
+	Model     string      `json:"model,omitempty"`    // 变更前的皮肤模型（slim或空）
+	Actor     string      `json:"actor"`              // 操作者用户ID（命令行为cli）
+	ActorIP   string      `json:"actor_ip,omitempty"` // 操作者IP
+	CreatedAt time.Time   `json:"created_at"`         // 变更时间
+}
+
 // TextureType 材质类型
 type TextureType string
 
@@ -95,6 +128,7 @@ type Storage interface {
 	UserStorage
 	ProfileStorage
 	TextureStorage
+	TextureHistoryStorage
 
 	// Close 关闭存储连接
 	Close() error
@@ -110,6 +144,35 @@ type Storage interface {
 	GetSignatureKeyPair() (privateKey string, publicKey string, err error)
 }
 
+// BlobStore 材质文件（二进制对象）存储接口
+// key 为使用 "/" 分隔的相对路径，与具体后端（本地磁盘、S3兼容对象存储）无关
+type BlobStore interface {
+	// Put 写入对象（已存在时覆盖）
+	Put(key string, data []byte, contentType string) error
+
+	// Get 读取对象
+	Get(key string) ([]byte, error)
+
+	// Delete 删除对象（不存在时不返回错误）
+	Delete(key string) error
+
+	// Exists 检查对象是否存在
+	Exists(key string) (bool, error)
+
+	// URL 获取对象的直接访问地址（公开地址或预签名地址）
+	// 后端未配置直接访问时返回空字符串，由调用方回退到本服务的材质地址
+	URL(key string) (string, error)
+
+	// GetBlobStoreType 获取对象存储类型
+	GetBlobStoreType() string
+}
+
+// BlobLister 支持列出对象的材质文件存储（材质清理使用）
+type BlobLister interface {
+	// List 列出指定前缀下的所有对象key
+	List(prefix string) ([]string, error)
+}
+
 // StorageFactory 存储工厂接口
 type StorageFactory interface {
 	// CreateStorage 创建存储实例
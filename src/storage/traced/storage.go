@@ -0,0 +1,167 @@
+// Package traced 带链路追踪的存储装饰器
+package traced
+
+import (
+	"context"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/tracing"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Storage 带链路追踪的存储：每次调用创建一个子Span，记录存储类型和结果
+// 未覆盖的方法直接委托给底层存储
+type Storage struct {
+	storage.Storage
+	backend string
+}
+
+// NewStorage 创建带链路追踪的存储
+func NewStorage(inner storage.Storage) *Storage {
+	return &Storage{
+		Storage: inner,
+		backend: inner.GetStorageType(),
+	}
+}
+
+// Unwrap 返回被装饰的存储
+func (s *Storage) Unwrap() storage.Storage {
+	return s.Storage
+}
+
+// start 创建存储操作的Span
+func (s *Storage) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("storage.backend", s.backend), attribute.String("storage.operation", operation))
+	return tracing.Start(ctx, "storage."+operation, attrs...)
+}
+
+// GetUserByEmail 根据邮箱获取用户
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*yggdrasil.User, error) {
+	ctx, span := s.start(ctx, "GetUserByEmail")
+	user, err := s.Storage.GetUserByEmail(ctx, email)
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetUserByID 根据用户ID获取用户
+func (s *Storage) GetUserByID(ctx context.Context, userID string) (*yggdrasil.User, error) {
+	ctx, span := s.start(ctx, "GetUserByID")
+	user, err := s.Storage.GetUserByID(ctx, userID)
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetUserByPlayerName 根据角色名获取用户
+func (s *Storage) GetUserByPlayerName(ctx context.Context, playerName string) (*yggdrasil.User, error) {
+	ctx, span := s.start(ctx, "GetUserByPlayerName")
+	user, err := s.Storage.GetUserByPlayerName(ctx, playerName)
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetUserByUUID 根据UUID获取用户
+func (s *Storage) GetUserByUUID(ctx context.Context, uuid string) (*yggdrasil.User, error) {
+	ctx, span := s.start(ctx, "GetUserByUUID")
+	user, err := s.Storage.GetUserByUUID(ctx, uuid)
+	tracing.End(span, err)
+	return user, err
+}
+
+// AuthenticateUser 用户认证
+func (s *Storage) AuthenticateUser(ctx context.Context, username, password string) (*yggdrasil.User, error) {
+	ctx, span := s.start(ctx, "AuthenticateUser")
+	user, err := s.Storage.AuthenticateUser(ctx, username, password)
+	tracing.End(span, err)
+	return user, err
+}
+
+// GetProfileByUUID 根据UUID获取角色
+func (s *Storage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
+	ctx, span := s.start(ctx, "GetProfileByUUID")
+	profile, err := s.Storage.GetProfileByUUID(ctx, uuid)
+	tracing.End(span, err)
+	return profile, err
+}
+
+// GetProfileByName 根据名称获取角色
+func (s *Storage) GetProfileByName(ctx context.Context, name string) (*yggdrasil.Profile, error) {
+	ctx, span := s.start(ctx, "GetProfileByName")
+	profile, err := s.Storage.GetProfileByName(ctx, name)
+	tracing.End(span, err)
+	return profile, err
+}
+
+// GetProfilesByNames 根据名称列表批量获取角色
+func (s *Storage) GetProfilesByNames(ctx context.Context, names []string) ([]*yggdrasil.Profile, error) {
+	ctx, span := s.start(ctx, "GetProfilesByNames", attribute.Int("storage.names", len(names)))
+	profiles, err := s.Storage.GetProfilesByNames(ctx, names)
+	tracing.End(span, err)
+	return profiles, err
+}
+
+// GetProfilesByUserEmail 获取用户的所有角色
+func (s *Storage) GetProfilesByUserEmail(ctx context.Context, userEmail string) ([]*yggdrasil.Profile, error) {
+	ctx, span := s.start(ctx, "GetProfilesByUserEmail")
+	profiles, err := s.Storage.GetProfilesByUserEmail(ctx, userEmail)
+	tracing.End(span, err)
+	return profiles, err
+}
+
+// GetUserProfiles 根据用户UUID获取角色
+func (s *Storage) GetUserProfiles(ctx context.Context, userUUID string) ([]*yggdrasil.Profile, error) {
+	ctx, span := s.start(ctx, "GetUserProfiles")
+	profiles, err := s.Storage.GetUserProfiles(ctx, userUUID)
+	tracing.End(span, err)
+	return profiles, err
+}
+
+// UploadTexture 上传材质文件
+func (s *Storage) UploadTexture(ctx context.Context, textureType storage.TextureType, playerUUID string, data []byte, metadata *storage.TextureMetadata) (*storage.TextureInfo, error) {
+	ctx, span := s.start(ctx, "UploadTexture", attribute.String("texture.type", string(textureType)), attribute.Int("texture.size", len(data)))
+	info, err := s.Storage.UploadTexture(ctx, textureType, playerUUID, data, metadata)
+	tracing.End(span, err)
+	return info, err
+}
+
+// GetTexture 获取材质文件
+func (s *Storage) GetTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) (*storage.TextureInfo, error) {
+	ctx, span := s.start(ctx, "GetTexture", attribute.String("texture.type", string(textureType)))
+	info, err := s.Storage.GetTexture(ctx, textureType, playerUUID)
+	tracing.End(span, err)
+	return info, err
+}
+
+// GetPlayerTextures 获取角色的所有材质
+func (s *Storage) GetPlayerTextures(ctx context.Context, playerUUID string) (map[storage.TextureType]*storage.TextureInfo, error) {
+	ctx, span := s.start(ctx, "GetPlayerTextures")
+	textures, err := s.Storage.GetPlayerTextures(ctx, playerUUID)
+	tracing.End(span, err)
+	return textures, err
+}
+
+// DeleteTexture 删除材质文件
+func (s *Storage) DeleteTexture(ctx context.Context, textureType storage.TextureType, playerUUID string) error {
+	ctx, span := s.start(ctx, "DeleteTexture", attribute.String("texture.type", string(textureType)))
+	err := s.Storage.DeleteTexture(ctx, textureType, playerUUID)
+	tracing.End(span, err)
+	return err
+}
+
+// GetTextureHistory 获取角色的材质变更历史
+func (s *Storage) GetTextureHistory(ctx context.Context, playerUUID string, textureType storage.TextureType, limit int) ([]*storage.TextureHistoryEntry, error) {
+	ctx, span := s.start(ctx, "GetTextureHistory")
+	entries, err := s.Storage.GetTextureHistory(ctx, playerUUID, textureType, limit)
+	tracing.End(span, err)
+	return entries, err
+}
+
+// RevertTexture 恢复历史材质
+func (s *Storage) RevertTexture(ctx context.Context, playerUUID string, entryID int64) (*storage.TextureHistoryEntry, error) {
+	ctx, span := s.start(ctx, "RevertTexture")
+	entry, err := s.Storage.RevertTexture(ctx, playerUUID, entryID)
+	tracing.End(span, err)
+	return entry, err
+}
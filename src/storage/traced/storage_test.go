@@ -0,0 +1,81 @@
+package traced
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storage "yggdrasil-api-go/src/storage/interface"
+	"yggdrasil-api-go/src/yggdrasil"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// stubStorage 只实现测试用到的方法，记录调用时Context中的Span
+type stubStorage struct {
+	storage.Storage
+	span trace.SpanContext
+}
+
+func (s *stubStorage) GetStorageType() string { return "stub" }
+
+func (s *stubStorage) GetProfileByUUID(ctx context.Context, uuid string) (*yggdrasil.Profile, error) {
+	s.span = trace.SpanContextFromContext(ctx)
+	if uuid != "steve" {
+		return nil, errors.New("profile not found")
+	}
+	return &yggdrasil.Profile{ID: uuid}, nil
+}
+
+func TestStorageSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	inner := &stubStorage{}
+	s := NewStorage(inner)
+	ctx := context.Background()
+
+	if _, err := s.GetProfileByUUID(ctx, "steve"); err != nil {
+		t.Fatalf("GetProfileByUUID: %v", err)
+	}
+	if _, err := s.GetProfileByUUID(ctx, "alex"); err == nil {
+		t.Fatal("GetProfileByUUID(alex) succeeded; want error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans; want 2", len(spans))
+	}
+	// 底层存储在存储Span内执行（数据库查询Span作为其子Span）
+	if inner.span.SpanID() != spans[1].SpanContext().SpanID() {
+		t.Fatal("inner storage did not receive the storage span context")
+	}
+
+	tests := []struct {
+		span    sdktrace.ReadOnlySpan
+		outcome string
+		status  codes.Code
+	}{
+		{span: spans[0], outcome: "success", status: codes.Unset},
+		{span: spans[1], outcome: "error", status: codes.Error},
+	}
+	for _, tt := range tests {
+		attrs := make(map[attribute.Key]string)
+		for _, attr := range tt.span.Attributes() {
+			attrs[attr.Key] = attr.Value.Emit()
+		}
+		if tt.span.Name() != "storage.GetProfileByUUID" || attrs["storage.backend"] != "stub" || attrs["outcome"] != tt.outcome {
+			t.Fatalf("span %s attributes = %v; want backend stub and outcome %s", tt.span.Name(), attrs, tt.outcome)
+		}
+		if tt.span.Status().Code != tt.status {
+			t.Fatalf("span status = %v; want %v", tt.span.Status().Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package tlsutil HTTPS证书加载和热更新
+package tlsutil
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultReloadInterval 未配置时检查证书文件变化的间隔
+const defaultReloadInterval = 30 * time.Second
+
+// CertReloader 证书热更新：定期检查证书和私钥文件，变化后重新加载
+// 新的TLS握手使用新证书，已建立的连接不受影响；加载失败时继续使用旧证书
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	cert  atomic.Pointer[tls.Certificate]
+	mu    sync.Mutex
+	stamp string // 上次加载时两个文件的修改时间和大小
+}
+
+// NewCertReloader 创建证书热更新器（立即加载一次，证书无效时返回错误）
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 返回当前证书（用于tls.Config.GetCertificate）
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Reload 重新加载证书和私钥
+func (r *CertReloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+
+	r.cert.Store(&cert)
+	r.stamp = stamp
+	if cert.Leaf != nil {
+		slog.Info("Loaded TLS certificate", "dns_names", cert.Leaf.DNSNames, "expires", cert.Leaf.NotAfter)
+	}
+	return nil
+}
+
+// Run 定期检查证书文件，文件变化时重新加载（ctx取消时返回）
+func (r *CertReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				slog.Warn("Failed to reload TLS certificate, keeping the current one", "cert_file", r.certFile, "error", err)
+			}
+		}
+	}
+}
+
+// changed 证书或私钥文件是否在上次加载后发生变化
+func (r *CertReloader) changed() bool {
+	stamp, err := r.fileStamp()
+	if err != nil {
+		// 证书更新过程中文件可能短暂不存在，下次检查时再加载
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return stamp != r.stamp
+}
+
+// fileStamp 证书和私钥文件的修改时间和大小（通过符号链接替换的文件同样能检测到）
+func (r *CertReloader) fileStamp() (string, error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat tls certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat tls key: %w", err)
+	}
+	return fmt.Sprintf("%d:%d/%d:%d",
+		certInfo.ModTime().UnixNano(), certInfo.Size(),
+		keyInfo.ModTime().UnixNano(), keyInfo.Size()), nil
+}
@@ -3,7 +3,7 @@ package utils
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -21,46 +21,45 @@ type CacheWarmupConfig struct {
 
 // WarmupCaches 预热所有缓存
 func WarmupCaches(cfg *config.Config, store storage.Storage) error {
-	log.Printf("🔥 开始缓存预热...")
+	slog.Info("Cache warmup started")
 	start := time.Now()
 
 	// 检查响应缓存配置
 	if !cfg.Cache.Response.Enabled {
-		log.Printf("ℹ️  响应缓存已禁用，跳过预热")
+		slog.Info("Response cache disabled, skipping cache warmup")
 		return nil
 	}
 
 	// 1. 预热错误响应缓存
 	if cfg.Cache.Response.ErrorResponses {
 		if err := warmupErrorResponses(); err != nil {
-			log.Printf("⚠️  错误响应缓存预热失败: %v", err)
+			slog.Warn("Error response cache warmup failed", "error", err)
 		} else {
-			log.Printf("✅ 错误响应缓存预热完成")
+			slog.Debug("Error response cache warmed up")
 		}
 	} else {
-		log.Printf("ℹ️  错误响应缓存已禁用")
+		slog.Debug("Error response cache disabled")
 	}
 
 	// 2. 预热API元数据缓存
 	if cfg.Cache.Response.APIMetadata {
 		if err := warmupAPIMetadata(cfg, store); err != nil {
-			log.Printf("⚠️  API元数据缓存预热失败: %v", err)
+			slog.Warn("API metadata cache warmup failed", "error", err)
 		} else {
-			log.Printf("✅ API元数据缓存预热完成")
+			slog.Debug("API metadata cache warmed up")
 		}
 	} else {
-		log.Printf("ℹ️  API元数据缓存已禁用")
+		slog.Debug("API metadata cache disabled")
 	}
 
 	// 3. 预热UUID缓存（如果存储支持）
 	if err := warmupUUIDCache(store); err != nil {
-		log.Printf("⚠️  UUID缓存预热失败: %v", err)
+		slog.Warn("UUID cache warmup failed", "error", err)
 	} else {
-		log.Printf("✅ UUID缓存预热完成")
+		slog.Debug("UUID cache warmed up")
 	}
 
-	duration := time.Since(start)
-	log.Printf("🎉 缓存预热完成，耗时: %v", duration)
+	slog.Info("Cache warmup completed", "duration", time.Since(start))
 	return nil
 }
 
@@ -108,7 +107,7 @@ func warmupAPIMetadata(cfg *config.Config, store storage.Storage) error {
 		}
 
 		if err != nil {
-			log.Printf("⚠️  Failed to load public key for cache warmup: %v", err)
+			slog.Warn("Failed to load public key for cache warmup", "error", err)
 			publicKey = "" // 使用空字符串作为降级
 		}
 
@@ -128,7 +127,7 @@ func warmupAPIMetadata(cfg *config.Config, store storage.Storage) error {
 		// 序列化并缓存
 		if jsonData, err := FastMarshal(metadata); err == nil {
 			cacheKey := "api_metadata_" + host
-			SetCachedResponse(cacheKey, jsonData)
+			SetCachedResponse(cacheKey, jsonData, APIMetadataResponseTag)
 		}
 	}
 
@@ -159,13 +158,10 @@ func GetCacheStats() map[string]any {
 	stats["performance"] = GlobalMetrics.GetStats()
 
 	// 响应缓存统计
-	responseCount := 0
-	responseCache.Range(func(key, value any) bool {
-		responseCount++
-		return true
-	})
+	responseCount, responseBytes := GlobalResponseCache().Stats()
 	stats["response_cache"] = map[string]any{
 		"cached_responses": responseCount,
+		"cached_bytes":     responseBytes,
 	}
 
 	// 错误响应缓存统计
@@ -180,18 +176,19 @@ func GetCacheStats() map[string]any {
 func PrintCacheStats() {
 	stats := GetCacheStats()
 
-	fmt.Printf("\n📊 Cache Statistics:\n")
+	attrs := []any{"qps", GlobalMetrics.GetQPS()}
 
 	if perfStats, ok := stats["performance"].(map[string]any); ok {
-		fmt.Printf("  Performance: QPS=%.2f, Cache Hit Rate=%.2f%%\n",
-			GlobalMetrics.GetQPS(), perfStats["cache_hit_rate"])
+		attrs = append(attrs, "cache_hit_rate", perfStats["cache_hit_rate"])
 	}
 
 	if respStats, ok := stats["response_cache"].(map[string]any); ok {
-		fmt.Printf("  Response Cache: %d cached responses\n", respStats["cached_responses"])
+		attrs = append(attrs, slog.Group("response_cache", "entries", respStats["cached_responses"], "bytes", respStats["cached_bytes"]))
 	}
 
 	if errStats, ok := stats["error_cache"].(map[string]any); ok {
-		fmt.Printf("  Error Cache: %d cached errors\n", errStats["cached_errors"])
+		attrs = append(attrs, "cached_errors", errStats["cached_errors"])
 	}
+
+	slog.Info("Cache statistics", attrs...)
 }
@@ -84,4 +84,9 @@ func GenerateShortCode() string {
 		result += fmt.Sprintf("%d", b%10)
 	}
 	return result
-}
\ No newline at end of file
+}
+// CalculateHash 计算数据的SHA-256哈希（十六进制）
+func CalculateHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
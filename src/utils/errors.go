@@ -16,6 +16,7 @@ const (
 	ErrNotFound             = "NotFoundException"
 	ErrUnauthorized         = "UnauthorizedException"
 	ErrUnsupportedMediaType = "Unsupported Media Type"
+	ErrPayloadTooLarge      = "Payload Too Large"
 )
 
 // 预定义的错误消息
@@ -31,6 +32,8 @@ const (
 	MsgUnsupportedMediaType   = "Unsupported Media Type"
 	MsgContentTypeRequired    = "Content-Type must be application/json"
 	MsgRateLimitExceeded      = "Rate limit exceeded. Please try again later."
+	MsgRequestTooLarge        = "Request body too large."
+	MsgClientCertRequired     = "A valid client certificate is required."
 )
 
 // RespondError 返回错误响应
@@ -3,6 +3,7 @@ package utils
 
 import (
 	"sync"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/gin-gonic/gin"
@@ -10,8 +11,6 @@ import (
 
 // 预编译的常用响应缓存
 var (
-	responseCache = sync.Map{}
-
 	// 预序列化的API元数据（在启动时设置）
 	cachedAPIMetadata []byte
 
@@ -55,15 +54,17 @@ func RespondJSONFast(c *gin.Context, data interface{}) {
 
 // GetCachedResponse 获取缓存的响应
 func GetCachedResponse(key string) ([]byte, bool) {
-	if cached, ok := responseCache.Load(key); ok {
-		return cached.([]byte), true
-	}
-	return nil, false
+	return GlobalResponseCache().Get(key)
+}
+
+// SetCachedResponse 设置缓存的响应（tags用于按角色等维度失效）
+func SetCachedResponse(key string, data []byte, tags ...string) {
+	GlobalResponseCache().Set(key, data, tags...)
 }
 
-// SetCachedResponse 设置缓存的响应
-func SetCachedResponse(key string, data []byte) {
-	responseCache.Store(key, data)
+// SetCachedResponseWithTTL 设置缓存的响应并指定过期时间（不超过cache_duration）
+func SetCachedResponseWithTTL(key string, data []byte, ttl time.Duration, tags ...string) {
+	GlobalResponseCache().SetWithTTL(key, data, ttl, tags...)
 }
 
 // GetCachedAPIMetadata 获取缓存的API元数据
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -8,10 +10,14 @@ import (
 )
 
 // JWTClaims JWT声明结构
+// Yggdrasil访问令牌使用UserID、ProfileID和TokenID；管理后台的令牌使用UserUUID、Username和IsAdmin
 type JWTClaims struct {
-	UserUUID string `json:"user_uuid"`
-	Username string `json:"username"`
-	IsAdmin  bool   `json:"is_admin"`
+	UserID    string `json:"uid,omitempty"` // 用户ID
+	ProfileID string `json:"pid,omitempty"` // 绑定的角色ID（未选择角色时为空）
+	TokenID   string `json:"tid,omitempty"` // 令牌ID（缓存中以 用户ID:令牌ID 为键）
+	UserUUID  string `json:"user_uuid,omitempty"`
+	Username  string `json:"username,omitempty"`
+	IsAdmin   bool   `json:"is_admin,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -22,21 +28,40 @@ func SetJWTSecret(secret string) {
 	jwtSecret = []byte(secret)
 }
 
-// GenerateJWT 生成JWT令牌
-func GenerateJWT(userUUID, username string, isAdmin bool, expirationTime time.Duration) (string, error) {
-	if len(jwtSecret) == 0 {
-		return "", errors.New("JWT secret not configured")
+// GenerateJWT 生成Yggdrasil访问令牌（每个令牌带有随机的TokenID）
+func GenerateJWT(userID, profileID string, expirationTime time.Duration) (string, error) {
+	tokenID := make([]byte, 16)
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", err
 	}
 
-	claims := &JWTClaims{
+	return signJWT(&JWTClaims{
+		UserID:    userID,
+		ProfileID: profileID,
+		TokenID:   hex.EncodeToString(tokenID),
+	}, expirationTime)
+}
+
+// GenerateAdminJWT 生成管理后台令牌
+func GenerateAdminJWT(userUUID, username string, isAdmin bool, expirationTime time.Duration) (string, error) {
+	return signJWT(&JWTClaims{
 		UserUUID: userUUID,
 		Username: username,
 		IsAdmin:  isAdmin,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expirationTime)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+	}, expirationTime)
+}
+
+// signJWT 签名JWT令牌
+func signJWT(claims *JWTClaims, expirationTime time.Duration) (string, error) {
+	if len(jwtSecret) == 0 {
+		return "", errors.New("JWT secret not configured")
+	}
+
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(expirationTime)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -73,5 +98,8 @@ func RefreshJWT(tokenString string, newExpirationTime time.Duration) (string, er
 	}
 
 	// 生成新的令牌
-	return GenerateJWT(claims.UserUUID, claims.Username, claims.IsAdmin, newExpirationTime)
+	if claims.UserID != "" {
+		return GenerateJWT(claims.UserID, claims.ProfileID, newExpirationTime)
+	}
+	return GenerateAdminJWT(claims.UserUUID, claims.Username, claims.IsAdmin, newExpirationTime)
 }
\ No newline at end of file
@@ -0,0 +1,71 @@
+// Package utils 签名密钥文件
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultKeyBits 生成签名密钥的默认长度（authlib-injector推荐4096位）
+const DefaultKeyBits = 4096
+
+// LoadOrGenerateKeyPair 读取PEM格式的密钥对，两个文件都不存在时生成新的密钥对并写入
+func LoadOrGenerateKeyPair(privateKeyPath, publicKeyPath string) (string, string, error) {
+	privatePEM, privateErr := os.ReadFile(privateKeyPath)
+	publicPEM, publicErr := os.ReadFile(publicKeyPath)
+	if privateErr == nil && publicErr == nil {
+		return string(privatePEM), string(publicPEM), nil
+	}
+	// 只缺少其中一个文件时不覆盖另一个（避免丢失已发布的公钥）
+	if !errors.Is(privateErr, os.ErrNotExist) || !errors.Is(publicErr, os.ErrNotExist) {
+		if privateErr != nil {
+			return "", "", fmt.Errorf("failed to read private key: %w", privateErr)
+		}
+		return "", "", fmt.Errorf("failed to read public key: %w", publicErr)
+	}
+
+	privateKey, publicKey, err := GenerateKeyPair(DefaultKeyBits)
+	if err != nil {
+		return "", "", err
+	}
+	if err := WriteKeyFile(privateKeyPath, privateKey, 0600); err != nil {
+		return "", "", err
+	}
+	if err := WriteKeyFile(publicKeyPath, publicKey, 0644); err != nil {
+		return "", "", err
+	}
+	return privateKey, publicKey, nil
+}
+
+// GenerateKeyPair 生成RSA密钥对（私钥PKCS#1，公钥PKIX，与Yggdrasil元数据中的signaturePublickey格式一致）
+func GenerateKeyPair(bits int) (string, string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key pair: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return string(privatePEM), string(publicPEM), nil
+}
+
+// WriteKeyFile 写入密钥文件（自动创建目录）
+func WriteKeyFile(path, content string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -2,10 +2,12 @@
 package utils
 
 import (
-	"fmt"
+	"log/slog"
 	"runtime"
 	"sync/atomic"
 	"time"
+
+	"yggdrasil-api-go/src/metrics"
 )
 
 // PerformanceMetrics 性能指标
@@ -27,6 +29,11 @@ type PerformanceMetrics struct {
 	CacheHits   int64 // 缓存命中次数
 	CacheMisses int64 // 缓存未命中次数
 
+	// 响应缓存统计
+	ResponseCacheHits      int64 // 响应缓存命中次数
+	ResponseCacheMisses    int64 // 响应缓存未命中次数
+	ResponseCacheEvictions int64 // 响应缓存淘汰次数
+
 	// 启动时间
 	StartTime time.Time
 }
@@ -88,6 +95,23 @@ func (m *PerformanceMetrics) RecordCacheMiss() {
 	atomic.AddInt64(&m.CacheMisses, 1)
 }
 
+// RecordResponseCacheHit 记录响应缓存命中
+func (m *PerformanceMetrics) RecordResponseCacheHit() {
+	atomic.AddInt64(&m.ResponseCacheHits, 1)
+	metrics.RecordCache("response", "memory", true)
+}
+
+// RecordResponseCacheMiss 记录响应缓存未命中
+func (m *PerformanceMetrics) RecordResponseCacheMiss() {
+	atomic.AddInt64(&m.ResponseCacheMisses, 1)
+	metrics.RecordCache("response", "memory", false)
+}
+
+// RecordResponseCacheEviction 记录响应缓存淘汰
+func (m *PerformanceMetrics) RecordResponseCacheEviction() {
+	atomic.AddInt64(&m.ResponseCacheEvictions, 1)
+}
+
 // GetStats 获取统计信息
 func (m *PerformanceMetrics) GetStats() map[string]interface{} {
 	requestCount := atomic.LoadInt64(&m.RequestCount)
@@ -99,6 +123,8 @@ func (m *PerformanceMetrics) GetStats() map[string]interface{} {
 	totalDBTime := atomic.LoadInt64(&m.TotalDBTime)
 	cacheHits := atomic.LoadInt64(&m.CacheHits)
 	cacheMisses := atomic.LoadInt64(&m.CacheMisses)
+	responseHits := atomic.LoadInt64(&m.ResponseCacheHits)
+	responseMisses := atomic.LoadInt64(&m.ResponseCacheMisses)
 
 	// 计算平均值
 	var avgResponseTime float64
@@ -118,6 +144,12 @@ func (m *PerformanceMetrics) GetStats() map[string]interface{} {
 		cacheHitRate = float64(cacheHits) / float64(totalCacheRequests) * 100
 	}
 
+	var responseHitRate float64
+	if total := responseHits + responseMisses; total > 0 {
+		responseHitRate = float64(responseHits) / float64(total) * 100
+	}
+	responseEntries, responseBytes := GlobalResponseCache().Stats()
+
 	// 获取内存统计
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
@@ -135,6 +167,14 @@ func (m *PerformanceMetrics) GetStats() map[string]interface{} {
 		"cache_hit_rate":       cacheHitRate,
 		"cache_hits":           cacheHits,
 		"cache_misses":         cacheMisses,
+		"response_cache": map[string]interface{}{
+			"hits":      responseHits,
+			"misses":    responseMisses,
+			"hit_rate":  responseHitRate,
+			"evictions": atomic.LoadInt64(&m.ResponseCacheEvictions),
+			"entries":   responseEntries,
+			"bytes":     responseBytes,
+		},
 		"memory": map[string]interface{}{
 			"alloc_mb":       float64(memStats.Alloc) / 1024 / 1024,
 			"total_alloc_mb": float64(memStats.TotalAlloc) / 1024 / 1024,
@@ -155,6 +195,9 @@ func (m *PerformanceMetrics) ResetStats() {
 	atomic.StoreInt64(&m.TotalDBTime, 0)
 	atomic.StoreInt64(&m.CacheHits, 0)
 	atomic.StoreInt64(&m.CacheMisses, 0)
+	atomic.StoreInt64(&m.ResponseCacheHits, 0)
+	atomic.StoreInt64(&m.ResponseCacheMisses, 0)
+	atomic.StoreInt64(&m.ResponseCacheEvictions, 0)
 	m.StartTime = time.Now()
 }
 
@@ -206,18 +249,29 @@ func (m *PerformanceMetrics) GetAvgDBTime() float64 {
 func (m *PerformanceMetrics) PrintStats() {
 	stats := m.GetStats()
 
-	fmt.Printf("\n📊 Performance Statistics:\n")
-	fmt.Printf("  Uptime: %.2f seconds\n", stats["uptime_seconds"])
-	fmt.Printf("  Requests: %d (QPS: %.2f)\n", stats["request_count"], m.GetQPS())
-	fmt.Printf("  Errors: %d (Rate: %.2f%%)\n", stats["error_count"], stats["error_rate"])
-	fmt.Printf("  Response Time: Avg=%.2fms, Max=%.2fms, Min=%.2fms\n",
-		stats["avg_response_time_ms"], stats["max_response_time_ms"], stats["min_response_time_ms"])
-	fmt.Printf("  DB Queries: %d (Avg: %.2fms)\n", stats["db_query_count"], stats["avg_db_time_ms"])
-	fmt.Printf("  Cache: Hit Rate=%.2f%% (Hits=%d, Misses=%d)\n",
-		stats["cache_hit_rate"], stats["cache_hits"], stats["cache_misses"])
+	attrs := []any{
+		"uptime_seconds", stats["uptime_seconds"],
+		"requests", stats["request_count"],
+		"qps", m.GetQPS(),
+		"errors", stats["error_count"],
+		"error_rate", stats["error_rate"],
+		slog.Group("response_time_ms",
+			"avg", stats["avg_response_time_ms"], "max", stats["max_response_time_ms"], "min", stats["min_response_time_ms"]),
+		slog.Group("db", "queries", stats["db_query_count"], "avg_ms", stats["avg_db_time_ms"]),
+		slog.Group("cache", "hit_rate", stats["cache_hit_rate"], "hits", stats["cache_hits"], "misses", stats["cache_misses"]),
+	}
+
+	if response, ok := stats["response_cache"].(map[string]interface{}); ok {
+		attrs = append(attrs, slog.Group("response_cache",
+			"hit_rate", response["hit_rate"], "hits", response["hits"], "misses", response["misses"],
+			"evictions", response["evictions"], "entries", response["entries"], "bytes", response["bytes"]))
+	}
 
 	if memory, ok := stats["memory"].(map[string]interface{}); ok {
-		fmt.Printf("  Memory: Alloc=%.2fMB, Total=%.2fMB, Sys=%.2fMB, GC=%d\n",
-			memory["alloc_mb"], memory["total_alloc_mb"], memory["sys_mb"], memory["gc_count"])
+		attrs = append(attrs, slog.Group("memory_mb",
+			"alloc", memory["alloc_mb"], "total_alloc", memory["total_alloc_mb"], "sys", memory["sys_mb"]),
+			"gc_count", memory["gc_count"])
 	}
+
+	slog.Info("Performance statistics", attrs...)
 }
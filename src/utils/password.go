@@ -96,46 +96,3 @@ func IsPasswordStrong(password string) (bool, []string) {
 	
 	return len(issues) == 0, issues
 }
-
-// CheckPasswordStrength 检查密码强度（返回分数和建议）
-func CheckPasswordStrength(password string) (score int, feedback string) {
-	score = 0
-	
-	// 长度加分
-	if len(password) >= 8 {
-		score += 2
-	}
-	if len(password) >= 12 {
-		score += 1
-	}
-	
-	// 复杂度加分
-	if regexp.MustCompile(`[a-z]`).MatchString(password) {
-		score += 1
-	}
-	if regexp.MustCompile(`[A-Z]`).MatchString(password) {
-		score += 1
-	}
-	if regexp.MustCompile(`[0-9]`).MatchString(password) {
-		score += 1
-	}
-	if regexp.MustCompile(`[^a-zA-Z0-9]`).MatchString(password) {
-		score += 2
-	}
-	
-	// 生成反馈
-	switch score {
-	case 0, 1:
-		feedback = "密码强度很弱，建议增加长度和复杂度"
-	case 2, 3:
-		feedback = "密码强度较弱，建议增加大小写字母、数字或特殊字符"
-	case 4, 5:
-		feedback = "密码强度中等，可以继续增强"
-	case 6, 7:
-		feedback = "密码强度较强"
-	default:
-		feedback = "密码强度很强"
-	}
-	
-	return score, feedback
-}
\ No newline at end of file
@@ -0,0 +1,133 @@
+// Package utils 有容量上限的响应缓存（LRU + TTL + 按标签失效）
+package utils
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"yggdrasil-api-go/src/cache/lru"
+	"yggdrasil-api-go/src/config"
+)
+
+// 默认响应缓存限制（未配置时使用）
+const (
+	defaultResponseCacheEntries = 1000
+	defaultResponseCacheBytes   = 32 * 1024 * 1024
+	defaultResponseCacheTTL     = 10 * time.Minute
+)
+
+// ResponseCache 响应缓存
+// 条目数和字节数任一超出上限时淘汰最久未使用的条目；条目可带标签，按标签批量失效
+type ResponseCache struct {
+	enabled bool
+	entries *lru.Cache[[]byte]
+}
+
+// NewResponseCache 根据配置创建响应缓存
+func NewResponseCache(cfg config.ResponseCacheConfig) *ResponseCache {
+	maxEntries := cfg.MaxCacheSize
+	if maxEntries <= 0 {
+		maxEntries = defaultResponseCacheEntries
+	}
+	maxBytes := cfg.MaxCacheBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultResponseCacheBytes
+	}
+	ttl := cfg.CacheDuration
+	if ttl <= 0 {
+		ttl = defaultResponseCacheTTL
+	}
+
+	return &ResponseCache{
+		enabled: cfg.Enabled,
+		entries: lru.NewSized(maxEntries, maxBytes, ttl, func(key string, data []byte) int64 {
+			return int64(len(key) + len(data))
+		}),
+	}
+}
+
+// Get 获取未过期的响应
+func (c *ResponseCache) Get(key string) ([]byte, bool) {
+	if !c.enabled {
+		return nil, false
+	}
+
+	data, ok := c.entries.Get(key)
+	if !ok {
+		GlobalMetrics.RecordResponseCacheMiss()
+		return nil, false
+	}
+	GlobalMetrics.RecordResponseCacheHit()
+	return data, true
+}
+
+// Set 缓存响应（tags用于之后按标签失效）
+func (c *ResponseCache) Set(key string, data []byte, tags ...string) {
+	c.SetWithTTL(key, data, 0, tags...)
+}
+
+// SetWithTTL 缓存响应并指定过期时间（不超过cache_duration，用于无法收到变更通知的数据）
+// 单个响应超过总字节上限时不缓存
+func (c *ResponseCache) SetWithTTL(key string, data []byte, ttl time.Duration, tags ...string) {
+	if !c.enabled {
+		return
+	}
+
+	for evicted := c.entries.Set(key, data, ttl, tags...); evicted > 0; evicted-- {
+		GlobalMetrics.RecordResponseCacheEviction()
+	}
+}
+
+// Delete 删除响应
+func (c *ResponseCache) Delete(key string) {
+	c.entries.Delete(key)
+}
+
+// InvalidateTag 删除带有指定标签的所有响应
+func (c *ResponseCache) InvalidateTag(tag string) {
+	c.entries.DeleteTag(tag)
+}
+
+// Stats 获取条目数和字节数
+func (c *ResponseCache) Stats() (entries int, bytes int64) {
+	entries, _, bytes = c.entries.Stats()
+	return entries, bytes
+}
+
+// globalResponseCache 全局响应缓存（启动时由InitResponseCache按配置重建，请求处理中可能并发读取）
+var globalResponseCache atomic.Pointer[ResponseCache]
+
+func init() {
+	globalResponseCache.Store(NewResponseCache(config.ResponseCacheConfig{Enabled: true}))
+}
+
+// GlobalResponseCache 获取全局响应缓存
+func GlobalResponseCache() *ResponseCache {
+	return globalResponseCache.Load()
+}
+
+// InitResponseCache 根据配置初始化响应缓存
+func InitResponseCache(cfg config.ResponseCacheConfig) {
+	globalResponseCache.Store(NewResponseCache(cfg))
+}
+
+// APIMetadataResponseTag API元数据响应的标签
+const APIMetadataResponseTag = "api_metadata"
+
+// InvalidateAPIMetadata 使缓存的API元数据失效（元数据相关配置热重载后调用）
+func InvalidateAPIMetadata() {
+	SetCachedAPIMetadata(nil)
+	GlobalResponseCache().InvalidateTag(APIMetadataResponseTag)
+}
+
+// ProfileResponseTag 角色相关响应的标签（UUID统一为无连字符小写）
+// 按名称查询的响应同样使用该标签：只缓存存在的角色，改名或删除时按UUID失效即可
+func ProfileResponseTag(profileUUID string) string {
+	return "profile:" + strings.ToLower(RemoveUUIDHyphens(profileUUID))
+}
+
+// InvalidateProfileResponses 使角色的所有缓存响应失效（材质或名称变化时调用）
+func InvalidateProfileResponses(profileUUID string) {
+	GlobalResponseCache().InvalidateTag(ProfileResponseTag(profileUUID))
+}
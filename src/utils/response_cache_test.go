@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"yggdrasil-api-go/src/config"
+)
+
+func TestResponseCacheEviction(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.ResponseCacheConfig
+		run      func(c *ResponseCache)
+		wantKeys []string // 仍在缓存中的键
+	}{
+		{
+			name: "max entries evicts least recently used",
+			cfg:  config.ResponseCacheConfig{Enabled: true, MaxCacheSize: 2},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("1"))
+				c.Set("b", []byte("2"))
+				c.Get("a")
+				c.Set("c", []byte("3"))
+			},
+			wantKeys: []string{"a", "c"},
+		},
+		{
+			name: "max bytes",
+			cfg:  config.ResponseCacheConfig{Enabled: true, MaxCacheBytes: 8},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("123"))
+				c.Set("b", []byte("123"))
+				c.Set("c", []byte("123"))
+			},
+			wantKeys: []string{"b", "c"},
+		},
+		{
+			name: "oversized response not cached",
+			cfg:  config.ResponseCacheConfig{Enabled: true, MaxCacheBytes: 4},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("1"))
+				c.Set("b", []byte("12345"))
+			},
+			wantKeys: []string{"a"},
+		},
+		{
+			name: "replace keeps single entry",
+			cfg:  config.ResponseCacheConfig{Enabled: true, MaxCacheSize: 2},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("1"))
+				c.Set("a", []byte("2"))
+				c.Set("b", []byte("3"))
+			},
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name: "invalidate tag",
+			cfg:  config.ResponseCacheConfig{Enabled: true},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("1"), "profile:x")
+				c.Set("b", []byte("2"), "profile:x", "profile:y")
+				c.Set("c", []byte("3"), "profile:y")
+				c.InvalidateTag("profile:x")
+			},
+			wantKeys: []string{"c"},
+		},
+		{
+			name: "tag index dropped with evicted entry",
+			cfg:  config.ResponseCacheConfig{Enabled: true, MaxCacheSize: 1},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("1"), "profile:x")
+				c.Set("b", []byte("2"))
+				c.Set("a", []byte("3"))
+				c.InvalidateTag("profile:x")
+			},
+			wantKeys: []string{"a"},
+		},
+		{
+			name: "expired",
+			cfg:  config.ResponseCacheConfig{Enabled: true},
+			run: func(c *ResponseCache) {
+				c.SetWithTTL("a", []byte("1"), time.Nanosecond)
+				c.Set("b", []byte("2"))
+				time.Sleep(time.Millisecond)
+			},
+			wantKeys: []string{"b"},
+		},
+		{
+			name: "disabled",
+			cfg:  config.ResponseCacheConfig{},
+			run: func(c *ResponseCache) {
+				c.Set("a", []byte("1"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewResponseCache(tt.cfg)
+			tt.run(c)
+
+			var keys []string
+			for _, key := range []string{"a", "b", "c"} {
+				if _, ok := c.Get(key); ok {
+					keys = append(keys, key)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Fatalf("cached keys = %v; want %v", keys, tt.wantKeys)
+			}
+
+			entries, bytes := c.Stats()
+			if entries != len(tt.wantKeys) || bytes < 0 {
+				t.Fatalf("Stats() = %d entries, %d bytes; want %d entries", entries, bytes, len(tt.wantKeys))
+			}
+		})
+	}
+}
+
+func TestInvalidateProfileResponses(t *testing.T) {
+	previous := GlobalResponseCache()
+	defer globalResponseCache.Store(previous)
+	InitResponseCache(config.ResponseCacheConfig{Enabled: true})
+
+	GlobalResponseCache().Set("profile", []byte("{}"), ProfileResponseTag("5627dd98e6be3c21b8a8e92344183641"))
+	InvalidateProfileResponses("5627DD98-E6BE-3C21-B8A8-E92344183641")
+	if _, ok := GlobalResponseCache().Get("profile"); ok {
+		t.Fatal("profile response still cached after invalidation by hyphenated uppercase UUID")
+	}
+}
@@ -74,6 +74,44 @@ func ParsePrivateKey(privateKeyPEM string) (*rsa.PrivateKey, error) {
 	return nil, fmt.Errorf("failed to parse private key")
 }
 
+// ParsePublicKey 解析PEM格式的RSA公钥（PKIX或PKCS#1）
+func ParsePublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode public key PEM")
+	}
+
+	// 尝试解析PKIX格式
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("not an RSA public key")
+	}
+
+	// 尝试解析PKCS#1格式
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("failed to parse public key")
+}
+
+// VerifySignatureWithRSAKey 使用已解析的RSA公钥验证签名（SHA1withRSA算法）
+func VerifySignatureWithRSAKey(data, signature string, publicKey *rsa.PublicKey) error {
+	// 解码签名
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	// 计算哈希
+	hash := sha1.Sum([]byte(data))
+
+	// 验证签名
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA1, hash[:], signatureBytes)
+}
+
 // VerifySignature 验证RSA签名（用于测试）
 func VerifySignature(data, signature, publicKeyPEM string) error {
 	// 解析公钥
@@ -0,0 +1,64 @@
+// Package utils 访问令牌哈希（缓存只保存令牌的HMAC-SHA256，不保存原始令牌）
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// tokenHashPrefix 哈希后的令牌前缀（用于区分旧的明文缓存条目）
+const tokenHashPrefix = "hmac-sha256:"
+
+var (
+	tokenHashKey          []byte
+	acceptPlaintextTokens = true
+)
+
+// SetTokenHashSecret 设置令牌哈希密钥（为空时不哈希，缓存保持明文格式）
+// acceptPlaintext为true时仍接受旧的明文缓存条目（迁移期），读取时改写为哈希格式
+func SetTokenHashSecret(secret string, acceptPlaintext bool) {
+	acceptPlaintextTokens = acceptPlaintext
+	if secret == "" {
+		tokenHashKey = nil
+		return
+	}
+
+	// 从配置的密钥派生专用的HMAC密钥（与JWT密钥相同时也不会直接复用）
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("yggdrasil-access-token-hash"))
+	tokenHashKey = mac.Sum(nil)
+}
+
+// TokenHashEnabled 是否启用令牌哈希
+func TokenHashEnabled() bool {
+	return len(tokenHashKey) > 0
+}
+
+// AcceptPlaintextTokens 是否接受明文缓存条目（未启用哈希时总是接受）
+func AcceptPlaintextTokens() bool {
+	return !TokenHashEnabled() || acceptPlaintextTokens
+}
+
+// HashAccessToken 计算客户端提交的访问令牌在缓存中使用的哈希（未启用时原样返回）
+// 形如哈希的令牌同样会被哈希，不能用令牌哈希冒充访问令牌；改写缓存条目时由调用方判断条目是否已是哈希
+func HashAccessToken(accessToken string) string {
+	if !TokenHashEnabled() || accessToken == "" {
+		return accessToken
+	}
+
+	mac := hmac.New(sha256.New, tokenHashKey)
+	mac.Write([]byte(accessToken))
+	return tokenHashPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsHashedAccessToken 判断缓存中的值是否为令牌哈希
+func IsHashedAccessToken(value string) bool {
+	return strings.HasPrefix(value, tokenHashPrefix)
+}
+
+// IsPlaintextTokenEntry 判断缓存条目是否为需要迁移的明文令牌（未启用哈希时始终为false）
+func IsPlaintextTokenEntry(value string) bool {
+	return TokenHashEnabled() && value != "" && !IsHashedAccessToken(value)
+}
@@ -0,0 +1,57 @@
+package utils
+
+import "testing"
+
+func TestHashAccessToken(t *testing.T) {
+	defer SetTokenHashSecret("", true)
+
+	tests := []struct {
+		name            string
+		secret          string
+		acceptPlaintext bool
+		token           string
+		wantHashed      bool
+		wantPlaintext   bool // IsPlaintextTokenEntry(token)
+		wantAccept      bool
+	}{
+		{name: "disabled", token: "abc", wantAccept: true},
+		{name: "hashed", secret: "secret", acceptPlaintext: true, token: "abc", wantHashed: true, wantPlaintext: true, wantAccept: true},
+		{name: "reject plaintext", secret: "secret", token: "abc", wantHashed: true, wantPlaintext: true},
+		{name: "empty token", secret: "secret", token: ""},
+		{name: "already hashed", secret: "secret", token: tokenHashPrefix + "00", wantHashed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTokenHashSecret(tt.secret, tt.acceptPlaintext)
+
+			hashed := HashAccessToken(tt.token)
+			if IsHashedAccessToken(hashed) != tt.wantHashed {
+				t.Fatalf("HashAccessToken(%q) = %q; hashed = %v", tt.token, hashed, tt.wantHashed)
+			}
+			if tt.wantHashed && HashAccessToken(hashed) == hashed {
+				t.Fatalf("HashAccessToken accepted the token hash %q as a token", hashed)
+			}
+			if got := IsPlaintextTokenEntry(tt.token); got != tt.wantPlaintext {
+				t.Fatalf("IsPlaintextTokenEntry(%q) = %v; want %v", tt.token, got, tt.wantPlaintext)
+			}
+			if got := AcceptPlaintextTokens(); got != tt.wantAccept {
+				t.Fatalf("AcceptPlaintextTokens() = %v; want %v", got, tt.wantAccept)
+			}
+		})
+	}
+}
+
+func TestHashAccessTokenSecret(t *testing.T) {
+	defer SetTokenHashSecret("", true)
+
+	SetTokenHashSecret("first", true)
+	first := HashAccessToken("abc")
+	if HashAccessToken("abc") != first || HashAccessToken("abd") == first {
+		t.Fatal("hash is not deterministic per token")
+	}
+
+	SetTokenHashSecret("second", true)
+	if HashAccessToken("abc") == first {
+		t.Fatal("hash does not depend on the secret")
+	}
+}
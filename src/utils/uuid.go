@@ -54,3 +54,12 @@ func FormatUUID(uuidStr string) string {
 func RemoveUUIDHyphens(uuidStr string) string {
 	return strings.ReplaceAll(uuidStr, "-", "")
 }
+
+// IsValidUUID 检查是否为有效的UUID（支持无符号和带连字符两种格式）
+func IsValidUUID(uuidStr string) bool {
+	if len(uuidStr) != 32 && len(uuidStr) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(uuidStr)
+	return err == nil
+}
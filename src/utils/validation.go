@@ -18,9 +18,12 @@ var (
 	// QQ号验证正则表达式
 	qqNumberRegex = regexp.MustCompile(`^[1-9][0-9]{4,10}$`)
 	
-	// 密码强度验证正则表达式
-	// 至少8位，包含大小写字母和数字
-	strongPasswordRegex = regexp.MustCompile(`^(?=.*[a-z])(?=.*[A-Z])(?=.*[0-9]).{8,}$`)
+	// 密码强度验证正则表达式（RE2不支持前瞻断言，分别匹配小写字母、大写字母和数字）
+	strongPasswordRegexes = []*regexp.Regexp{
+		regexp.MustCompile(`[a-z]`),
+		regexp.MustCompile(`[A-Z]`),
+		regexp.MustCompile(`[0-9]`),
+	}
 )
 
 // IsValidEmail 验证邮箱格式
@@ -89,12 +92,17 @@ func IsValidPassword(password string) bool {
 	return true
 }
 
-// IsStrongPassword 验证强密码格式
+// IsStrongPassword 验证强密码格式（至少8位，包含大小写字母和数字）
 func IsStrongPassword(password string) bool {
 	if len(password) < 8 {
 		return false
 	}
-	return strongPasswordRegex.MatchString(password)
+	for _, re := range strongPasswordRegexes {
+		if !re.MatchString(password) {
+			return false
+		}
+	}
+	return true
 }
 
 // SanitizePlayerName 清理游戏名
@@ -14,6 +14,7 @@ type User struct {
 	Email    string    `json:"email"`    // 邮箱
 	Password string    `json:"-"`        // 密码（不序列化）
 	Profiles []Profile `json:"profiles"` // 用户拥有的角色列表
+	IsAdmin  bool      `json:"-"`        // 是否为管理员（不序列化）
 }
 
 // Profile 角色模型
@@ -118,16 +119,29 @@ type JoinRequest struct {
 
 // Session 会话信息
 type Session struct {
-	ServerID    string    `json:"serverId"`    // 服务器ID
-	AccessToken string    `json:"accessToken"` // 访问令牌
-	ProfileID   string    `json:"profileId"`   // 角色ID
-	ClientIP    string    `json:"clientIp"`    // 客户端IP
-	CreatedAt   time.Time `json:"createdAt"`   // 创建时间
+	ServerID    string    `json:"serverId"`         // 服务器ID
+	AccessToken string    `json:"accessToken"`      // 访问令牌
+	ProfileID   string    `json:"profileId"`        // 角色ID
+	ClientIP    string    `json:"clientIp"`         // 客户端IP
+	CreatedAt   time.Time `json:"createdAt"`        // 创建时间
+	Checks      int       `json:"checks,omitempty"` // 已通过的hasJoined验证次数（允许重复验证时使用）
 }
 
+// SessionMaxAge 会话有效期（与Yggdrasil标准一致）
+const SessionMaxAge = 30 * time.Second
+
 // IsValid 检查会话是否有效（30秒内）
 func (s *Session) IsValid() bool {
-	return time.Since(s.CreatedAt) < 30*time.Second
+	return time.Since(s.CreatedAt) < SessionMaxAge
+}
+
+// TTL 会话的剩余有效期（未设置创建时间时为完整有效期，已过期时不大于0）
+// 缓存按剩余有效期存储，重新存储的会话（如允许重复验证时放回）不会延长有效期
+func (s *Session) TTL() time.Duration {
+	if s.CreatedAt.IsZero() {
+		return SessionMaxAge
+	}
+	return time.Until(s.CreatedAt.Add(SessionMaxAge))
 }
 
 // APIMetadata API元数据
@@ -163,32 +177,22 @@ type TextureInfo struct {
 
 // GenerateTexturesProperty 生成 textures 属性的 base64 编码值
 func GenerateTexturesProperty(profileID, profileName string, skinURL, capeURL string, isSlim bool) (string, error) {
+	return GenerateTexturesPropertyFromMap(profileID, profileName, legacyTextures(skinURL, capeURL, isSlim))
+}
+
+// GenerateTexturesPropertyFromMap 根据材质映射（键为材质类型，如SKIN、CAPE、ELYTRA）生成 textures 属性
+func GenerateTexturesPropertyFromMap(profileID, profileName string, textures map[string]TextureInfo) (string, error) {
 	textureData := TextureData{
 		Timestamp:   time.Now().UnixMilli(),
 		ProfileID:   profileID,
 		ProfileName: profileName,
 		IsPublic:    true,
-		Textures:    make(map[string]TextureInfo),
-	}
-
-	// 添加皮肤材质
-	if skinURL != "" {
-		skinInfo := TextureInfo{
-			URL: skinURL,
-		}
-		// 如果是纤细模型，添加 metadata
-		if isSlim {
-			skinInfo.Metadata = map[string]interface{}{
-				"model": "slim",
-			}
-		}
-		textureData.Textures["SKIN"] = skinInfo
+		Textures:    make(map[string]TextureInfo, len(textures)),
 	}
 
-	// 添加披风材质
-	if capeURL != "" {
-		textureData.Textures["CAPE"] = TextureInfo{
-			URL: capeURL,
+	for textureType, info := range textures {
+		if info.URL != "" {
+			textureData.Textures[textureType] = info
 		}
 	}
 
@@ -204,10 +208,15 @@ func GenerateTexturesProperty(profileID, profileName string, skinURL, capeURL st
 
 // GenerateProfileProperties 生成角色的完整属性列表
 func GenerateProfileProperties(profileID, profileName string, skinURL, capeURL string, isSlim bool) ([]ProfileProperty, error) {
+	return BuildProfileProperties(profileID, profileName, legacyTextures(skinURL, capeURL, isSlim), "skin,cape")
+}
+
+// BuildProfileProperties 根据材质映射生成角色属性列表（textures 和 uploadableTextures）
+func BuildProfileProperties(profileID, profileName string, textures map[string]TextureInfo, uploadableTextures string) ([]ProfileProperty, error) {
 	var properties []ProfileProperty
 
 	// 生成 textures 属性
-	texturesValue, err := GenerateTexturesProperty(profileID, profileName, skinURL, capeURL, isSlim)
+	texturesValue, err := GenerateTexturesPropertyFromMap(profileID, profileName, textures)
 	if err != nil {
 		return nil, err
 	}
@@ -218,14 +227,44 @@ func GenerateProfileProperties(profileID, profileName string, skinURL, capeURL s
 	})
 
 	// 添加 uploadableTextures 属性
-	properties = append(properties, ProfileProperty{
-		Name:  "uploadableTextures",
-		Value: "skin,cape",
-	})
+	if uploadableTextures != "" {
+		properties = append(properties, ProfileProperty{
+			Name:  "uploadableTextures",
+			Value: uploadableTextures,
+		})
+	}
 
 	return properties, nil
 }
 
+// legacyTextures 将皮肤/披风URL转换为材质映射
+func legacyTextures(skinURL, capeURL string, isSlim bool) map[string]TextureInfo {
+	textures := make(map[string]TextureInfo)
+
+	// 添加皮肤材质
+	if skinURL != "" {
+		skinInfo := TextureInfo{
+			URL: skinURL,
+		}
+		// 如果是纤细模型，添加 metadata
+		if isSlim {
+			skinInfo.Metadata = map[string]interface{}{
+				"model": "slim",
+			}
+		}
+		textures["SKIN"] = skinInfo
+	}
+
+	// 添加披风材质
+	if capeURL != "" {
+		textures["CAPE"] = TextureInfo{
+			URL: capeURL,
+		}
+	}
+
+	return textures
+}
+
 // ErrorResponse 错误响应
 type ErrorResponse struct {
 	Error        string `json:"error"`           // 错误类型